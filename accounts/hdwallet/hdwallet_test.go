@@ -0,0 +1,129 @@
+package hdwallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/mihongtech/linkchain/accounts"
+)
+
+// BIP-39 test vectors of https://github.com/trezor/python-mnemonic/blob/master/vectors.json
+var mnemonicTests = []struct {
+	entropy  string
+	mnemonic string
+	seed     string
+}{
+	{
+		"00000000000000000000000000000000",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+	},
+	{
+		"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		"legal winner thank year wave sausage worth useful legal winner thank yellow",
+		"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+	},
+	{
+		"ffffffffffffffffffffffffffffffff",
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+		"ac27495480225222079d7be181583751e86f571027b0497b5b5d11218e0a8a13332572917f0f8e5a589620c6f15b11c61dee327651a14c34e18231052e48c069",
+	},
+	{
+		"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		"legal winner thank year wave sausage worth useful legal winner thank year wave sausage worth useful legal will",
+		"f2b94508732bcbacbcc020faefecfc89feafa6649a5491b8c952cede496c214a0c7b3c392d168748f2d4a612bada0753b52a1c7ac53c1e93abd5c6320b9e95dd",
+	},
+	{
+		"8080808080808080808080808080808080808080808080808080808080808080",
+		"letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic bless",
+		"c0c519bd0e91a2ed54357d9d1ebef6f5af218a153624cf4f2da911a0ed8f7a09e2ef61af0aca007096df430022f7a2b6fb91661a9589097069720d015e4e982f",
+	},
+}
+
+func TestMnemonic(t *testing.T) {
+	for i, test := range mnemonicTests {
+		entropy, _ := hex.DecodeString(test.entropy)
+		mnemonic, err := NewMnemonic(entropy)
+		if err != nil {
+			t.Fatalf("test %d: new mnemonic failed: %v", i, err)
+		}
+		if mnemonic != test.mnemonic {
+			t.Errorf("test %d: mnemonic mismatch: have %q, want %q", i, mnemonic, test.mnemonic)
+		}
+
+		decoded, err := EntropyFromMnemonic(test.mnemonic)
+		if err != nil || !bytes.Equal(decoded, entropy) {
+			t.Errorf("test %d: entropy mismatch: have %x, want %x, err %v", i, decoded, entropy, err)
+		}
+
+		seed, err := NewSeed(test.mnemonic, "TREZOR")
+		if err != nil || hex.EncodeToString(seed) != test.seed {
+			t.Errorf("test %d: seed mismatch: have %x, want %s, err %v", i, seed, test.seed, err)
+		}
+	}
+}
+
+func TestInvalidMnemonic(t *testing.T) {
+	tests := []string{
+		"",
+		"abandon abandon abandon",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon linkchain",
+	}
+	for i, mnemonic := range tests {
+		if IsMnemonicValid(mnemonic) {
+			t.Errorf("test %d: invalid mnemonic %q is accepted", i, mnemonic)
+		}
+	}
+
+	entropy, err := NewEntropy(DefaultEntropyBits)
+	if err != nil {
+		t.Fatalf("new entropy failed: %v", err)
+	}
+	mnemonic, _ := NewMnemonic(entropy)
+	if !IsMnemonicValid(mnemonic) {
+		t.Fatalf("new mnemonic %q is invalid", mnemonic)
+	}
+	if _, err := NewEntropy(100); err != ErrInvalidEntropySize {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrInvalidEntropySize)
+	}
+}
+
+// BIP-32 test vector 1 of https://github.com/bitcoin/bips/blob/master/bip-0032.mediawiki
+func TestDerive(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := NewMaster(seed)
+	if err != nil {
+		t.Fatalf("new master failed: %v", err)
+	}
+
+	tests := []struct {
+		path      string
+		key       string
+		chainCode string
+	}{
+		{"m", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35", "873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508"},
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea", "47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368", "2a7857631386ba23dacac34180dd1983734e444fdbf774041578e9b6adb37c19"},
+		{"m/0'/1/2'", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca", "04466b9cc8e161e966409ca52986c584f07e9dc81f735db683c3ff6ec7b1503f"},
+	}
+	for i, test := range tests {
+		key := master
+		if test.path != "m" {
+			path, err := accounts.ParseDerivationPath(test.path)
+			if err != nil {
+				t.Fatalf("test %d: parse path failed: %v", i, err)
+			}
+			if key, err = master.Derive(path); err != nil {
+				t.Fatalf("test %d: derive failed: %v", i, err)
+			}
+		}
+		if have := hex.EncodeToString(key.PrivateKey().Serialize()); have != test.key {
+			t.Errorf("test %d: key mismatch: have %s, want %s", i, have, test.key)
+		}
+		if have := hex.EncodeToString(key.ChainCode()); have != test.chainCode {
+			t.Errorf("test %d: chain code mismatch: have %s, want %s", i, have, test.chainCode)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package hdwallet
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/mihongtech/linkchain/accounts"
+	"github.com/mihongtech/linkchain/common/btcec"
+)
+
+const (
+	// HardenedKeyStart is the index of the first hardened child key.
+	HardenedKeyStart = 0x80000000
+
+	// MinSeedBytes and MaxSeedBytes are the allowed sizes of a master seed.
+	MinSeedBytes = 16
+	MaxSeedBytes = 64
+)
+
+var (
+	// masterKey is the key of HMAC-SHA512 to derive the master key from seed.
+	masterKey = []byte("Bitcoin seed")
+
+	ErrInvalidSeedLen = errors.New("seed length must be between 128 and 512 bits")
+	ErrUnusableSeed   = errors.New("unusable seed")
+	ErrInvalidChild   = errors.New("the child key at the index is invalid")
+)
+
+// ExtendedKey is a BIP-32 extended private key, it derives the child keys.
+type ExtendedKey struct {
+	key       []byte // 32 bytes private key
+	chainCode []byte
+	depth     uint8
+	childNum  uint32
+}
+
+// NewMaster returns the master extended key of the seed.
+func NewMaster(seed []byte) (*ExtendedKey, error) {
+	if len(seed) < MinSeedBytes || len(seed) > MaxSeedBytes {
+		return nil, ErrInvalidSeedLen
+	}
+
+	hmac512 := hmac.New(sha512.New, masterKey)
+	hmac512.Write(seed)
+	lr := hmac512.Sum(nil)
+
+	key, chainCode := lr[:32], lr[32:]
+	keyNum := new(big.Int).SetBytes(key)
+	if keyNum.Cmp(btcec.S256().N) >= 0 || keyNum.Sign() == 0 {
+		return nil, ErrUnusableSeed
+	}
+	return &ExtendedKey{key: key, chainCode: chainCode}, nil
+}
+
+// Child returns the child extended key at index, the index of hardened child
+// starts from HardenedKeyStart.  ErrInvalidChild is returned for the rare
+// invalid child, the next index should be used instead.
+func (k *ExtendedKey) Child(i uint32) (*ExtendedKey, error) {
+	// data = 0x00 || ser256(k) || ser32(i) for hardened child, and
+	// serP(point(k)) || ser32(i) for normal child.
+	var data []byte
+	if i >= HardenedKeyStart {
+		data = make([]byte, 0, 37)
+		data = append(data, 0x00)
+		data = append(data, k.key...)
+	} else {
+		_, pub := btcec.PrivKeyFromBytes(btcec.S256(), k.key)
+		data = make([]byte, 0, 37)
+		data = append(data, pub.SerializeCompressed()...)
+	}
+	var index [4]byte
+	binary.BigEndian.PutUint32(index[:], i)
+	data = append(data, index[:]...)
+
+	hmac512 := hmac.New(sha512.New, k.chainCode)
+	hmac512.Write(data)
+	lr := hmac512.Sum(nil)
+
+	// child key = parse256(IL) + k (mod n)
+	curve := btcec.S256()
+	il := new(big.Int).SetBytes(lr[:32])
+	if il.Cmp(curve.N) >= 0 {
+		return nil, ErrInvalidChild
+	}
+	childNum := il.Add(il, new(big.Int).SetBytes(k.key))
+	childNum.Mod(childNum, curve.N)
+	if childNum.Sign() == 0 {
+		return nil, ErrInvalidChild
+	}
+
+	childKey := make([]byte, 32)
+	keyBytes := childNum.Bytes()
+	copy(childKey[32-len(keyBytes):], keyBytes)
+
+	return &ExtendedKey{
+		key:       childKey,
+		chainCode: lr[32:],
+		depth:     k.depth + 1,
+		childNum:  i,
+	}, nil
+}
+
+// Derive returns the extended key at the derivation path relative to k.
+func (k *ExtendedKey) Derive(path accounts.DerivationPath) (*ExtendedKey, error) {
+	key := k
+	for _, i := range path {
+		child, err := key.Child(i)
+		if err != nil {
+			return nil, err
+		}
+		key = child
+	}
+	return key, nil
+}
+
+// PrivateKey returns the private key of the extended key.
+func (k *ExtendedKey) PrivateKey() *btcec.PrivateKey {
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), k.key)
+	return priv
+}
+
+// ChainCode returns the chain code of the extended key.
+func (k *ExtendedKey) ChainCode() []byte {
+	return k.chainCode
+}
+
+// Depth returns the depth of the extended key, the master key is 0.
+func (k *ExtendedKey) Depth() uint8 {
+	return k.depth
+}
@@ -0,0 +1,129 @@
+// Package hdwallet implements the hierarchical deterministic keys of BIP-32
+// and the mnemonic seeds of BIP-39.
+package hdwallet
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// DefaultEntropyBits is the entropy size of the default 12 words mnemonic.
+	DefaultEntropyBits = 128
+
+	// seedIterations is the iteration count of PBKDF2 to derive the seed.
+	seedIterations = 2048
+
+	// SeedSize is the size in bytes of the seed derived from a mnemonic.
+	SeedSize = 64
+)
+
+var (
+	ErrInvalidEntropySize = errors.New("entropy size must be a multiple of 32 in [128, 256]")
+	ErrInvalidMnemonic    = errors.New("invalid mnemonic")
+	ErrChecksumMismatch   = errors.New("mnemonic checksum mismatch")
+)
+
+// NewEntropy returns random entropy of bitSize bits for a new mnemonic.
+func NewEntropy(bitSize int) ([]byte, error) {
+	if err := validateEntropyBits(bitSize); err != nil {
+		return nil, err
+	}
+	entropy := make([]byte, bitSize/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return nil, err
+	}
+	return entropy, nil
+}
+
+// NewMnemonic returns the mnemonic of the entropy, every word encodes 11 bits
+// of the entropy appended with its checksum.
+func NewMnemonic(entropy []byte) (string, error) {
+	bitSize := len(entropy) * 8
+	if err := validateEntropyBits(bitSize); err != nil {
+		return "", err
+	}
+	checksumBits := bitSize / 32
+	wordCount := (bitSize + checksumBits) / 11
+
+	// entropy || checksum as a big number
+	checksum := sha256.Sum256(entropy)
+	data := new(big.Int).SetBytes(entropy)
+	data.Lsh(data, uint(checksumBits))
+	data.Or(data, big.NewInt(int64(checksum[0]>>uint(8-checksumBits))))
+
+	words := make([]string, wordCount)
+	mask := big.NewInt(2047)
+	index := new(big.Int)
+	for i := wordCount - 1; i >= 0; i-- {
+		index.And(data, mask)
+		words[i] = englishWords[index.Int64()]
+		data.Rsh(data, 11)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// EntropyFromMnemonic returns the entropy of the mnemonic, the checksum of the
+// mnemonic is verified.
+func EntropyFromMnemonic(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	wordCount := len(words)
+	if wordCount%3 != 0 || wordCount < 12 || wordCount > 24 {
+		return nil, ErrInvalidMnemonic
+	}
+
+	data := new(big.Int)
+	for _, word := range words {
+		index, ok := wordIndexes[word]
+		if !ok {
+			return nil, fmt.Errorf("%v: unknown word %q", ErrInvalidMnemonic, word)
+		}
+		data.Lsh(data, 11)
+		data.Or(data, big.NewInt(int64(index)))
+	}
+
+	checksumBits := wordCount * 11 / 33
+	bitSize := checksumBits * 32
+	checksum := new(big.Int).And(data, big.NewInt(int64(1<<uint(checksumBits)-1)))
+	data.Rsh(data, uint(checksumBits))
+
+	entropy := make([]byte, bitSize/8)
+	dataBytes := data.Bytes()
+	copy(entropy[len(entropy)-len(dataBytes):], dataBytes)
+
+	hash := sha256.Sum256(entropy)
+	if int64(hash[0]>>uint(8-checksumBits)) != checksum.Int64() {
+		return nil, ErrChecksumMismatch
+	}
+	return entropy, nil
+}
+
+// IsMnemonicValid returns whether the mnemonic has known words and a valid checksum.
+func IsMnemonicValid(mnemonic string) bool {
+	_, err := EntropyFromMnemonic(mnemonic)
+	return err == nil
+}
+
+// NewSeed returns the seed of the mnemonic protected by the passphrase, an
+// empty passphrase is allowed.  The mnemonic is verified before.
+func NewSeed(mnemonic string, passphrase string) ([]byte, error) {
+	if _, err := EntropyFromMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	normalized := strings.Join(strings.Fields(mnemonic), " ")
+	return pbkdf2.Key([]byte(normalized), []byte("mnemonic"+passphrase), seedIterations, SeedSize, sha512.New), nil
+}
+
+func validateEntropyBits(bitSize int) error {
+	if bitSize%32 != 0 || bitSize < 128 || bitSize > 256 {
+		return ErrInvalidEntropySize
+	}
+	return nil
+}
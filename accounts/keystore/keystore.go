@@ -330,6 +330,14 @@ func (ks *KeyStore) Unlock(a accounts.Account, passphrase string) error {
 	return ks.TimedUnlock(a, passphrase, 0)
 }
 
+// IsUnlocked reports whether the private key with the given address is in memory.
+func (ks *KeyStore) IsUnlocked(addr meta.AccountID) bool {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	_, found := ks.unlocked[addr.String()]
+	return found
+}
+
 // Lock removes the private key with the given address from memory.
 func (ks *KeyStore) Lock(addr meta.AccountID) error {
 	ks.mu.Lock()
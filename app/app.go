@@ -83,6 +83,25 @@ func Setup(globalConfig *config.LinkChainConfig) bool {
 	return true
 }
 
+//SetupNode sets up the node service alone, it is used by the commands run on the data dir
+//of a stopped node, e.g. lcd import.
+func SetupNode(globalConfig *config.LinkChainConfig) bool {
+	appContext.Config = globalConfig
+	appContext.InterpreterAPI = chooseInterpreterAPI(globalConfig.InterpreterAPI)
+
+	nodeSvc = node.NewNode()
+	if !nodeSvc.Setup(&appContext) {
+		return false
+	}
+	appContext.NodeAPI = node.NewPublicNodeAPI(nodeSvc)
+	return true
+}
+
+//StopNode stops the blockchain of node set up by SetupNode and closes its database.
+func StopNode() {
+	nodeSvc.Close()
+}
+
 func Run() {
 	//start all service
 	nodeSvc.Start()
@@ -135,8 +154,22 @@ func startRPC() {
 	s, err := rpcserver.NewRPCServer(&rpcserver.Config{
 		StartupTime: time.Now().Unix(),
 		Addr:        appContext.Config.RpcAddr,
+		MaxBodySize: appContext.Config.RpcMaxBodySize,
+		BatchLimit:  appContext.Config.RpcBatchLimit,
+		TLSCert:     appContext.Config.RpcTLSCert,
+		TLSKey:      appContext.Config.RpcTLSKey,
+		Credentials: appContext.Config.RpcCredentials,
+		WSOrigins:   appContext.Config.RpcWSOrigins,
+
+		IPRate:          appContext.Config.RpcIPRate,
+		IPBurst:         appContext.Config.RpcIPBurst,
+		CredentialRate:  appContext.Config.RpcCredentialRate,
+		CredentialBurst: appContext.Config.RpcCredentialBurst,
+		MethodCosts:     appContext.Config.RpcMethodCosts,
+		Timeout:         appContext.Config.RpcTimeout,
 	}, &appContext)
 	if err != nil {
+		log.Error("create rpc server failed", "err", err)
 		return
 	}
 
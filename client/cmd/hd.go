@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mihongtech/linkchain/common/util/log"
+	"github.com/mihongtech/linkchain/rpc/rpcobject"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	walletCmd.AddCommand(hdCmd)
+	hdCmd.AddCommand(hdCreateCmd,
+		hdImportCmd,
+		hdInfoCmd,
+		hdDiscoverCmd)
+}
+
+var hdCmd = &cobra.Command{
+	Use:   "hd",
+	Short: "hd wallet command",
+	Long:  "This is all hd wallet command for handling mnemonic and derived accounts",
+}
+
+// create hd wallet with a new mnemonic
+var hdCreateCmd = &cobra.Command{
+	Use:     "create",
+	Short:   "hd create <password> [bits] [passphrase]",
+	Long:    "This is create hd wallet with a new mnemonic command, the mnemonic must be written down, the password encrypts the seed and derived keys",
+	Example: "wallet hd create mypassword 128",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 || len(args) > 3 {
+			log.Error("hd create", "error", "please input <password> [bits] [passphrase]")
+			return
+		}
+		bits := 0
+		if len(args) > 1 {
+			var err error
+			if bits, err = strconv.Atoi(args[1]); err != nil {
+				log.Error("hd create", "error", "please input valid [bits]", "err", err)
+				return
+			}
+		}
+		passphrase := ""
+		if len(args) > 2 {
+			passphrase = args[2]
+		}
+
+		//call
+		out, err := rpc("newMnemonic", &rpcobject.NewMnemonicCmd{Bits: bits})
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		var rsp struct {
+			Result string `json:"result"`
+		}
+		if err := json.Unmarshal([]byte(out), &rsp); err != nil || rsp.Result == "" {
+			fmt.Println(out)
+			return
+		}
+
+		out, err = rpc("importMnemonic", &rpcobject.ImportMnemonicCmd{Mnemonic: rsp.Result, Passphrase: passphrase, Password: args[0]})
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(out)
+		fmt.Println("mnemonic:", rsp.Result)
+	},
+}
+
+// import hd wallet from mnemonic
+var hdImportCmd = &cobra.Command{
+	Use:     "import",
+	Short:   "hd import <mnemonic> <password> [passphrase] [path]",
+	Long:    "This is import hd wallet from mnemonic command, the password encrypts the seed and derived keys",
+	Example: "wallet hd import \"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about\" mypassword \"\" \"m/44'/19523'/0'/0/0\"",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 || len(args) > 4 {
+			log.Error("hd import", "error", "please input <mnemonic> <password> [passphrase] [path]")
+			return
+		}
+		c := &rpcobject.ImportMnemonicCmd{Mnemonic: strings.TrimSpace(args[0]), Password: args[1]}
+		if len(args) > 2 {
+			c.Passphrase = args[2]
+		}
+		if len(args) > 3 {
+			c.Path = args[3]
+		}
+
+		//call
+		out, err := rpc("importMnemonic", c)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(out)
+	},
+}
+
+// get hd wallet information
+var hdInfoCmd = &cobra.Command{
+	Use:     "info",
+	Short:   "hd info",
+	Long:    "This is get hd wallet path and next account index command",
+	Example: "wallet hd info",
+	Run: func(cmd *cobra.Command, args []string) {
+		//call
+		out, err := rpc("getHDWalletInfo", nil)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(out)
+	},
+}
+
+// discover used hd accounts
+var hdDiscoverCmd = &cobra.Command{
+	Use:     "discover",
+	Short:   "hd discover <password> [gap]",
+	Long:    "This is discover the derived accounts which are used on chain command",
+	Example: "wallet hd discover mypassword 20",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 || len(args) > 2 {
+			log.Error("hd discover", "error", "please input <password> [gap]")
+			return
+		}
+		var gap uint64
+		if len(args) > 1 {
+			var err error
+			if gap, err = strconv.ParseUint(args[1], 10, 32); err != nil {
+				log.Error("hd discover", "error", "please input valid [gap]", "err", err)
+				return
+			}
+		}
+
+		//call
+		out, err := rpc("discoverHDAccounts", &rpcobject.DiscoverHDAccountsCmd{Gap: uint32(gap), Password: args[0]})
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(out)
+	},
+}
@@ -2,6 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
+
+	"github.com/mihongtech/linkchain/common/util/log"
+	"github.com/mihongtech/linkchain/rpc/rpcobject"
 	"github.com/spf13/cobra"
 )
 
@@ -10,7 +14,11 @@ func init() {
 	minerCmd.AddCommand(minerInfoCmd,
 		startMineCmd,
 		stopMineCmd,
-		mineCmd)
+		mineCmd,
+		signersCmd,
+		proposalsCmd,
+		proposeCmd,
+		discardCmd)
 }
 
 var minerCmd = &cobra.Command{
@@ -91,3 +99,93 @@ var mineCmd = &cobra.Command{
 		fmt.Println(out)
 	},
 }
+
+var signersCmd = &cobra.Command{
+	Use:     "signers",
+	Short:   "miner signers",
+	Long:    "This is get poa signers of best block command",
+	Example: "miner signers",
+	Run: func(cmd *cobra.Command, args []string) {
+		method := "getSigners"
+
+		//call
+		out, err := rpc(method, nil)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(out)
+	},
+}
+
+var proposalsCmd = &cobra.Command{
+	Use:     "proposals",
+	Short:   "miner proposals",
+	Long:    "This is get poa proposals which the node is voting command",
+	Example: "miner proposals",
+	Run: func(cmd *cobra.Command, args []string) {
+		method := "getProposals"
+
+		//call
+		out, err := rpc(method, nil)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(out)
+	},
+}
+
+var proposeCmd = &cobra.Command{
+	Use:     "propose",
+	Short:   "miner propose <accountId> <authorize>",
+	Long:    "This is vote to add(true) or remove(false) a poa signer command",
+	Example: "miner propose 07411e1beff277bf1dd9d810c07a4db0e1e45f5a true",
+	Run: func(cmd *cobra.Command, args []string) {
+		example := []string{"example", "miner propose 07411e1beff277bf1dd9d810c07a4db0e1e45f5a true"}
+		if len(args) != 2 {
+			log.Error("propose", "error", "please input accountId and authorize", example[0], example[1])
+			return
+		}
+
+		authorize, err := strconv.ParseBool(args[1])
+		if err != nil {
+			log.Error("propose", "error", err, example[0], example[1])
+			return
+		}
+
+		method := "propose"
+
+		//call
+		out, err := rpc(method, &rpcobject.ProposeCmd{AccountId: args[0], Authorize: authorize})
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(out)
+	},
+}
+
+var discardCmd = &cobra.Command{
+	Use:     "discard",
+	Short:   "miner discard <accountId>",
+	Long:    "This is stop voting for a poa signer command",
+	Example: "miner discard 07411e1beff277bf1dd9d810c07a4db0e1e45f5a",
+	Run: func(cmd *cobra.Command, args []string) {
+		example := []string{"example", "miner discard 07411e1beff277bf1dd9d810c07a4db0e1e45f5a"}
+		if len(args) != 1 {
+			log.Error("discard", "error", "please input accountId", example[0], example[1])
+			return
+		}
+
+		method := "discard"
+
+		//call
+		out, err := rpc(method, &rpcobject.DiscardCmd{AccountId: args[0]})
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(out)
+	},
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/mihongtech/linkchain/common/math"
+	"github.com/mihongtech/linkchain/common/util/log"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/helper"
+	"github.com/mihongtech/linkchain/rpc/rpcobject"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RootCmd.AddCommand(proofCmd)
+	proofCmd.AddCommand(accountProofCmd,
+		txProofCmd,
+		verifyAccountProofCmd,
+		verifyTxProofCmd)
+}
+
+var proofCmd = &cobra.Command{
+	Use:   "proof",
+	Short: "proof command",
+	Long:  "This is all proof command for getting and verifying merkle proofs of accounts and txs",
+}
+
+var accountProofCmd = &cobra.Command{
+	Use:     "account",
+	Short:   "account <id> [block_hash]",
+	Long:    "This is get account proof command, the proof is against the state root of block, the best block by default",
+	Example: "proof account 55b55e136cc6671014029dcbefc42a7db8ad9386",
+	Run: func(cmd *cobra.Command, args []string) {
+		example := []string{"example", "proof account 55b55e136cc6671014029dcbefc42a7db8ad9386"}
+		if len(args) != 1 && len(args) != 2 {
+			log.Error("accountproof", "error", "please input account id", example[0], example[1])
+			return
+		}
+		c := &rpcobject.GetAccountProofCmd{AccountId: args[0]}
+		if len(args) == 2 {
+			c.BlockHash = args[1]
+		}
+
+		//call
+		out, err := rpc("getAccountProof", c)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(out)
+	},
+}
+
+var txProofCmd = &cobra.Command{
+	Use:     "tx",
+	Short:   "tx <txid>",
+	Long:    "This is get tx proof command, the proof is against the tx root of the block of tx",
+	Example: "proof tx 98acd27a58c79eaab05ea4abd0daa8e63021df3bf2e65fcb38e2474fb706c3fe",
+	Run: func(cmd *cobra.Command, args []string) {
+		example := []string{"example", "proof tx 98acd27a58c79eaab05ea4abd0daa8e63021df3bf2e65fcb38e2474fb706c3fe"}
+		if len(args) != 1 {
+			log.Error("txproof", "error", "please input txid", example[0], example[1])
+			return
+		}
+
+		//call
+		out, err := rpc("getTxProof", &rpcobject.GetTxProofCmd{args[0]})
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(out)
+	},
+}
+
+// verify the output of proof account offline
+var verifyAccountProofCmd = &cobra.Command{
+	Use:     "verifyaccount",
+	Short:   "verifyaccount <proof_file> [trusted_block_hash]",
+	Long:    "This is verify account proof command, the proof file is the output of proof account, it is verified without rpc server",
+	Example: "proof verifyaccount ./account_proof.json",
+	Run: func(cmd *cobra.Command, args []string) {
+		example := []string{"example", "proof verifyaccount ./account_proof.json"}
+		if len(args) != 1 && len(args) != 2 {
+			log.Error("verifyaccountproof", "error", "please input proof file", example[0], example[1])
+			return
+		}
+		rsp := rpcobject.AccountProofRSP{}
+		if err := readProofFile(args[0], &rsp); err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		if err := verifyAccountProof(&rsp, args[1:]); err != nil {
+			fmt.Println("invalid proof:", err.Error())
+			return
+		}
+		fmt.Println("account proof is valid, block", rsp.BlockHash, "height", rsp.Height)
+	},
+}
+
+// verify the output of proof tx offline
+var verifyTxProofCmd = &cobra.Command{
+	Use:     "verifytx",
+	Short:   "verifytx <proof_file> [trusted_block_hash]",
+	Long:    "This is verify tx proof command, the proof file is the output of proof tx, it is verified without rpc server",
+	Example: "proof verifytx ./tx_proof.json",
+	Run: func(cmd *cobra.Command, args []string) {
+		example := []string{"example", "proof verifytx ./tx_proof.json"}
+		if len(args) != 1 && len(args) != 2 {
+			log.Error("verifytxproof", "error", "please input proof file", example[0], example[1])
+			return
+		}
+		rsp := rpcobject.TxProofRSP{}
+		if err := readProofFile(args[0], &rsp); err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		tx, err := verifyTxProof(&rsp, args[1:])
+		if err != nil {
+			fmt.Println("invalid proof:", err.Error())
+			return
+		}
+		fmt.Println("tx proof is valid, block", rsp.BlockHash, "height", rsp.Height)
+		fmt.Println(tx.String())
+	},
+}
+
+func readProofFile(file string, rsp interface{}) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, rsp)
+}
+
+//verifyProofHeader checks the header hashes to the block hash of proof, and the trusted block hash if it is given.
+func verifyProofHeader(raw string, blockHash string, trusted []string) (*meta.BlockHeader, error) {
+	header, err := helper.DecodeBlockHeader(raw)
+	if err != nil {
+		return nil, err
+	}
+	hashes := append([]string{blockHash}, trusted...)
+	for _, h := range hashes {
+		hash, err := math.NewHashFromStr(h)
+		if err != nil {
+			return nil, err
+		}
+		if !header.GetBlockID().IsEqual(hash) {
+			return nil, fmt.Errorf("header hash %s is not block hash %s", header.GetBlockID().String(), h)
+		}
+	}
+	return header, nil
+}
+
+func verifyAccountProof(rsp *rpcobject.AccountProofRSP, trusted []string) error {
+	header, err := verifyProofHeader(rsp.Header, rsp.BlockHash, trusted)
+	if err != nil {
+		return err
+	}
+	if header.Status.String() != rsp.StateRoot {
+		return errors.New("state root is not the status of header")
+	}
+	id, err := helper.CreateAccountIdByAddress(rsp.ID)
+	if err != nil {
+		return err
+	}
+	proof, err := helper.DecodeProof(rsp.Proof)
+	if err != nil {
+		return err
+	}
+	account, err := helper.VerifyAccountProof(header.Status, *id, proof)
+	if err != nil {
+		return err
+	}
+
+	//the proven account must be the account of proof
+	if account == nil {
+		if rsp.Exist {
+			return errors.New("the account does not exist")
+		}
+		return nil
+	}
+	if !rsp.Exist {
+		return errors.New("the account exists")
+	}
+	if account.StorageRoot.String() != rsp.StorageRoot || account.CodeHash.String() != rsp.CodeHash {
+		return errors.New("storage root or code hash mismatch")
+	}
+	if len(account.UTXOs) != len(rsp.UTXO) {
+		return fmt.Errorf("utxo count mismatch: proven %d, claimed %d", len(account.UTXOs), len(rsp.UTXO))
+	}
+	for i, u := range account.UTXOs {
+		c := rsp.UTXO[i]
+		if u.Txid.String() != c.TxID || u.Index != c.Index || u.Value.GetInt64() != c.Value ||
+			u.LocatedHeight != c.LocatedHeight || u.EffectHeight != c.EffectHeight {
+			return fmt.Errorf("utxo %d mismatch", i)
+		}
+	}
+	return nil
+}
+
+func verifyTxProof(rsp *rpcobject.TxProofRSP, trusted []string) (*meta.Transaction, error) {
+	header, err := verifyProofHeader(rsp.Header, rsp.BlockHash, trusted)
+	if err != nil {
+		return nil, err
+	}
+	if header.TxRoot.String() != rsp.TxRoot {
+		return nil, errors.New("tx root is not the tx root of header")
+	}
+	txid, err := math.NewHashFromStr(rsp.TxID)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := helper.DecodeProof(rsp.Proof)
+	if err != nil {
+		return nil, err
+	}
+	return helper.VerifyTxProof(header.TxRoot, *txid, proof)
+}
@@ -32,8 +32,8 @@ var stopCmd = &cobra.Command{
 	},
 }
 
-func StartCmd(server string) {
-	httpConfig.RPCServer = server
+func StartCmd(cfg *httpclient.Config) {
+	httpConfig = cfg
 	scanner := bufio.NewScanner(os.Stdin)
 	for {
 		fmt.Print(">")
@@ -1,18 +1,37 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
-	"github.com/mihongtech/linkchain/common/util/log"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
 
+	"github.com/mihongtech/linkchain/accounts/keystore"
+	"github.com/mihongtech/linkchain/common/math"
+	"github.com/mihongtech/linkchain/common/util/log"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/helper"
 	"github.com/mihongtech/linkchain/rpc/rpcobject"
+
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 func init() {
 	RootCmd.AddCommand(txCmd)
-	txCmd.AddCommand(getTxCmd)
+	txCmd.AddCommand(getTxCmd,
+		buildTxCmd,
+		signTxCmd,
+		sendRawTxCmd)
+
+	signTxCmd.Flags().StringVar(&signPasswordFile, "password-file", "", "the file of keystore passphrase (default prompt for it)")
 }
 
+//flags of tx sign
+var signPasswordFile string
+
 var txCmd = &cobra.Command{
 	Use:   "tx",
 	Short: "tx command",
@@ -43,3 +62,183 @@ var getTxCmd = &cobra.Command{
 		fmt.Println(out)
 	},
 }
+
+// build unsigned transfer
+var buildTxCmd = &cobra.Command{
+	Use:     "build",
+	Short:   "build <from_address> <to_address> <amount>",
+	Long:    "This is build unsigned transaction command, the output raw tx should be signed by tx sign",
+	Example: "tx build 55b55e136cc6671014029dcbefc42a7db8ad9386 8dafd997b6e65e680768076d92821716fd7950ee 10",
+	Run: func(cmd *cobra.Command, args []string) {
+		example := []string{"example", "tx build 55b55e136cc6671014029dcbefc42a7db8ad9386 8dafd997b6e65e680768076d92821716fd7950ee 10"}
+		if len(args) != 3 {
+			log.Error("buildtx", "error", "please input from, to and amount", example[0], example[1])
+			return
+		}
+
+		fromID, err := helper.CreateAccountIdByAddress(args[0])
+		if err != nil {
+			log.Error("buildtx", "error", "please input from address:hex")
+			return
+		}
+		toID, err := helper.CreateAccountIdByAddress(args[1])
+		if err != nil {
+			log.Error("buildtx", "error", "please input to address:hex")
+			return
+		}
+		value, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			log.Error("buildtx", "error", "please input amount:int")
+			return
+		}
+
+		//get utxo of from account and best height
+		from, err := getRemoteAccount(*fromID)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		out, err := rpc("getBestBlock", nil)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		best := rpcobject.BlockRSP{}
+		if err := json.Unmarshal([]byte(out), &best); err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+
+		amount := meta.NewAmount(value)
+		fromCoin, fromAmount, err := from.MakeFromCoin(amount, best.Height)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		transaction := helper.CreateTransaction(*fromCoin, *helper.CreateToCoin(*toID, amount))
+		backChange := helper.CreateToCoin(*fromID, fromAmount.Subtraction(*amount))
+		if backChange.Value.GetInt64() > 0 {
+			transaction.AddToCoin(*backChange)
+		}
+
+		raw, err := helper.EncodeRawTransaction(transaction)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(raw)
+	},
+}
+
+// sign raw tx by keystore file
+var signTxCmd = &cobra.Command{
+	Use:     "sign",
+	Short:   "sign <keystore_file> <raw_tx>",
+	Long:    "This is sign raw transaction command, the key is decrypted from keystore file locally, the passphrase is prompted or read from --password-file",
+	Example: "tx sign ./keystore/UTC--2018-10-25T08-19-06.017612000Z--55b55e136cc6671014029dcbefc42a7db8ad9386 0801... --password-file ./password.txt",
+	Run: func(cmd *cobra.Command, args []string) {
+		example := []string{"example", "tx sign ./keystore/UTC--2018-10-25T08-19-06.017612000Z--55b55e136cc6671014029dcbefc42a7db8ad9386 0801..."}
+		if len(args) != 2 {
+			log.Error("signtx", "error", "please input keystore file and raw tx", example[0], example[1])
+			return
+		}
+
+		keyjson, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		passphrase, err := readPassphrase(signPasswordFile)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		key, err := keystore.DecryptKey(keyjson, passphrase)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+
+		transaction, err := helper.DecodeRawTransaction(args[1])
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		if err := helper.SignTransaction(transaction, key.PrivateKey); err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+
+		raw, err := helper.EncodeRawTransaction(transaction)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(raw)
+	},
+}
+
+// send signed raw tx
+var sendRawTxCmd = &cobra.Command{
+	Use:     "sendraw",
+	Short:   "sendraw <raw_tx>",
+	Long:    "This is send signed raw transaction command",
+	Example: "tx sendraw 0801...",
+	Run: func(cmd *cobra.Command, args []string) {
+		example := []string{"example", "tx sendraw 0801..."}
+		if len(args) != 1 {
+			log.Error("sendrawtx", "error", "please input raw tx", example[0], example[1])
+			return
+		}
+
+		method := "sendRawTransaction"
+
+		//call
+		out, err := rpc(method, &rpcobject.SendRawTransactionCmd{args[0]})
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(out)
+	},
+}
+
+//read the passphrase from the first line of file, or prompt for it without echo if file is empty
+func readPassphrase(file string) (string, error) {
+	if file != "" {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(strings.SplitN(string(content), "\n", 2)[0], "\r"), nil
+	}
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	passphrase, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(passphrase), nil
+}
+
+//get the account with utxo from rpc server
+func getRemoteAccount(id meta.AccountID) (*meta.Account, error) {
+	out, err := rpc("getAccountInfo", &rpcobject.SingleCmd{id.String()})
+	if err != nil {
+		return nil, err
+	}
+	rsp := rpcobject.AccountRSP{}
+	if err := json.Unmarshal([]byte(out), &rsp); err != nil {
+		return nil, err
+	}
+
+	utxos := make([]meta.UTXO, 0, len(rsp.UTXO))
+	for _, u := range rsp.UTXO {
+		txid, err := math.NewHashFromStr(u.TxID)
+		if err != nil {
+			return nil, err
+		}
+		utxos = append(utxos, *meta.NewUTXO(meta.NewTicket(*txid, u.Index), u.LocatedHeight, u.EffectHeight, *meta.NewAmount(u.Value)))
+	}
+	return meta.NewAccount(id, rsp.Type, utxos, &rsp.ClearDetail, meta.AccountID{}), nil
+}
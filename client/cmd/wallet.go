@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"strconv"
+	"strings"
 
 	"github.com/mihongtech/linkchain/common/util/log"
 	"github.com/mihongtech/linkchain/rpc/rpcobject"
@@ -16,8 +19,52 @@ func init() {
 		getAccountCmd,
 		newAddressCmd,
 		sendMoneyCmd,
+		sendManyCmd,
 		importCmd,
-		exportCmd)
+		exportCmd,
+		unlockCmd,
+		lockCmd,
+		migrateCmd)
+
+	addCoinSelectFlags(sendMoneyCmd)
+	addCoinSelectFlags(sendManyCmd)
+	sendManyCmd.Flags().StringSliceVar(&sendFroms, "from", nil, "the funding accounts, can be repeated (default all accounts of wallet)")
+	sendManyCmd.Flags().StringVar(&sendChange, "change", "", "the account of change (default the first funding account)")
+	sendManyCmd.Flags().StringVar(&sendRecipients, "recipients", "", "json file of the amounts keyed by recipient")
+}
+
+//coin selection flags of send and sendmany
+var (
+	sendStrategy    string
+	sendFeeRate     int64
+	sendDustLimit   int64
+	sendTickets     []string
+	sendConsolidate bool
+)
+
+//flags of sendmany
+var (
+	sendFroms      []string
+	sendChange     string
+	sendRecipients string
+)
+
+func addCoinSelectFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&sendStrategy, "strategy", "", "coin selection strategy: bnb, largest, oldest or random (default bnb)")
+	cmd.Flags().Int64Var(&sendFeeRate, "feerate", 0, "fee per byte of tx")
+	cmd.Flags().Int64Var(&sendDustLimit, "dustlimit", 0, "the change less than it is paid as fee (default 1000)")
+	cmd.Flags().StringSliceVar(&sendTickets, "ticket", nil, "pin the ticket txid:index to spend, can be repeated")
+	cmd.Flags().BoolVar(&sendConsolidate, "consolidate", false, "spend the dust utxos of funding accounts into the change")
+}
+
+func coinSelectCmd() rpcobject.CoinSelectCmd {
+	return rpcobject.CoinSelectCmd{
+		Strategy:    sendStrategy,
+		FeeRate:     sendFeeRate,
+		DustLimit:   sendDustLimit,
+		Tickets:     sendTickets,
+		Consolidate: sendConsolidate,
+	}
 }
 
 var walletCmd = &cobra.Command{
@@ -73,14 +120,18 @@ var getAccountCmd = &cobra.Command{
 // create new account
 var newAddressCmd = &cobra.Command{
 	Use:     "newaccount",
-	Short:   "wallet newaccount",
-	Long:    "This is generate new account command",
-	Example: "wallet newaccount",
+	Short:   "wallet newaccount <passphrase>",
+	Long:    "This is generate new account command, the key is encrypted by passphrase",
+	Example: "wallet newaccount mypassphrase",
 	Run: func(cmd *cobra.Command, args []string) {
 		method := "newAcount"
+		if len(args) != 1 {
+			log.Error("newaccount", "error", "please input <passphrase>")
+			return
+		}
 
 		//call
-		out, err := rpc(method, nil)
+		out, err := rpc(method, rpcobject.NewAccountCmd{Passphrase: args[0]})
 		if err != nil {
 			fmt.Println(err.Error())
 			return
@@ -92,9 +143,9 @@ var newAddressCmd = &cobra.Command{
 //normal transaction
 var sendMoneyCmd = &cobra.Command{
 	Use:     "send ",
-	Short:   "send <from_address> <target_address> <amount>",
-	Long:    "This is send money to account command(normal tx)",
-	Example: "wallet send 02ed6749d314c2e725f1d23d250b4a041ea9c6369594b4f55500d7db41746cdf50 55b55e136cc6671014029dcbefc42a7db8ad9b9d11f62677a47fd2ed77eeef7b 10",
+	Short:   "send <from_address> <target_address> <amount> [--strategy s] [--feerate n] [--ticket txid:index] [--consolidate]",
+	Long:    "This is send money to account command(normal tx), the tickets are selected by the strategy and the fee is paid by fee rate",
+	Example: "wallet send 02ed6749d314c2e725f1d23d250b4a041ea9c6369594b4f55500d7db41746cdf50 55b55e136cc6671014029dcbefc42a7db8ad9b9d11f62677a47fd2ed77eeef7b 10 --strategy largest --feerate 2",
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) != 3 {
 			log.Error("send", "error", "incorrect parameter number")
@@ -111,7 +162,66 @@ var sendMoneyCmd = &cobra.Command{
 		method := "sendMoneyTransaction"
 
 		//call
-		out, err := rpc(method, &rpcobject.SendToTxCmd{fromAccountID, toAccountID, amount})
+		out, err := rpc(method, &rpcobject.SendToTxCmd{
+			FromAccountId: fromAccountID,
+			ToAccountId:   toAccountID,
+			Amount:        amount,
+			CoinSelectCmd: coinSelectCmd(),
+		})
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(out)
+	},
+}
+
+//multi-recipient transaction
+var sendManyCmd = &cobra.Command{
+	Use:     "sendmany",
+	Short:   "sendmany <target_address=amount>... [--recipients file] [--from address] [--change address]",
+	Long:    "This is send money to many accounts command, the payments are split into txs when a tx exceeds the size limit",
+	Example: "wallet sendmany 55b55e136cc6671014029dcbefc42a7db8ad9b9d11f62677a47fd2ed77eeef7b=10 07411e1beff277bf1dd9d810c07a4db0e1e45f5a=20 --from 02ed6749d314c2e725f1d23d250b4a041ea9c6369594b4f55500d7db41746cdf50",
+	Run: func(cmd *cobra.Command, args []string) {
+		amounts := make(map[string]int64)
+		if sendRecipients != "" {
+			data, err := ioutil.ReadFile(sendRecipients)
+			if err != nil {
+				log.Error("sendmany", "error", "read recipients file failed", "err", err)
+				return
+			}
+			if err := json.Unmarshal(data, &amounts); err != nil {
+				log.Error("sendmany", "error", "recipients file must be a json object of amounts", "err", err)
+				return
+			}
+		}
+		for _, arg := range args {
+			kv := strings.SplitN(arg, "=", 2)
+			if len(kv) != 2 {
+				log.Error("sendmany", "error", "please input <target_address=amount>", "arg", arg)
+				return
+			}
+			amount, err := strconv.ParseInt(kv[1], 10, 64)
+			if err != nil {
+				log.Error("sendmany", "error", "please input money:int", "arg", arg)
+				return
+			}
+			amounts[kv[0]] += amount
+		}
+		if len(amounts) == 0 {
+			log.Error("sendmany", "error", "no recipient")
+			return
+		}
+
+		method := "sendMany"
+
+		//call
+		out, err := rpc(method, &rpcobject.SendManyCmd{
+			FromAccountIds:  sendFroms,
+			Amounts:         amounts,
+			ChangeAccountId: sendChange,
+			CoinSelectCmd:   coinSelectCmd(),
+		})
 		if err != nil {
 			fmt.Println(err.Error())
 			return
@@ -122,17 +232,17 @@ var sendMoneyCmd = &cobra.Command{
 
 var importCmd = &cobra.Command{
 	Use:     "import",
-	Short:   "import <privkey>",
-	Long:    "This is import privkey into wallet command",
-	Example: "wallet import 55b55e136cc6671014029dcbefc42a7db8ad9b9d11f62677a47fd2ed77eeef7b",
+	Short:   "import <privkey> <passphrase>",
+	Long:    "This is import privkey into wallet command, the key is encrypted by passphrase",
+	Example: "wallet import 55b55e136cc6671014029dcbefc42a7db8ad9b9d11f62677a47fd2ed77eeef7b mypassphrase",
 	Run: func(cmd *cobra.Command, args []string) {
 		method := "importAccount"
-		if len(args) != 1 {
-			log.Error("wallet", "input error", "please input <privkey hex str>")
+		if len(args) != 2 {
+			log.Error("wallet", "input error", "please input <privkey hex str> <passphrase>")
 			return
 		}
 		//call
-		out, err := rpc(method, rpcobject.ImportAccountCmd{Signer: args[0]})
+		out, err := rpc(method, rpcobject.ImportAccountCmd{Signer: args[0], Passphrase: args[1]})
 		if err != nil {
 			fmt.Println(err.Error())
 			return
@@ -143,17 +253,89 @@ var importCmd = &cobra.Command{
 
 var exportCmd = &cobra.Command{
 	Use:     "export",
-	Short:   "export <address>",
+	Short:   "export <address> <passphrase>",
 	Long:    "This is export privkey from wallet command",
-	Example: "wallet export 025aa040dddd8f873ac5d02dfd249adc4d2c9d6def472a4405252fa6f6650ee1f0",
+	Example: "wallet export 025aa040dddd8f873ac5d02dfd249adc4d2c9d6def472a4405252fa6f6650ee1f0 mypassphrase",
 	Run: func(cmd *cobra.Command, args []string) {
 		method := "exportAccount"
+		if len(args) != 2 {
+			log.Error("export", "error", "please input <accountId hex str> <passphrase>", "example", "wallet export 025aa040dddd8f873ac5d02dfd249adc4d2c9d6def472a4405252fa6f6650ee1f0 mypassphrase")
+			return
+		}
+		//call
+		out, err := rpc(method, rpcobject.ExportAccountCmd{AccountId: args[0], Passphrase: args[1]})
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(out)
+	},
+}
+
+var unlockCmd = &cobra.Command{
+	Use:     "unlock",
+	Short:   "unlock <address> <passphrase> [duration]",
+	Long:    "This is unlock account for signing command, the duration is in seconds, 300 by default and 0 means until locked",
+	Example: "wallet unlock 025aa040dddd8f873ac5d02dfd249adc4d2c9d6def472a4405252fa6f6650ee1f0 mypassphrase 600",
+	Run: func(cmd *cobra.Command, args []string) {
+		method := "unlockAccount"
+		if len(args) < 2 || len(args) > 3 {
+			log.Error("unlock", "error", "please input <accountId hex str> <passphrase> [duration]")
+			return
+		}
+		c := rpcobject.UnlockAccountCmd{AccountId: args[0], Passphrase: args[1]}
+		if len(args) == 3 {
+			duration, err := strconv.ParseUint(args[2], 10, 64)
+			if err != nil {
+				log.Error("unlock", "error", "please input valid [duration]", "err", err)
+				return
+			}
+			c.Duration = &duration
+		}
+		//call
+		out, err := rpc(method, c)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(out)
+	},
+}
+
+var lockCmd = &cobra.Command{
+	Use:     "lock",
+	Short:   "lock <address>",
+	Long:    "This is lock account command",
+	Example: "wallet lock 025aa040dddd8f873ac5d02dfd249adc4d2c9d6def472a4405252fa6f6650ee1f0",
+	Run: func(cmd *cobra.Command, args []string) {
+		method := "lockAccount"
+		if len(args) != 1 {
+			log.Error("lock", "error", "please input <accountId hex str>")
+			return
+		}
+		//call
+		out, err := rpc(method, rpcobject.LockAccountCmd{AccountId: args[0]})
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(out)
+	},
+}
+
+var migrateCmd = &cobra.Command{
+	Use:     "migrate",
+	Short:   "migrate <passphrase>",
+	Long:    "This is re-encrypt the keys and hd seed of the old default password with passphrase command",
+	Example: "wallet migrate mypassphrase",
+	Run: func(cmd *cobra.Command, args []string) {
+		method := "migrateAccounts"
 		if len(args) != 1 {
-			log.Error("export", "error", "please input <accountId hex str>", "example", "wallet export 025aa040dddd8f873ac5d02dfd249adc4d2c9d6def472a4405252fa6f6650ee1f0")
+			log.Error("migrate", "error", "please input <passphrase>")
 			return
 		}
 		//call
-		out, err := rpc(method, rpcobject.ExportAccountCmd{AccountId: args[0]})
+		out, err := rpc(method, rpcobject.MigrateAccountsCmd{Passphrase: args[0]})
 		if err != nil {
 			fmt.Println(err.Error())
 			return
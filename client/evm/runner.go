@@ -113,7 +113,10 @@ func runCmd(ctx *cli.Context) error {
 	if ctx.GlobalString(GenesisFlag.Name) != "" {
 		gen := readGenesis(ctx.GlobalString(GenesisFlag.Name))
 		db, _ := lcdb.NewMemDatabase()
-		genesis := gen.ToBlock(db)
+		genesis, err := gen.ToBlock(db)
+		if err != nil {
+			Fatalf("invalid genesis: %v", err)
+		}
 		s, _ := state.New(*genesis.GetStatus(), db)
 		statedb = contract.NewStateAdapter(s, math.Hash{}, math.Hash{}, meta.AccountID{}, 0)
 		chainConfig = gen.Config
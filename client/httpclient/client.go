@@ -2,6 +2,8 @@ package httpclient
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -15,6 +17,10 @@ type Config struct {
 	RPCUser     string `short:"u" long:"rpcuser" description:"RPC username"`
 	RPCPassword string `short:"P" long:"rpcpass" default-mask:"-" description:"RPC password"`
 	RPCServer   string `short:"s" long:"rpcserver" description:"RPC server to connect to"`
+	RPCToken    string `long:"rpctoken" description:"RPC bearer token, used instead of user and password if it is set"`
+	TLS         bool   `long:"tls" description:"Connect to RPC server with TLS"`
+	RPCCert     string `short:"c" long:"rpccert" description:"RPC server certificate chain for validation"`
+	SkipVerify  bool   `long:"skipverify" description:"Do not verify tls certificates (not recommended!)"`
 }
 
 // newHTTPClient returns a new HTTP client that is configured according to the
@@ -23,11 +29,32 @@ func newHTTPClient(cfg *Config) (*http.Client, error) {
 	// Configure proxy if needed.
 	var dial func(network, addr string) (net.Conn, error)
 
+	// Configure TLS if needed.
+	var tlsConfig *tls.Config
+	if cfg.TLS {
+		tlsConfig = &tls.Config{
+			InsecureSkipVerify: cfg.SkipVerify,
+		}
+		if cfg.RPCCert != "" {
+			pem, err := ioutil.ReadFile(cfg.RPCCert)
+			if err != nil {
+				return nil, err
+			}
+
+			pool := x509.NewCertPool()
+			if ok := pool.AppendCertsFromPEM(pem); !ok {
+				return nil, fmt.Errorf("invalid certificate file: %v", cfg.RPCCert)
+			}
+			tlsConfig.RootCAs = pool
+		}
+	}
+
 	// Create and return the new HTTP client potentially configured with a
 	// proxy and TLS.
 	client := http.Client{
 		Transport: &http.Transport{
-			Dial: dial,
+			Dial:            dial,
+			TLSClientConfig: tlsConfig,
 		},
 	}
 	return &client, nil
@@ -40,6 +67,9 @@ func newHTTPClient(cfg *Config) (*http.Client, error) {
 func SendPostRequest(marshalledJSON []byte, cfg *Config) ([]byte, error) {
 	// Generate a request to the configured RPC server.
 	protocol := "http"
+	if cfg.TLS {
+		protocol = "https"
+	}
 
 	url := protocol + "://" + cfg.RPCServer
 	bodyReader := bytes.NewReader(marshalledJSON)
@@ -50,8 +80,12 @@ func SendPostRequest(marshalledJSON []byte, cfg *Config) ([]byte, error) {
 	httpRequest.Close = true
 	httpRequest.Header.Set("Content-Type", "application/json")
 
-	// Configure basic access authorization.
-	httpRequest.SetBasicAuth(cfg.RPCUser, cfg.RPCPassword)
+	// Configure bearer or basic access authorization.
+	if cfg.RPCToken != "" {
+		httpRequest.Header.Set("Authorization", "Bearer "+cfg.RPCToken)
+	} else {
+		httpRequest.SetBasicAuth(cfg.RPCUser, cfg.RPCPassword)
+	}
 
 	// Create the new HTTP client that is configured according to the user-
 	// specified options and submit the request.
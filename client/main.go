@@ -7,6 +7,7 @@ import (
 
 	"github.com/mihongtech/linkchain/client/cmd"
 	"github.com/mihongtech/linkchain/client/explorer"
+	"github.com/mihongtech/linkchain/client/httpclient"
 	"github.com/mihongtech/linkchain/common/util/log"
 )
 
@@ -15,6 +16,12 @@ func main() {
 	isExplorer := flag.Bool("explorer", false, "is explorer mode")
 	rpcIp := flag.String("rpcip", "127.0.0.1", "linkchain rpc ip")
 	rpcPort := flag.Int("rpcport", 8082, "linkchain rpc port")
+	rpcUser := flag.String("rpcuser", "lc", "linkchain rpc user")
+	rpcPass := flag.String("rpcpass", "lc", "linkchain rpc password")
+	rpcToken := flag.String("rpctoken", "", "linkchain rpc bearer token, used instead of user and password if it is set")
+	rpcTLS := flag.Bool("tls", false, "connect to linkchain rpc with TLS")
+	rpcCert := flag.String("rpccert", "", "the certificate file to verify linkchain rpc server")
+	skipVerify := flag.Bool("skipverify", false, "do not verify the certificate of linkchain rpc server (not recommended)")
 	flag.Parse()
 
 	//init log
@@ -27,6 +34,14 @@ func main() {
 	if *isExplorer {
 		explorer.StartExplore()
 	} else {
-		cmd.StartCmd(*rpcIp + ":" + strconv.Itoa(*rpcPort))
+		cmd.StartCmd(&httpclient.Config{
+			RPCUser:     *rpcUser,
+			RPCPassword: *rpcPass,
+			RPCServer:   *rpcIp + ":" + strconv.Itoa(*rpcPort),
+			RPCToken:    *rpcToken,
+			TLS:         *rpcTLS,
+			RPCCert:     *rpcCert,
+			SkipVerify:  *skipVerify,
+		})
 	}
 }
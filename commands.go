@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mihongtech/linkchain/app"
+	"github.com/mihongtech/linkchain/common/lcdb"
+	"github.com/mihongtech/linkchain/common/util/log"
+	"github.com/mihongtech/linkchain/config"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/storage"
+	"github.com/mihongtech/linkchain/storage/pruner"
+
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+//commands are run on the data dir instead of starting the node, e.g. lcd prune-state --datadir ./data
+var commands = map[string]func(cfg *config.LinkChainConfig, args []string) error{
+	"prune-state": pruneState,
+	"export":      exportChain,
+	"import":      importChain,
+}
+
+//importBatchSize is the count of blocks read from the file and imported in a batch
+const importBatchSize = 2500
+
+func runCommand(name string, cfg *config.LinkChainConfig, args []string) error {
+	cmd, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %s", name)
+	}
+	return cmd(cfg, args)
+}
+
+//open the chain database of data dir, it fails if the node is running
+func openChainDB(cfg *config.LinkChainConfig) (lcdb.Database, error) {
+	s := storage.NewStrorage(cfg.DataDir)
+	if s == nil {
+		return nil, errors.New("open storage failed, the node must be stopped")
+	}
+	return s.GetDB(), nil
+}
+
+//pruneState deletes the stale state of a stopped node, the states of the recent blocks,
+//the checkpoints and the genesis are kept
+func pruneState(cfg *config.LinkChainConfig, args []string) error {
+	db, err := openChainDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	hash := storage.GetHeadBlockHash(db)
+	head := storage.GetBlock(db, hash, storage.GetBlockNumber(db, hash))
+	if head == nil {
+		return errors.New("head block is missing")
+	}
+	retention := cfg.StateRetention
+	if retention == 0 {
+		retention = pruner.DefaultRetention
+	}
+	p := pruner.NewPruner(db, nil, pruner.Config{
+		Retention:  retention,
+		Checkpoint: cfg.StateCheckpoint,
+		BloomSize:  cfg.PruneBloomSize,
+	})
+	if err := p.Prune(head); err != nil {
+		return err
+	}
+
+	//reclaim the disk space of the deleted entries
+	kvdb := db
+	if chain, ok := db.(*storage.ChainDB); ok {
+		kvdb = chain.KeyValueStore()
+	}
+	if ldb, ok := kvdb.(*lcdb.LDBDatabase); ok {
+		log.Info("Compacting database")
+		if err := ldb.LDB().CompactRange(util.Range{}); err != nil {
+			return err
+		}
+	}
+	log.Info("State pruning done", "number", head.GetHeight(), "retention", retention)
+	return nil
+}
+
+//exportChain writes the canonical blocks from the from block to the to block into the file, e.g.
+//lcd export blocks.gz 0 10000, all the blocks are exported by default, and the file is gzipped
+//if its name ends with .gz
+func exportChain(cfg *config.LinkChainConfig, args []string) error {
+	if len(args) < 1 || len(args) > 3 {
+		return errors.New("usage: lcd export <file> [from] [to]")
+	}
+	db, err := openChainDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	head := storage.GetBlockNumber(db, storage.GetHeadBlockHash(db))
+	if head == storage.MissingNumber {
+		return errors.New("head block is missing")
+	}
+	from, to := uint64(0), head
+	if len(args) > 1 {
+		if from, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+			return fmt.Errorf("invalid from block %s", args[1])
+		}
+	}
+	if len(args) > 2 {
+		if to, err = strconv.ParseUint(args[2], 10, 64); err != nil {
+			return fmt.Errorf("invalid to block %s", args[2])
+		}
+	}
+	if from > to || to > head {
+		return fmt.Errorf("invalid block range [%d, %d], the head is %d", from, to, head)
+	}
+
+	file, err := os.OpenFile(args[0], os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	start := time.Now()
+	writer := bufio.NewWriter(file)
+	var w io.Writer = writer
+	var gw *gzip.Writer
+	if strings.HasSuffix(args[0], ".gz") {
+		gw = gzip.NewWriter(writer)
+		w = gw
+	}
+	if err := storage.ExportBlocks(db, w, from, to); err != nil {
+		return err
+	}
+	if gw != nil {
+		if err := gw.Close(); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	log.Info("Exported blocks", "file", args[0], "from", from, "to", to, "elapsed", time.Since(start))
+	return nil
+}
+
+//importChain processes the blocks of the file written by lcd export, e.g. lcd import blocks.gz,
+//the blocks already in the chain are skipped, so an interrupted import is resumed by running it again
+func importChain(cfg *config.LinkChainConfig, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: lcd import <file>")
+	}
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var r io.Reader = bufio.NewReader(file)
+	if strings.HasSuffix(args[0], ".gz") {
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	if !app.SetupNode(cfg) {
+		return errors.New("setup node failed, the node must be stopped")
+	}
+	defer app.StopNode()
+	nodeAPI := app.GetNodeAPI()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	var (
+		imported, skipped int
+		start             = time.Now()
+		batch             = make([]*meta.Block, 0, importBatchSize)
+	)
+	for done := false; !done; {
+		batch = batch[:0]
+		for len(batch) < importBatchSize {
+			block, err := storage.ReadExportedBlock(r)
+			if err == io.EOF {
+				done = true
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("read block %d of file failed: %v", imported+skipped+len(batch), err)
+			}
+			batch = append(batch, block)
+		}
+		for _, block := range batch {
+			select {
+			case <-interrupt:
+				log.Warn("Import interrupted", "imported", imported, "skipped", skipped, "head", nodeAPI.GetBestBlock().GetHeight())
+				return errors.New("import interrupted, run it again to resume")
+			default:
+			}
+			if nodeAPI.HasBlock(*block.GetBlockID()) {
+				skipped++
+				continue
+			}
+			if err := nodeAPI.ProcessBlock(block); err != nil {
+				return fmt.Errorf("import block %d %s failed: %v", block.GetHeight(), block.GetBlockID(), err)
+			}
+			imported++
+		}
+		log.Info("Importing blocks", "imported", imported, "skipped", skipped, "head", nodeAPI.GetBestBlock().GetHeight(), "elapsed", time.Since(start))
+	}
+	log.Info("Imported blocks", "file", args[0], "imported", imported, "skipped", skipped, "head", nodeAPI.GetBestBlock().GetHeight(), "elapsed", time.Since(start))
+	return nil
+}
@@ -0,0 +1,35 @@
+package lcdb
+
+import (
+	"bytes"
+	"errors"
+)
+
+var ErrNotIterable = errors.New("database does not support iteration")
+
+//IterateKeys calls fn with every key of db which has the prefix until fn returns false,
+//a database wrapping a key-value store iterates the keys of the store.
+//The key is a copy, so it is safe to delete it in fn.
+func IterateKeys(db Database, prefix []byte, fn func(key []byte) bool) error {
+	switch db := db.(type) {
+	case *LDBDatabase:
+		it := db.NewIteratorWithPrefix(prefix)
+		defer it.Release()
+		for it.Next() {
+			if !fn(CopyBytes(it.Key())) {
+				break
+			}
+		}
+		return it.Error()
+	case *MemDatabase:
+		for _, key := range db.Keys() {
+			if bytes.HasPrefix(key, prefix) && !fn(key) {
+				break
+			}
+		}
+		return nil
+	case interface{ KeyValueStore() Database }:
+		return IterateKeys(db.KeyValueStore(), prefix, fn)
+	}
+	return ErrNotIterable
+}
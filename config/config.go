@@ -1,26 +1,205 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/mihongtech/linkchain/contract/vm/params"
+	"io/ioutil"
 	"math/big"
 	"os"
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"time"
 )
 
 type ChainConfig struct {
 	ChainId *big.Int `json:"chainId"` // chain id identifies the current chain and is used for replay protection
 	Period  uint64   `json:"period"`  // Number of seconds between blocks to enforce
+
+	BlockReward int64  `json:"blockReward,omitempty"` // The reward of mining a block, DefaultBlockReward if zero
+	GasLimit    uint64 `json:"gasLimit,omitempty"`    // The gas limit of contract in a block, DefaultBlockGasLimit if zero
+
+	// Fork activation heights, a nil height means the fork is never activated unless noted.
+	RewardChangeBlock   *big.Int `json:"rewardChangeBlock,omitempty"`   // Height from which ChangedBlockReward is rewarded
+	ChangedBlockReward  int64    `json:"changedBlockReward,omitempty"`  // The reward of mining a block since RewardChangeBlock
+	ContractEnableBlock *big.Int `json:"contractEnableBlock,omitempty"` // Height from which contract txs are accepted, nil enables them since genesis
+	ConstantinopleBlock *big.Int `json:"constantinopleBlock,omitempty"` // Height from which the constantinople gas table and instructions are used
+	OutTurnBlock        *big.Int `json:"outTurnBlock,omitempty"`        // Height from which poa signers may seal out of turn, nil allows it since genesis
+
+	Poa *PoaConfig `json:"poa,omitempty"` // Proof-of-authority consensus params
+	Pow *PowConfig `json:"pow,omitempty"` // Proof-of-work consensus params, the chain is sealed by pow if set
+}
+
+// PoaConfig is the consensus engine configs for proof-of-authority based sealing.
+type PoaConfig struct {
+	Epoch        uint64   `json:"epoch"`        // Epoch length to reset votes and checkpoint
+	Signers      []string `json:"signers"`      // The initial signers of the chain
+	OutTurnDelay uint64   `json:"outTurnDelay"` // Seconds after the parent before an out-of-turn signer may seal, 2*period if zero
+}
+
+// PowConfig is the consensus engine configs for proof-of-work based sealing.
+type PowConfig struct {
+	Limit uint32 `json:"limit"` // The easiest compact target a block may have, DefaultDifficulty if zero
+}
+
+// GetBlockReward returns the reward of mining the block at height.
+func (c *ChainConfig) GetBlockReward(height uint32) int64 {
+	if c.IsRewardChange(height) {
+		return c.ChangedBlockReward
+	}
+	if c.BlockReward == 0 {
+		return DefaultBlockReward
+	}
+	return c.BlockReward
+}
+
+// GetGasLimit returns the gas limit of contract in a block.
+func (c *ChainConfig) GetGasLimit() uint64 {
+	if c.GasLimit == 0 {
+		return DefaultBlockGasLimit
+	}
+	return c.GasLimit
+}
+
+// IsRewardChange returns whether height is either equal to the reward change fork block or greater.
+func (c *ChainConfig) IsRewardChange(height uint32) bool {
+	return isForked(c.RewardChangeBlock, height)
+}
+
+// IsContractEnable returns whether contract txs are accepted at height.
+func (c *ChainConfig) IsContractEnable(height uint32) bool {
+	return c.ContractEnableBlock == nil || isForked(c.ContractEnableBlock, height)
 }
 
-// GasTable returns the gas table corresponding to the current phase (homestead or homestead reprice).
+// IsConstantinople returns whether height is either equal to the constantinople fork block or greater.
+func (c *ChainConfig) IsConstantinople(height uint32) bool {
+	return isForked(c.ConstantinopleBlock, height)
+}
+
+// IsOutTurn returns whether poa signers may seal out of turn at height.
+func (c *ChainConfig) IsOutTurn(height uint32) bool {
+	return c.OutTurnBlock == nil || isForked(c.OutTurnBlock, height)
+}
+
+// GasTable returns the gas table corresponding to the current phase (eip158 or constantinople).
 //
 // The returned GasTable's fields shouldn't, under any circumstances, be changed.
 func (c *ChainConfig) GasTable(num *big.Int) params.GasTable {
+	if num != nil && c.ConstantinopleBlock != nil && c.ConstantinopleBlock.Cmp(num) <= 0 {
+		return params.GasTableConstantinople
+	}
 	return params.GasTableEIP158
 }
 
+// CheckCompatible checks whether scheduled fork transitions have been imported
+// with a mismatching chain configuration.
+func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint32) *ConfigCompatError {
+	if isForkIncompatible(c.RewardChangeBlock, newcfg.RewardChangeBlock, height) {
+		return newCompatError("reward change fork block", c.RewardChangeBlock, newcfg.RewardChangeBlock)
+	}
+	if c.IsRewardChange(height) && c.ChangedBlockReward != newcfg.ChangedBlockReward {
+		return newCompatError("changed block reward", c.RewardChangeBlock, newcfg.RewardChangeBlock)
+	}
+	if isForkIncompatible(genesisForkBlock(c.ContractEnableBlock), genesisForkBlock(newcfg.ContractEnableBlock), height) {
+		return newCompatError("contract enable fork block", c.ContractEnableBlock, newcfg.ContractEnableBlock)
+	}
+	if isForkIncompatible(c.ConstantinopleBlock, newcfg.ConstantinopleBlock, height) {
+		return newCompatError("constantinople fork block", c.ConstantinopleBlock, newcfg.ConstantinopleBlock)
+	}
+	if isForkIncompatible(genesisForkBlock(c.OutTurnBlock), genesisForkBlock(newcfg.OutTurnBlock), height) {
+		return newCompatError("out turn fork block", c.OutTurnBlock, newcfg.OutTurnBlock)
+	}
+	// The consensus params have no fork block, the sealed blocks are only valid with the stored ones
+	if (c.Pow == nil) != (newcfg.Pow == nil) {
+		return newCompatError("consensus engine", nil, nil)
+	}
+	if c.Pow == nil {
+		if c.Period != newcfg.Period {
+			return newCompatError("poa period", new(big.Int).SetUint64(c.Period), new(big.Int).SetUint64(newcfg.Period))
+		}
+		stored, poa := poaOrEmpty(c.Poa), poaOrEmpty(newcfg.Poa)
+		if stored.Epoch != poa.Epoch {
+			return newCompatError("poa epoch", new(big.Int).SetUint64(stored.Epoch), new(big.Int).SetUint64(poa.Epoch))
+		}
+		if !stringsEqual(stored.Signers, poa.Signers) {
+			return newCompatError("poa signers", nil, nil)
+		}
+	}
+	return nil
+}
+
+func poaOrEmpty(c *PoaConfig) *PoaConfig {
+	if c == nil {
+		return &PoaConfig{}
+	}
+	return c
+}
+
+func stringsEqual(x, y []string) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// genesisForkBlock returns the fork block s, the fork is activated since
+// genesis if s is not set.
+func genesisForkBlock(s *big.Int) *big.Int {
+	if s == nil {
+		return new(big.Int)
+	}
+	return s
+}
+
+// isForkIncompatible returns true if a fork scheduled at s1 cannot be rescheduled to
+// block s2 because head is already past the fork.
+func isForkIncompatible(s1, s2 *big.Int, height uint32) bool {
+	return (isForked(s1, height) || isForked(s2, height)) && !configNumEqual(s1, s2)
+}
+
+// isForked returns whether a fork scheduled at block s is active at the given height.
+func isForked(s *big.Int, height uint32) bool {
+	if s == nil {
+		return false
+	}
+	return s.Cmp(new(big.Int).SetUint64(uint64(height))) <= 0
+}
+
+func configNumEqual(x, y *big.Int) bool {
+	if x == nil {
+		return y == nil
+	}
+	if y == nil {
+		return x == nil
+	}
+	return x.Cmp(y) == 0
+}
+
+// ConfigCompatError is raised if the locally-stored blockchain is initialised with a
+// ChainConfig that would alter the past.
+type ConfigCompatError struct {
+	What string
+	// block numbers of the stored and new configurations
+	StoredConfig, NewConfig *big.Int
+}
+
+func newCompatError(what string, storedblock, newblock *big.Int) *ConfigCompatError {
+	return &ConfigCompatError{What: what, StoredConfig: storedblock, NewConfig: newblock}
+}
+
+func (err *ConfigCompatError) Error() string {
+	if err.StoredConfig == nil && err.NewConfig == nil {
+		return fmt.Sprintf("mismatching %s in database", err.What)
+	}
+	return fmt.Sprintf("mismatching %s in database (have %d, want %d)", err.What, err.StoredConfig, err.NewConfig)
+}
+
 type LinkChainConfig struct {
 	// DataDir is the file system folder the node should use for any data storage
 	// requirements. The configured data directory will not be directly shared with
@@ -32,10 +211,67 @@ type LinkChainConfig struct {
 	//NodeService 	  common.Service
 	ListenAddress  string
 	NoDiscovery    bool
+	NoEncryption   bool
 	BootstrapNodes string
 	InterpreterAPI string
+	//TxPool
+	TxPoolSize int
+	//Sync mode of blocks, full or fast
+	SyncMode string
+	//State pruning, the states of the recent StateRetention blocks and every StateCheckpoint blocks are kept,
+	//the others are pruned every PruneInterval blocks, zero retention keeps all states
+	StateRetention  uint64
+	StateCheckpoint uint64
+	PruneInterval   uint64
+	PruneBloomSize  uint64
+	//Blocks older than AncientDepth are frozen into the ancient store, and the bodies older than BodyHorizon
+	//are pruned while the headers are kept, zero disables them
+	AncientDepth uint64
+	BodyHorizon  uint64
 	//Rpc
-	RpcAddr string
+	RpcAddr        string
+	RpcMaxBodySize int64
+	RpcBatchLimit  int
+	RpcTLSCert     string
+	RpcTLSKey      string
+	RpcCredentials []RpcCredential
+	RpcWSOrigins   []string
+	//Rpc limits, zero means no limit
+	RpcIPRate          float64
+	RpcIPBurst         float64
+	RpcCredentialRate  float64
+	RpcCredentialBurst float64
+	RpcMethodCosts     map[string]float64
+	RpcTimeout         time.Duration
+
+	//Wallet accounts unlocked at start, the passwords are matched in order and the last one is used for the rest
+	WalletUnlock    []string
+	WalletPasswords []string
+}
+
+// RpcCredential is a credential of rpc clients, it is either a user with password
+// for HTTP basic authentication or a token for bearer authentication. The
+// credential is only allowed to call the methods of its namespaces.
+type RpcCredential struct {
+	User       string   `json:"user"`
+	Password   string   `json:"password"`
+	Token      string   `json:"token"`
+	Namespaces []string `json:"namespaces"`
+	Rate       float64  `json:"rate,omitempty"`  // Requests cost per second, the rate of all credentials is used if zero
+	Burst      float64  `json:"burst,omitempty"` // Max requests cost of burst, the burst of all credentials is used if zero
+}
+
+// LoadRpcCredentials loads the rpc credentials from a json file of credential list.
+func LoadRpcCredentials(path string) ([]RpcCredential, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var credentials []RpcCredential
+	if err := json.Unmarshal(data, &credentials); err != nil {
+		return nil, fmt.Errorf("invalid rpc credential file %s: %v", path, err)
+	}
+	return credentials, nil
 }
 
 // DefaultDataDir is the default data directory to use for the databases and other
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/mihongtech/linkchain/contract/vm/params"
+)
+
+func TestForkSchedule(t *testing.T) {
+	c := &ChainConfig{
+		BlockReward:         10,
+		RewardChangeBlock:   big.NewInt(100),
+		ChangedBlockReward:  5,
+		ContractEnableBlock: big.NewInt(50),
+		ConstantinopleBlock: big.NewInt(200),
+	}
+	if reward := c.GetBlockReward(99); reward != 10 {
+		t.Errorf("reward before fork: want 10, got %d", reward)
+	}
+	if reward := c.GetBlockReward(100); reward != 5 {
+		t.Errorf("reward at fork: want 5, got %d", reward)
+	}
+	if c.IsContractEnable(49) || !c.IsContractEnable(50) {
+		t.Errorf("contract enable fork mismatch")
+	}
+	if c.GasTable(big.NewInt(199)) != params.GasTableEIP158 || c.GasTable(big.NewInt(200)) != params.GasTableConstantinople {
+		t.Errorf("gas table fork mismatch")
+	}
+	if !c.IsOutTurn(0) {
+		t.Errorf("unset out-of-turn fork is not active since genesis")
+	}
+
+	//unset forks keep their defaults
+	empty := &ChainConfig{}
+	if empty.GetBlockReward(1000) != DefaultBlockReward || !empty.IsContractEnable(0) || empty.IsConstantinople(1000) {
+		t.Errorf("unset forks mismatch")
+	}
+}
+
+func TestCheckCompatible(t *testing.T) {
+	tests := []struct {
+		stored, new *ChainConfig
+		height      uint32
+		wantErr     bool
+	}{
+		{stored: &ChainConfig{}, new: &ChainConfig{}, height: 100},
+		//a future fork may be scheduled or rescheduled
+		{stored: &ChainConfig{}, new: &ChainConfig{RewardChangeBlock: big.NewInt(200)}, height: 100},
+		{stored: &ChainConfig{RewardChangeBlock: big.NewInt(200)}, new: &ChainConfig{RewardChangeBlock: big.NewInt(300)}, height: 100},
+		//a past fork can not be changed
+		{stored: &ChainConfig{}, new: &ChainConfig{RewardChangeBlock: big.NewInt(50)}, height: 100, wantErr: true},
+		{stored: &ChainConfig{RewardChangeBlock: big.NewInt(50), ChangedBlockReward: 1}, new: &ChainConfig{RewardChangeBlock: big.NewInt(50), ChangedBlockReward: 2}, height: 100, wantErr: true},
+		{stored: &ChainConfig{ConstantinopleBlock: big.NewInt(50)}, new: &ChainConfig{}, height: 100, wantErr: true},
+		//an unset genesis fork is equal to zero
+		{stored: &ChainConfig{}, new: &ChainConfig{ContractEnableBlock: big.NewInt(0)}, height: 100},
+		{stored: &ChainConfig{}, new: &ChainConfig{ContractEnableBlock: big.NewInt(50)}, height: 100, wantErr: true},
+		{stored: &ChainConfig{}, new: &ChainConfig{OutTurnBlock: big.NewInt(200)}, height: 100, wantErr: true},
+		//the consensus engine and its params can not be changed
+		{stored: &ChainConfig{}, new: &ChainConfig{Pow: &PowConfig{}}, height: 100, wantErr: true},
+		{stored: &ChainConfig{Period: 5}, new: &ChainConfig{Period: 3}, height: 100, wantErr: true},
+		{stored: &ChainConfig{Poa: &PoaConfig{Epoch: 100}}, new: &ChainConfig{Poa: &PoaConfig{Epoch: 200}}, height: 100, wantErr: true},
+		{stored: &ChainConfig{Poa: &PoaConfig{Signers: []string{"a", "b"}}}, new: &ChainConfig{Poa: &PoaConfig{Signers: []string{"a", "c"}}}, height: 100, wantErr: true},
+		{stored: &ChainConfig{Poa: &PoaConfig{Signers: []string{"a"}}}, new: &ChainConfig{}, height: 100, wantErr: true},
+		{stored: &ChainConfig{Poa: &PoaConfig{Signers: []string{"a"}, OutTurnDelay: 5}}, new: &ChainConfig{Poa: &PoaConfig{Signers: []string{"a"}}}, height: 100},
+		{stored: &ChainConfig{Period: 5, Pow: &PowConfig{}}, new: &ChainConfig{Period: 3, Pow: &PowConfig{}}, height: 100},
+	}
+	for i, test := range tests {
+		if err := test.stored.CheckCompatible(test.new, test.height); (err != nil) != test.wantErr {
+			t.Errorf("test %d: want error %v, got %v", i, test.wantErr, err)
+		}
+	}
+}
@@ -15,16 +15,19 @@ const (
 	DefaultNounce             = 0x00000000 //the default nounce of  block.
 	DefaultTransactionVersion = 0x00000001 //the version of transaction
 	DefaultBlockReward        = 5000000000 //the reward of mining a block
+	DefaultBlockGasLimit      = 200000000000 //the gas limit of contract in a block
 
 	DefaultNodeDatabaseDir = "nodes"   // Path within the datadir to store the node infos
 	DefaultPrivateKeyDir   = "nodekey" // Path within the datadir to the node's private key
 	DefaultMaxPeers        = 25
 
-	TxTypeCount = 2
+	TxTypeCount = 3
 	CoinBaseTx  = 0x00000000 //the coinbase tx for reward to miner
 	NormalTx    = 0x00000001 //the normal tx
+	VoteTx      = 0x00000002 //the tx which signer votes to add or remove a signer
 
-	NormalAccount = 0x00000000 // the normal account
+	NormalAccount   = 0x00000000 // the normal account
+	ContractAccount = 0x00000002 // the contract account
 
 	TargetTimespan = 10 * time.Second
 	MaxTimespan    = 1 * time.Minute
@@ -33,12 +36,16 @@ const (
 
 	BlockSizeLimit       = 2 * 1024 * 1024 * 8
 	TransactionSizeLimit = 100 * 1024 * 8
+
+	DefaultEpoch = 30000 //the number of blocks after which to reset the poa votes
+
+	DefaultTxPoolSize = 4096 //the max count of txs in tx pool
 )
 
 var (
 	SignMiners         = []string{FirstPubMiner, SecondPubMiner, ThirdPubMiner}
 	DefaultPeriod      = 15
-	DefaultChainConfig = &ChainConfig{big.NewInt(1337), uint64(DefaultPeriod)}
+	DefaultChainConfig = &ChainConfig{ChainId: big.NewInt(1337), Period: uint64(DefaultPeriod), BlockReward: DefaultBlockReward, GasLimit: DefaultBlockGasLimit, Poa: &PoaConfig{Epoch: DefaultEpoch, Signers: SignMiners}}
 
 	// PowLimit is the highest proof of work value a Bitcoin block can
 	// have for the main network.  It is the value 2^224 - 1.
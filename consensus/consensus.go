@@ -2,6 +2,8 @@ package consensus
 
 import (
 	"errors"
+	"math/big"
+	"time"
 
 	"github.com/mihongtech/linkchain/core/meta"
 )
@@ -41,7 +43,23 @@ type Engine interface {
 	// the consensus rules of the given engine.
 	VerifySeal(chain meta.ChainReader, block *meta.Block) error
 
+	// Prepare initializes the consensus fields of a block according to the
+	// rules of a particular engine.
+	Prepare(chain meta.ChainReader, block *meta.Block) error
+
+	// Seal generates the consensus seal of the block, the sealing is aborted
+	// once stop is closed.
+	Seal(chain meta.ChainReader, block *meta.Block, stop <-chan struct{}) error
+
 	// Get the signer of current block
 	// ,return signer publicKey string.
-	GetBlockSigner(header *meta.BlockHeader) string
+	GetBlockSigner(chain meta.ChainReader, header *meta.BlockHeader) string
+
+	// CalcWeight returns the fork-choice weight the block adds to its chain,
+	// the chain with the highest total weight is the canonical one.
+	CalcWeight(chain meta.ChainReader, block *meta.Block) (*big.Int, error)
+
+	// SealTime returns the earliest time the signer is allowed to seal the
+	// block with the given header.
+	SealTime(chain meta.ChainReader, header *meta.BlockHeader, signer meta.AccountID) (time.Time, error)
 }
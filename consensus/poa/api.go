@@ -0,0 +1,51 @@
+package poa
+
+import (
+	"github.com/mihongtech/linkchain/core/meta"
+)
+
+// Propose injects a new authorization proposal that the signer will attempt to
+// push through.
+func (p *Poa) Propose(candidate meta.AccountID, authorize bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.proposals[candidate] = authorize
+}
+
+// Discard drops a currently running proposal, stopping the signer from casting
+// further votes (either for or against).
+func (p *Poa) Discard(candidate meta.AccountID) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.proposals, candidate)
+}
+
+// Proposals returns the current proposals the node tries to uphold and vote on.
+func (p *Poa) Proposals() map[meta.AccountID]bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	proposals := make(map[meta.AccountID]bool)
+	for candidate, authorize := range p.proposals {
+		proposals[candidate] = authorize
+	}
+	return proposals
+}
+
+// GetSnapshot retrieves the state snapshot at a given block.
+func (p *Poa) GetSnapshot(chain meta.ChainReader, block *meta.Block) (*Snapshot, error) {
+	return p.snapshot(chain, block.GetHeight(), *block.GetBlockID())
+}
+
+// GetSigners retrieves the list of authorized signers at the specified block.
+func (p *Poa) GetSigners(chain meta.ChainReader, block *meta.Block) ([]meta.AccountID, error) {
+	snap, err := p.GetSnapshot(chain, block)
+	if err != nil {
+		return nil, err
+	}
+	signers := make([]meta.AccountID, len(snap.Signers))
+	copy(signers, snap.Signers)
+	return signers, nil
+}
@@ -1,16 +1,72 @@
 package poa
 
 import (
-	"encoding/hex"
 	"errors"
-	"fmt"
+	"math/big"
+	"math/rand"
 	"sync"
+	"time"
 
+	"github.com/hashicorp/golang-lru"
 	"github.com/mihongtech/linkchain/common/btcec"
 	"github.com/mihongtech/linkchain/common/lcdb"
 	"github.com/mihongtech/linkchain/common/math"
+	"github.com/mihongtech/linkchain/common/util/log"
 	"github.com/mihongtech/linkchain/config"
+	"github.com/mihongtech/linkchain/consensus"
 	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/helper"
+)
+
+const (
+	checkpointInterval = 1024 // Number of blocks after which to save the vote snapshot to the database
+	inmemorySnapshots  = 128  // Number of recent vote snapshots to keep in memory
+
+	allowedFutureBlockTime = 15 * time.Second // Max time from current time allowed for blocks, before they're considered future blocks
+)
+
+var (
+	weightInTurn = big.NewInt(2) // Block weight of in-turn signatures
+	weightNoTurn = big.NewInt(1) // Block weight of out-of-turn signatures
+)
+
+var (
+	// errUnknownBlock is returned when the list of signers is requested for a block
+	// that is not part of the local blockchain.
+	errUnknownBlock = errors.New("unknown block")
+
+	// errInvalidVotingChain is returned if an authorization list is attempted to
+	// be modified via out-of-range or non-contiguous blocks.
+	errInvalidVotingChain = errors.New("invalid voting chain")
+
+	// errUnauthorized is returned if a block is signed by a non-authorized entity.
+	errUnauthorized = errors.New("unauthorized signer")
+
+	// errMultiVotes is returned if a block contains more than one vote tx.
+	errMultiVotes = errors.New("block contains more than one vote")
+
+	// errCheckpointVote is returned if a checkpoint block contains a vote tx.
+	errCheckpointVote = errors.New("vote in checkpoint block")
+
+	// errInvalidVote is returned if the vote tx of block can not be parsed or
+	// the height of vote is not equal to the block.
+	errInvalidVote = errors.New("invalid vote")
+
+	// errInvalidTimestamp is returned if the timestamp of a block is lower than
+	// the timestamp of its parent plus the period.
+	errInvalidTimestamp = errors.New("invalid timestamp")
+
+	// errOutOfTurnTooEarly is returned if an out-of-turn signer sealed the block
+	// before the out-of-turn delay passed.
+	errOutOfTurnTooEarly = errors.New("out-of-turn block sealed too early")
+
+	// errOutOfTurnInactive is returned if an out-of-turn signer sealed the block
+	// before the out-of-turn fork is activated.
+	errOutOfTurnInactive = errors.New("out-of-turn sealing is not activated")
+
+	// errSealAborted is returned if the sealing is stopped before the time of
+	// the block.
+	errSealAborted = errors.New("seal aborted")
 )
 
 // SignerFn is a signer callback function to request a hash to be signed by a
@@ -20,9 +76,11 @@ type SignerFn func(meta.Account, []byte) ([]byte, error)
 // Poa is the proof-of-authority consensus engine proposed
 type Poa struct {
 	chainConfig *config.ChainConfig // Consensus engine configuration parameters
+	config      *config.PoaConfig   // Poa consensus params of chain config
 	db          lcdb.Database       // Database to store and retrieve snapshot checkpoints
 
-	proposals map[math.Hash]bool // Current list of proposals we are pushing
+	recents   *lru.ARCCache           // Snapshots for recent block to speed up reorgs
+	proposals map[meta.AccountID]bool // Current list of proposals we are pushing
 
 	signer math.Hash    // address of the signing key
 	signFn SignerFn     // Signer function to authorize hashes with
@@ -34,49 +92,309 @@ type Poa struct {
 func NewPoa(chainConfig *config.ChainConfig, db lcdb.Database) *Poa {
 	// Set any missing consensus parameters to their defaults
 	conf := *chainConfig
+	poaConf := config.PoaConfig{Epoch: config.DefaultEpoch, Signers: config.SignMiners}
+	if conf.Poa != nil {
+		poaConf = *conf.Poa
+	}
+	if poaConf.Epoch == 0 {
+		poaConf.Epoch = config.DefaultEpoch
+	}
+	if len(poaConf.Signers) == 0 {
+		poaConf.Signers = config.SignMiners
+	}
+	if conf.Period == 0 {
+		conf.Period = uint64(config.DefaultPeriod)
+	}
+	if poaConf.OutTurnDelay == 0 {
+		poaConf.OutTurnDelay = 2 * conf.Period
+	}
+	conf.Poa = &poaConf
+
+	recents, _ := lru.NewARC(inmemorySnapshots)
 
 	return &Poa{
 		chainConfig: &conf,
+		config:      &poaConf,
 		db:          db,
-		proposals:   make(map[math.Hash]bool),
+		recents:     recents,
+		proposals:   make(map[meta.AccountID]bool),
 	}
 }
 
-func (p *Poa) Author(header *meta.BlockHeader) ([]byte, error) {
-	pub, _, err := btcec.RecoverCompact(btcec.S256(), header.Sign.Code, (*header.GetBlockID())[:])
+// ecrecover extracts the account id of signer from a signed block header.
+func ecrecover(header *meta.BlockHeader) (meta.AccountID, error) {
+	pub, _, err := btcec.RecoverCompact(btcec.S256(), header.Sign.Code, header.GetBlockID().CloneBytes())
+	if err != nil {
+		return meta.AccountID{}, err
+	}
+	return *meta.NewAccountId(pub), nil
+}
+
+// getVoteTx returns the vote tx of block, nil if the block has no vote.
+func getVoteTx(block *meta.Block) *meta.Transaction {
+	txs := block.GetTxs()
+	for i := range txs {
+		if txs[i].Type == config.VoteTx {
+			return &txs[i]
+		}
+	}
+	return nil
+}
+
+// snapshot retrieves the authorization snapshot at a given point in time.
+func (p *Poa) snapshot(chain meta.ChainReader, height uint32, hash meta.BlockID) (*Snapshot, error) {
+	// Search for a snapshot in memory or on disk for checkpoints
+	var (
+		blocks []*meta.Block
+		snap   *Snapshot
+	)
+	for snap == nil {
+		// If an in-memory snapshot was found, use that
+		if s, ok := p.recents.Get(hash); ok {
+			snap = s.(*Snapshot)
+			break
+		}
+		// If an on-disk checkpoint snapshot can be found, use that
+		if height%checkpointInterval == 0 {
+			if s, err := loadSnapshot(p.config, p.db, hash); err == nil {
+				log.Trace("Loaded voting snapshot from disk", "height", height, "hash", hash)
+				snap = s
+				break
+			}
+		}
+		// If we're at the genesis, snapshot the initial state
+		if height == 0 {
+			signers := make([]meta.AccountID, 0, len(p.config.Signers))
+			for _, str := range p.config.Signers {
+				signer, err := meta.HexToAccountID(str)
+				if err != nil {
+					return nil, err
+				}
+				signers = append(signers, signer)
+			}
+			snap = newSnapshot(p.config, 0, hash, signers)
+			if err := snap.store(p.db); err != nil {
+				return nil, err
+			}
+			log.Trace("Stored genesis voting snapshot to disk")
+			break
+		}
+		// No snapshot for this block, gather the block and move backward
+		block, err := chain.GetBlockByID(hash)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil || block.GetHeight() != height {
+			return nil, consensus.ErrUnknownAncestor
+		}
+		blocks = append(blocks, block)
+		height, hash = height-1, *block.GetPrevBlockID()
+	}
+	// Previous snapshot found, apply any pending blocks on top of it
+	for i := 0; i < len(blocks)/2; i++ {
+		blocks[i], blocks[len(blocks)-1-i] = blocks[len(blocks)-1-i], blocks[i]
+	}
+	snap, err := snap.apply(blocks)
 	if err != nil {
 		return nil, err
 	}
+	p.recents.Add(snap.Hash, snap)
 
-	id := meta.NewAccountId(pub)
+	// If we've generated a new checkpoint snapshot, save to disk
+	if snap.Height%checkpointInterval == 0 && len(blocks) > 0 {
+		if err = snap.store(p.db); err != nil {
+			return nil, err
+		}
+		log.Trace("Stored voting snapshot to disk", "height", snap.Height, "hash", snap.Hash)
+	}
+	return snap, err
+}
+
+func (p *Poa) Author(header *meta.BlockHeader) ([]byte, error) {
+	id, err := ecrecover(header)
+	if err != nil {
+		return nil, err
+	}
 
 	return id.CloneBytes(), nil
 }
 
+// Prepare add the consensus fields of poa into the block,
+// the vote of signer is pushed into block as a vote tx.
+func (p *Poa) Prepare(chain meta.ChainReader, block *meta.Block) error {
+	height := block.GetHeight()
+	if uint64(height)%p.config.Epoch == 0 {
+		return nil
+	}
+	snap, err := p.snapshot(chain, height-1, *block.GetPrevBlockID())
+	if err != nil {
+		return err
+	}
+
+	// Gather all the proposals that make sense voting on
+	p.lock.RLock()
+	candidates := make([]meta.AccountID, 0, len(p.proposals))
+	for candidate, authorize := range p.proposals {
+		if snap.validVote(candidate, authorize) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	// If there's pending proposals, cast a vote on them
+	if len(candidates) > 0 {
+		candidate := candidates[rand.Intn(len(candidates))]
+		err = block.SetTx(*helper.CreateVoteTx(candidate, p.proposals[candidate], height))
+	}
+	p.lock.RUnlock()
+	return err
+}
+
+// Seal waits until the time of block and refreshes the hash of block, the block
+// is sealed by the signature of signer. The waiting is aborted once stop is closed.
+func (p *Poa) Seal(chain meta.ChainReader, block *meta.Block, stop <-chan struct{}) error {
+	if delay := time.Until(block.GetTime()); delay > 0 {
+		log.Debug("Waiting for slot to seal", "height", block.GetHeight(), "delay", delay)
+		select {
+		case <-stop:
+			return errSealAborted
+		case <-time.After(delay):
+		}
+	}
+	return block.Deserialize(block.Serialize())
+}
+
 func (p *Poa) VerifyBlock(chain meta.ChainReader, block *meta.Block) error {
+	// Don't waste time checking blocks from the future
+	if block.GetTime().After(time.Now().Add(allowedFutureBlockTime)) {
+		return consensus.ErrFutureBlock
+	}
+
+	var vote *meta.Transaction
+	txs := block.GetTxs()
+	for i := range txs {
+		if txs[i].Type != config.VoteTx {
+			continue
+		}
+		if vote != nil {
+			return errMultiVotes
+		}
+		vote = &txs[i]
+	}
+	if vote == nil {
+		return nil
+	}
+	if uint64(block.GetHeight())%p.config.Epoch == 0 {
+		return errCheckpointVote
+	}
+	if height, _, _, err := helper.ParseVoteTx(vote); err != nil || height != block.GetHeight() {
+		return errInvalidVote
+	}
 	return nil
 }
 
 func (p *Poa) VerifySeal(chain meta.ChainReader, block *meta.Block) error {
-	signerIndex := block.GetHeight() % uint32(len(config.SignMiners))
-	miner, err := hex.DecodeString(config.SignMiners[signerIndex])
+	height := block.GetHeight()
+	if height == 0 {
+		return errUnknownBlock
+	}
+	parent, err := chain.GetBlockByID(*block.GetPrevBlockID())
+	if err != nil || parent == nil || parent.GetHeight() != height-1 {
+		return consensus.ErrUnknownAncestor
+	}
+	if block.GetTime().Before(parent.GetTime().Add(p.period())) {
+		return errInvalidTimestamp
+	}
+	snap, err := p.snapshot(chain, height-1, *block.GetPrevBlockID())
 	if err != nil {
 		return err
 	}
-	pubkey, _, err := btcec.RecoverCompact(btcec.S256(), block.Header.Sign.Code, block.GetBlockID().CloneBytes())
+
+	signer, err := ecrecover(&block.Header)
 	if err != nil {
 		return err
 	}
+	if !snap.isSigner(signer) {
+		return errUnauthorized
+	}
 
-	accountID := meta.NewAccountId(pubkey)
-	if accountID.IsEqual(meta.BytesToAccountID(miner)) {
-		return nil
+	// Any authorised signer may seal once the in-turn signer missed its turn
+	if !snap.inturn(height, signer) {
+		if !p.chainConfig.IsOutTurn(height) {
+			return errOutOfTurnInactive
+		}
+		if block.GetTime().Before(parent.GetTime().Add(p.period() + p.outTurnDelay())) {
+			return errOutOfTurnTooEarly
+		}
 	}
+	return nil
+}
 
-	return errors.New(fmt.Sprintf("Verify seal failed %s\n, want %s\n", accountID.String(), meta.BytesToAccountID(miner).String()))
+// CalcWeight returns the weight of the block, in-turn blocks weigh more than
+// out-of-turn ones so that the chain sealed in turn is preferred.
+func (p *Poa) CalcWeight(chain meta.ChainReader, block *meta.Block) (*big.Int, error) {
+	height := block.GetHeight()
+	if height == 0 {
+		return new(big.Int).Set(weightNoTurn), nil
+	}
+	snap, err := p.snapshot(chain, height-1, *block.GetPrevBlockID())
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ecrecover(&block.Header)
+	if err != nil {
+		return nil, err
+	}
+	if snap.inturn(height, signer) {
+		return new(big.Int).Set(weightInTurn), nil
+	}
+	return new(big.Int).Set(weightNoTurn), nil
 }
 
-func (p *Poa) GetBlockSigner(header *meta.BlockHeader) string {
-	signerIndex := header.Height % uint32(len(config.SignMiners))
-	return config.SignMiners[signerIndex]
+// SealTime returns the earliest time the signer may seal the block, the in-turn
+// signer may seal one period after the parent and the others have to wait for
+// the out-of-turn delay in addition.
+func (p *Poa) SealTime(chain meta.ChainReader, header *meta.BlockHeader, signer meta.AccountID) (time.Time, error) {
+	if header.Height == 0 {
+		return time.Time{}, errUnknownBlock
+	}
+	parent, err := chain.GetBlockByID(header.Prev)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if parent == nil || parent.GetHeight() != header.Height-1 {
+		return time.Time{}, consensus.ErrUnknownAncestor
+	}
+	snap, err := p.snapshot(chain, header.Height-1, header.Prev)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !snap.isSigner(signer) {
+		return time.Time{}, errUnauthorized
+	}
+	if snap.inturn(header.Height, signer) {
+		return parent.GetTime().Add(p.period()), nil
+	}
+	if !p.chainConfig.IsOutTurn(header.Height) {
+		return time.Time{}, errOutOfTurnInactive
+	}
+	return parent.GetTime().Add(p.period() + p.outTurnDelay()), nil
+}
+
+func (p *Poa) period() time.Duration {
+	return time.Duration(p.chainConfig.Period) * time.Second
+}
+
+func (p *Poa) outTurnDelay() time.Duration {
+	return time.Duration(p.config.OutTurnDelay) * time.Second
+}
+
+func (p *Poa) GetBlockSigner(chain meta.ChainReader, header *meta.BlockHeader) string {
+	if header.Height == 0 {
+		return ""
+	}
+	snap, err := p.snapshot(chain, header.Height-1, header.Prev)
+	if err != nil {
+		log.Error("Get poa snapshot failed", "height", header.Height-1, "err", err)
+		return ""
+	}
+	return snap.Signers[header.Height%uint32(len(snap.Signers))].String()
 }
@@ -0,0 +1,157 @@
+package poa
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/mihongtech/linkchain/common/btcec"
+	"github.com/mihongtech/linkchain/common/lcdb"
+	"github.com/mihongtech/linkchain/config"
+	"github.com/mihongtech/linkchain/consensus"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/helper"
+)
+
+type testChain map[meta.BlockID]*meta.Block
+
+func (c testChain) GetBlockByID(hash meta.BlockID) (*meta.Block, error) {
+	return c[hash], nil
+}
+
+func (c testChain) GetBlockByHeight(height uint32) (*meta.Block, error) {
+	for _, block := range c {
+		if block.GetHeight() == height {
+			return block, nil
+		}
+	}
+	return nil, nil
+}
+
+//Reseal the block with the given time by signer.
+func resealTestBlock(t *testing.T, block *meta.Block, signer *testSigner, tm time.Time) *meta.Block {
+	block.Header.Time = tm
+	if err := block.Deserialize(block.Serialize()); err != nil {
+		t.Fatal(err)
+	}
+	sign, err := btcec.SignCompact(btcec.S256(), signer.key, block.GetBlockID().CloneBytes(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.SetSign(meta.NewSignature(sign))
+	return block
+}
+
+func TestOutOfTurnSeal(t *testing.T) {
+	signers := newTestSigners(t, 3)
+	poaConf := &config.PoaConfig{Epoch: config.DefaultEpoch}
+	for _, signer := range signers {
+		poaConf.Signers = append(poaConf.Signers, signer.id.String())
+	}
+	db, _ := lcdb.NewMemDatabase()
+	engine := NewPoa(&config.ChainConfig{Period: 5, OutTurnBlock: big.NewInt(1), Poa: poaConf}, db)
+	if engine.config.OutTurnDelay != 10 {
+		t.Fatalf("default out-of-turn delay: want 10, got %d", engine.config.OutTurnDelay)
+	}
+	delay := engine.outTurnDelay()
+	period := 5 * time.Second
+
+	genesis, _ := helper.CreateBlock(0, meta.BlockID{})
+	genesis.Header.Height = 0
+	genesis.Header.Time = time.Unix(time.Now().Add(-time.Hour).Unix(), 0)
+	genesis.Deserialize(genesis.Serialize())
+	chain := testChain{*genesis.GetBlockID(): genesis}
+
+	snap, err := engine.snapshot(chain, 0, *genesis.GetBlockID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var inturn, outturn *testSigner
+	for _, signer := range signers {
+		if snap.inturn(1, signer.id) {
+			inturn = signer
+		} else {
+			outturn = signer
+		}
+	}
+	parentTime := genesis.GetTime()
+
+	//the in-turn signer has to wait for the period
+	b1 := resealTestBlock(t, newTestBlock(t, genesis, inturn, nil), inturn, parentTime.Add(period-time.Second))
+	if err := engine.VerifySeal(chain, b1); err != errInvalidTimestamp {
+		t.Errorf("verify early in-turn seal: want %v, got %v", errInvalidTimestamp, err)
+	}
+	b1 = resealTestBlock(t, b1, inturn, parentTime.Add(period))
+	if err := engine.VerifySeal(chain, b1); err != nil {
+		t.Errorf("verify in-turn seal: %v", err)
+	}
+	if weight, err := engine.CalcWeight(chain, b1); err != nil || weight.Cmp(weightInTurn) != 0 {
+		t.Errorf("in-turn weight: want %v, got %v (%v)", weightInTurn, weight, err)
+	}
+	if tm, err := engine.SealTime(chain, &b1.Header, inturn.id); err != nil || !tm.Equal(parentTime.Add(period)) {
+		t.Errorf("in-turn seal time: want %v, got %v (%v)", parentTime.Add(period), tm, err)
+	}
+
+	//the out-of-turn signer has to wait for the period and the delay
+	b2 := resealTestBlock(t, newTestBlock(t, genesis, outturn, nil), outturn, parentTime.Add(period+time.Second))
+	if err := engine.VerifySeal(chain, b2); err != errOutOfTurnTooEarly {
+		t.Errorf("verify early out-of-turn seal: want %v, got %v", errOutOfTurnTooEarly, err)
+	}
+	b2 = resealTestBlock(t, b2, outturn, parentTime.Add(period+delay))
+	if err := engine.VerifySeal(chain, b2); err != nil {
+		t.Errorf("verify out-of-turn seal: %v", err)
+	}
+	if weight, err := engine.CalcWeight(chain, b2); err != nil || weight.Cmp(weightNoTurn) != 0 {
+		t.Errorf("out-of-turn weight: want %v, got %v (%v)", weightNoTurn, weight, err)
+	}
+	if tm, err := engine.SealTime(chain, &b2.Header, outturn.id); err != nil || !tm.Equal(parentTime.Add(period+delay)) {
+		t.Errorf("out-of-turn seal time: want %v, got %v (%v)", parentTime.Add(period+delay), tm, err)
+	}
+
+	//the out-of-turn signer can not seal before the fork
+	forkEngine := NewPoa(&config.ChainConfig{Period: 5, OutTurnBlock: big.NewInt(2), Poa: poaConf}, db)
+	if err := forkEngine.VerifySeal(chain, b2); err != errOutOfTurnInactive {
+		t.Errorf("verify out-of-turn seal before fork: want %v, got %v", errOutOfTurnInactive, err)
+	}
+	if _, err := forkEngine.SealTime(chain, &b2.Header, outturn.id); err != errOutOfTurnInactive {
+		t.Errorf("out-of-turn seal time before fork: want %v, got %v", errOutOfTurnInactive, err)
+	}
+
+	//the unauthorized account can not seal at any time
+	stranger := newTestSigners(t, 1)[0]
+	if _, err := engine.SealTime(chain, &b2.Header, stranger.id); err != errUnauthorized {
+		t.Errorf("unauthorized seal time: want %v, got %v", errUnauthorized, err)
+	}
+
+	//the block from future is rejected
+	b3 := resealTestBlock(t, newTestBlock(t, genesis, inturn, nil), inturn, time.Now().Add(time.Hour))
+	if err := engine.VerifyBlock(chain, b3); err != consensus.ErrFutureBlock {
+		t.Errorf("verify future block: want %v, got %v", consensus.ErrFutureBlock, err)
+	}
+}
+
+func TestSealWaitsBlockTime(t *testing.T) {
+	db, _ := lcdb.NewMemDatabase()
+	engine := NewPoa(&config.ChainConfig{Period: 5}, db)
+	signer := newTestSigners(t, 1)[0]
+	genesis, _ := helper.CreateBlock(0, meta.BlockID{})
+	genesis.Header.Height = 0
+
+	//the block of future is not sealed until its time
+	block := newTestBlock(t, genesis, signer, nil)
+	block.Header.Time = time.Now().Add(time.Hour)
+	stop := make(chan struct{})
+	close(stop)
+	if err := engine.Seal(testChain{}, block, stop); err != errSealAborted {
+		t.Errorf("seal future block: want %v, got %v", errSealAborted, err)
+	}
+
+	block.Header.Time = time.Now().Add(100 * time.Millisecond)
+	start := time.Now()
+	if err := engine.Seal(testChain{}, block, make(chan struct{})); err != nil {
+		t.Fatalf("seal block: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("seal returned before the block time, elapsed %v", elapsed)
+	}
+}
@@ -0,0 +1,282 @@
+package poa
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/mihongtech/linkchain/common/lcdb"
+	"github.com/mihongtech/linkchain/config"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/helper"
+)
+
+// Vote represents a single vote that an authorized signer made to modify the
+// list of authorizations.
+type Vote struct {
+	Signer    meta.AccountID `json:"signer"`    // Authorized signer that cast this vote
+	Height    uint32         `json:"height"`    // Block height the vote was cast in (expire old votes)
+	Candidate meta.AccountID `json:"candidate"` // Account being voted on to change its authorization
+	Authorize bool           `json:"authorize"` // Whether to authorize or deauthorize the voted account
+}
+
+// Tally is a simple vote tally to keep the current score of votes. Votes that
+// go against the proposal aren't counted since it's equivalent to not voting.
+type Tally struct {
+	Authorize bool `json:"authorize"` // Whether the vote is about authorizing or kicking someone
+	Votes     int  `json:"votes"`     // Number of votes until now wanting to pass the proposal
+}
+
+// Snapshot is the state of the authorization voting at a given point in time.
+type Snapshot struct {
+	config *config.PoaConfig // Consensus engine parameters to fine tune behavior
+
+	Height  uint32                   `json:"height"`  // Block height where the snapshot was created
+	Hash    meta.BlockID             `json:"hash"`    // Block hash where the snapshot was created
+	Signers []meta.AccountID         `json:"signers"` // Set of authorized signers at this moment, sorted ascending
+	Votes   []*Vote                  `json:"votes"`   // List of votes cast in chronological order
+	Tally   map[meta.AccountID]Tally `json:"-"`       // Current vote tally to avoid recalculating
+}
+
+// newSnapshot creates a new snapshot with the specified startup parameters. This
+// method is only used for the genesis block.
+func newSnapshot(config *config.PoaConfig, height uint32, hash meta.BlockID, signers []meta.AccountID) *Snapshot {
+	snap := &Snapshot{
+		config:  config,
+		Height:  height,
+		Hash:    hash,
+		Signers: make([]meta.AccountID, 0, len(signers)),
+		Votes:   make([]*Vote, 0),
+		Tally:   make(map[meta.AccountID]Tally),
+	}
+	for _, signer := range signers {
+		if !snap.isSigner(signer) {
+			snap.Signers = append(snap.Signers, signer)
+		}
+	}
+	snap.sortSigners()
+	return snap
+}
+
+func snapshotKey(hash meta.BlockID) []byte {
+	return append([]byte("poa-"), hash.CloneBytes()...)
+}
+
+// loadSnapshot loads an existing snapshot from the database.
+func loadSnapshot(config *config.PoaConfig, db lcdb.Database, hash meta.BlockID) (*Snapshot, error) {
+	blob, err := db.Get(snapshotKey(hash))
+	if err != nil {
+		return nil, err
+	}
+	snap := new(Snapshot)
+	if err := json.Unmarshal(blob, snap); err != nil {
+		return nil, err
+	}
+	snap.config = config
+
+	// The tally is not persisted, rebuild it from the votes
+	snap.Tally = make(map[meta.AccountID]Tally)
+	for _, vote := range snap.Votes {
+		snap.cast(vote.Candidate, vote.Authorize)
+	}
+	return snap, nil
+}
+
+// store inserts the snapshot into the database.
+func (s *Snapshot) store(db lcdb.Database) error {
+	blob, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return db.Put(snapshotKey(s.Hash), blob)
+}
+
+// copy creates a deep copy of the snapshot, though not the individual votes.
+func (s *Snapshot) copy() *Snapshot {
+	cpy := &Snapshot{
+		config:  s.config,
+		Height:  s.Height,
+		Hash:    s.Hash,
+		Signers: make([]meta.AccountID, len(s.Signers)),
+		Votes:   make([]*Vote, len(s.Votes)),
+		Tally:   make(map[meta.AccountID]Tally),
+	}
+	copy(cpy.Signers, s.Signers)
+	copy(cpy.Votes, s.Votes)
+	for candidate, tally := range s.Tally {
+		cpy.Tally[candidate] = tally
+	}
+	return cpy
+}
+
+func (s *Snapshot) sortSigners() {
+	sort.Slice(s.Signers, func(i, j int) bool {
+		return bytes.Compare(s.Signers[i][:], s.Signers[j][:]) < 0
+	})
+}
+
+// isSigner returns whether the account is authorized at this moment.
+func (s *Snapshot) isSigner(id meta.AccountID) bool {
+	for _, signer := range s.Signers {
+		if signer.IsEqual(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// validVote returns whether it makes sense to cast the specified vote in the
+// given snapshot context (e.g. don't try to add an already authorized signer).
+func (s *Snapshot) validVote(candidate meta.AccountID, authorize bool) bool {
+	signer := s.isSigner(candidate)
+	// The last signer can not be kicked out, otherwise nobody can seal the chain
+	if signer && !authorize && len(s.Signers) == 1 {
+		return false
+	}
+	return (signer && !authorize) || (!signer && authorize)
+}
+
+// cast adds a new vote into the tally.
+func (s *Snapshot) cast(candidate meta.AccountID, authorize bool) bool {
+	// Ensure the vote is meaningful
+	if !s.validVote(candidate, authorize) {
+		return false
+	}
+	// Cast the vote into an existing or new tally
+	if old, ok := s.Tally[candidate]; ok {
+		old.Votes++
+		s.Tally[candidate] = old
+	} else {
+		s.Tally[candidate] = Tally{Authorize: authorize, Votes: 1}
+	}
+	return true
+}
+
+// uncast removes a previously cast vote from the tally.
+func (s *Snapshot) uncast(candidate meta.AccountID, authorize bool) bool {
+	// If there's no tally, it's a dangling vote, just drop
+	tally, ok := s.Tally[candidate]
+	if !ok {
+		return false
+	}
+	// Ensure we only revert counted votes
+	if tally.Authorize != authorize {
+		return false
+	}
+	// Otherwise revert the vote
+	if tally.Votes > 1 {
+		tally.Votes--
+		s.Tally[candidate] = tally
+	} else {
+		delete(s.Tally, candidate)
+	}
+	return true
+}
+
+// apply creates a new authorization snapshot by applying the given blocks to
+// the original one.
+func (s *Snapshot) apply(blocks []*meta.Block) (*Snapshot, error) {
+	// Allow passing in no blocks for cleaner code
+	if len(blocks) == 0 {
+		return s, nil
+	}
+	// Sanity check that the blocks are ordered and continuous
+	for i := 0; i < len(blocks)-1; i++ {
+		if blocks[i+1].GetHeight() != blocks[i].GetHeight()+1 {
+			return nil, errInvalidVotingChain
+		}
+	}
+	if blocks[0].GetHeight() != s.Height+1 {
+		return nil, errInvalidVotingChain
+	}
+	// Iterate through the blocks and create a new snapshot
+	snap := s.copy()
+
+	for _, block := range blocks {
+		// Remove any votes on checkpoint blocks
+		height := block.GetHeight()
+		if uint64(height)%s.config.Epoch == 0 {
+			snap.Votes = nil
+			snap.Tally = make(map[meta.AccountID]Tally)
+		}
+		// Resolve the authorization key and check against signers
+		signer, err := ecrecover(&block.Header)
+		if err != nil {
+			return nil, err
+		}
+		if !snap.isSigner(signer) {
+			return nil, errUnauthorized
+		}
+		vote := getVoteTx(block)
+		if vote == nil {
+			continue
+		}
+		_, candidate, authorize, err := helper.ParseVoteTx(vote)
+		if err != nil {
+			return nil, err
+		}
+		// Discard any previous votes from the signer
+		for i, v := range snap.Votes {
+			if v.Signer.IsEqual(signer) && v.Candidate.IsEqual(candidate) {
+				// Uncast the vote from the cached tally
+				snap.uncast(v.Candidate, v.Authorize)
+
+				// Uncast the vote from the chronological list
+				snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
+				break // only one vote allowed
+			}
+		}
+		// Tally up the new vote from the signer
+		if snap.cast(candidate, authorize) {
+			snap.Votes = append(snap.Votes, &Vote{
+				Signer:    signer,
+				Height:    height,
+				Candidate: candidate,
+				Authorize: authorize,
+			})
+		}
+		// If the vote passed, update the list of signers
+		if tally := snap.Tally[candidate]; tally.Votes > len(snap.Signers)/2 {
+			if tally.Authorize {
+				snap.Signers = append(snap.Signers, candidate)
+				snap.sortSigners()
+			} else {
+				for i, s := range snap.Signers {
+					if s.IsEqual(candidate) {
+						snap.Signers = append(snap.Signers[:i], snap.Signers[i+1:]...)
+						break
+					}
+				}
+				// Discard any previous votes the deauthorized signer cast
+				for i := 0; i < len(snap.Votes); i++ {
+					if snap.Votes[i].Signer.IsEqual(candidate) {
+						// Uncast the vote from the cached tally
+						snap.uncast(snap.Votes[i].Candidate, snap.Votes[i].Authorize)
+
+						// Uncast the vote from the chronological list
+						snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
+
+						i--
+					}
+				}
+			}
+			// Discard any previous votes around the just changed account
+			for i := 0; i < len(snap.Votes); i++ {
+				if snap.Votes[i].Candidate.IsEqual(candidate) {
+					snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
+					i--
+				}
+			}
+			delete(snap.Tally, candidate)
+		}
+	}
+	snap.Height += uint32(len(blocks))
+	snap.Hash = *blocks[len(blocks)-1].GetBlockID()
+
+	return snap, nil
+}
+
+// inturn returns if a signer at a given block height is in-turn or not.
+func (s *Snapshot) inturn(height uint32, signer meta.AccountID) bool {
+	return s.Signers[height%uint32(len(s.Signers))].IsEqual(signer)
+}
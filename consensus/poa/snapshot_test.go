@@ -0,0 +1,128 @@
+package poa
+
+import (
+	"testing"
+
+	"github.com/mihongtech/linkchain/common/btcec"
+	"github.com/mihongtech/linkchain/common/lcdb"
+	"github.com/mihongtech/linkchain/config"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/helper"
+)
+
+type testSigner struct {
+	key *btcec.PrivateKey
+	id  meta.AccountID
+}
+
+func newTestSigners(t *testing.T, count int) []*testSigner {
+	signers := make([]*testSigner, 0, count)
+	for i := 0; i < count; i++ {
+		key, err := btcec.NewPrivateKey(btcec.S256())
+		if err != nil {
+			t.Fatal(err)
+		}
+		signers = append(signers, &testSigner{key: key, id: *meta.NewAccountId(key.PubKey())})
+	}
+	return signers
+}
+
+//Create a block signed by signer,vote is nil if the block has no vote.
+func newTestBlock(t *testing.T, prev *meta.Block, signer *testSigner, vote *meta.Transaction) *meta.Block {
+	block, err := helper.CreateBlock(prev.GetHeight(), *prev.GetBlockID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.SetTx(*helper.CreateCoinBaseTx(signer.id, meta.NewAmount(config.DefaultBlockReward), block.GetHeight()))
+	if vote != nil {
+		block.SetTx(*vote)
+	}
+	block, _ = helper.RebuildBlock(block)
+	if err := block.Deserialize(block.Serialize()); err != nil {
+		t.Fatal(err)
+	}
+
+	sign, err := btcec.SignCompact(btcec.S256(), signer.key, block.GetBlockID().CloneBytes(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.SetSign(meta.NewSignature(sign))
+	return block
+}
+
+func TestSnapshotVote(t *testing.T) {
+	signers := newTestSigners(t, 4)
+	poaConf := &config.PoaConfig{Epoch: config.DefaultEpoch}
+	genesis, _ := helper.CreateBlock(0, meta.BlockID{})
+	genesis.Header.Height = 0
+	genesis.Deserialize(genesis.Serialize())
+
+	snap := newSnapshot(poaConf, 0, *genesis.GetBlockID(), []meta.AccountID{signers[0].id, signers[1].id, signers[2].id})
+	candidate := signers[3]
+
+	//a single vote of three signers is not enough
+	b1 := newTestBlock(t, genesis, signers[0], helper.CreateVoteTx(candidate.id, true, 1))
+	next, err := snap.apply([]*meta.Block{b1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.isSigner(candidate.id) {
+		t.Errorf("candidate is authorized by one vote")
+	}
+	if tally := next.Tally[candidate.id]; tally.Votes != 1 || !tally.Authorize {
+		t.Errorf("tally mismatch: have %v", tally)
+	}
+
+	//the same signer votes twice only counts once
+	b2 := newTestBlock(t, b1, signers[0], helper.CreateVoteTx(candidate.id, true, 2))
+	next, err = snap.apply([]*meta.Block{b1, b2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.isSigner(candidate.id) {
+		t.Errorf("candidate is authorized by duplicated vote")
+	}
+
+	//the majority of signers authorize the candidate
+	b3 := newTestBlock(t, b2, signers[1], helper.CreateVoteTx(candidate.id, true, 3))
+	next, err = snap.apply([]*meta.Block{b1, b2, b3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !next.isSigner(candidate.id) || len(next.Signers) != 4 {
+		t.Errorf("candidate is not authorized, signers %v", next.Signers)
+	}
+	if len(next.Votes) != 0 || len(next.Tally) != 0 {
+		t.Errorf("votes of passed proposal are not discarded")
+	}
+
+	//the unauthorized account can not seal block
+	b4 := newTestBlock(t, genesis, candidate, nil)
+	if _, err := snap.apply([]*meta.Block{b4}); err != errUnauthorized {
+		t.Errorf("apply unauthorized block: want %v, got %v", errUnauthorized, err)
+	}
+}
+
+func TestSnapshotStore(t *testing.T) {
+	signers := newTestSigners(t, 2)
+	poaConf := &config.PoaConfig{Epoch: config.DefaultEpoch}
+	db, _ := lcdb.NewMemDatabase()
+
+	snap := newSnapshot(poaConf, 0, meta.BlockID{}, []meta.AccountID{signers[0].id, signers[1].id})
+	snap.Votes = append(snap.Votes, &Vote{Signer: signers[0].id, Height: 1, Candidate: signers[1].id, Authorize: false})
+	snap.cast(signers[1].id, false)
+	if err := snap.store(db); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadSnapshot(poaConf, db, snap.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Signers) != 2 || len(loaded.Votes) != 1 {
+		t.Errorf("loaded snapshot mismatch: have %v", loaded)
+	}
+	if tally := loaded.Tally[signers[1].id]; tally.Votes != 1 || tally.Authorize {
+		t.Errorf("tally is not rebuilt: have %v", tally)
+	}
+}
@@ -0,0 +1,159 @@
+package pow
+
+import (
+	"errors"
+	"math/big"
+	"math/rand"
+	"time"
+
+	"github.com/mihongtech/linkchain/common/btcec"
+	"github.com/mihongtech/linkchain/common/util/log"
+	"github.com/mihongtech/linkchain/config"
+	"github.com/mihongtech/linkchain/consensus"
+	"github.com/mihongtech/linkchain/core/meta"
+)
+
+const (
+	allowedFutureBlockTime = 15 * time.Second // Max time from current time allowed for blocks, before they're considered future blocks
+	abortCheckInterval     = 1 << 10          // Number of nonces to try between checks of the abort channel
+)
+
+var (
+	// errInvalidDifficulty is returned if the difficulty of a block is not
+	// positive or easier than the pow limit.
+	errInvalidDifficulty = errors.New("invalid difficulty")
+
+	// errInvalidPoW is returned if the hash of a block does not meet its target.
+	errInvalidPoW = errors.New("invalid proof-of-work")
+
+	// errSealAborted is returned if the nonce search is aborted before a
+	// valid nonce is found.
+	errSealAborted = errors.New("seal aborted")
+)
+
+// Pow is the proof-of-work consensus engine, any account may seal a block by
+// searching a nonce whose block hash meets the compact target of the block.
+type Pow struct {
+	chainConfig *config.ChainConfig // Consensus engine configuration parameters
+	config      *config.PowConfig   // Pow consensus params of chain config
+	powLimit    *big.Int            // The easiest target a block may have
+}
+
+// NewPow creates a proof-of-work consensus engine.
+func NewPow(chainConfig *config.ChainConfig) *Pow {
+	// Set any missing consensus parameters to their defaults
+	conf := *chainConfig
+	powConf := config.PowConfig{Limit: config.DefaultDifficulty}
+	if conf.Pow != nil {
+		powConf = *conf.Pow
+	}
+	if powConf.Limit == 0 {
+		powConf.Limit = config.DefaultDifficulty
+	}
+	conf.Pow = &powConf
+
+	return &Pow{
+		chainConfig: &conf,
+		config:      &powConf,
+		powLimit:    CompactToBig(powConf.Limit),
+	}
+}
+
+// ecrecover extracts the account id of signer from a signed block header.
+func ecrecover(header *meta.BlockHeader) (meta.AccountID, error) {
+	pub, _, err := btcec.RecoverCompact(btcec.S256(), header.Sign.Code, header.GetBlockID().CloneBytes())
+	if err != nil {
+		return meta.AccountID{}, err
+	}
+	return *meta.NewAccountId(pub), nil
+}
+
+func (p *Pow) Author(header *meta.BlockHeader) ([]byte, error) {
+	id, err := ecrecover(header)
+	if err != nil {
+		return nil, err
+	}
+
+	return id.CloneBytes(), nil
+}
+
+// Prepare does nothing, the pow fields of block are filled in by Seal.
+func (p *Pow) Prepare(chain meta.ChainReader, block *meta.Block) error {
+	return nil
+}
+
+// VerifyBlock checks the time and the target of block.
+func (p *Pow) VerifyBlock(chain meta.ChainReader, block *meta.Block) error {
+	// Don't waste time checking blocks from the future
+	if block.GetTime().After(time.Now().Add(allowedFutureBlockTime)) {
+		return consensus.ErrFutureBlock
+	}
+
+	target := CompactToBig(block.Header.Difficulty)
+	if target.Sign() <= 0 || target.Cmp(p.powLimit) > 0 {
+		return errInvalidDifficulty
+	}
+	return nil
+}
+
+// VerifySeal checks the hash of block meets the target of block.
+func (p *Pow) VerifySeal(chain meta.ChainReader, block *meta.Block) error {
+	target := CompactToBig(block.Header.Difficulty)
+	if target.Sign() <= 0 {
+		return errInvalidDifficulty
+	}
+	if HashToBig(block.GetBlockID()).Cmp(target) > 0 {
+		return errInvalidPoW
+	}
+	return nil
+}
+
+// Seal searches a nonce which makes the hash of block meet the target of block,
+// the search is aborted once stop is closed.
+func (p *Pow) Seal(chain meta.ChainReader, block *meta.Block, stop <-chan struct{}) error {
+	header := &block.Header
+	target := CompactToBig(header.Difficulty)
+	if target.Sign() <= 0 {
+		return errInvalidDifficulty
+	}
+
+	start := rand.Uint32()
+	nonce := start
+	for attempts := uint64(0); ; attempts++ {
+		if attempts%abortCheckInterval == 0 {
+			select {
+			case <-stop:
+				log.Debug("Pow nonce search aborted", "height", header.Height, "attempts", attempts)
+				return errSealAborted
+			default:
+			}
+		}
+		header.Nonce = nonce
+		if err := header.Deserialize(header.Serialize()); err != nil {
+			return err
+		}
+		if HashToBig(header.GetBlockID()).Cmp(target) <= 0 {
+			log.Debug("Pow nonce found", "height", header.Height, "nonce", nonce, "attempts", attempts)
+			return nil
+		}
+		// All nonces are tried, continue with a new time
+		if nonce++; nonce == start {
+			header.Time = time.Now()
+		}
+	}
+}
+
+// CalcWeight returns the work of block.
+func (p *Pow) CalcWeight(chain meta.ChainReader, block *meta.Block) (*big.Int, error) {
+	return CalcWork(block.Header.Difficulty), nil
+}
+
+// SealTime returns the zero time, everyone may seal a pow block at any time.
+func (p *Pow) SealTime(chain meta.ChainReader, header *meta.BlockHeader, signer meta.AccountID) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+// GetBlockSigner returns empty string, pow block has no designated signer.
+func (p *Pow) GetBlockSigner(chain meta.ChainReader, header *meta.BlockHeader) string {
+	return ""
+}
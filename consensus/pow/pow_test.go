@@ -0,0 +1,88 @@
+package pow
+
+import (
+	"testing"
+
+	"github.com/mihongtech/linkchain/config"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/helper"
+)
+
+const testPowLimit = 0x1f7fffff
+
+func newTestBlock(t *testing.T, difficulty uint32) *meta.Block {
+	block, err := helper.CreateBlock(0, meta.BlockID{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Header.Difficulty = difficulty
+	if err := block.Deserialize(block.Serialize()); err != nil {
+		t.Fatal(err)
+	}
+	return block
+}
+
+func TestSeal(t *testing.T) {
+	engine := NewPow(&config.ChainConfig{Pow: &config.PowConfig{Limit: testPowLimit}})
+	block := newTestBlock(t, testPowLimit)
+
+	if err := engine.Seal(nil, block, make(chan struct{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.VerifyBlock(nil, block); err != nil {
+		t.Errorf("verify sealed block: %v", err)
+	}
+	if err := engine.VerifySeal(nil, block); err != nil {
+		t.Errorf("verify sealed block seal: %v", err)
+	}
+
+	//find a nonce which does not meet the target
+	for HashToBig(block.GetBlockID()).Cmp(CompactToBig(testPowLimit)) <= 0 {
+		block.Header.Nonce++
+		block.Deserialize(block.Serialize())
+	}
+	if err := engine.VerifySeal(nil, block); err != errInvalidPoW {
+		t.Errorf("verify bad seal: want %v, got %v", errInvalidPoW, err)
+	}
+}
+
+func TestSealAbort(t *testing.T) {
+	engine := NewPow(&config.ChainConfig{Pow: &config.PowConfig{Limit: testPowLimit}})
+	block := newTestBlock(t, 0x0300ffff)
+
+	stop := make(chan struct{})
+	close(stop)
+	if err := engine.Seal(nil, block, stop); err != errSealAborted {
+		t.Errorf("seal with closed stop: want %v, got %v", errSealAborted, err)
+	}
+}
+
+func TestVerifyDifficulty(t *testing.T) {
+	engine := NewPow(&config.ChainConfig{Pow: &config.PowConfig{Limit: 0x1e00ffff}})
+
+	if err := engine.VerifyBlock(nil, newTestBlock(t, 0x1f00ffff)); err != errInvalidDifficulty {
+		t.Errorf("verify block easier than limit: want %v, got %v", errInvalidDifficulty, err)
+	}
+	if err := engine.VerifyBlock(nil, newTestBlock(t, 0x1e00ffff)); err != nil {
+		t.Errorf("verify block at limit: %v", err)
+	}
+	if err := engine.VerifyBlock(nil, newTestBlock(t, 0x1e80ffff)); err != errInvalidDifficulty {
+		t.Errorf("verify block with negative target: want %v, got %v", errInvalidDifficulty, err)
+	}
+}
+
+func TestCalcWork(t *testing.T) {
+	tests := []struct {
+		bits uint32
+		work int64
+	}{
+		{0x1d00ffff, 4295032833},
+		{0x1e80ffff, 0},
+		{0, 0},
+	}
+	for _, test := range tests {
+		if work := CalcWork(test.bits); work.Int64() != test.work {
+			t.Errorf("CalcWork(%08x): want %d, got %v", test.bits, test.work, work)
+		}
+	}
+}
@@ -37,13 +37,13 @@ func (p *Interpreter) ProcessBlockState(block *meta.Block, stateDb *state.StateD
 			useGas += outputs[i].Receipt.GasUsed
 		}
 	}
-	blockHeaderData := NewBlockHeaderData(receipts, useGas)
+	blockHeaderData := NewBlockHeaderData(receipts, useGas, chain.Config().GetGasLimit())
 	headerData, err := proto.Marshal(blockHeaderData.Serialize())
 	if err != nil {
 		return err, nil
 	}
 	//check status with header status root.
-	if err := validate.VerifyBlockState(block, *root, actualReward, fee, headerData); err != nil {
+	if err := validate.VerifyBlockState(block, chain, *root, actualReward, fee, headerData); err != nil {
 		return err, nil
 	}
 	return nil, results
@@ -66,8 +66,7 @@ func (p *Interpreter) processBlockState(block *meta.Block, stateDb *state.StateD
 
 	coinBase := meta.NewAmount(0)
 	txFee := meta.NewAmount(0)
-	headerData := GetHeaderData(&block.Header)
-	gp := new(core.GasPool).AddGas(headerData.GasLimit)
+	gp := new(core.GasPool).AddGas(chain.Config().GetGasLimit())
 	inputData := Input{normal.Input{&block.Header, stateDb, chain, block.TXs[0].To.Coins[0].Id},
 		chain,
 		chain.Config(),
@@ -5,14 +5,14 @@ import (
 	"errors"
 	"github.com/mihongtech/linkchain/common/math"
 	"github.com/mihongtech/linkchain/common/util/log"
-	"github.com/mihongtech/linkchain/config"
+	"github.com/mihongtech/linkchain/core"
 	"github.com/mihongtech/linkchain/core/meta"
 )
 
-func (v *Interpreter) VerifyBlockState(block *meta.Block, root math.Hash, actualReward *meta.Amount, fee *meta.Amount, headerData []byte) error {
+func (v *Interpreter) VerifyBlockState(block *meta.Block, chain core.Chain, root math.Hash, actualReward *meta.Amount, fee *meta.Amount, headerData []byte) error {
 	log.Debug("VerifyBlockState", "actualReward", actualReward.GetInt64(), "fee", fee.GetInt64())
 	//Check block reward
-	if actualReward.Subtraction(*meta.NewAmount(config.DefaultBlockReward)).GetInt64() != fee.GetInt64() && len(block.TXs) > 0 {
+	if actualReward.Subtraction(*meta.NewAmount(chain.Config().GetBlockReward(block.GetHeight()))).GetInt64() != fee.GetInt64() && len(block.TXs) > 0 {
 		return errors.New("coin base tx reward is error")
 	}
 
@@ -1,10 +1,18 @@
 package contract
 
+import "github.com/mihongtech/linkchain/config"
+
 const (
 	ContractTx       = 0x00000007 // the tx is represent for creating of calling contract
 	ContractResultTx = 0x00000008 // the tx is represent for creating of calling contract
 
-	ContractAccount = 0x00000002 // the contract account
+	ContractAccount = config.ContractAccount // the contract account
+
+	DefaultBlockGasLimit = config.DefaultBlockGasLimit // the block gas limit
+
+	BloomBitsBlocks = 4096 // the number of blocks a single bloom bits section contains
+	BloomConfirms   = 256  // the number of confirmations before a section is indexed, so it is safe from reorg
 
-	DefaultBlockGasLimit = 200000000000 // the block gas limit
+	MaxFilterBlocks = 8 * BloomBitsBlocks // the max number of blocks a log filter query covers
+	MaxFilterLogs   = 10000               // the max number of logs a log filter query returns
 )
@@ -5,7 +5,9 @@ import (
 	"github.com/mihongtech/linkchain/contract/vm"
 	"github.com/mihongtech/linkchain/core"
 	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/interpreter"
 	"github.com/mihongtech/linkchain/normal"
+	"github.com/mihongtech/linkchain/storage/state"
 )
 
 type Input struct {
@@ -21,6 +23,17 @@ type Interpreter struct {
 	normal.Interpreter
 }
 
+//Create the input data which is used to verify tx outside of block processing.
+func (i *Interpreter) CreateInputData(header *meta.BlockHeader, stateDb *state.StateDB, chain core.Chain) interpreter.Params {
+	return &Input{normal.Input{header, stateDb, chain, meta.AccountID{}},
+		chain,
+		chain.Config(),
+		vm.Config{},
+		new(uint64),
+		new(core.GasPool).AddGas(chain.Config().GetGasLimit()),
+	}
+}
+
 type Output struct {
 	normal.Output
 	ResultTx *meta.Transaction
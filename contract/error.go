@@ -16,4 +16,24 @@ var (
 	// ErrNonceTooHigh is returned if the nonce of a transaction is higher than the
 	// next one expected based on the local ChainReader.
 	ErrNonceTooHigh = errors.New("nonce too high")
+
+	// ErrContractNotEnabled is returned if a contract transaction is included in
+	// a block before the contract fork is activated.
+	ErrContractNotEnabled = errors.New("contract is not enabled")
+
+	// ErrMissingBlock is returned if a canonical block is not found while
+	// indexing the bloom bits or filtering the logs.
+	ErrMissingBlock = errors.New("missing block")
+
+	// ErrFilterRange is returned if the block range of a log filter query is
+	// larger than MaxFilterBlocks.
+	ErrFilterRange = errors.New("block range of log filter is too large")
+
+	// ErrTooManyLogs is returned if a log filter query matches more than
+	// MaxFilterLogs logs.
+	ErrTooManyLogs = errors.New("too many logs matched, narrow the log filter")
+
+	// ErrNotIndexed is returned if a log filter query covers the confirmed blocks
+	// whose bloom bits are not indexed yet.
+	ErrNotIndexed = errors.New("bloom bits of blocks are not indexed yet")
 )
@@ -4,12 +4,13 @@ import (
 	"errors"
 	"github.com/golang/protobuf/proto"
 	"github.com/mihongtech/linkchain/config"
+	"github.com/mihongtech/linkchain/core"
 	"github.com/mihongtech/linkchain/core/meta"
 	"github.com/mihongtech/linkchain/interpreter"
 	"github.com/mihongtech/linkchain/normal"
 )
 
-func (e *Interpreter) ExecuteResult(results []interpreter.Result, txFee *meta.Amount, block *meta.Block) error {
+func (e *Interpreter) ExecuteResult(results []interpreter.Result, txFee *meta.Amount, block *meta.Block, chain core.Chain) error {
 
 	//push txfee into coinbase
 	if block.TXs[0].Type != config.CoinBaseTx {
@@ -26,7 +27,7 @@ func (e *Interpreter) ExecuteResult(results []interpreter.Result, txFee *meta.Am
 			useGas += results[i].GetReceipt().GasUsed
 		}
 	}
-	blockHeaderData := NewBlockHeaderData(normal.GetReceiptsByResult(results), useGas)
+	blockHeaderData := NewBlockHeaderData(normal.GetReceiptsByResult(results), useGas, chain.Config().GetGasLimit())
 	headerData, err := proto.Marshal(blockHeaderData.Serialize())
 	if err != nil {
 		return err
@@ -14,11 +14,12 @@ import (
 	"github.com/golang/protobuf/proto"
 )
 
+//BlockHeaderData is the contract data of block header, the logs bloom of block is
+//not kept in the header, it is created from the receipts.
 type BlockHeaderData struct {
-	ReceiptHash math.Hash  `json:"receiptsRoot"     gencodec:"required"`
-	Bloom       core.Bloom `json:"logsBloom"        gencodec:"required"`
-	GasLimit    uint64     `json:"gasLimit"         gencodec:"required"`
-	GasUsed     uint64     `json:"gasUsed"          gencodec:"required"`
+	ReceiptHash math.Hash `json:"receiptsRoot"     gencodec:"required"`
+	GasLimit    uint64    `json:"gasLimit"         gencodec:"required"`
+	GasUsed     uint64    `json:"gasUsed"          gencodec:"required"`
 }
 
 //Serialize/Deserialize
@@ -47,8 +48,6 @@ func GetHeaderData(header *meta.BlockHeader) *BlockHeaderData {
 	if len(header.Data) == 0 {
 		return &BlockHeaderData{GasLimit: DefaultBlockGasLimit}
 	}
-	buffer := make([]byte, 256)
-
 	headerData := new(protobuf.BlockHeaderData)
 	data := new(BlockHeaderData)
 	err := proto.Unmarshal(header.Data, headerData)
@@ -63,14 +62,12 @@ func GetHeaderData(header *meta.BlockHeader) *BlockHeaderData {
 		return nil
 	}
 
-	data.Bloom = core.BytesToBloom(buffer)
 	return data
 }
 
-func NewBlockHeaderData(receipts core.Receipts, gasUsed uint64) *BlockHeaderData {
-	headerData := BlockHeaderData{GasLimit: DefaultBlockGasLimit, GasUsed: gasUsed}
+func NewBlockHeaderData(receipts core.Receipts, gasUsed uint64, gasLimit uint64) *BlockHeaderData {
+	headerData := BlockHeaderData{GasLimit: gasLimit, GasUsed: gasUsed}
 	headerData.ReceiptHash, _ = core.GetReceiptHash(receipts)
-	headerData.Bloom = core.CreateBloom(receipts)
 	return &headerData
 }
 
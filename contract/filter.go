@@ -0,0 +1,281 @@
+package contract
+
+import (
+	"errors"
+
+	"github.com/mihongtech/linkchain/common/math"
+	"github.com/mihongtech/linkchain/core"
+	"github.com/mihongtech/linkchain/core/bloombits"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/storage"
+)
+
+//FilterQuery contains the options for contract log filtering.
+type FilterQuery struct {
+	FromBlock uint64           // beginning of the queried range
+	ToBlock   uint64           // end of the range, it is included
+	Addresses []meta.AccountID // restricts matches to logs created by specific contracts, empty matches all
+
+	// The Topic list restricts matches to particular event topics. Each event has a list
+	// of topics, every position is matched by any of its topics, empty position matches all.
+	//
+	// {} or nil          matches any topic list
+	// {{A}}              matches topic A in first position
+	// {{}, {B}}          matches any topic in first position, B in second position
+	// {{A}, {B}}         matches topic A in first position, B in second position
+	// {{A, B}, {C, D}}   matches topic (A OR B) in first position, (C OR D) in second position
+	Topics [][]math.Hash
+}
+
+//Get the logs of main chain matching the query.
+//The blocks in indexed sections are picked by bloom bits, the recent blocks which are not
+//confirmed for indexing yet are picked by the blooms of their receipts.
+func (o *OffChainState) GetLogs(q *FilterQuery) ([]*meta.Log, error) {
+	if q.FromBlock > q.ToBlock {
+		return nil, errors.New("the from block is higher than to block")
+	}
+	if q.ToBlock-q.FromBlock >= MaxFilterBlocks {
+		return nil, ErrFilterRange
+	}
+
+	//the sections below confirmed ones are indexed by indexLoop
+	var confirmed uint64
+	if head := storage.GetBlockNumber(o.db, storage.GetHeadBlockHash(o.db)); head+1 >= BloomConfirms {
+		confirmed = (head + 1 - BloomConfirms) / BloomBitsBlocks
+	}
+	sections := storage.GetBloomSections(o.db)
+	logs := make([]*meta.Log, 0)
+	for number := q.FromBlock; number <= q.ToBlock; {
+		section := number / BloomBitsBlocks
+		end := (section+1)*BloomBitsBlocks - 1
+		if end > q.ToBlock {
+			end = q.ToBlock
+		}
+
+		var matches []byte
+		if section < sections {
+			matches = o.matchSection(q, section)
+		}
+		if matches == nil && section < confirmed {
+			return nil, ErrNotIndexed
+		}
+		for ; number <= end; number++ {
+			if offset := number % BloomBitsBlocks; matches != nil && matches[offset/8]&(1<<(7-offset%8)) == 0 {
+				continue
+			}
+			found, err := o.blockLogs(q, number, matches == nil)
+			if err != nil {
+				return nil, err
+			}
+			if len(logs)+len(found) > MaxFilterLogs {
+				return nil, ErrTooManyLogs
+			}
+			logs = append(logs, found...)
+		}
+	}
+	return logs, nil
+}
+
+//Get the bitset of blocks in section whose bloom may match the query.
+//Return nil if the section is not indexed on main chain.
+func (o *OffChainState) matchSection(q *FilterQuery, section uint64) []byte {
+	head := storage.GetBloomSectionHead(o.db, section)
+	if head != storage.GetCanonicalHash(o.db, (section+1)*BloomBitsBlocks-1) {
+		return nil
+	}
+
+	//all bloom bits of a value must be set
+	value := func(data []byte) []byte {
+		var result []byte
+		for _, bit := range bloombits.BloomValues(data) {
+			bits, err := storage.GetBloomBits(o.db, bit, section, head)
+			if err != nil || len(bits) != BloomBitsBlocks/8 {
+				return nil
+			}
+			if result == nil {
+				result = append([]byte{}, bits...)
+			} else {
+				andBits(result, bits)
+			}
+		}
+		return result
+	}
+	//any value of the group can match
+	group := func(datas [][]byte) []byte {
+		result := make([]byte, BloomBitsBlocks/8)
+		for _, data := range datas {
+			bits := value(data)
+			if bits == nil {
+				return nil
+			}
+			orBits(result, bits)
+		}
+		return result
+	}
+
+	groups := make([][][]byte, 0, len(q.Topics)+1)
+	if len(q.Addresses) > 0 {
+		datas := make([][]byte, 0, len(q.Addresses))
+		for _, address := range q.Addresses {
+			datas = append(datas, address.CloneBytes())
+		}
+		groups = append(groups, datas)
+	}
+	for _, topics := range q.Topics {
+		if len(topics) == 0 {
+			continue
+		}
+		datas := make([][]byte, 0, len(topics))
+		for _, topic := range topics {
+			datas = append(datas, topic.CloneBytes())
+		}
+		groups = append(groups, datas)
+	}
+
+	matches := make([]byte, BloomBitsBlocks/8)
+	for i := range matches {
+		matches[i] = 0xff
+	}
+	for _, datas := range groups {
+		bits := group(datas)
+		if bits == nil {
+			return nil
+		}
+		andBits(matches, bits)
+	}
+	return matches
+}
+
+//Get the matched logs of canonical block, the bloom of receipts is checked first if checkBloom is set.
+func (o *OffChainState) blockLogs(q *FilterQuery, number uint64, checkBloom bool) ([]*meta.Log, error) {
+	hash := storage.GetCanonicalHash(o.db, number)
+	block := storage.GetBlock(o.db, hash, number)
+	if block == nil {
+		return nil, ErrMissingBlock
+	}
+	receipts := storage.ReadReceipts(o.db, hash, number)
+	if checkBloom && !bloomFilter(receiptsBloom(receipts), q.Addresses, q.Topics) {
+		return nil, nil
+	}
+
+	txIndex := make(map[math.Hash]uint, len(block.TXs))
+	for i := range block.TXs {
+		txIndex[*block.TXs[i].GetTxID()] = uint(i)
+	}
+
+	//fill the derived fields which are not secured by block
+	logs := make([]*meta.Log, 0)
+	index := uint(0)
+	for _, receipt := range receipts {
+		for _, l := range receipt.Logs {
+			log := *l
+			log.BlockNumber = number
+			log.BlockHash = hash
+			log.TxHash = receipt.TxHash
+			log.TxIndex = txIndex[receipt.TxHash]
+			log.Index = index
+			index++
+			logs = append(logs, &log)
+		}
+	}
+	return FilterLogs(logs, q.Addresses, q.Topics), nil
+}
+
+//Get the logs which match the addresses and topics.
+func FilterLogs(logs []*meta.Log, addresses []meta.AccountID, topics [][]math.Hash) []*meta.Log {
+	ret := make([]*meta.Log, 0)
+Logs:
+	for _, log := range logs {
+		if len(addresses) > 0 && !includes(addresses, log.Address) {
+			continue
+		}
+		// If the to filtered topics is greater than the amount of topics in logs, skip.
+		if len(topics) > len(log.Topics) {
+			continue Logs
+		}
+		for i, sub := range topics {
+			match := len(sub) == 0 // empty rule set == wildcard
+			for _, topic := range sub {
+				if log.Topics[i] == topic {
+					match = true
+					break
+				}
+			}
+			if !match {
+				continue Logs
+			}
+		}
+		ret = append(ret, log)
+	}
+	return ret
+}
+
+//Get the bloom of block by the blooms of its receipts, which are kept out of block header.
+func receiptsBloom(receipts core.Receipts) core.Bloom {
+	var bloom core.Bloom
+	for _, receipt := range receipts {
+		for i := range bloom {
+			bloom[i] |= receipt.Bloom[i]
+		}
+	}
+	return bloom
+}
+
+func bloomFilter(bloom core.Bloom, addresses []meta.AccountID, topics [][]math.Hash) bool {
+	if len(addresses) > 0 {
+		var included bool
+		for _, addr := range addresses {
+			if bloomLookup(bloom, addr.CloneBytes()) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+
+	for _, sub := range topics {
+		included := len(sub) == 0 // empty rule set == wildcard
+		for _, topic := range sub {
+			if bloomLookup(bloom, topic.Bytes()) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	return true
+}
+
+func bloomLookup(bloom core.Bloom, data []byte) bool {
+	for _, bit := range bloombits.BloomValues(data) {
+		if bloom[core.BloomByteLength-1-bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func includes(addresses []meta.AccountID, a meta.AccountID) bool {
+	for _, addr := range addresses {
+		if addr == a {
+			return true
+		}
+	}
+	return false
+}
+
+func andBits(dst, src []byte) {
+	for i := range dst {
+		dst[i] &= src[i]
+	}
+}
+
+func orBits(dst, src []byte) {
+	for i := range dst {
+		dst[i] |= src[i]
+	}
+}
@@ -0,0 +1,135 @@
+package contract
+
+import (
+	"github.com/mihongtech/linkchain/common/lcdb"
+	"github.com/mihongtech/linkchain/common/math"
+	"github.com/mihongtech/linkchain/common/util/log"
+	"github.com/mihongtech/linkchain/core"
+	"github.com/mihongtech/linkchain/core/bloombits"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/interpreter"
+	"github.com/mihongtech/linkchain/storage"
+)
+
+func (i *Interpreter) CreateOffChain(db lcdb.Database) interpreter.OffChain {
+	return NewOffChainState(db)
+}
+
+//OffChainState indexes the header blooms of main chain into bloom bits sections,
+//which are used to filter the contract logs.
+type OffChainState struct {
+	db     lcdb.Database
+	headCh chan uint64
+	quit   chan struct{}
+}
+
+func NewOffChainState(db lcdb.Database) *OffChainState {
+	return &OffChainState{db: db, headCh: make(chan uint64, 1), quit: make(chan struct{})}
+}
+
+func (o *OffChainState) Setup(i interface{}) bool {
+	return true
+}
+
+func (o *OffChainState) Start() bool {
+	go o.indexLoop()
+	return true
+}
+
+func (o *OffChainState) Stop() {
+	close(o.quit)
+}
+
+func (o *OffChainState) UpdateMainChain(ev meta.ChainEvent) {
+	//drop the stale head if the indexer is busy, the newest one is enough
+	select {
+	case <-o.headCh:
+	default:
+	}
+	o.headCh <- uint64(ev.Block.GetHeight())
+}
+
+func (o *OffChainState) UpdateSideChain(ev meta.ChainSideEvent) {
+}
+
+//Get the number of sections which are indexed into bloom bits.
+func (o *OffChainState) IndexedSections() uint64 {
+	return storage.GetBloomSections(o.db)
+}
+
+func (o *OffChainState) indexLoop() {
+	for {
+		select {
+		case head := <-o.headCh:
+			o.indexSections(head)
+		case <-o.quit:
+			return
+		}
+	}
+}
+
+//Index all confirmed sections below head.
+func (o *OffChainState) indexSections(head uint64) {
+	if head+1 < BloomConfirms {
+		return
+	}
+	confirmed := (head + 1 - BloomConfirms) / BloomBitsBlocks
+
+	//the section is rebuilt if its head is not canonical anymore
+	sections := storage.GetBloomSections(o.db)
+	for sections > 0 {
+		last := sections*BloomBitsBlocks - 1
+		if storage.GetBloomSectionHead(o.db, sections-1) == storage.GetCanonicalHash(o.db, last) {
+			break
+		}
+		sections--
+	}
+
+	for ; sections < confirmed; sections++ {
+		select {
+		case <-o.quit:
+			return
+		default:
+		}
+		if err := o.indexSection(sections); err != nil {
+			log.Error("Index bloom bits section failed", "section", sections, "err", err)
+			break
+		}
+	}
+}
+
+func (o *OffChainState) indexSection(section uint64) error {
+	gen, err := bloombits.NewGenerator(BloomBitsBlocks)
+	if err != nil {
+		return err
+	}
+
+	var head math.Hash
+	for i := uint64(0); i < BloomBitsBlocks; i++ {
+		number := section*BloomBitsBlocks + i
+		head = storage.GetCanonicalHash(o.db, number)
+		if !storage.HasBlock(o.db, head, number) {
+			return ErrMissingBlock
+		}
+		bloom := receiptsBloom(storage.ReadReceipts(o.db, head, number))
+		if err := gen.AddBloom(uint(i), bloom); err != nil {
+			return err
+		}
+	}
+
+	batch := o.db.NewBatch()
+	for i := uint(0); i < core.BloomBitLength; i++ {
+		bits, err := gen.Bitset(i)
+		if err != nil {
+			return err
+		}
+		storage.WriteBloomBits(batch, i, section, head, bits)
+	}
+	storage.WriteBloomSectionHead(batch, section, head)
+	storage.WriteBloomSections(batch, section+1)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Debug("Indexed bloom bits section", "section", section, "head", head)
+	return nil
+}
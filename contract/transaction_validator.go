@@ -62,6 +62,9 @@ func (v *Interpreter) VerifyTx(tx *meta.Transaction, data interpreter.Params) er
 		normal := normal.Interpreter{}
 		return normal.VerifyTx(tx, &(data.(*Input).Input))
 	} else if IsContract(tx.Type) {
+		if input := data.(*Input); input.Config != nil && input.Header != nil && !input.Config.IsContractEnable(input.Header.Height) {
+			return ErrContractNotEnabled
+		}
 		if err := normal.VerifyUnCoinBaseTx(tx, &(data.(*Input).Input)); err != nil && ContractTx == tx.Type {
 			return err
 		}
@@ -81,20 +81,15 @@ type EVMInterpreter struct {
 func NewEVMInterpreter(evm *EVM, cfg Config) *EVMInterpreter {
 	// We use the STOP instruction whether to see
 	// the jump table was initialised. If it was not
-	// we'll set the default jump table.
-	/*if !cfg.JumpTable[STOP].valid {
+	// we'll set the jump table of the fork at the block.
+	if !cfg.JumpTable[STOP].valid {
 		switch {
-		case evm.ChainConfig().IsConstantinople(evm.BlockNumber):
+		case evm.BlockNumber != nil && evm.ChainConfig().IsConstantinople(uint32(evm.BlockNumber.Uint64())):
 			cfg.JumpTable = constantinopleInstructionSet
-		case evm.ChainConfig().IsByzantium(evm.BlockNumber):
-			cfg.JumpTable = byzantiumInstructionSet
-		case evm.ChainConfig().IsHomestead(evm.BlockNumber):
-			cfg.JumpTable = homesteadInstructionSet
 		default:
-			cfg.JumpTable = frontierInstructionSet
+			cfg.JumpTable = byzantiumInstructionSet
 		}
-	}*/
-	cfg.JumpTable = byzantiumInstructionSet
+	}
 
 	return &EVMInterpreter{
 		evm:      evm,
@@ -0,0 +1,90 @@
+package bloombits
+
+import (
+	"errors"
+
+	"github.com/mihongtech/linkchain/common/math"
+	"github.com/mihongtech/linkchain/core"
+)
+
+var (
+	// errSectionOutOfBounds is returned if the user tried to add more bloom filters
+	// to the batch than available space, or if tries to retrieve above the capacity.
+	errSectionOutOfBounds = errors.New("section out of bounds")
+
+	// errBloomBitOutOfBounds is returned if the user tried to retrieve specified
+	// bit bloom above the capacity.
+	errBloomBitOutOfBounds = errors.New("bloom bit out of bounds")
+)
+
+// Generator takes a number of bloom filters and generates the rotated bloom bits
+// to be used for batched filtering.
+type Generator struct {
+	blooms   [core.BloomBitLength][]byte // Rotated blooms for per-bit matching
+	sections uint                        // Number of sections to batch together
+	nextSec  uint                        // Next section to set when adding a bloom
+}
+
+// NewGenerator creates a rotated bloom generator that can iteratively fill a
+// batched bloom filter's bits.
+func NewGenerator(sections uint) (*Generator, error) {
+	if sections%8 != 0 {
+		return nil, errors.New("section count not multiple of 8")
+	}
+	b := &Generator{sections: sections}
+	for i := 0; i < core.BloomBitLength; i++ {
+		b.blooms[i] = make([]byte, sections/8)
+	}
+	return b, nil
+}
+
+// AddBloom takes a single bloom filter and sets the corresponding bit column
+// in memory accordingly.
+func (b *Generator) AddBloom(index uint, bloom core.Bloom) error {
+	// Make sure we're not adding more bloom filters than our capacity
+	if b.nextSec >= b.sections {
+		return errSectionOutOfBounds
+	}
+	if b.nextSec != index {
+		return errors.New("bloom filter with unexpected index")
+	}
+	// Rotate the bloom and insert into our collection
+	byteIndex := b.nextSec / 8
+	bitMask := byte(1) << byte(7-b.nextSec%8)
+
+	for i := 0; i < core.BloomBitLength; i++ {
+		bloomByteIndex := core.BloomByteLength - 1 - i/8
+		bloomBitMask := byte(1) << byte(i%8)
+
+		if (bloom[bloomByteIndex] & bloomBitMask) != 0 {
+			b.blooms[i][byteIndex] |= bitMask
+		}
+	}
+	b.nextSec++
+
+	return nil
+}
+
+// Bitset returns the bit vector belonging to the given bit index after all
+// blooms have been added.
+func (b *Generator) Bitset(idx uint) ([]byte, error) {
+	if b.nextSec != b.sections {
+		return nil, errors.New("bloom not fully generated yet")
+	}
+	if idx >= core.BloomBitLength {
+		return nil, errBloomBitOutOfBounds
+	}
+	return b.blooms[idx], nil
+}
+
+// BloomValues returns the three bloom bit indexes which are set by data,
+// the same bits are set by core.Bloom9.
+func BloomValues(data []byte) [3]uint {
+	hash := math.HashB(data)
+
+	var idxs [3]uint
+	for i := 0; i < len(idxs); i++ {
+		idxs[i] = (uint(hash[2*i+1]) + (uint(hash[2*i]) << 8)) & (core.BloomBitLength - 1)
+	}
+	return idxs
+}
@@ -7,6 +7,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/mihongtech/linkchain/common/lcdb"
 	"github.com/mihongtech/linkchain/common/math"
 	"github.com/mihongtech/linkchain/common/serialize"
 	"github.com/mihongtech/linkchain/common/trie"
@@ -150,6 +151,25 @@ func (b *Block) CalculateTxTreeRoot() TreeID {
 	return hash
 }
 
+//ProveTx writes the merkle proof of the tx to the tx tree root into proofDb.
+func (b *Block) ProveTx(txid TxID, proofDb lcdb.Putter) error {
+	tree := new(trie.Trie)
+	found := false
+	for index := range b.TXs {
+		txbuff, err := proto.Marshal(b.TXs[index].Serialize())
+		if err != nil {
+			return err
+		}
+		id := b.TXs[index].GetTxID()
+		tree.Update(id.Bytes(), txbuff)
+		found = found || id.IsEqual(&txid)
+	}
+	if !found {
+		return errors.New("the tx is not in block")
+	}
+	return tree.Prove(txid.Bytes(), 0, proofDb)
+}
+
 func (b *Block) IsGensis() bool {
 	return b.Header.IsGensis()
 }
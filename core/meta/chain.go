@@ -35,3 +35,7 @@ type ChainSideEvent struct {
 }
 
 type ChainHeadEvent struct{ Block *Block }
+
+//RemovedTxsEvent carries the txs of the blocks dropped by a reorg which are not in the new chain,
+//the txs of the older block come first.
+type RemovedTxsEvent struct{ Txs []Transaction }
@@ -1,15 +1,20 @@
 package genesis
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
+	"github.com/mihongtech/linkchain/common"
+	"github.com/mihongtech/linkchain/common/hexutil"
 	"github.com/mihongtech/linkchain/common/lcdb"
 	"github.com/mihongtech/linkchain/common/math"
 	"github.com/mihongtech/linkchain/common/util/log"
 	"github.com/mihongtech/linkchain/config"
 	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/helper"
 	"github.com/mihongtech/linkchain/storage"
 	"github.com/mihongtech/linkchain/storage/state"
 )
@@ -22,6 +27,7 @@ type Genesis struct {
 	Time       int64               `json:"time"`
 	Data       []byte              `json:"data"`
 	Difficulty uint32              `json:"difficulty" gencodec:"required"`
+	Alloc      GenesisAlloc        `json:"alloc"`
 
 	// These fields are used for consensus tests. Please don't use them
 	// in actual genesis blocks.
@@ -29,6 +35,68 @@ type Genesis struct {
 	Prev   math.Hash `json:"prev"`
 }
 
+// GenesisAlloc specifies the initial state that is part of the genesis block,
+// it is keyed by the hex string of account id.
+type GenesisAlloc map[string]GenesisAccount
+
+// GenesisAccount is an account in the state of the genesis block.
+type GenesisAccount struct {
+	Balance int64             `json:"balance,omitempty"` // Balance given to the account as one utxo
+	UTXOs   []int64           `json:"utxos,omitempty"`   // Values of the other utxos given to the account
+	Code    hexutil.Bytes     `json:"code,omitempty"`    // Code of the contract account
+	Storage map[string]string `json:"storage,omitempty"` // Storage of the contract account, hex key to hex value
+}
+
+// allocAccount is a parsed genesis account.
+type allocAccount struct {
+	id meta.AccountID
+	GenesisAccount
+}
+
+// accounts parses the account ids of alloc and sorts them, so that the genesis
+// block is independent of the map order.
+func (ga GenesisAlloc) accounts() ([]allocAccount, error) {
+	accounts := make([]allocAccount, 0, len(ga))
+	for str, account := range ga {
+		id, err := meta.HexToAccountID(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid genesis alloc account %s: %v", str, err)
+		}
+		if account.Balance < 0 {
+			return nil, fmt.Errorf("invalid genesis alloc balance of %s", str)
+		}
+		for _, value := range account.UTXOs {
+			if value <= 0 {
+				return nil, fmt.Errorf("invalid genesis alloc utxo of %s", str)
+			}
+		}
+		accounts = append(accounts, allocAccount{id, account})
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return bytes.Compare(accounts[i].id[:], accounts[j].id[:]) < 0
+	})
+	return accounts, nil
+}
+
+// allocTx creates the tx which gives the balance and utxos of alloc,
+// nil if no account is funded.
+func allocTx(accounts []allocAccount) *meta.Transaction {
+	tx := meta.NewEmptyTransaction(config.DefaultTransactionVersion, config.CoinBaseTx)
+	for _, account := range accounts {
+		if account.Balance > 0 {
+			tx.AddToCoin(*helper.CreateToCoin(account.id, meta.NewAmount(account.Balance)))
+		}
+		for _, value := range account.UTXOs {
+			tx.AddToCoin(*helper.CreateToCoin(account.id, meta.NewAmount(value)))
+		}
+	}
+	if len(tx.To.Coins) == 0 {
+		return nil
+	}
+	tx.Data = common.UInt32ToBytes(0)
+	return tx
+}
+
 // GenesisMismatchError is raised when trying to overwrite an existing
 // genesis block with an incompatible one.
 type GenesisMismatchError struct {
@@ -82,13 +150,31 @@ func SetupGenesisBlock(db lcdb.Database, genesis *Genesis) (*config.ChainConfig,
 			log.Info("Writing custom genesis block")
 		}
 		block, err := genesis.Commit(db)
+		if err != nil {
+			return genesis.Config, math.Hash{}, err
+		}
 
 		hash := math.BytesToHash(block.GetBlockID().CloneBytes())
-		return genesis.Config, hash, err
+		return genesis.Config, hash, nil
 	}
 
-	newcfg := config.DefaultChainConfig
+	// Check whether the genesis block is already written.
+	if genesis != nil {
+		block, err := genesis.ToBlock(nil)
+		if err != nil {
+			return genesis.Config, stored, err
+		}
+		hash := math.BytesToHash(block.GetBlockID().CloneBytes())
+		if hash != stored {
+			return genesis.Config, hash, &GenesisMismatchError{stored, hash}
+		}
+	}
 
+	// Get the existing chain configuration.
+	newcfg := config.DefaultChainConfig
+	if genesis != nil {
+		newcfg = genesis.Config
+	}
 	storedcfg, err := storage.GetChainConfig(db, stored)
 	if err != nil {
 		if err == storage.ErrChainConfigNotFound {
@@ -98,24 +184,21 @@ func SetupGenesisBlock(db lcdb.Database, genesis *Genesis) (*config.ChainConfig,
 		}
 		return newcfg, stored, err
 	}
+	// Special case: don't change the existing config of a chain if no new
+	// config is supplied.
 	if genesis == nil {
-		newcfg = config.DefaultChainConfig
-	}
-	newcfg = storedcfg
-
-	// Check whether the genesis block is already written.
-	if genesis != nil {
-		hash := math.BytesToHash(genesis.ToBlock(nil).GetBlockID().CloneBytes())
-		if hash == stored {
-			return genesis.Config, hash, &GenesisMismatchError{stored, hash}
-		}
+		newcfg = storedcfg
 	}
 
+	// Check config compatibility and write the config. Compatibility errors
+	// are returned to the caller unless we're already at block zero.
 	height := storage.GetBlockNumber(db, storage.GetHeadBlockHash(db))
 	if height == storage.MissingNumber {
-		return genesis.Config, stored, fmt.Errorf("missing block number for head block hash")
+		return newcfg, stored, fmt.Errorf("missing block number for head block hash")
+	}
+	if compatErr := storedcfg.CheckCompatible(newcfg, uint32(height)); compatErr != nil && height != 0 {
+		return newcfg, stored, compatErr
 	}
-
 	return newcfg, stored, storage.WriteChainConfig(db, &stored, newcfg)
 
 }
@@ -131,13 +214,48 @@ func (g *Genesis) configOrDefault(ghash math.Hash) *config.ChainConfig {
 
 // ToBlock creates the genesis block and writes state of a genesis specification
 // to the given database (or discards it if nil).
-func (g *Genesis) ToBlock(db lcdb.Database) *meta.Block {
+func (g *Genesis) ToBlock(db lcdb.Database) (*meta.Block, error) {
 	if db == nil {
 		db, _ = lcdb.NewMemDatabase()
 	}
+	accounts, err := g.Alloc.accounts()
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]meta.Transaction, 0)
+	tx := allocTx(accounts)
+	if tx != nil {
+		txs = append(txs, *tx)
+	}
+
 	statedb, _ := state.New(math.Hash{}, db)
-	// TODO: add tx to account
-	root, _ := statedb.Commit()
+	index := uint32(0)
+	for _, account := range accounts {
+		a := *helper.CreateTemplateAccount(account.id)
+		if len(account.Code) > 0 || len(account.Storage) > 0 {
+			a.AccountType = config.ContractAccount
+		}
+		obj := statedb.NewObject(meta.GetAccountHash(a.Id), a)
+		for ; tx != nil && index < uint32(len(tx.To.Coins)) && tx.To.Coins[index].Id.IsEqual(account.id); index++ {
+			ticket := meta.NewTicket(*tx.GetTxID(), index)
+			utxo := meta.NewUTXO(ticket, g.Height, g.Height, *tx.To.Coins[index].GetValue())
+			obj.GetAccount().UTXOs = append(obj.GetAccount().UTXOs, *utxo)
+		}
+		if len(account.Code) > 0 {
+			obj.SetCode(math.HashH(account.Code), account.Code)
+		}
+		for key, value := range account.Storage {
+			obj.SetState(statedb.DataBase(), math.HexToHash(key), math.HexToHash(value))
+		}
+		statedb.SetObject(obj)
+	}
+	root, err := statedb.Commit()
+	if err != nil {
+		return nil, err
+	}
+	if err := statedb.DataBase().TrieDB().Commit(root, true); err != nil {
+		return nil, err
+	}
 	head := meta.BlockHeader{
 		Version:    g.Version,
 		Height:     g.Height,
@@ -150,17 +268,20 @@ func (g *Genesis) ToBlock(db lcdb.Database) *meta.Block {
 		Difficulty: g.Difficulty,
 	}
 
-	block := meta.NewBlock(head, []meta.Transaction{})
+	block := meta.NewBlock(head, txs)
 	txRoot := block.CalculateTxTreeRoot()
 	block.Header.SetMerkleRoot(txRoot)
 
-	return block
+	return block, nil
 }
 
 // Commit writes the block and state of a genesis specification to the database.
 // The block is committed as the canonical head block.
 func (g *Genesis) Commit(db lcdb.Database) (*meta.Block, error) {
-	block := g.ToBlock(db)
+	block, err := g.ToBlock(db)
+	if err != nil {
+		return nil, err
+	}
 	if block.GetHeight() != 0 {
 		return nil, fmt.Errorf("can't commit genesis block with number > 0")
 	}
@@ -170,6 +291,9 @@ func (g *Genesis) Commit(db lcdb.Database) (*meta.Block, error) {
 		return nil, err
 	}
 	storage.WriteReceipts(db, *block.GetBlockID(), uint64(block.GetHeight()), nil)
+	if err := storage.WriteTxLookupEntries(db, block); err != nil {
+		return nil, err
+	}
 
 	if err := storage.WriteCanonicalHash(db, *block.GetBlockID(), uint64(block.GetHeight())); err != nil {
 		return nil, err
@@ -0,0 +1,76 @@
+package genesis
+
+import (
+	"testing"
+
+	"github.com/mihongtech/linkchain/common/lcdb"
+	"github.com/mihongtech/linkchain/common/math"
+	"github.com/mihongtech/linkchain/config"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/storage"
+	"github.com/mihongtech/linkchain/storage/state"
+)
+
+const (
+	testFunded   = "07411e1beff277bf1dd9d810c07a4db0e1e45f5a"
+	testContract = "0a35c1bd74497c851265774e7e98027b46c27c41"
+)
+
+func testGenesis() *Genesis {
+	g := DefaultGenesisBlock()
+	g.Alloc = GenesisAlloc{
+		testFunded: {Balance: 100, UTXOs: []int64{20, 30}},
+		testContract: {
+			Code:    []byte{0x60, 0x00},
+			Storage: map[string]string{"0x01": "0x02"},
+		},
+	}
+	return g
+}
+
+func TestGenesisAlloc(t *testing.T) {
+	db, _ := lcdb.NewMemDatabase()
+	block, err := testGenesis().Commit(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(block.TXs) != 1 || len(block.TXs[0].To.Coins) != 3 {
+		t.Fatalf("genesis alloc tx mismatch: have %v", block.TXs)
+	}
+	if tx, _, _, _ := storage.GetTransaction(db, *block.TXs[0].GetTxID()); tx == nil {
+		t.Errorf("genesis alloc tx is not found")
+	}
+
+	statedb, err := state.New(*block.GetStatus(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	funded, _ := meta.HexToAccountID(testFunded)
+	obj := statedb.GetObject(meta.GetAccountHash(funded))
+	if obj == nil {
+		t.Fatal("funded account is not found")
+	}
+	if amount := obj.GetAccount().GetAmount().GetInt64(); amount != 150 || len(obj.GetAccount().UTXOs) != 3 {
+		t.Errorf("funded account mismatch: have %d in %d utxos", amount, len(obj.GetAccount().UTXOs))
+	}
+
+	contract, _ := meta.HexToAccountID(testContract)
+	obj = statedb.GetObject(meta.GetAccountHash(contract))
+	if obj == nil {
+		t.Fatal("contract account is not found")
+	}
+	if obj.GetAccount().AccountType != config.ContractAccount || len(obj.Code()) != 2 {
+		t.Errorf("contract account mismatch: have %v", obj.GetAccount())
+	}
+	if value := obj.GetState(statedb.DataBase(), math.HexToHash("0x01")); value != math.HexToHash("0x02") {
+		t.Errorf("contract storage mismatch: have %x", value)
+	}
+
+	//the same genesis is accepted by the database, another one is not
+	if _, hash, err := SetupGenesisBlock(db, testGenesis()); err != nil || !hash.IsEqual(block.GetBlockID()) {
+		t.Errorf("setup stored genesis: hash %x, err %v", hash, err)
+	}
+	if _, _, err := SetupGenesisBlock(db, DefaultGenesisBlock()); err == nil {
+		t.Errorf("setup mismatched genesis succeeded")
+	}
+}
@@ -2,6 +2,7 @@ package helper
 
 import (
 	"encoding/hex"
+	"errors"
 	"sort"
 	"time"
 
@@ -10,6 +11,9 @@ import (
 	"github.com/mihongtech/linkchain/common/math"
 	"github.com/mihongtech/linkchain/config"
 	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/protobuf"
+
+	"github.com/golang/protobuf/proto"
 )
 
 /*
@@ -105,6 +109,39 @@ func CreateCoinBaseTx(to meta.AccountID, amount *meta.Amount, height uint32) *me
 	return transaction
 }
 
+//Create the tx which is used by block signer to vote a candidate.
+//The data of tx is the block height followed by the vote flag(1=authorize,0=deauthorize).
+func CreateVoteTx(candidate meta.AccountID, authorize bool, height uint32) *meta.Transaction {
+	toCoin := meta.NewToCoin(candidate, meta.NewAmount(0))
+	transaction := meta.NewEmptyTransaction(config.DefaultTransactionVersion, config.VoteTx)
+	transaction.AddToCoin(*toCoin)
+	flag := byte(0)
+	if authorize {
+		flag = 1
+	}
+	transaction.Data = append(common.UInt32ToBytes(height), flag)
+	return transaction
+}
+
+//Parse the vote tx,return the height, candidate and vote flag of tx.
+func ParseVoteTx(tx *meta.Transaction) (uint32, meta.AccountID, bool, error) {
+	if tx.Type != config.VoteTx || len(tx.To.Coins) != 1 || len(tx.Data) != 9 {
+		return 0, meta.AccountID{}, false, errors.New("the tx is not a valid vote tx")
+	}
+	height, err := common.BytesToUInt32(tx.Data[:8])
+	if err != nil {
+		return 0, meta.AccountID{}, false, err
+	}
+	switch tx.Data[8] {
+	case 0:
+		return height, tx.To.Coins[0].Id, false, nil
+	case 1:
+		return height, tx.To.Coins[0].Id, true, nil
+	default:
+		return 0, meta.AccountID{}, false, errors.New("the vote flag of vote tx is error")
+	}
+}
+
 func SortTransaction(tx *meta.Transaction) {
 	//sort from
 	sort.Slice(tx.From.Coins, func(i, j int) bool {
@@ -142,6 +179,54 @@ func SortTransaction(tx *meta.Transaction) {
 	})
 }
 
+//Encode the tx into the raw hex string which can be sent by sendRawTransaction.
+func EncodeRawTransaction(tx *meta.Transaction) (string, error) {
+	buffer, err := proto.Marshal(tx.Serialize())
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buffer), nil
+}
+
+//Decode the raw hex string made by EncodeRawTransaction into tx.
+func DecodeRawTransaction(raw string) (*meta.Transaction, error) {
+	buffer, err := hex.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	pb := protobuf.Transaction{}
+	if err := proto.Unmarshal(buffer, &pb); err != nil {
+		return nil, err
+	}
+	tx := meta.Transaction{}
+	if err := tx.Deserialize(&pb); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+//Sign the unsigned from coins of tx which belong to key.
+//The signatures must follow the order of from coins, so signing stops at the first from coin of other account.
+func SignTransaction(tx *meta.Transaction, key *btcec.PrivateKey) error {
+	id := meta.NewAccountId(key.PubKey())
+	count := 0
+	for i := len(tx.Sign); i < len(tx.From.Coins); i++ {
+		if !tx.From.Coins[i].Id.IsEqual(*id) {
+			break
+		}
+		sign, err := btcec.SignCompact(btcec.S256(), key, tx.GetTxID().CloneBytes(), true)
+		if err != nil {
+			return err
+		}
+		tx.AddSignature(meta.NewSignature(sign))
+		count++
+	}
+	if count == 0 {
+		return errors.New("the key can not sign any unsigned from coin of tx")
+	}
+	return nil
+}
+
 /*
 
 	Block
@@ -1,6 +1,7 @@
 package helper
 
 import (
+	"github.com/mihongtech/linkchain/common/btcec"
 	"github.com/mihongtech/linkchain/common/math"
 	"github.com/mihongtech/linkchain/config"
 	"github.com/mihongtech/linkchain/core/meta"
@@ -49,3 +50,44 @@ func TestSortTransaction(t *testing.T) {
 	t.Log("after sort", tx.String())
 
 }
+
+func TestSignRawTransaction(t *testing.T) {
+	key, _ := btcec.NewPrivateKey(btcec.S256())
+	from := meta.NewAccountId(key.PubKey())
+	to := meta.BytesToAccountID([]byte("02"))
+
+	hash, _ := math.NewHash([]byte("11"))
+	tx := CreateTransaction(*CreateFromCoin(*from, *meta.NewTicket(*hash, 0)), *CreateToCoin(to, meta.NewAmount(1)))
+
+	raw, err := EncodeRawTransaction(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned, err := DecodeRawTransaction(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SignTransaction(unsigned, key); err != nil {
+		t.Fatal(err)
+	}
+
+	other, _ := btcec.NewPrivateKey(btcec.S256())
+	if err := SignTransaction(unsigned, other); err == nil {
+		t.Fatal("sign tx by other key should be failed")
+	}
+
+	raw, err = EncodeRawTransaction(unsigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := DecodeRawTransaction(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signed.GetTxID().IsEqual(tx.GetTxID()) {
+		t.Fatalf("txid mismatch: have %s, want %s", signed.GetTxID(), tx.GetTxID())
+	}
+	if err := signed.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,139 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/mihongtech/linkchain/common/math"
+	"github.com/mihongtech/linkchain/common/trie"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/protobuf"
+
+	"github.com/golang/protobuf/proto"
+)
+
+//ProofList is the merkle proof of a trie, the encoded nodes on the path from root to the key.
+//It is written by trie.Prove, and read by trie.VerifyProof whose nodes are keyed by their hashes.
+type ProofList [][]byte
+
+func (l *ProofList) Put(key []byte, value []byte) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func (l ProofList) Has(key []byte) (bool, error) {
+	v, _ := l.Get(key)
+	return v != nil, nil
+}
+
+func (l ProofList) Get(key []byte) ([]byte, error) {
+	for _, node := range l {
+		if hash := math.HashH(node); bytes.Equal(hash[:], key) {
+			return node, nil
+		}
+	}
+	return nil, errors.New("proof node not found")
+}
+
+//Encode returns the hex strings of proof nodes.
+func (l ProofList) Encode() []string {
+	nodes := make([]string, 0, len(l))
+	for _, node := range l {
+		nodes = append(nodes, hex.EncodeToString(node))
+	}
+	return nodes
+}
+
+//DecodeProof decodes the hex strings made by ProofList.Encode.
+func DecodeProof(nodes []string) (ProofList, error) {
+	l := make(ProofList, 0, len(nodes))
+	for _, node := range nodes {
+		buffer, err := hex.DecodeString(node)
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, buffer)
+	}
+	return l, nil
+}
+
+//GetAccountProofKey returns the key of account in the state trie, the secure trie hashes the account hash again.
+func GetAccountProofKey(id meta.AccountID) []byte {
+	key := meta.GetAccountHash(id)
+	return math.HashB(key.CloneBytes())
+}
+
+//VerifyAccountProof verifies the proof of account against the state root.
+//The account is nil if the proof proves that the account does not exist.
+func VerifyAccountProof(root math.Hash, id meta.AccountID, proof ProofList) (*meta.Account, error) {
+	value, err, _ := trie.VerifyProof(root, GetAccountProofKey(id), proof)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
+	}
+	pa := protobuf.Account{}
+	if err := proto.Unmarshal(value, &pa); err != nil {
+		return nil, err
+	}
+	account := meta.Account{}
+	if err := account.Deserialize(&pa); err != nil {
+		return nil, err
+	}
+	if !account.Id.IsEqual(id) {
+		return nil, fmt.Errorf("proven account %s is not %s", account.Id.String(), id.String())
+	}
+	return &account, nil
+}
+
+//VerifyTxProof verifies the proof of tx against the tx tree root.
+func VerifyTxProof(root math.Hash, txid meta.TxID, proof ProofList) (*meta.Transaction, error) {
+	value, err, _ := trie.VerifyProof(root, txid.Bytes(), proof)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, errors.New("the tx is not in the tx tree")
+	}
+	pb := protobuf.Transaction{}
+	if err := proto.Unmarshal(value, &pb); err != nil {
+		return nil, err
+	}
+	tx := meta.Transaction{}
+	if err := tx.Deserialize(&pb); err != nil {
+		return nil, err
+	}
+	if !tx.GetTxID().IsEqual(&txid) {
+		return nil, fmt.Errorf("proven tx %s is not %s", tx.GetTxID().String(), txid.String())
+	}
+	return &tx, nil
+}
+
+//EncodeBlockHeader returns the hex string of block header.
+func EncodeBlockHeader(header *meta.BlockHeader) (string, error) {
+	buffer, err := proto.Marshal(header.Serialize())
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buffer), nil
+}
+
+//DecodeBlockHeader decodes the hex string made by EncodeBlockHeader.
+func DecodeBlockHeader(raw string) (*meta.BlockHeader, error) {
+	buffer, err := hex.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	pb := protobuf.BlockHeader{}
+	if err := proto.Unmarshal(buffer, &pb); err != nil {
+		return nil, err
+	}
+	header := meta.BlockHeader{}
+	if err := header.Deserialize(&pb); err != nil {
+		return nil, err
+	}
+	return &header, nil
+}
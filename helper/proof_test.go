@@ -0,0 +1,103 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/mihongtech/linkchain/common/lcdb"
+	"github.com/mihongtech/linkchain/common/math"
+	"github.com/mihongtech/linkchain/common/trie"
+	"github.com/mihongtech/linkchain/config"
+	"github.com/mihongtech/linkchain/core/meta"
+
+	"github.com/golang/protobuf/proto"
+)
+
+func TestAccountProof(t *testing.T) {
+	diskdb, _ := lcdb.NewMemDatabase()
+	tr, _ := trie.NewSecure(math.Hash{}, trie.NewDatabase(diskdb), 0)
+
+	//the accounts are keyed by account hash in state
+	ids := make([]meta.AccountID, 0)
+	for i := byte(1); i <= 20; i++ {
+		id := meta.BytesToAccountID([]byte{i})
+		hash, _ := math.NewHash([]byte{i, i})
+		utxos := []meta.UTXO{*meta.NewUTXO(meta.NewTicket(*hash, 0), 1, 1, *meta.NewAmount(int64(i)))}
+		data, err := proto.Marshal(meta.NewAccount(id, config.NormalAccount, utxos, meta.NewClearTime(0, 0), id).Serialize())
+		if err != nil {
+			t.Fatalf("failed to encode account: %v", err)
+		}
+		key := meta.GetAccountHash(id)
+		tr.Update(key[:], data)
+		ids = append(ids, id)
+	}
+	root, _ := tr.Commit(nil)
+
+	proof := ProofList{}
+	if err := tr.Prove(GetAccountProofKey(ids[3]), 0, &proof); err != nil {
+		t.Fatalf("failed to prove account: %v", err)
+	}
+	decoded, err := DecodeProof(proof.Encode())
+	if err != nil {
+		t.Fatalf("failed to decode proof: %v", err)
+	}
+	account, err := VerifyAccountProof(root, ids[3], decoded)
+	if err != nil {
+		t.Fatalf("failed to verify proof: %v", err)
+	}
+	if account == nil || account.GetAmount().GetInt64() != 4 {
+		t.Fatalf("account mismatch: %v", account)
+	}
+
+	//the proof is not valid against other root or account
+	if _, err := VerifyAccountProof(math.Hash{1}, ids[3], proof); err == nil {
+		t.Errorf("the proof is verified against other root")
+	}
+	if account, err := VerifyAccountProof(root, ids[4], proof); err == nil && account != nil {
+		t.Errorf("the proof is verified for other account")
+	}
+
+	//the absence of account is proven
+	absent := meta.BytesToAccountID([]byte{0xff})
+	proof = ProofList{}
+	if err := tr.Prove(GetAccountProofKey(absent), 0, &proof); err != nil {
+		t.Fatalf("failed to prove absent account: %v", err)
+	}
+	if account, err := VerifyAccountProof(root, absent, proof); err != nil || account != nil {
+		t.Fatalf("absence proof mismatch: account %v, err %v", account, err)
+	}
+}
+
+func TestTxProof(t *testing.T) {
+	txs := make([]meta.Transaction, 0)
+	for i := byte(1); i <= 10; i++ {
+		hash, _ := math.NewHash([]byte{i})
+		from := meta.BytesToAccountID([]byte{i})
+		to := meta.BytesToAccountID([]byte{i + 1})
+		txs = append(txs, *CreateTransaction(*CreateFromCoin(from, *meta.NewTicket(*hash, 0)), *CreateToCoin(to, meta.NewAmount(int64(i)))))
+	}
+	block := &meta.Block{TXs: txs}
+	root := block.CalculateTxTreeRoot()
+
+	txid := *txs[5].GetTxID()
+	proof := ProofList{}
+	if err := block.ProveTx(txid, &proof); err != nil {
+		t.Fatalf("failed to prove tx: %v", err)
+	}
+	tx, err := VerifyTxProof(root, txid, proof)
+	if err != nil {
+		t.Fatalf("failed to verify proof: %v", err)
+	}
+	if !tx.GetTxID().IsEqual(&txid) {
+		t.Fatalf("tx mismatch: have %v, want %v", tx.GetTxID(), txid)
+	}
+
+	if _, err := VerifyTxProof(math.Hash{1}, txid, proof); err == nil {
+		t.Errorf("the proof is verified against other root")
+	}
+	if _, err := VerifyTxProof(root, *txs[6].GetTxID(), proof); err == nil {
+		t.Errorf("the proof is verified for other tx")
+	}
+	if err := block.ProveTx(math.Hash{1}, &proof); err == nil {
+		t.Errorf("the tx not in block is proven")
+	}
+}
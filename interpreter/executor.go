@@ -7,7 +7,7 @@ import (
 )
 
 type Executor interface {
-	ExecuteResult(results []Result, txFee *meta.Amount, block *meta.Block) error                                                             //After executing block state,execute the result
+	ExecuteResult(results []Result, txFee *meta.Amount, block *meta.Block, chain core.Chain) error                                           //After executing block state,execute the result
 	ChooseTransaction(txs []meta.Transaction, best *meta.Block, offChain OffChain, wallet Wallet, signer *meta.AccountID) []meta.Transaction //choose some of tx into block
 }
 
@@ -21,10 +21,11 @@ type Wallet interface {
 	core.Service
 	SignMessage(accountId meta.AccountID, hash []byte) (math.ISignature, error)
 	SignTransaction(tx meta.Transaction) (*meta.Transaction, error)
-	ImportAccount(privateKeyStr string) (*meta.AccountID, error)
-	ExportAccount(id meta.AccountID) (string, error)
+	ImportAccount(privateKeyStr string, passphrase string) (*meta.AccountID, error)
+	ExportAccount(id meta.AccountID, passphrase string) (string, error)
 	GetAccount(key string) (*meta.Account, error)
 	GetAllWAccount() []meta.Account
 	AddAccount(account meta.Account)
-	NewAccount() (*meta.AccountID, error)
+	NewAccount(passphrase string) (*meta.AccountID, error)
+	IsUnlocked(id meta.AccountID) bool
 }
@@ -1,10 +1,16 @@
 package interpreter
 
-import "github.com/mihongtech/linkchain/common/lcdb"
+import (
+	"github.com/mihongtech/linkchain/common/lcdb"
+	"github.com/mihongtech/linkchain/core"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/storage/state"
+)
 
 type Interpreter interface {
 	Executor
 	Validator
 	Processor
 	CreateOffChain(db lcdb.Database) OffChain
+	CreateInputData(header *meta.BlockHeader, stateDb *state.StateDB, chain core.Chain) Params
 }
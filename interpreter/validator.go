@@ -8,7 +8,7 @@ import (
 )
 
 type BlockValidator interface {
-	VerifyBlockState(block *meta.Block, root math.Hash, actualReward *meta.Amount, fee *meta.Amount, headerData []byte) error
+	VerifyBlockState(block *meta.Block, chain core.Chain, root math.Hash, actualReward *meta.Amount, fee *meta.Amount, headerData []byte) error
 	ValidateBlockBody(txValidator TransactionValidator, chain core.Chain, block *meta.Block) error
 	ValidateBlockHeader(engine consensus.Engine, chain core.Chain, block *meta.Block) error
 }
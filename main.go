@@ -3,13 +3,17 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/mihongtech/linkchain/app"
 	"github.com/mihongtech/linkchain/common/util/log"
 	"github.com/mihongtech/linkchain/config"
+	"github.com/mihongtech/linkchain/rpc/rpcserver"
+	"github.com/mihongtech/linkchain/storage/pruner"
 )
 
 func main() {
@@ -21,13 +25,45 @@ func main() {
 		dataDir     = flag.String("datadir", config.DefaultDataDir(), "linkchain data dir")
 		console     = flag.Bool("console", false, "log out put console(default=false).")
 		nodiscovery = flag.Bool("nodiscovery", false, "default = false means use discovery protocol")
+		noencrypt   = flag.Bool("noencryption", false, "default = false means encrypt and authenticate peer connections")
 		genesispath = flag.String("genesis", "genesis.json", "linkchain genesis config file path")
 		bootnodes   = flag.String("bootnodes", "", "Comma separated enode URLs for P2P discovery bootstrap")
 		interpreter = flag.String("interpreter", "contract", "choose interprete api")
+		txpoolsize  = flag.Int("txpoolsize", config.DefaultTxPoolSize, "the max count of txs in tx pool")
+		syncmode    = flag.String("syncmode", "full", "blockchain sync mode, full executes all blocks and fast syncs the recent state of a new node")
+		prunestate  = flag.Uint64("prunestate", 0, "the count of recent blocks whose state is kept, the stale state is pruned online if it is set, 0 keeps all states")
+		checkpoint  = flag.Uint64("statecheckpoint", pruner.DefaultCheckpoint, "the interval of blocks whose state is kept forever by state pruning, 0 means no checkpoint")
+		pruneinterv = flag.Uint64("pruneinterval", pruner.DefaultInterval, "the count of blocks imported between two online state prunings")
+		bloomsize   = flag.Uint64("prunebloomsize", pruner.DefaultBloomSize, "the memory in MB of the bloom filter marking the retained state of state pruning")
+		ancient     = flag.Uint64("ancientdepth", 0, "the count of recent blocks kept in database, the older blocks and receipts are moved into the ancient store, 0 disables it")
+		bodyhorizon = flag.Uint64("bodyhorizon", 0, "the count of recent blocks whose bodies and receipts are kept, the older ones are pruned and only their headers are served, 0 keeps all bodies")
+		rpcmaxbody  = flag.Int64("rpcmaxbody", rpcserver.DefaultMaxBodySize, "the max size in bytes of a rpc request body")
+		rpcbatch    = flag.Int("rpcbatchlimit", rpcserver.DefaultBatchLimit, "the max count of requests of a rpc batch processed concurrently")
+		rpccert     = flag.String("rpccert", "", "the certificate file of rpc TLS, rpc is served with TLS if it is set with rpckey")
+		rpckey      = flag.String("rpckey", "", "the key file of rpc TLS")
+		rpcuser     = flag.String("rpcuser", "", "the rpc user which is allowed to call all namespaces")
+		rpcpass     = flag.String("rpcpass", "", "the password of rpc user")
+		rpcorigins  = flag.String("rpcwsorigins", "", "comma separated origins of browsers allowed to connect the rpc websocket, * allows any origin")
+		rpciprate   = flag.Float64("rpciprate", 0, "the max rpc requests cost per second of each remote ip, 0 means no limit")
+		rpcipburst  = flag.Float64("rpcipburst", 0, "the max rpc requests cost of burst of each remote ip")
+		rpccredrate = flag.Float64("rpccredrate", 0, "the max rpc requests cost per second of each credential, 0 means no limit")
+		rpccredbst  = flag.Float64("rpccredburst", 0, "the max rpc requests cost of burst of each credential")
+		rpccosts    = flag.String("rpcmethodcosts", "", "comma separated cost weights of rpc methods for rate limiting, e.g. call=10,getLogs=10")
+		rpctimeout  = flag.Duration("rpctimeout", rpcserver.DefaultTimeout, "the execution deadline of a rpc request except the state changing ones, 0 means no deadline")
+		rpcauthfile = flag.String("rpcauthfile", "", "json file of rpc credentials, each has user and password or token, and the allowed namespaces of public, wallet, miner and admin")
+		unlock      = flag.String("unlock", "", "comma separated wallet accounts to unlock until exit, e.g. the signer of miner")
+		password    = flag.String("password", "", "password file of the unlocked accounts, one password per line in order of accounts")
 	)
-	flag.Parse()
+	//the command is the first argument, its flags follow it
+	command := ""
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		command = os.Args[1]
+		flag.CommandLine.Parse(os.Args[2:])
+	} else {
+		flag.Parse()
+	}
 
-	if err := initLog(logLevel, *console, *dataDir); err != nil {
+	if err := initLog(logLevel, *console || command != "", *dataDir); err != nil {
 		log.Error("initLog failed, exit", "err", err)
 		return
 	}
@@ -38,9 +74,73 @@ func main() {
 	globalConfig.DataDir = *dataDir
 	globalConfig.GenesisPath = *genesispath
 	globalConfig.NoDiscovery = *nodiscovery
+	globalConfig.NoEncryption = *noencrypt
 	globalConfig.BootstrapNodes = *bootnodes
 	globalConfig.InterpreterAPI = *interpreter
+	globalConfig.TxPoolSize = *txpoolsize
+	globalConfig.SyncMode = *syncmode
+	globalConfig.StateRetention = *prunestate
+	globalConfig.StateCheckpoint = *checkpoint
+	globalConfig.PruneInterval = *pruneinterv
+	globalConfig.PruneBloomSize = *bloomsize
+	globalConfig.AncientDepth = *ancient
+	globalConfig.BodyHorizon = *bodyhorizon
 	globalConfig.RpcAddr = *rpcIp + ":" + strconv.Itoa(*rpcPort)
+	globalConfig.RpcMaxBodySize = *rpcmaxbody
+	globalConfig.RpcBatchLimit = *rpcbatch
+	globalConfig.RpcTLSCert = *rpccert
+	globalConfig.RpcTLSKey = *rpckey
+	if *rpcuser != "" {
+		globalConfig.RpcCredentials = append(globalConfig.RpcCredentials, config.RpcCredential{
+			User:       *rpcuser,
+			Password:   *rpcpass,
+			Namespaces: []string{rpcserver.NamespacePublic, rpcserver.NamespaceWallet, rpcserver.NamespaceMiner, rpcserver.NamespaceAdmin},
+		})
+	}
+	if *rpcorigins != "" {
+		globalConfig.RpcWSOrigins = strings.Split(*rpcorigins, ",")
+	}
+	globalConfig.RpcIPRate = *rpciprate
+	globalConfig.RpcIPBurst = *rpcipburst
+	globalConfig.RpcCredentialRate = *rpccredrate
+	globalConfig.RpcCredentialBurst = *rpccredbst
+	globalConfig.RpcTimeout = *rpctimeout
+	if *rpccosts != "" {
+		costs, err := parseMethodCosts(*rpccosts)
+		if err != nil {
+			log.Error("parse rpc method costs failed, exit", "err", err)
+			return
+		}
+		globalConfig.RpcMethodCosts = costs
+	}
+	if *rpcauthfile != "" {
+		credentials, err := config.LoadRpcCredentials(*rpcauthfile)
+		if err != nil {
+			log.Error("load rpc credentials failed, exit", "err", err)
+			return
+		}
+		globalConfig.RpcCredentials = append(globalConfig.RpcCredentials, credentials...)
+	}
+	if *unlock != "" {
+		globalConfig.WalletUnlock = strings.Split(*unlock, ",")
+		if *password == "" {
+			log.Error("password file is required to unlock accounts, exit")
+			return
+		}
+		passwords, err := readPasswords(*password)
+		if err != nil {
+			log.Error("read password file failed, exit", "err", err)
+			return
+		}
+		globalConfig.WalletPasswords = passwords
+	}
+	if command != "" {
+		if err := runCommand(command, globalConfig, flag.Args()); err != nil {
+			log.Error("command failed, exit", "command", command, "err", err)
+		}
+		return
+	}
+
 	// start node
 	if !app.Setup(globalConfig) {
 		log.Error("app setup failed, exit")
@@ -51,6 +151,42 @@ func main() {
 	defer app.Stop()
 }
 
+//parse the method costs in the form of method=cost,method=cost
+func parseMethodCosts(s string) (map[string]float64, error) {
+	costs := make(map[string]float64)
+	for _, item := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid method cost %q", item)
+		}
+		cost, err := strconv.ParseFloat(kv[1], 64)
+		if err != nil || cost < 0 {
+			return nil, fmt.Errorf("invalid method cost %q", item)
+		}
+		costs[kv[0]] = cost
+	}
+	return costs, nil
+}
+
+//read the passwords of file, one password per line
+func readPasswords(path string) ([]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(data), "\n")
+	passwords := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			passwords = append(passwords, line)
+		}
+	}
+	if len(passwords) == 0 {
+		return nil, fmt.Errorf("no password in %s", path)
+	}
+	return passwords, nil
+}
+
 func initLog(logLevel *int, console bool, dataDir string) error {
 	//init log
 	ostream := log.StreamHandler(os.Stdout, log.TerminalFormat(true))
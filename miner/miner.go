@@ -3,6 +3,7 @@ package miner
 import (
 	"container/heap"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -16,12 +17,17 @@ import (
 	"github.com/mihongtech/linkchain/txpool"
 )
 
+const (
+	sealCheckInterval = 500 * time.Millisecond // Interval to check the best block while waiting for the seal time
+)
+
 type Miner struct {
 	nodeAPI   *node.PublicNodeAPI
 	executor  interpreter.Executor
 	walletAPI interpreter.Wallet
 	txPoolAPI *txpool.TxPool
 	isMining  bool
+	quit      chan struct{} // closed when the mining is stopped
 	minerMtx  sync.Mutex
 }
 
@@ -46,11 +52,14 @@ func (m *Miner) Stop() {
 }
 
 func (m *Miner) MineBlock() (*meta.Block, error) {
-	signerId, err := m.getMineBlock()
+	best := m.nodeAPI.GetBestBlock()
+	signerId, sealTime, err := m.getMineBlock(best)
 	if err != nil {
 		return nil, errors.New("the node can not mine block" + err.Error())
 	}
-	best := m.nodeAPI.GetBestBlock()
+	if err := m.waitSealTime(best, sealTime); err != nil {
+		return nil, err
+	}
 	block, err := helper.CreateBlock(best.GetHeight(), *best.GetBlockID())
 	if err != nil {
 		log.Error("Miner", "New Block error", err)
@@ -64,9 +73,13 @@ func (m *Miner) MineBlock() (*meta.Block, error) {
 	}
 	block.Header.Difficulty = difficulty
 
-	coinbase := helper.CreateCoinBaseTx(*signerId, meta.NewAmount(config.DefaultBlockReward), block.GetHeight())
+	coinbase := helper.CreateCoinBaseTx(*signerId, meta.NewAmount(m.nodeAPI.GetChainConfig().GetBlockReward(block.GetHeight())), block.GetHeight())
 	block.SetTx(*coinbase)
 
+	if err := m.nodeAPI.GetEngine().Prepare(m.nodeAPI, block); err != nil {
+		return nil, err
+	}
+
 	txs := m.txPoolAPI.GetAllTransaction()
 	txs = m.executor.ChooseTransaction(txs, best, m.nodeAPI.GetOffChain(), m.walletAPI, signerId)
 
@@ -85,16 +98,15 @@ func (m *Miner) MineBlock() (*meta.Block, error) {
 		return nil, err
 	}
 
-	for extraNonce := uint32(0); extraNonce < ^uint32(0); extraNonce++ {
-		block.Header.Time = time.Now()
-		block.Header.Nonce = extraNonce
-		err := block.Deserialize(block.Serialize())
-		if err != nil {
-			return nil, err
-		}
-		if node.HashToBig(block.GetBlockID()).Cmp(node.CompactToBig(difficulty)) < 0 {
-			break
-		}
+	//the block can not be sealed before the seal time of signer
+	block.Header.Time = time.Now()
+	if block.Header.Time.Before(sealTime) {
+		block.Header.Time = sealTime
+	}
+	if err := m.sealBlock(block); err != nil {
+		log.Debug("Miner", "seal Block error", err)
+		m.removeBlockTxs(block)
+		return nil, err
 	}
 
 	err = m.signBlock(*signerId, block)
@@ -124,6 +136,35 @@ func (m *Miner) signBlock(signer meta.AccountID, block *meta.Block) error {
 	return nil
 }
 
+//seal the block by consensus engine,
+//the sealing is aborted when a new best block arrives or the mining is stopped.
+func (m *Miner) sealBlock(block *meta.Block) error {
+	m.minerMtx.Lock()
+	quit := m.quit
+	m.minerMtx.Unlock()
+
+	sub := m.nodeAPI.GetTxPoolEvent().Subscribe(node.InsertBlockEvent{})
+	defer sub.Unsubscribe()
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-sub.Chan():
+		case <-quit:
+		case <-done:
+			return
+		}
+		close(stop)
+	}()
+
+	if !IsBestBlockOffspring(m.nodeAPI, block) {
+		return errors.New("current block is not block prev")
+	}
+	return m.nodeAPI.GetEngine().Seal(m.nodeAPI, block, stop)
+}
+
 func (m *Miner) StartMine() error {
 	m.minerMtx.Lock()
 	if m.isMining {
@@ -131,16 +172,23 @@ func (m *Miner) StartMine() error {
 		return errors.New("the node is mining")
 	}
 	m.isMining = true
+	m.quit = make(chan struct{})
 	m.minerMtx.Unlock()
 	for true {
 		m.minerMtx.Lock()
 		tempMing := m.isMining
+		quit := m.quit
 		m.minerMtx.Unlock()
 		if !tempMing {
 			break
 		}
-		m.MineBlock()
-		//time.Sleep(time.Duration(config.DefaultPeriod) * time.Second)
+		if _, err := m.MineBlock(); err != nil {
+			//retry later, the node can not mine until the chain or wallet changes
+			select {
+			case <-quit:
+			case <-time.After(sealCheckInterval):
+			}
+		}
 	}
 	return nil
 }
@@ -148,6 +196,10 @@ func (m *Miner) StartMine() error {
 func (m *Miner) StopMine() {
 	m.minerMtx.Lock()
 	defer m.minerMtx.Unlock()
+	if m.isMining {
+		close(m.quit)
+		m.quit = nil
+	}
 	m.isMining = false
 }
 
@@ -157,27 +209,69 @@ func (m *Miner) GetInfo() bool {
 	return m.isMining
 }
 
-//check miner if not can mine next block
-func (m *Miner) getMineBlock() (*meta.AccountID, error) {
-	best := m.nodeAPI.GetBestBlock()
+//check miner if not can mine next block,
+//return the unlocked wallet signer which is allowed to seal first and its seal time.
+func (m *Miner) getMineBlock(best *meta.Block) (*meta.AccountID, time.Time, error) {
 	newBlock, err := helper.CreateBlock(best.GetHeight(), *best.GetBlockID())
 	if err != nil {
-		return nil, err
+		return nil, time.Time{}, err
 	}
-	signerStr := m.nodeAPI.GetEngine().GetBlockSigner(&newBlock.Header)
-
-	if _, err = meta.NewAccountIdFromStr(signerStr); err != nil {
-		log.Error("Get signer account id failed", "err", err)
-		return nil, err
+	engine := m.nodeAPI.GetEngine()
+
+	var (
+		signer   *meta.AccountID
+		sealTime time.Time
+		locked   *meta.AccountID
+	)
+	for _, account := range m.walletAPI.GetAllWAccount() {
+		id := account.GetAccountID()
+		t, err := engine.SealTime(m.nodeAPI, &newBlock.Header, *id)
+		if err != nil {
+			continue
+		}
+		//the locked signer can not sign the block
+		if !m.walletAPI.IsUnlocked(*id) {
+			locked = id
+			continue
+		}
+		if signer == nil || t.Before(sealTime) {
+			signer, sealTime = id, t
+		}
 	}
+	if signer == nil && locked != nil {
+		log.Warn("Authorized signer in wallet is locked", "signer", locked.String())
+		return nil, time.Time{}, fmt.Errorf("authorized signer %s is locked, please unlock it", locked.String())
+	}
+	if signer == nil {
+		log.Debug("No authorized signer in wallet", "height", newBlock.GetHeight())
+		return nil, time.Time{}, errors.New("no authorized signer in wallet")
+	}
+	return signer, sealTime, nil
+}
 
-	signer, err := m.walletAPI.GetAccount(signerStr)
-	if err != nil {
-
-		log.Error("GetAccount failed", "err", err, "signerStr", signerStr)
-		return nil, err
+//wait until the seal time,
+//abort if the best block changed or the mining is stopped in the meantime.
+func (m *Miner) waitSealTime(best *meta.Block, sealTime time.Time) error {
+	m.minerMtx.Lock()
+	quit := m.quit
+	m.minerMtx.Unlock()
+	for {
+		if !m.nodeAPI.GetBestBlock().GetBlockID().IsEqual(best.GetBlockID()) {
+			return errors.New("best block changed while waiting to seal")
+		}
+		delay := time.Until(sealTime)
+		if delay <= 0 {
+			return nil
+		}
+		if delay > sealCheckInterval {
+			delay = sealCheckInterval
+		}
+		select {
+		case <-quit:
+			return errors.New("mining is stopped while waiting to seal")
+		case <-time.After(delay):
+		}
 	}
-	return signer.GetAccountID(), nil
 }
 
 func (m *Miner) removeBlockTxs(block *meta.Block) {
@@ -219,7 +313,7 @@ func (m *Miner) generateValidBlock(tq TxDescQueue, block *meta.Block, signerId *
 		return err
 	}
 
-	if err := m.executor.ExecuteResult(results, txFee, block); err != nil {
+	if err := m.executor.ExecuteResult(results, txFee, block, m.nodeAPI); err != nil {
 		m.removeBlockTxs(block)
 		return err
 	}
@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/helper"
 )
 
 func (n *Node) initAccountManager() {
@@ -23,3 +24,24 @@ func (n *Node) getAccount(id meta.AccountID) (meta.Account, error) {
 	}
 	return *stateObject.GetAccount(), nil
 }
+
+//getAccountProof proves the account in the state of block, the account is nil if the proof proves its absence.
+func (n *Node) getAccountProof(id meta.AccountID, hash meta.BlockID) (*meta.Account, *meta.Block, helper.ProofList, error) {
+	block, _ := n.blockchain.GetBlockByID(hash)
+	if block == nil {
+		return nil, nil, nil, errors.New("can not find block")
+	}
+	tr, err := n.blockchain.stateCache.OpenTrie(*block.GetStatus())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	proof := helper.ProofList{}
+	if err := tr.Prove(helper.GetAccountProofKey(id), 0, &proof); err != nil {
+		return nil, nil, nil, err
+	}
+	account, err := helper.VerifyAccountProof(*block.GetStatus(), id, proof)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return account, block, proof, nil
+}
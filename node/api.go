@@ -3,6 +3,7 @@ package node
 import (
 	"errors"
 
+	"github.com/mihongtech/linkchain/common/lcdb"
 	"github.com/mihongtech/linkchain/common/math"
 	"github.com/mihongtech/linkchain/common/util/event"
 	"github.com/mihongtech/linkchain/common/util/log"
@@ -10,6 +11,7 @@ import (
 	"github.com/mihongtech/linkchain/consensus"
 	"github.com/mihongtech/linkchain/core"
 	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/helper"
 	"github.com/mihongtech/linkchain/interpreter"
 	"github.com/mihongtech/linkchain/storage/state"
 )
@@ -54,11 +56,20 @@ func (a *PublicNodeAPI) GetTxEvent() *event.Feed {
 	return a.n.newTxEvent
 }
 
+func (a *PublicNodeAPI) SubscribeChainHeadEvent(ch chan<- meta.ChainHeadEvent) event.Subscription {
+	return a.n.blockchain.SubscribeChainHeadEvent(ch)
+}
+
 //block
 func (a *PublicNodeAPI) GetBestBlock() *meta.Block {
 	return a.n.blockchain.CurrentBlock()
 }
 
+//GetBestFastBlock returns the head of fast synced blocks, it may be above the best block
+func (a *PublicNodeAPI) GetBestFastBlock() *meta.Block {
+	return a.n.blockchain.CurrentFastBlock()
+}
+
 func (a *PublicNodeAPI) HasBlock(hash meta.BlockID) bool {
 	return a.n.blockchain.HasBlock(hash)
 }
@@ -68,17 +79,25 @@ func (a *PublicNodeAPI) GetBlockByID(hash meta.BlockID) (*meta.Block, error) {
 }
 
 func (a *PublicNodeAPI) GetHeader(hash math.Hash, height uint64) *meta.BlockHeader {
-	block, err := a.n.blockchain.GetBlockByID(hash)
-	if err != nil {
-		return nil
-	}
-	return &block.Header
+	return a.n.blockchain.GetHeader(hash, height)
+}
+
+func (a *PublicNodeAPI) GetHeaderByID(hash meta.BlockID) *meta.BlockHeader {
+	return a.n.blockchain.GetHeader(hash, a.n.blockchain.GetBlockNumber(hash))
+}
+
+func (a *PublicNodeAPI) GetHeaderByHeight(height uint64) *meta.BlockHeader {
+	return a.n.blockchain.GetHeaderByHeight(height)
 }
 
 func (a *PublicNodeAPI) GetBlockByHeight(height uint32) (*meta.Block, error) {
 	return a.n.blockchain.GetBlockByHeight(height)
 }
 
+func (a *PublicNodeAPI) Config() *config.ChainConfig {
+	return a.n.blockchain.Config()
+}
+
 func (a *PublicNodeAPI) GetChainConfig() *config.ChainConfig {
 	return a.n.blockchain.Config()
 }
@@ -91,16 +110,53 @@ func (a *PublicNodeAPI) CheckBlock(block *meta.Block) error {
 	return a.n.checkBlock(block)
 }
 
+//fast sync
+func (a *PublicNodeAPI) InsertFastChain(blocks []*meta.Block) error {
+	return a.n.blockchain.InsertFastChain(blocks)
+}
+
+func (a *PublicNodeAPI) FastSyncCommitHead(hash meta.BlockID) error {
+	return a.n.blockchain.FastSyncCommitHead(hash)
+}
+
+func (a *PublicNodeAPI) TrieNode(hash math.Hash) ([]byte, error) {
+	return a.n.blockchain.TrieNode(hash)
+}
+
+//GetDB returns the database of chain and state
+func (a *PublicNodeAPI) GetDB() lcdb.Database {
+	return a.n.db
+}
+
 //account
 func (a *PublicNodeAPI) GetAccount(id meta.AccountID) (meta.Account, error) {
 	return a.n.getAccount(id)
 }
 
+//GetAccountProof returns the account in the state of block and its merkle proof against the state root
+func (a *PublicNodeAPI) GetAccountProof(id meta.AccountID, hash meta.BlockID) (*meta.Account, *meta.Block, helper.ProofList, error) {
+	return a.n.getAccountProof(id, hash)
+}
+
 // tx
 func (a *PublicNodeAPI) GetTXByID(hash meta.TxID) (*meta.Transaction, math.Hash, uint64, uint64) {
 	return a.n.getTxByID(hash)
 }
 
+func (a *PublicNodeAPI) VerifyTx(tx *meta.Transaction, stateDb *state.StateDB) error {
+	return a.n.verifyTx(tx, stateDb)
+}
+
+//ApplyTx applies tx to stateDb as if it was packed into the next block of best block.
+func (a *PublicNodeAPI) ApplyTx(tx *meta.Transaction, stateDb *state.StateDB) error {
+	return a.n.applyTx(tx, stateDb)
+}
+
+//GetTxProof returns the tx in block and its merkle proof against the tx root
+func (a *PublicNodeAPI) GetTxProof(hash meta.TxID) (*meta.Transaction, *meta.Block, helper.ProofList, error) {
+	return a.n.getTxProof(hash)
+}
+
 //chain
 func (a *PublicNodeAPI) GetBlockChainInfo() interface{} {
 	// TODO: implement me
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	mrand "math/rand"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -16,11 +17,13 @@ import (
 	"github.com/mihongtech/linkchain/common/util/mclock"
 	"github.com/mihongtech/linkchain/config"
 	"github.com/mihongtech/linkchain/consensus"
+	"github.com/mihongtech/linkchain/consensus/pow"
 	"github.com/mihongtech/linkchain/core"
 	"github.com/mihongtech/linkchain/core/meta"
 	"github.com/mihongtech/linkchain/interpreter"
 	"github.com/mihongtech/linkchain/normal"
 	"github.com/mihongtech/linkchain/storage"
+	"github.com/mihongtech/linkchain/storage/pruner"
 	"github.com/mihongtech/linkchain/storage/state"
 
 	"github.com/hashicorp/golang-lru"
@@ -39,6 +42,7 @@ const (
 	numberCacheLimit    = 2048
 	triesInMemory       = 128
 	receiptsCacheLimit  = 32
+	tdCacheLimit        = 1024
 
 	// BlockChainVersion ensures that an incompatible database forces a resync from scratch.
 	BlockChainVersion = 3
@@ -50,6 +54,23 @@ type CacheConfig struct {
 	Disabled      bool          // Whether to disable trie write caching (archive node)
 	TrieNodeLimit int           // Memory limit (MB) at which to flush the current in-memory trie to disk
 	TrieTimeLimit time.Duration // Time limit after which to flush the current in-memory trie to disk
+
+	StateRetention  uint64 // Number of recent blocks whose state is kept on disk, zero keeps all states
+	StateCheckpoint uint64 // Interval of blocks whose state is kept forever when pruning
+	PruneInterval   uint64 // Number of blocks imported between two online state prunings
+	PruneBloomSize  uint64 // Memory (MB) of the bloom filter marking the retained state
+
+	AncientDepth uint64 // Number of recent blocks kept in the database, older ones are frozen into the ancient store, zero disables freezing
+	BodyHorizon  uint64 // Number of recent blocks whose bodies and receipts are kept, zero keeps all of them
+}
+
+// DefaultCacheConfig returns the cache configuration of a full node keeping all
+// the states on disk.
+func DefaultCacheConfig() *CacheConfig {
+	return &CacheConfig{
+		TrieNodeLimit: 256 * 1024 * 1024,
+		TrieTimeLimit: 5 * time.Minute,
+	}
 }
 
 // BlockChain represents the canonical chain given a database with a genesis
@@ -74,9 +95,13 @@ type BlockChain struct {
 	triegc *prque.Prque  // Priority queue mapping block numbers to tries to gc
 	gcproc time.Duration // Accumulates canonical block processing for trie dumping
 
+	pruner    *pruner.Pruner // State pruner deleting the stale state from disk, nil if not pruning
+	lastPrune uint64         // Head number of the last state pruning
+
 	chainFeed     event.Feed
 	chainSideFeed event.Feed
 	chainHeadFeed event.Feed
+	removedTxFeed event.Feed
 	scope         event.SubscriptionScope
 	genesisBlock  *meta.Block
 
@@ -94,6 +119,7 @@ type BlockChain struct {
 	receiptsCache *lru.Cache // Cache for the most recent receipts per block
 	futureBlocks  *lru.Cache // future blocks are blocks added for later processing
 	numberCache   *lru.Cache // Cache for the most recent block numbers
+	tdCache       *lru.Cache // Cache for the most recent block total weights
 
 	stateCache state.Database
 	quit       chan struct{} // blockchain quit channel
@@ -115,16 +141,14 @@ type BlockChain struct {
 // Processor.
 func NewBlockChain(db lcdb.Database, genesisHash math.Hash, cacheConfig *CacheConfig, chainConfig *config.ChainConfig, intrepreterAPI interpreter.Interpreter, engine consensus.Engine) (*BlockChain, error) {
 	if cacheConfig == nil {
-		cacheConfig = &CacheConfig{
-			TrieNodeLimit: 256 * 1024 * 1024,
-			TrieTimeLimit: 5 * time.Minute,
-		}
+		cacheConfig = DefaultCacheConfig()
 	}
 	blockCache, _ := lru.New(blockCacheLimit)
 	futureBlocks, _ := lru.New(maxFutureBlocks)
 	badBlocks, _ := lru.New(badBlockLimit)
 	numberCache, _ := lru.New(numberCacheLimit)
 	receiptsCache, _ := lru.New(receiptsCacheLimit)
+	tdCache, _ := lru.New(tdCacheLimit)
 	bc := &BlockChain{
 		chainConfig:   chainConfig,
 		cacheConfig:   cacheConfig,
@@ -137,6 +161,7 @@ func NewBlockChain(db lcdb.Database, genesisHash math.Hash, cacheConfig *CacheCo
 		badBlocks:     badBlocks,
 		numberCache:   numberCache,
 		receiptsCache: receiptsCache,
+		tdCache:       tdCache,
 		engine:        engine,
 	}
 	bc.validator = intrepreterAPI
@@ -150,6 +175,34 @@ func NewBlockChain(db lcdb.Database, genesisHash math.Hash, cacheConfig *CacheCo
 		return nil, err
 	}
 	bc.SetCurrentBlockHead(bc.CurrentBlock())
+
+	if !cacheConfig.Disabled && cacheConfig.StateRetention > 0 {
+		bc.pruner = pruner.NewPruner(db, bc.stateCache.TrieDB(), pruner.Config{
+			Retention:  cacheConfig.StateRetention,
+			Checkpoint: cacheConfig.StateCheckpoint,
+			BloomSize:  cacheConfig.PruneBloomSize,
+		})
+		if progress := storage.GetPruneProgress(db); progress > 0 {
+			bc.lastPrune = progress + cacheConfig.StateRetention - 1
+		}
+	}
+	if cacheConfig.AncientDepth > 0 {
+		if _, ok := db.(storage.AncientReader); !ok {
+			log.Warn("Database has no ancient store, block freezing disabled")
+			cacheConfig.AncientDepth, cacheConfig.BodyHorizon = 0, 0
+		} else if cacheConfig.AncientDepth < triesInMemory {
+			// The blocks whose state may be rewritten by a reorg stay in the database
+			log.Warn("Ancient depth is too small, use the min depth", "depth", cacheConfig.AncientDepth, "min", triesInMemory)
+			cacheConfig.AncientDepth = triesInMemory
+		}
+		if cacheConfig.BodyHorizon > 0 && cacheConfig.BodyHorizon < cacheConfig.AncientDepth {
+			log.Warn("Body horizon is below the ancient depth, use the ancient depth", "horizon", cacheConfig.BodyHorizon, "depth", cacheConfig.AncientDepth)
+			cacheConfig.BodyHorizon = cacheConfig.AncientDepth
+		}
+	} else if cacheConfig.BodyHorizon > 0 {
+		log.Warn("Body pruning requires the ancient store, body pruning disabled")
+		cacheConfig.BodyHorizon = 0
+	}
 	// Take ownership of this particular state
 	go bc.update()
 	return bc, nil
@@ -220,6 +273,10 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	if hdr := bc.CurrentBlock(); hdr != nil {
 		height = uint64(hdr.GetHeight())
 	}
+	// The fast synced blocks may be above the head block
+	if hdr := bc.CurrentFastBlock(); hdr != nil && uint64(hdr.GetHeight()) > height {
+		height = uint64(hdr.GetHeight())
+	}
 
 	for hdr := bc.CurrentBlock(); hdr != nil && uint64(hdr.GetHeight()) > head; hdr = bc.CurrentBlock() {
 		hash := *hdr.GetBlockID()
@@ -233,12 +290,21 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	for i := height; i > head; i-- {
 		storage.DeleteCanonicalHash(bc.db, i)
 	}
+	// The frozen blocks above the new head are discarded from the ancient store, after
+	// the rewind as the new head itself may only be found in the ancient store
+	if chain, ok := bc.db.(*storage.ChainDB); ok && head+1 < chain.Ancients() {
+		if err := chain.Freezer().TruncateAncients(head + 1); err != nil {
+			log.Error("Failed to truncate ancient store", "target", head, "err", err)
+			return err
+		}
+	}
 	bc.SetCurrentBlockHead(bc.CurrentBlock())
 	// Clear out any stale content from the caches
 	bc.blockCache.Purge()
 	bc.futureBlocks.Purge()
 	bc.receiptsCache.Purge()
 	bc.numberCache.Purge()
+	bc.tdCache.Purge()
 
 	// Rewind the block chain, ensuring we don't end up with a stateless head block
 	if currentBlock := bc.CurrentBlock(); currentBlock != nil {
@@ -308,6 +374,11 @@ func (bc *BlockChain) ResetWithGenesisBlock(genesis *meta.Block) error {
 	if err := storage.WriteBlock(bc.db, genesis); err != nil {
 		log.Crit("Failed to write genesis block", "err", err)
 	}
+	weight, err := bc.engine.CalcWeight(bc, genesis)
+	if err != nil {
+		return err
+	}
+	storage.WriteTd(bc.db, *genesis.GetBlockID(), 0, weight)
 	bc.genesisBlock = genesis
 	bc.insert(bc.genesisBlock)
 	bc.currentBlock.Store(bc.genesisBlock)
@@ -424,6 +495,63 @@ func (bc *BlockChain) GetBlock(hash math.Hash, number uint64) *meta.Block {
 	return block
 }
 
+// GetTd retrieves a block's total weight from the database by hash and number,
+// caching it if found. The weight of blocks stored without one is recalculated
+// from the closest ancestor known to have a weight.
+func (bc *BlockChain) GetTd(hash math.Hash, number uint64) (*big.Int, error) {
+	var (
+		blocks []*meta.Block
+		td     *big.Int
+	)
+	for td == nil {
+		if cached, ok := bc.tdCache.Get(hash); ok {
+			td = cached.(*big.Int)
+			break
+		}
+		if td = storage.GetTd(bc.db, hash, number); td != nil {
+			bc.tdCache.Add(hash, td)
+			break
+		}
+		block := bc.GetBlock(hash, number)
+		if block == nil {
+			return nil, consensus.ErrUnknownAncestor
+		}
+		blocks = append(blocks, block)
+		if number == 0 {
+			td = new(big.Int)
+			break
+		}
+		hash, number = *block.GetPrevBlockID(), number-1
+	}
+	for i := len(blocks) - 1; i >= 0; i-- {
+		weight, err := bc.engine.CalcWeight(bc, blocks[i])
+		if err != nil {
+			return nil, err
+		}
+		td = new(big.Int).Add(td, weight)
+		storage.WriteTd(bc.db, *blocks[i].GetBlockID(), uint64(blocks[i].GetHeight()), td)
+		bc.tdCache.Add(*blocks[i].GetBlockID(), td)
+	}
+	return td, nil
+}
+
+// calcTd calculates the total weight of the chain ending with block, the parent
+// of block must be known.
+func (bc *BlockChain) calcTd(block *meta.Block) (*big.Int, error) {
+	if block.IsGensis() {
+		return bc.engine.CalcWeight(bc, block)
+	}
+	ptd, err := bc.GetTd(*block.GetPrevBlockID(), uint64(block.GetHeight()-1))
+	if err != nil {
+		return nil, err
+	}
+	weight, err := bc.engine.CalcWeight(bc, block)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).Add(ptd, weight), nil
+}
+
 // GetBlockByHash retrieves a block from the database by hash, caching it if found.
 func (bc *BlockChain) GetBlockByID(hash math.Hash) (*meta.Block, error) {
 	return bc.GetBlock(hash, bc.GetBlockNumber(hash)), nil
@@ -453,7 +581,104 @@ func (bc *BlockChain) GetBlockByHeight(number uint32) (*meta.Block, error) {
 // TrieNode retrieves a blob of data associated with a trie node (or code hash)
 // either from ephemeral in-memory cache, or from persistent storage.
 func (bc *BlockChain) TrieNode(hash math.Hash) ([]byte, error) {
-	return bc.stateCache.TrieDB().Node(hash)
+	if node, err := bc.stateCache.TrieDB().Node(hash); err == nil && len(node) > 0 {
+		return node, nil
+	}
+	return storage.GetCode(bc.db, hash)
+}
+
+// InsertFastChain writes a batch of fast synced blocks to the canonical chain
+// without executing them, the known blocks are skipped. The state of the blocks
+// is not available, so the head block is left intact and only the fast block is
+// advanced. The receipts of the blocks are not available either.
+func (bc *BlockChain) InsertFastChain(blocks []*meta.Block) error {
+	if len(blocks) == 0 {
+		return nil
+	}
+	bc.wg.Add(1)
+	defer bc.wg.Done()
+
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+
+	for _, block := range blocks {
+		// If the chain is terminating, stop processing blocks
+		if atomic.LoadInt32(&bc.procInterrupt) == 1 {
+			log.Debug("Premature abort during fast blocks processing")
+			return nil
+		}
+		if bc.HasBlock(*block.GetBlockID()) {
+			continue
+		}
+		difficulty, err := bc.calcEasiestDifficulty(block)
+		if err != nil {
+			return err
+		}
+		if pow.CompactToBig(difficulty).Cmp(pow.CompactToBig(block.Header.Difficulty)) < 0 {
+			return errors.New("block target difficulty is too low")
+		}
+		if err := bc.validator.ValidateBlockHeader(bc.engine, bc, block); err != nil {
+			return err
+		}
+		if err := bc.validator.ValidateBlockBody(bc.validator, bc, block); err != nil {
+			return err
+		}
+		td, err := bc.calcTd(block)
+		if err != nil {
+			return err
+		}
+		batch := bc.db.NewBatch()
+		if err := storage.WriteTd(batch, *block.GetBlockID(), uint64(block.GetHeight()), td); err != nil {
+			return err
+		}
+		if err := storage.WriteBlock(batch, block); err != nil {
+			return err
+		}
+		if err := storage.WriteTxLookupEntries(batch, block); err != nil {
+			return err
+		}
+		if err := storage.WriteCanonicalHash(batch, *block.GetBlockID(), uint64(block.GetHeight())); err != nil {
+			return err
+		}
+		if err := storage.WriteHeadFastBlockHash(batch, *block.GetBlockID()); err != nil {
+			return err
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		bc.tdCache.Add(*block.GetBlockID(), td)
+
+		bc.mu.Lock()
+		bc.currentFastBlock.Store(block)
+		bc.mu.Unlock()
+	}
+	last := blocks[len(blocks)-1]
+	log.Debug("Inserted fast blocks", "count", len(blocks), "number", last.GetHeight(), "hash", last.GetBlockID())
+	return nil
+}
+
+// FastSyncCommitHead sets the current head block to the fast synced block of
+// hash, whose state must be fully synced.
+func (bc *BlockChain) FastSyncCommitHead(hash math.Hash) error {
+	block, _ := bc.GetBlockByID(hash)
+	if block == nil {
+		return fmt.Errorf("non existent block [%s]", hash.String())
+	}
+	if !bc.HasState(*block.GetStatus()) {
+		return fmt.Errorf("missing state of block %d [%s]", block.GetHeight(), hash.String())
+	}
+	bc.mu.Lock()
+	if err := storage.WriteHeadBlockHash(bc.db, hash); err != nil {
+		bc.mu.Unlock()
+		return err
+	}
+	bc.currentBlock.Store(block)
+	bc.SetCurrentBlockHead(block)
+	bc.mu.Unlock()
+
+	log.Info("Committed new head block", "number", block.GetHeight(), "hash", hash)
+	bc.chainHeadFeed.Send(meta.ChainHeadEvent{block})
+	return nil
 }
 
 // Stop stops the blockchain service. If any imports are currently in progress
@@ -555,9 +780,15 @@ func (bc *BlockChain) WriteBlockWithoutState(block *meta.Block) (err error) {
 	bc.wg.Add(1)
 	defer bc.wg.Done()
 
+	td, err := bc.calcTd(block)
+	if err != nil {
+		return err
+	}
 	if err := storage.WriteBlock(bc.db, block); err != nil {
 		return err
 	}
+	storage.WriteTd(bc.db, *block.GetBlockID(), uint64(block.GetHeight()), td)
+	bc.tdCache.Add(*block.GetBlockID(), td)
 	return nil
 }
 
@@ -587,8 +818,19 @@ func (bc *BlockChain) WriteBlockWithState(block *meta.Block, state *state.StateD
 	currentBlock := bc.CurrentBlock()
 	localHeight := uint64(currentBlock.GetHeight())
 	externHeight := uint64(block.GetHeight())
+	localTd, err := bc.GetTd(*currentBlock.GetBlockID(), localHeight)
+	if err != nil {
+		return NonStatTy, err
+	}
+	externTd, err := bc.calcTd(block)
+	if err != nil {
+		return NonStatTy, err
+	}
 	// Write other block data using a batch.
 	batch := bc.db.NewBatch()
+	if err := storage.WriteTd(batch, *block.GetBlockID(), externHeight, externTd); err != nil {
+		return NonStatTy, err
+	}
 	if err := storage.WriteBlock(batch, block); err != nil {
 		return NonStatTy, err
 	}
@@ -652,8 +894,13 @@ func (bc *BlockChain) WriteBlockWithState(block *meta.Block, state *state.StateD
 	// Write other block data using a batch.
 	storage.WriteReceipts(batch, *block.GetBlockID(), uint64(block.GetHeight()), normal.GetReceiptsByResult(results))
 
-	// If the externHeight is higher than our known, add it to the canonical chain
-	reorg := externHeight > localHeight
+	// If the total weight is higher than our known, add it to the canonical chain.
+	// Same weight chains are split randomly, preferring the shorter one which has
+	// more in-turn blocks.
+	reorg := externTd.Cmp(localTd) > 0
+	if !reorg && externTd.Cmp(localTd) == 0 {
+		reorg = externHeight < localHeight || (externHeight == localHeight && mrand.Float64() < 0.5)
+	}
 	currentBlock = bc.CurrentBlock()
 	if reorg {
 		// Reorganise the chain if the parent is not the head block
@@ -674,6 +921,7 @@ func (bc *BlockChain) WriteBlockWithState(block *meta.Block, state *state.StateD
 	if err := batch.Write(); err != nil {
 		return NonStatTy, err
 	}
+	bc.tdCache.Add(*block.GetBlockID(), externTd)
 
 	// Set new head.
 	if status == CanonStatTy {
@@ -725,7 +973,7 @@ func (bc *BlockChain) insertChain(chain *meta.Block) ([]interface{}, error) {
 	if err != nil {
 		return events, err
 	}
-	if CompactToBig(difficulty).Cmp(CompactToBig(chain.Header.Difficulty)) < 0 {
+	if pow.CompactToBig(difficulty).Cmp(pow.CompactToBig(chain.Header.Difficulty)) < 0 {
 		return events, errors.New("block target difficulty is too low")
 	}
 
@@ -741,6 +989,12 @@ func (bc *BlockChain) insertChain(chain *meta.Block) ([]interface{}, error) {
 	}
 	bstart := time.Now()
 	err = bc.validator.ValidateBlockBody(bc.validator, bc, chain)
+	if err == nil && !chain.IsGensis() {
+		// The block is valid, but its parent state may have been pruned
+		if parent := bc.GetHeader(*chain.GetPrevBlockID(), uint64(chain.GetHeight()-1)); parent != nil && !bc.HasState(parent.Status) {
+			err = consensus.ErrPrunedAncestor
+		}
+	}
 	switch {
 	case err == consensus.ErrFutureBlock:
 		// Allow up to MaxFuture second in the future blocks. If this limit is exceeded
@@ -760,22 +1014,31 @@ func (bc *BlockChain) insertChain(chain *meta.Block) ([]interface{}, error) {
 		// Block competing with the canonical chain, store in the db, but don't process
 		// until the competitor TD goes above the canonical TD
 		currentBlock := bc.CurrentBlock()
-		localHeight := currentBlock.GetHeight()
-		externHeight := chain.GetHeight()
-		if localHeight > externHeight {
+		localTd, err := bc.GetTd(*currentBlock.GetBlockID(), uint64(currentBlock.GetHeight()))
+		if err != nil {
+			return events, err
+		}
+		externTd, err := bc.calcTd(chain)
+		if err != nil {
+			return events, err
+		}
+		if localTd.Cmp(externTd) > 0 {
 			if err = bc.WriteBlockWithoutState(chain); err != nil {
 				return events, err
 			}
-			break
+			return events, nil
 		}
 		// Competitor chain beat canonical, gather all blocks from the common ancestor
 		var winner []*meta.Block
 
 		parent := bc.GetBlock(*chain.GetPrevBlockID(), uint64(chain.GetHeight()-1))
-		for !bc.HasState(*parent.GetStatus()) {
+		for parent != nil && !bc.HasState(*parent.GetStatus()) {
 			winner = append(winner, parent)
 			parent = bc.GetBlock(*parent.GetPrevBlockID(), uint64(parent.GetHeight()-1))
 		}
+		if parent == nil {
+			return events, consensus.ErrUnknownAncestor
+		}
 		for j := 0; j < len(winner)/2; j++ {
 			winner[j], winner[len(winner)-1-j] = winner[len(winner)-1-j], winner[j]
 		}
@@ -783,8 +1046,9 @@ func (bc *BlockChain) insertChain(chain *meta.Block) ([]interface{}, error) {
 		bc.chainmu.Unlock()
 		for j := 0; j < len(winner); j++ {
 			evs, err := bc.insertChain(winner[j])
-			events = evs
+			events = append(events, evs...)
 			if err != nil {
+				bc.chainmu.Lock()
 				return events, err
 			}
 		}
@@ -961,6 +1225,10 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *meta.Block) error {
 			return fmt.Errorf("Invalid new chain")
 		}
 	}
+	// The frozen blocks are final, a fork below them can't be reorganised
+	if ancients := storage.GetAncients(bc.db); uint64(commonBlock.GetHeight())+1 < ancients {
+		return fmt.Errorf("reorg below the ancient blocks: fork %d, ancients %d", commonBlock.GetHeight(), ancients)
+	}
 	// Ensure the user sees large reorgs
 	if len(oldChain) > 0 && len(newChain) > 0 {
 		logFn := log.Debug
@@ -993,10 +1261,18 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *meta.Block) error {
 	}
 
 	if len(oldChain) > 0 {
+		// The txs of the dropped blocks are sent oldest first, so the txs spending the
+		// outputs of the txs of the earlier blocks can be reinjected after them
+		var removedTxs []meta.Transaction
+		for i := len(oldChain) - 1; i >= 0; i-- {
+			removedTxs = append(removedTxs, oldChain[i].GetTxs()...)
+		}
+		removedTxs = meta.TxDifference(removedTxs, addedTxs)
 		go func() {
-			for _, block := range oldChain {
-				bc.chainSideFeed.Send(meta.ChainSideEvent{Block: block})
+			for i := len(oldChain) - 1; i >= 0; i-- {
+				bc.chainSideFeed.Send(meta.ChainSideEvent{Block: oldChain[i]})
 			}
+			bc.removedTxFeed.Send(meta.RemovedTxsEvent{Txs: removedTxs})
 		}()
 	}
 
@@ -1026,23 +1302,115 @@ func (bc *BlockChain) PostChainEvents(events []interface{}) {
 func (bc *BlockChain) update() {
 	futureTimer := time.NewTicker(5 * time.Second)
 	defer futureTimer.Stop()
+
+	var pruneCh, freezeCh <-chan time.Time
+	if bc.pruner != nil {
+		pruneTimer := time.NewTicker(time.Minute)
+		defer pruneTimer.Stop()
+		pruneCh = pruneTimer.C
+	}
+	if bc.cacheConfig.AncientDepth > 0 {
+		freezeTimer := time.NewTicker(time.Minute)
+		defer freezeTimer.Stop()
+		freezeCh = freezeTimer.C
+	}
 	for {
 		select {
 		case <-futureTimer.C:
 			bc.procFutureBlocks()
+		case <-pruneCh:
+			bc.pruneState()
+		case <-freezeCh:
+			bc.freezeChain()
 		case <-bc.quit:
 			return
 		}
 	}
 }
 
+// pruneState deletes the stale state from disk once enough blocks have been
+// imported since the last pruning. Block imports are held off until it's done,
+// as the trie nodes committed during pruning would not be marked as retained.
+//
+// A reorg deeper than the retention is still possible: the state of the fork
+// point is missing, so the side chain is re-executed from the nearest retained
+// ancestor (a checkpoint or the genesis) through ErrPrunedAncestor.
+func (bc *BlockChain) pruneState() {
+	if uint64(bc.CurrentBlock().GetHeight()) < bc.lastPrune+bc.cacheConfig.PruneInterval {
+		return
+	}
+	bc.wg.Add(1)
+	defer bc.wg.Done()
+
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+
+	if atomic.LoadInt32(&bc.procInterrupt) == 1 {
+		return
+	}
+	head := bc.CurrentBlock()
+	if err := bc.pruner.Prune(head); err == pruner.ErrFastSyncing {
+		log.Debug("Skip state pruning during fast sync", "number", head.GetHeight())
+		return
+	} else if err != nil {
+		log.Warn("Failed to prune state", "number", head.GetHeight(), "err", err)
+		return
+	}
+	bc.lastPrune = uint64(head.GetHeight())
+}
+
+// freezeChain moves the canonical blocks older than the ancient depth into the
+// ancient store, and drops the bodies and receipts beyond the body horizon. The
+// side chains forking below the ancient blocks are deleted, so a reorg never
+// goes deeper than the ancient depth.
+func (bc *BlockChain) freezeChain() {
+	bc.wg.Add(1)
+	defer bc.wg.Done()
+
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+
+	if atomic.LoadInt32(&bc.procInterrupt) == 1 {
+		return
+	}
+	number := uint64(bc.CurrentBlock().GetHeight())
+	if number < bc.cacheConfig.AncientDepth {
+		return
+	}
+	if _, err := storage.FreezeBlocks(bc.db, number-bc.cacheConfig.AncientDepth+1); err != nil {
+		log.Warn("Failed to freeze ancient blocks", "number", number, "err", err)
+		return
+	}
+	if horizon := bc.cacheConfig.BodyHorizon; horizon > 0 && number >= horizon {
+		if _, err := storage.PruneBodies(bc.db, number-horizon+1); err != nil {
+			log.Warn("Failed to prune ancient bodies", "number", number, "err", err)
+			return
+		}
+		bc.blockCache.Purge()
+		bc.receiptsCache.Purge()
+	}
+}
+
 func (bc *BlockChain) Engine() consensus.Engine {
 	return bc.engine
 }
 
+// GetHeader retrieves a block header from the database by hash and number, the
+// header of a block whose body is pruned is still found.
 func (bc *BlockChain) GetHeader(hash math.Hash, height uint64) *meta.BlockHeader {
-	block := bc.GetBlock(hash, height)
-	return &block.Header
+	if block, ok := bc.blockCache.Get(hash); ok {
+		return &block.(*meta.Block).Header
+	}
+	return storage.GetHeader(bc.db, hash, height)
+}
+
+// GetHeaderByHeight retrieves a canonical block header from the database by number.
+func (bc *BlockChain) GetHeaderByHeight(number uint64) *meta.BlockHeader {
+	hash := storage.GetCanonicalHash(bc.db, number)
+	if hash == (math.Hash{}) {
+		return nil
+	}
+	return bc.GetHeader(hash, number)
 }
 
 // BadBlockArgs represents the entries in the list returned when bad blocks are queried.
@@ -1101,3 +1469,8 @@ func (bc *BlockChain) SubscribeChainHeadEvent(ch chan<- meta.ChainHeadEvent) eve
 func (bc *BlockChain) SubscribeChainSideEvent(ch chan<- meta.ChainSideEvent) event.Subscription {
 	return bc.scope.Track(bc.chainSideFeed.Subscribe(ch))
 }
+
+// SubscribeRemovedTxsEvent registers a subscription of RemovedTxsEvent.
+func (bc *BlockChain) SubscribeRemovedTxsEvent(ch chan<- meta.RemovedTxsEvent) event.Subscription {
+	return bc.scope.Track(bc.removedTxFeed.Subscribe(ch))
+}
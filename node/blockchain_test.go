@@ -1 +1,219 @@
 package node
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mihongtech/linkchain/common/lcdb"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/genesis"
+	"github.com/mihongtech/linkchain/helper"
+	"github.com/mihongtech/linkchain/normal"
+	"github.com/mihongtech/linkchain/storage"
+)
+
+//testEngine accepts every block and weights each block by one, so the longest chain is canonical.
+type testEngine struct{}
+
+func (testEngine) Author(header *meta.BlockHeader) ([]byte, error) { return nil, nil }
+
+func (testEngine) VerifyBlock(chain meta.ChainReader, block *meta.Block) error { return nil }
+
+func (testEngine) VerifySeal(chain meta.ChainReader, block *meta.Block) error { return nil }
+
+func (testEngine) Prepare(chain meta.ChainReader, block *meta.Block) error { return nil }
+
+func (testEngine) Seal(chain meta.ChainReader, block *meta.Block, stop <-chan struct{}) error {
+	return nil
+}
+
+func (testEngine) GetBlockSigner(chain meta.ChainReader, header *meta.BlockHeader) string {
+	return ""
+}
+
+func (testEngine) CalcWeight(chain meta.ChainReader, block *meta.Block) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (testEngine) SealTime(chain meta.ChainReader, header *meta.BlockHeader, signer meta.AccountID) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+//newTestBlockChain creates an archive blockchain on db, the genesis is set up if db is empty.
+func newTestBlockChain(t *testing.T, db lcdb.Database) *BlockChain {
+	chainConfig, hash, err := genesis.SetupGenesisBlock(db, nil)
+	if err != nil {
+		t.Fatalf("setup genesis failed: %v", err)
+	}
+	cacheConfig := DefaultCacheConfig()
+	cacheConfig.Disabled = true
+	bc, err := NewBlockChain(db, hash, cacheConfig, chainConfig, &normal.Interpreter{}, testEngine{})
+	if err != nil {
+		t.Fatalf("create blockchain failed: %v", err)
+	}
+	return bc
+}
+
+//makeTestChain inserts count blocks mined by signer on top of parent into bc.
+func makeTestChain(t *testing.T, bc *BlockChain, parent *meta.Block, count int, signer meta.AccountID) []*meta.Block {
+	blocks := make([]*meta.Block, 0, count)
+	for i := 0; i < count; i++ {
+		block, _ := helper.CreateBlock(parent.GetHeight(), *parent.GetBlockID())
+		block.Header.Time = parent.GetTime().Add(time.Second)
+		difficulty, err := bc.calcDifficulty(parent)
+		if err != nil {
+			t.Fatalf("calc difficulty failed: %v", err)
+		}
+		block.Header.Difficulty = difficulty
+		block.SetTx(*helper.CreateCoinBaseTx(signer, meta.NewAmount(bc.Config().GetBlockReward(block.GetHeight())), block.GetHeight()))
+
+		err, _, root, _ := bc.executeBlock(block)
+		if err != nil {
+			t.Fatalf("execute block %d failed: %v", block.GetHeight(), err)
+		}
+		block.Header.Status = root
+		block, _ = helper.RebuildBlock(block)
+		if err := bc.ProcessBlock(block); err != nil {
+			t.Fatalf("insert block %d failed: %v", block.GetHeight(), err)
+		}
+		blocks = append(blocks, block)
+		parent = block
+	}
+	return blocks
+}
+
+func TestReorgAcrossPrunedState(t *testing.T) {
+	db, _ := lcdb.NewMemDatabase()
+	bc := newTestBlockChain(t, db)
+	defer bc.Stop()
+	gendb, _ := lcdb.NewMemDatabase()
+	gen := newTestBlockChain(t, gendb)
+	defer gen.Stop()
+
+	minerA := meta.CreateAccountId([]byte("miner a"))
+	minerB := meta.CreateAccountId([]byte("miner b"))
+
+	//the common blocks and the canonical chain are known by bc
+	common := makeTestChain(t, gen, gen.genesisBlock, 2, minerA)
+	for _, block := range common {
+		if err := bc.ProcessBlock(block); err != nil {
+			t.Fatalf("insert common block %d failed: %v", block.GetHeight(), err)
+		}
+	}
+	canon := makeTestChain(t, bc, common[1], 3, minerA)
+	side := makeTestChain(t, gen, common[1], 4, minerB)
+
+	//the states of the common blocks are below the retention horizon
+	for _, block := range common {
+		if err := db.Delete(block.GetStatus().CloneBytes()); err != nil {
+			t.Fatalf("delete state failed: %v", err)
+		}
+		if bc.HasState(*block.GetStatus()) {
+			t.Fatalf("state of block %d is not pruned", block.GetHeight())
+		}
+	}
+
+	for _, block := range side {
+		if err := bc.ProcessBlock(block); err != nil {
+			t.Fatalf("insert side block %d failed: %v", block.GetHeight(), err)
+		}
+	}
+	head := side[len(side)-1]
+	if current := bc.CurrentBlock(); !current.GetBlockID().IsEqual(head.GetBlockID()) {
+		t.Fatalf("head mismatch: have %d %v, want %d %v", current.GetHeight(), current.GetBlockID(), head.GetHeight(), head.GetBlockID())
+	}
+	for _, block := range append(common, side...) {
+		if !bc.HasState(*block.GetStatus()) {
+			t.Errorf("state of block %d %v is missing", block.GetHeight(), block.GetBlockID())
+		}
+		if canonical, _ := bc.GetBlockByHeight(block.GetHeight()); canonical == nil || !canonical.GetBlockID().IsEqual(block.GetBlockID()) {
+			t.Errorf("canonical block %d mismatch, want %v", block.GetHeight(), block.GetBlockID())
+		}
+	}
+	if block, _ := bc.GetBlockByID(*canon[0].GetBlockID()); block == nil {
+		t.Errorf("reorged block %d is missing", canon[0].GetHeight())
+	}
+}
+
+func TestSetHeadToAncientBlock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ancient")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	freezer, err := storage.NewFreezer(dir)
+	if err != nil {
+		t.Fatalf("failed to open freezer: %v", err)
+	}
+	memdb, _ := lcdb.NewMemDatabase()
+	db := storage.NewChainDB(memdb, freezer)
+	defer db.Close()
+
+	bc := newTestBlockChain(t, db)
+	blocks := makeTestChain(t, bc, bc.genesisBlock, 8, meta.CreateAccountId([]byte("miner")))
+	if _, err := storage.FreezeBlocks(db, 6); err != nil {
+		t.Fatalf("failed to freeze blocks: %v", err)
+	}
+	bc.Stop()
+
+	//the restarted chain finds the frozen blocks in the ancient store only
+	bc = newTestBlockChain(t, db)
+	defer bc.Stop()
+	if err := bc.SetHead(3); err != nil {
+		t.Fatalf("failed to set head: %v", err)
+	}
+	head := blocks[2]
+	if current := bc.CurrentBlock(); !current.GetBlockID().IsEqual(head.GetBlockID()) {
+		t.Fatalf("head mismatch: have %d %v, want %d %v", current.GetHeight(), current.GetBlockID(), head.GetHeight(), head.GetBlockID())
+	}
+	if ancients := db.Ancients(); ancients != 4 {
+		t.Errorf("ancients mismatch: have %d, want %d", ancients, 4)
+	}
+	if block, _ := bc.GetBlockByHeight(4); block != nil {
+		t.Errorf("block %d above the head is not discarded", block.GetHeight())
+	}
+}
+
+func TestReorgRemovedTxs(t *testing.T) {
+	db, _ := lcdb.NewMemDatabase()
+	bc := newTestBlockChain(t, db)
+	defer bc.Stop()
+	gendb, _ := lcdb.NewMemDatabase()
+	gen := newTestBlockChain(t, gendb)
+	defer gen.Stop()
+
+	minerA := meta.CreateAccountId([]byte("miner a"))
+	minerB := meta.CreateAccountId([]byte("miner b"))
+
+	canon := makeTestChain(t, bc, bc.genesisBlock, 2, minerA)
+	//the first side block has the same coinbase tx as the first canonical block
+	side := makeTestChain(t, gen, gen.genesisBlock, 1, minerA)
+	side = append(side, makeTestChain(t, gen, side[0], 2, minerB)...)
+
+	ch := make(chan meta.RemovedTxsEvent, 1)
+	sub := bc.SubscribeRemovedTxsEvent(ch)
+	defer sub.Unsubscribe()
+	for _, block := range side {
+		if err := bc.ProcessBlock(block); err != nil {
+			t.Fatalf("insert side block %d failed: %v", block.GetHeight(), err)
+		}
+	}
+
+	select {
+	case ev := <-ch:
+		want := canon[1].GetTxs()
+		if len(ev.Txs) != len(want) {
+			t.Fatalf("removed txs count mismatch: have %d, want %d", len(ev.Txs), len(want))
+		}
+		for i := range want {
+			if !ev.Txs[i].GetTxID().IsEqual(want[i].GetTxID()) {
+				t.Errorf("removed tx %d mismatch: have %v, want %v", i, ev.Txs[i].GetTxID(), want[i].GetTxID())
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("removed txs event is not sent")
+	}
+}
@@ -17,3 +17,7 @@ type AccountEvent struct {
 type InsertBlockEvent struct {
 	Block *meta.Block
 }
+
+type RemovedTxsEvent struct {
+	Txs []meta.Transaction
+}
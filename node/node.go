@@ -15,6 +15,7 @@ import (
 	"github.com/mihongtech/linkchain/config"
 	"github.com/mihongtech/linkchain/consensus"
 	"github.com/mihongtech/linkchain/consensus/poa"
+	"github.com/mihongtech/linkchain/consensus/pow"
 	"github.com/mihongtech/linkchain/core/meta"
 	"github.com/mihongtech/linkchain/genesis"
 	"github.com/mihongtech/linkchain/storage"
@@ -53,12 +54,14 @@ type Node struct {
 
 	updateMainState event.Subscription
 	updateSideState event.Subscription
+	removedTxsSub   event.Subscription
 	MainChainCh     chan meta.ChainEvent
 	SideChainCh     chan meta.ChainSideEvent
+	RemovedTxsCh    chan meta.RemovedTxsEvent
 }
 
 func NewNode() *Node {
-	return &Node{MainChainCh: make(chan meta.ChainEvent, 10), SideChainCh: make(chan meta.ChainSideEvent, 10)}
+	return &Node{MainChainCh: make(chan meta.ChainEvent, 10), SideChainCh: make(chan meta.ChainSideEvent, 10), RemovedTxsCh: make(chan meta.RemovedTxsEvent, 10)}
 }
 
 func (n *Node) Setup(i interface{}) bool {
@@ -82,12 +85,20 @@ func (n *Node) Setup(i interface{}) bool {
 
 	config, genesisHash, err := n.initGenesis(n.db, globalConfig.GenesisPath)
 
-	n.engine = poa.NewPoa(config, s.GetDB())
+	n.engine = createConsensusEngine(config, s.GetDB())
 	n.validatorAPI = i.(*context.Context).InterpreterAPI
 	n.interpreterAPI = i.(*context.Context).InterpreterAPI
 	n.offchain = n.interpreterAPI.CreateOffChain(n.db)
 
-	n.blockchain, err = NewBlockChain(s.GetDB(), genesisHash, nil, config, n.interpreterAPI, n.engine)
+	cacheConfig := DefaultCacheConfig()
+	cacheConfig.StateRetention = globalConfig.StateRetention
+	cacheConfig.StateCheckpoint = globalConfig.StateCheckpoint
+	cacheConfig.PruneInterval = globalConfig.PruneInterval
+	cacheConfig.PruneBloomSize = globalConfig.PruneBloomSize
+	cacheConfig.AncientDepth = globalConfig.AncientDepth
+	cacheConfig.BodyHorizon = globalConfig.BodyHorizon
+
+	n.blockchain, err = NewBlockChain(s.GetDB(), genesisHash, cacheConfig, config, n.interpreterAPI, n.engine)
 	if err != nil {
 		log.Error("init blockchain failed", "err", err)
 		return false
@@ -98,6 +109,17 @@ func (n *Node) Setup(i interface{}) bool {
 	return true
 }
 
+//create the consensus engine selected by chain config,
+//the chain is sealed by pow if pow config is set, otherwise by poa.
+func createConsensusEngine(chainConfig *config.ChainConfig, db lcdb.Database) consensus.Engine {
+	if chainConfig.Pow != nil {
+		log.Info("Use proof-of-work consensus engine")
+		return pow.NewPow(chainConfig)
+	}
+	log.Info("Use proof-of-authority consensus engine")
+	return poa.NewPoa(chainConfig, db)
+}
+
 func (n *Node) initGenesis(db lcdb.Database, genesisPath string) (*config.ChainConfig, math.Hash, error) {
 	if len(genesisPath) == 0 {
 		return nil, math.Hash{}, errors.New("genesis file is nil")
@@ -132,6 +154,7 @@ func (n *Node) Start() bool {
 	//n.offchain.SetSubscription(n.blockchain.SubscribeChainEvent(n.offchain.MainChainCh), n.blockchain.SubscribeChainSideEvent(n.offchain.SideChainCh))
 	n.updateMainState = n.blockchain.SubscribeChainEvent(n.MainChainCh)
 	n.updateSideState = n.blockchain.SubscribeChainSideEvent(n.SideChainCh)
+	n.removedTxsSub = n.blockchain.SubscribeRemovedTxsEvent(n.RemovedTxsCh)
 	if !n.offchain.Start() {
 		return false
 	}
@@ -149,6 +172,8 @@ func (n *Node) updateState() {
 			n.txPoolEvent.Post(InsertBlockEvent{Block: ev.Block})
 		case ev := <-n.SideChainCh:
 			n.offchain.UpdateSideChain(ev)
+		case ev := <-n.RemovedTxsCh:
+			n.txPoolEvent.Post(RemovedTxsEvent{Txs: ev.Txs})
 		}
 	}
 }
@@ -157,6 +182,12 @@ func (n *Node) Stop() {
 	n.offchain.Stop()
 }
 
+//Close stops the blockchain and closes the database of node which is set up without starting.
+func (n *Node) Close() {
+	n.blockchain.Stop()
+	n.db.Close()
+}
+
 //func (n *Node) getBlockEvent() *event.TypeMux {
 //	return n.newBlockEvent
 //}
@@ -1,9 +1,15 @@
 package node
 
 import (
+	"errors"
+	"time"
+
 	"github.com/mihongtech/linkchain/common/math"
 	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/helper"
+	"github.com/mihongtech/linkchain/interpreter"
 	"github.com/mihongtech/linkchain/storage"
+	"github.com/mihongtech/linkchain/storage/state"
 )
 
 func (n *Node) getTxByID(hash meta.TxID) (*meta.Transaction, math.Hash, uint64, uint64) {
@@ -14,3 +20,42 @@ func (n *Node) getTxByID(hash meta.TxID) (*meta.Transaction, math.Hash, uint64,
 		return tx, hash, number, index
 	}
 }
+
+//getTxProof proves the tx in its block against the tx root of block.
+func (n *Node) getTxProof(hash meta.TxID) (*meta.Transaction, *meta.Block, helper.ProofList, error) {
+	tx, blockHash, _, _ := storage.GetTransaction(n.db, hash)
+	if tx == nil {
+		return nil, nil, nil, errors.New("can not find tx")
+	}
+	block, _ := n.blockchain.GetBlockByID(blockHash)
+	if block == nil {
+		return nil, nil, nil, errors.New("can not find block of tx")
+	}
+	proof := helper.ProofList{}
+	if err := block.ProveTx(hash, &proof); err != nil {
+		return nil, nil, nil, err
+	}
+	return tx, block, proof, nil
+}
+
+//create the input data of the next block of best block.
+func (n *Node) nextInputData(stateDb *state.StateDB) interpreter.Params {
+	best := n.blockchain.CurrentBlock()
+	header := meta.BlockHeader{
+		Height: best.GetHeight() + 1,
+		Time:   time.Now(),
+		Prev:   *best.GetBlockID(),
+	}
+	return n.interpreterAPI.CreateInputData(&header, stateDb, n.blockchain)
+}
+
+//verify tx as if it was packed into the next block of best block.
+func (n *Node) verifyTx(tx *meta.Transaction, stateDb *state.StateDB) error {
+	return n.validatorAPI.VerifyTx(tx, n.nextInputData(stateDb))
+}
+
+//apply tx to state as if it was packed into the next block of best block.
+func (n *Node) applyTx(tx *meta.Transaction, stateDb *state.StateDB) error {
+	err, _ := n.interpreterAPI.ProcessTxState(tx, n.nextInputData(stateDb))
+	return err
+}
@@ -18,7 +18,7 @@ func (n *Interpreter) ProcessBlockState(block *meta.Block, stateDb *state.StateD
 		return err, nil
 	}
 
-	if err := validator.VerifyBlockState(block, *root, actualReward, fee, nil); err != nil {
+	if err := validator.VerifyBlockState(block, chain, *root, actualReward, fee, nil); err != nil {
 		return err, nil
 	}
 	return nil, results
@@ -4,11 +4,12 @@ import (
 	"errors"
 
 	"github.com/mihongtech/linkchain/config"
+	"github.com/mihongtech/linkchain/core"
 	"github.com/mihongtech/linkchain/core/meta"
 	"github.com/mihongtech/linkchain/interpreter"
 )
 
-func (e *Interpreter) ExecuteResult(results []interpreter.Result, txFee *meta.Amount, block *meta.Block) error {
+func (e *Interpreter) ExecuteResult(results []interpreter.Result, txFee *meta.Amount, block *meta.Block, chain core.Chain) error {
 	//push txfee into coinbase
 	if block.TXs[0].Type != config.CoinBaseTx {
 		return errors.New("the frist tx of block must be coinbase")
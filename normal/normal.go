@@ -16,6 +16,11 @@ func (i *Interpreter) CreateOffChain(db lcdb.Database) interpreter.OffChain {
 	return &OffChainState{}
 }
 
+//Create the input data which is used to verify tx outside of block processing.
+func (i *Interpreter) CreateInputData(header *meta.BlockHeader, stateDb *state.StateDB, chain core.Chain) interpreter.Params {
+	return &Input{header, stateDb, chain, meta.AccountID{}}
+}
+
 type Input struct {
 	Header      *meta.BlockHeader
 	StateDB     *state.StateDB
@@ -57,7 +62,7 @@ func (o *Output) WriteResult() error {
 }
 
 func IsNormal(txType uint32) bool {
-	return txType == config.CoinBaseTx || txType == config.NormalTx
+	return txType == config.CoinBaseTx || txType == config.NormalTx || txType == config.VoteTx
 }
 
 func GetReceiptsByResult(results []interpreter.Result) []*core.Receipt {
@@ -22,6 +22,11 @@ func (n *Interpreter) ProcessTxState(tx *meta.Transaction, data interpreter.Para
 		output := &Output{}
 		output.TxFee = fee
 		return err, output
+	case config.VoteTx:
+		//Vote tx only change the signers of consensus,not change account.
+		output := &Output{}
+		output.TxFee = meta.NewAmount(0)
+		return nil, output
 	}
 	return nil, nil
 }
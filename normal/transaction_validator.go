@@ -6,6 +6,7 @@ import (
 	"github.com/mihongtech/linkchain/common"
 	"github.com/mihongtech/linkchain/config"
 	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/helper"
 	"github.com/mihongtech/linkchain/interpreter"
 )
 
@@ -21,6 +22,8 @@ func (n *Interpreter) CheckTx(tx *meta.Transaction) error {
 			err = checkCoinBaseTx(tx)
 		case config.NormalTx:
 			err = checkNormalTx(tx)
+		case config.VoteTx:
+			err = checkVoteTx(tx)
 		}
 		return err
 	} else {
@@ -65,6 +68,23 @@ func checkCoinBaseTx(tx *meta.Transaction) error {
 	return nil
 }
 
+func checkVoteTx(tx *meta.Transaction) error {
+	fromCount := len(tx.From.Coins)
+	signCount := len(tx.Sign)
+	if fromCount > 0 || signCount > 0 {
+		return errors.New("the vote tx from/sign count must be 0")
+	}
+
+	if _, _, _, err := helper.ParseVoteTx(tx); err != nil {
+		return err
+	}
+
+	if tx.GetToValue().Sign() != 0 {
+		return errors.New("the vote tx toValue must be 0")
+	}
+	return nil
+}
+
 func checkNormalTx(tx *meta.Transaction) error {
 	// verify transaction size
 	size, err := tx.Size()
@@ -136,6 +156,8 @@ func (n *Interpreter) VerifyTx(tx *meta.Transaction, data interpreter.Params) er
 			err = verifyCoinBaseTx(tx, data)
 		case config.NormalTx:
 			err = verifyNormalTx(tx, data)
+		case config.VoteTx:
+			err = verifyVoteTx(tx, data)
 		}
 		return err
 	} else {
@@ -184,6 +206,19 @@ func verifyCoinBaseTx(tx *meta.Transaction, data interpreter.Params) error {
 	return nil
 }
 
+//The signer of vote is checked by consensus engine,only check the height of vote.
+func verifyVoteTx(tx *meta.Transaction, data interpreter.Params) error {
+	inputData := data.(*Input)
+	height, _, _, err := helper.ParseVoteTx(tx)
+	if err != nil {
+		return err
+	}
+	if height != inputData.Header.Height {
+		return errors.New("the vote tx height must be equal to block height")
+	}
+	return nil
+}
+
 func VerifyUnCoinBaseTx(tx *meta.Transaction, data interpreter.Params) error {
 	inputData := data.(*Input)
 	fcValue := meta.NewAmount(0)
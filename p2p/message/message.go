@@ -30,6 +30,10 @@ type Msg struct {
 	ReceivedAt time.Time
 }
 
+// MaxMsgSize is the max size of a message payload of the protocols, the compressed
+// payloads are rejected if they decode larger than it.
+const MaxMsgSize = 10 * 1024 * 1024
+
 const (
 	// devp2p message codes
 	HandshakeMsg = 0x00
@@ -86,6 +86,10 @@ type Config struct {
 	// Disabling is useful for protocol debugging (manual topology).
 	NoDiscovery bool
 
+	// NoEncryption selects the plaintext transport, the peer connections are
+	// neither encrypted nor authenticated by the node keys.
+	NoEncryption bool `toml:",omitempty"`
+
 	// If Dialer is set to a non-nil value, the given Dialer
 	// is used to dial outbound peer connections.
 	Dialer NodeDialer `toml:"-"`
@@ -150,6 +154,7 @@ func (srv *Service) Setup(i interface{}) bool {
 	srv.ListenAddr = i.(*context.Context).Config.ListenAddress
 	srv.PrivateKey = srv.NodeKey(filepath.Join(i.(*context.Context).Config.DataDir, config.DefaultPrivateKeyDir))
 	srv.NoDiscovery = i.(*context.Context).Config.NoDiscovery
+	srv.NoEncryption = i.(*context.Context).Config.NoEncryption
 	srv.NodeDatabase = filepath.Join(i.(*context.Context).Config.DataDir, config.DefaultNodeDatabaseDir)
 	srv.sync = &data_sync.Service{}
 	srv.NoDial = false
@@ -192,7 +197,11 @@ func (srv *Service) Start() bool {
 	srv.log.Info("Starting P2P networking")
 
 	if srv.newTransport == nil {
-		srv.newTransport = transport.NewPbfmsg
+		if srv.NoEncryption {
+			srv.newTransport = transport.NewPbfmsg
+		} else {
+			srv.newTransport = transport.NewEncmsg
+		}
 	}
 	if srv.Dialer == nil {
 		srv.Dialer = TCPDialer{&net.Dialer{Timeout: peer.DefaultDialTimeout}}
@@ -415,8 +424,16 @@ func (srv *Service) setupConn(c *peer.Conn, flags peer.ConnFlag, dialDest *disco
 	}
 	// Run the encryption handshake.
 	var err error
+	if c.ID, err = c.DoEncHandshake(srv.PrivateKey, dialDest); err != nil {
+		srv.log.Trace("Failed encryption handshake", "addr", c.FD.RemoteAddr(), "conn", c.Flags, "err", err)
+		return err
+	}
 	clog := srv.log.New("id", c.ID, "addr", c.FD.RemoteAddr(), "conn", c.Flags)
 	// For dialed connections, check that the remote public key matches.
+	if dialDest != nil && (c.ID != discover.NodeID{}) && c.ID != dialDest.ID {
+		clog.Trace("Dialed identity mismatch", "want", dialDest.ID)
+		return peer_error.DiscUnexpectedIdentity
+	}
 	if (dialDest != nil) && (c.ID == discover.NodeID{}) {
 		c.ID = dialDest.ID
 	}
@@ -18,7 +18,7 @@ import (
 
 const (
 	pingInterval        = 15 * time.Second
-	BaseProtocolVersion = 5
+	BaseProtocolVersion = 6
 	BaseProtocolLength  = uint64(16)
 )
 
@@ -1,6 +1,7 @@
 package peer
 
 import (
+	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -49,6 +50,10 @@ func (c *testTransport) Close(err error) {
 	c.closeErr = err
 }
 
+func (c *testTransport) DoEncHandshake(prv *ecdsa.PrivateKey, dialDest *discover.Node) (discover.NodeID, error) {
+	return c.id, nil
+}
+
 func (c *testTransport) DoProtoHandshake(our *message.ProtoHandshake) (*message.ProtoHandshake, error) {
 	return &message.ProtoHandshake{ID: c.id, Name: "test"}, nil
 }
@@ -0,0 +1,37 @@
+package transport
+
+import (
+	"errors"
+
+	"github.com/golang/snappy"
+	"github.com/mihongtech/linkchain/p2p/message"
+)
+
+// snappyProtocolVersion is the base protocol version from which the message
+// payloads are snappy compressed, it is negotiated by the protocol handshake.
+const snappyProtocolVersion = 6
+
+// errPayloadTooLarge is returned if a decompressed payload exceeds the protocol max message size.
+var errPayloadTooLarge = errors.New("decompressed payload is too large")
+
+// useSnappy returns whether the payloads are compressed after the protocol handshake.
+func useSnappy(our, their *message.ProtoHandshake) bool {
+	return our.Version >= snappyProtocolVersion && their.Version >= snappyProtocolVersion
+}
+
+func compressPayload(content []byte) []byte {
+	return snappy.Encode(nil, content)
+}
+
+// decompressPayload decompresses the payload received from peer, the decoded
+// length is checked before decoding so a peer can not send decompression bombs.
+func decompressPayload(content []byte) ([]byte, error) {
+	size, err := snappy.DecodedLen(content)
+	if err != nil {
+		return nil, err
+	}
+	if size > message.MaxMsgSize {
+		return nil, errPayloadTooLarge
+	}
+	return snappy.Decode(nil, content)
+}
@@ -0,0 +1,54 @@
+package transport
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/mihongtech/linkchain/p2p/message"
+)
+
+func TestSnappyFrame(t *testing.T) {
+	buf := new(bytes.Buffer)
+	rw := newPBFrameRW(buf)
+	rw.snappy = true
+
+	payload := bytes.Repeat([]byte("linkchain"), 1000)
+	if err := rw.WriteMsg(message.Msg{Code: 8, Size: uint32(len(payload)), Payload: bytes.NewReader(payload)}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() >= len(payload) {
+		t.Errorf("payload is not compressed: %d bytes on wire", buf.Len())
+	}
+	msg, err := rw.ReadMsg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := ioutil.ReadAll(msg.Payload)
+	if msg.Size != uint32(len(payload)) || !bytes.Equal(content, payload) {
+		t.Errorf("message mismatch: size %d", msg.Size)
+	}
+}
+
+func TestSnappyNegotiation(t *testing.T) {
+	old := &message.ProtoHandshake{Version: snappyProtocolVersion - 1}
+	cur := &message.ProtoHandshake{Version: snappyProtocolVersion}
+	if useSnappy(cur, old) || useSnappy(old, cur) || !useSnappy(cur, cur) {
+		t.Errorf("snappy negotiation mismatch")
+	}
+}
+
+func TestDecompressLimit(t *testing.T) {
+	//a snappy stream which claims a decoded length above the limit
+	bomb := snappy.Encode(nil, make([]byte, message.MaxMsgSize+1))
+	if _, err := decompressPayload(bomb); err != errPayloadTooLarge {
+		t.Errorf("decompress bomb: want %v, got %v", errPayloadTooLarge, err)
+	}
+	if _, err := decompressPayload(snappy.Encode(nil, make([]byte, message.MaxMsgSize))); err != nil {
+		t.Errorf("decompress max payload failed: %v", err)
+	}
+	if _, err := decompressPayload([]byte{0xff}); err == nil {
+		t.Errorf("decompress corrupt payload succeeded")
+	}
+}
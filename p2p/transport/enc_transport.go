@@ -0,0 +1,463 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/mihongtech/linkchain/common/btcec"
+	"github.com/mihongtech/linkchain/p2p/discover"
+	"github.com/mihongtech/linkchain/p2p/message"
+	"github.com/mihongtech/linkchain/p2p/peer_error"
+	"github.com/mihongtech/linkchain/protobuf"
+
+	"github.com/golang/protobuf/proto"
+)
+
+const (
+	encHandshakeVersion = 1
+
+	sigLen   = 65 // compact recoverable signature
+	pubLen   = 64 // uncompressed public key without the format byte
+	nonceLen = 32
+
+	// auth: sig || initiator static pub || initiator ephemeral pub || nonce || version
+	authMsgLen = sigLen + pubLen + pubLen + nonceLen + 1
+	// ack: sig || responder ephemeral pub || nonce || version
+	ackMsgLen = sigLen + pubLen + nonceLen + 1
+
+	// eciesOverhead is the size btcec.Encrypt adds to the padded plaintext:
+	// iv || ephemeral pub || ... || hmac
+	eciesOverhead = aes.BlockSize + 70 + sha256.Size
+
+	frameHeaderLen = 4 // big endian uint24 frame size and one zero byte
+)
+
+var (
+	errEncHandshakeVersion = errors.New("unsupported encryption handshake version")
+	errInvalidAuthSig      = errors.New("invalid handshake signature")
+	errInvalidRemoteID     = errors.New("handshake is addressed to another node")
+	errFrameTooLarge       = errors.New("frame size exceeds uint24")
+
+	authPrefix = []byte("linkchain-auth")
+	ackPrefix  = []byte("linkchain-ack")
+)
+
+// the encrypted transport protocol used by actual (non-test) connections.
+// The static node keys of both sides are proved by an ECIES encrypted
+// handshake, the frames are sealed by AES-GCM with the ECDH derived keys.
+type encmsg struct {
+	fd net.Conn
+
+	rmu, wmu sync.Mutex
+	rw       *encFrameRW
+}
+
+func NewEncmsg(fd net.Conn) Transport {
+	fd.SetDeadline(time.Now().Add(handshakeTimeout))
+	return &encmsg{fd: fd}
+}
+
+func (t *encmsg) ReadMsg() (message.Msg, error) {
+	t.rmu.Lock()
+	defer t.rmu.Unlock()
+	t.fd.SetReadDeadline(time.Now().Add(frameReadTimeout))
+	return t.rw.ReadMsg()
+}
+
+func (t *encmsg) WriteMsg(msg message.Msg) error {
+	t.wmu.Lock()
+	defer t.wmu.Unlock()
+	t.fd.SetWriteDeadline(time.Now().Add(frameWriteTimeout))
+	return t.rw.WriteMsg(msg)
+}
+
+func (t *encmsg) Close(err error) {
+	t.wmu.Lock()
+	defer t.wmu.Unlock()
+	// Tell the remote end why we're disconnecting if possible.
+	if t.rw != nil {
+		if r, ok := err.(peer_error.DiscReason); ok && r != peer_error.DiscNetworkError {
+			if err := t.fd.SetWriteDeadline(time.Now().Add(discWriteTimeout)); err == nil {
+				message.SendItems(t.rw, message.DiscMsg, nil)
+			}
+		}
+	}
+	t.fd.Close()
+}
+
+func (t *encmsg) DoProtoHandshake(our *message.ProtoHandshake) (*message.ProtoHandshake, error) {
+	if t.rw == nil {
+		return nil, errors.New("encryption handshake is not done")
+	}
+	their, err := doProtoHandshake(t.rw, our)
+	if err != nil {
+		return nil, err
+	}
+	t.rw.snappy = useSnappy(our, their)
+	return their, nil
+}
+
+// DoEncHandshake runs the encryption handshake, the connection is dialed by us
+// if dialDest is not nil. It returns the proved id of the remote node.
+func (t *encmsg) DoEncHandshake(prv *ecdsa.PrivateKey, dialDest *discover.Node) (discover.NodeID, error) {
+	var (
+		sec secrets
+		err error
+	)
+	if dialDest == nil {
+		sec, err = receiverEncHandshake(t.fd, prv)
+	} else {
+		sec, err = initiatorEncHandshake(t.fd, prv, dialDest.ID)
+	}
+	if err != nil {
+		return discover.NodeID{}, err
+	}
+	t.wmu.Lock()
+	t.rw, err = newEncFrameRW(t.fd, sec)
+	t.wmu.Unlock()
+	if err != nil {
+		return discover.NodeID{}, err
+	}
+	return sec.RemoteID, nil
+}
+
+// secrets represents the connection secrets which are negotiated during the
+// encryption handshake.
+type secrets struct {
+	RemoteID        discover.NodeID
+	Egress, Ingress []byte // AES-256 keys of both directions
+}
+
+// encHandshake contains the state of the encryption handshake.
+type encHandshake struct {
+	initiator            bool
+	remoteID             discover.NodeID
+	remotePub            *ecdsa.PublicKey
+	initNonce, respNonce []byte
+	randomPrivKey        *ecdsa.PrivateKey
+	remoteRandomPub      *ecdsa.PublicKey
+}
+
+// initiatorEncHandshake negotiates a session token on conn, it should be called
+// on the dialing side of the connection.
+func initiatorEncHandshake(conn io.ReadWriter, prv *ecdsa.PrivateKey, remoteID discover.NodeID) (s secrets, err error) {
+	h := &encHandshake{initiator: true, remoteID: remoteID}
+	if h.remotePub, err = remoteID.Pubkey(); err != nil {
+		return s, fmt.Errorf("bad remoteID: %v", err)
+	}
+	if h.randomPrivKey, err = generateKey(); err != nil {
+		return s, err
+	}
+	if h.initNonce, err = randomNonce(); err != nil {
+		return s, err
+	}
+
+	// Prove our static key and bind our ephemeral key and nonce to the remote.
+	randomPub := discover.PubkeyID(&h.randomPrivKey.PublicKey)
+	sig, err := signHash(prv, authPrefix, randomPub[:], h.initNonce, remoteID[:])
+	if err != nil {
+		return s, err
+	}
+	ourID := discover.PubkeyID(&prv.PublicKey)
+	auth := make([]byte, 0, authMsgLen)
+	auth = append(auth, sig...)
+	auth = append(auth, ourID[:]...)
+	auth = append(auth, randomPub[:]...)
+	auth = append(auth, h.initNonce...)
+	auth = append(auth, encHandshakeVersion)
+	if err = writeSealed(conn, h.remotePub, auth); err != nil {
+		return s, err
+	}
+
+	ack, err := readSealed(conn, prv, ackMsgLen)
+	if err != nil {
+		return s, err
+	}
+	if ack[len(ack)-1] != encHandshakeVersion {
+		return s, errEncHandshakeVersion
+	}
+	var respRandom discover.NodeID
+	copy(respRandom[:], ack[sigLen:sigLen+pubLen])
+	h.respNonce = ack[sigLen+pubLen : sigLen+pubLen+nonceLen]
+	if err = verifyHash(ack[:sigLen], h.remoteID, ackPrefix, respRandom[:], h.respNonce, h.initNonce, ourID[:]); err != nil {
+		return s, err
+	}
+	if h.remoteRandomPub, err = respRandom.Pubkey(); err != nil {
+		return s, err
+	}
+	return h.secrets()
+}
+
+// receiverEncHandshake negotiates a session token on conn, it should be called
+// on the listening side of the connection.
+func receiverEncHandshake(conn io.ReadWriter, prv *ecdsa.PrivateKey) (s secrets, err error) {
+	auth, err := readSealed(conn, prv, authMsgLen)
+	if err != nil {
+		return s, err
+	}
+	if auth[len(auth)-1] != encHandshakeVersion {
+		return s, errEncHandshakeVersion
+	}
+	h := &encHandshake{initiator: false}
+	var initRandom discover.NodeID
+	copy(h.remoteID[:], auth[sigLen:sigLen+pubLen])
+	copy(initRandom[:], auth[sigLen+pubLen:sigLen+2*pubLen])
+	h.initNonce = auth[sigLen+2*pubLen : sigLen+2*pubLen+nonceLen]
+	if h.remotePub, err = h.remoteID.Pubkey(); err != nil {
+		return s, fmt.Errorf("bad remoteID: %v", err)
+	}
+	ourID := discover.PubkeyID(&prv.PublicKey)
+	if err = verifyHash(auth[:sigLen], h.remoteID, authPrefix, initRandom[:], h.initNonce, ourID[:]); err != nil {
+		return s, err
+	}
+	if h.remoteRandomPub, err = initRandom.Pubkey(); err != nil {
+		return s, err
+	}
+
+	if h.randomPrivKey, err = generateKey(); err != nil {
+		return s, err
+	}
+	if h.respNonce, err = randomNonce(); err != nil {
+		return s, err
+	}
+	randomPub := discover.PubkeyID(&h.randomPrivKey.PublicKey)
+	sig, err := signHash(prv, ackPrefix, randomPub[:], h.respNonce, h.initNonce, h.remoteID[:])
+	if err != nil {
+		return s, err
+	}
+	ack := make([]byte, 0, ackMsgLen)
+	ack = append(ack, sig...)
+	ack = append(ack, randomPub[:]...)
+	ack = append(ack, h.respNonce...)
+	ack = append(ack, encHandshakeVersion)
+	if err = writeSealed(conn, h.remotePub, ack); err != nil {
+		return s, err
+	}
+	return h.secrets()
+}
+
+// secrets derives the keys of both directions from the ephemeral ECDH secret
+// and the nonces of both sides.
+func (h *encHandshake) secrets() (secrets, error) {
+	ecdheSecret := btcec.GenerateSharedSecret((*btcec.PrivateKey)(h.randomPrivKey), (*btcec.PublicKey)(h.remoteRandomPub))
+	shared := sha256.New()
+	shared.Write(ecdheSecret)
+	shared.Write(h.initNonce)
+	shared.Write(h.respNonce)
+	sharedSecret := shared.Sum(nil)
+
+	initKey := deriveKey(sharedSecret, "initiator")
+	respKey := deriveKey(sharedSecret, "responder")
+	s := secrets{RemoteID: h.remoteID, Egress: respKey, Ingress: initKey}
+	if h.initiator {
+		s.Egress, s.Ingress = initKey, respKey
+	}
+	return s, nil
+}
+
+func deriveKey(secret []byte, label string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(label))
+	return mac.Sum(nil)
+}
+
+func generateKey() (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKey(btcec.S256(), rand.Reader)
+}
+
+func randomNonce() ([]byte, error) {
+	nonce := make([]byte, nonceLen)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+func handshakeHash(parts ...[]byte) []byte {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write(part)
+	}
+	return h.Sum(nil)
+}
+
+func signHash(prv *ecdsa.PrivateKey, parts ...[]byte) ([]byte, error) {
+	return btcec.SignCompact(btcec.S256(), (*btcec.PrivateKey)(prv), handshakeHash(parts...), false)
+}
+
+// verifyHash checks the signature of parts is made by the static key of id.
+func verifyHash(sig []byte, id discover.NodeID, parts ...[]byte) error {
+	pub, _, err := btcec.RecoverCompact(btcec.S256(), sig, handshakeHash(parts...))
+	if err != nil {
+		return errInvalidAuthSig
+	}
+	if discover.PubkeyID(pub.ToECDSA()) != id {
+		return errInvalidAuthSig
+	}
+	return nil
+}
+
+// sealedLen returns the size of a handshake message of plainLen bytes after
+// it is encrypted by btcec.Encrypt.
+func sealedLen(plainLen int) int {
+	return eciesOverhead + (plainLen/aes.BlockSize+1)*aes.BlockSize
+}
+
+func writeSealed(w io.Writer, pub *ecdsa.PublicKey, msg []byte) error {
+	enc, err := btcec.Encrypt((*btcec.PublicKey)(pub), msg)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(enc)
+	return err
+}
+
+func readSealed(r io.Reader, prv *ecdsa.PrivateKey, plainLen int) ([]byte, error) {
+	buf := make([]byte, sealedLen(plainLen))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	msg, err := btcec.Decrypt((*btcec.PrivateKey)(prv), buf)
+	if err != nil {
+		// The message can not be decrypted by our static key, the remote
+		// dialed another node.
+		return nil, errInvalidRemoteID
+	}
+	if len(msg) != plainLen {
+		return nil, fmt.Errorf("bad handshake message size %d", len(msg))
+	}
+	return msg, nil
+}
+
+// encFrameRW implements the encrypted framing. Every frame is made of the
+// sealed size header and the sealed protobuf message, both are authenticated
+// by the GCM tag and a per direction sequence number is used as the nonce so
+// frames can not be replayed or reordered.
+//
+// encFrameRW is not safe for concurrent use from multiple goroutines.
+type encFrameRW struct {
+	conn   io.ReadWriter
+	snappy bool
+
+	enc, dec     cipher.AEAD
+	egressSeq    uint64
+	ingressSeq   uint64
+	egressNonce  []byte
+	ingressNonce []byte
+}
+
+func newEncFrameRW(conn io.ReadWriter, s secrets) (*encFrameRW, error) {
+	enc, err := newGCM(s.Egress)
+	if err != nil {
+		return nil, err
+	}
+	dec, err := newGCM(s.Ingress)
+	if err != nil {
+		return nil, err
+	}
+	return &encFrameRW{
+		conn:         conn,
+		enc:          enc,
+		dec:          dec,
+		egressNonce:  make([]byte, enc.NonceSize()),
+		ingressNonce: make([]byte, dec.NonceSize()),
+	}, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (rw *encFrameRW) seal(plain []byte) []byte {
+	binary.BigEndian.PutUint64(rw.egressNonce[len(rw.egressNonce)-8:], rw.egressSeq)
+	rw.egressSeq++
+	return rw.enc.Seal(nil, rw.egressNonce, plain, nil)
+}
+
+func (rw *encFrameRW) open(sealed []byte) ([]byte, error) {
+	binary.BigEndian.PutUint64(rw.ingressNonce[len(rw.ingressNonce)-8:], rw.ingressSeq)
+	rw.ingressSeq++
+	return rw.dec.Open(nil, rw.ingressNonce, sealed, nil)
+}
+
+func (rw *encFrameRW) WriteMsg(msg message.Msg) error {
+	var content []byte
+	if msg.Payload != nil {
+		var err error
+		if content, err = ioutil.ReadAll(msg.Payload); err != nil {
+			return err
+		}
+	}
+	if rw.snappy {
+		content = compressPayload(content)
+	}
+	data, err := proto.Marshal(&protobuf.Msg{Code: &msg.Code, Payload: content})
+	if err != nil {
+		return err
+	}
+	if uint32(len(data)) > maxUint24 {
+		return errFrameTooLarge
+	}
+
+	header := make([]byte, frameHeaderLen)
+	putInt24(uint32(len(data)), header)
+	frame := rw.seal(header)
+	frame = append(frame, rw.seal(data)...)
+	_, err = rw.conn.Write(frame)
+	return err
+}
+
+func (rw *encFrameRW) ReadMsg() (msg message.Msg, err error) {
+	// read and authenticate the header
+	headbuf := make([]byte, frameHeaderLen+rw.dec.Overhead())
+	if _, err := io.ReadFull(rw.conn, headbuf); err != nil {
+		return msg, err
+	}
+	header, err := rw.open(headbuf)
+	if err != nil {
+		return msg, errors.New("bad header MAC")
+	}
+	dataSize := readInt24(header)
+
+	// read and authenticate the frame content
+	framebuf := make([]byte, int(dataSize)+rw.dec.Overhead())
+	if _, err := io.ReadFull(rw.conn, framebuf); err != nil {
+		return msg, err
+	}
+	data, err := rw.open(framebuf)
+	if err != nil {
+		return msg, errors.New("bad frame MAC")
+	}
+
+	protobufMsg := protobuf.Msg{}
+	if err := proto.Unmarshal(data, &protobufMsg); err != nil {
+		return msg, err
+	}
+	if rw.snappy {
+		if protobufMsg.Payload, err = decompressPayload(protobufMsg.Payload); err != nil {
+			return msg, err
+		}
+	}
+	msg.Code = *protobufMsg.Code
+	msg.Size = uint32(len(protobufMsg.Payload))
+	msg.Payload = bytes.NewReader(protobufMsg.Payload)
+	msg.ReceivedAt = time.Now()
+	return msg, nil
+}
@@ -0,0 +1,101 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/mihongtech/linkchain/p2p/crypto"
+	"github.com/mihongtech/linkchain/p2p/discover"
+	"github.com/mihongtech/linkchain/p2p/message"
+)
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+type handshakeResult struct {
+	id  discover.NodeID
+	err error
+}
+
+//Run the encryption handshake between a dialer which expects destID and a listener.
+func runEncHandshake(t *testing.T, dialKey, listenKey *ecdsa.PrivateKey, destID discover.NodeID) (Transport, Transport, handshakeResult, handshakeResult) {
+	fd0, fd1 := net.Pipe()
+	dialer, listener := NewEncmsg(fd0), NewEncmsg(fd1)
+	done := make(chan handshakeResult, 1)
+	go func() {
+		id, err := listener.DoEncHandshake(listenKey, nil)
+		if err != nil {
+			fd1.Close()
+		}
+		done <- handshakeResult{id, err}
+	}()
+	id, err := dialer.DoEncHandshake(dialKey, &discover.Node{ID: destID})
+	if err != nil {
+		fd0.Close()
+	}
+	return dialer, listener, handshakeResult{id, err}, <-done
+}
+
+func TestEncHandshake(t *testing.T) {
+	dialKey, listenKey := newTestKey(t), newTestKey(t)
+	dialer, listener, dres, lres := runEncHandshake(t, dialKey, listenKey, discover.PubkeyID(&listenKey.PublicKey))
+	if dres.err != nil || lres.err != nil {
+		t.Fatalf("handshake failed: dialer %v, listener %v", dres.err, lres.err)
+	}
+	if dres.id != discover.PubkeyID(&listenKey.PublicKey) {
+		t.Errorf("dialer got remote id %x", dres.id[:8])
+	}
+	if lres.id != discover.PubkeyID(&dialKey.PublicKey) {
+		t.Errorf("listener got remote id %x", lres.id[:8])
+	}
+
+	//messages are exchanged in both directions
+	for i, pair := range [][2]Transport{{dialer, listener}, {listener, dialer}} {
+		payload := bytes.Repeat([]byte{byte(i)}, 100)
+		go pair[0].WriteMsg(message.Msg{Code: 8, Size: uint32(len(payload)), Payload: bytes.NewReader(payload)})
+		msg, err := pair[1].ReadMsg()
+		if err != nil {
+			t.Fatalf("direction %d: read error: %v", i, err)
+		}
+		content, _ := ioutil.ReadAll(msg.Payload)
+		if msg.Code != 8 || !bytes.Equal(content, payload) {
+			t.Errorf("direction %d: message mismatch: code %d, payload %x", i, msg.Code, content)
+		}
+	}
+}
+
+func TestEncHandshakeWrongID(t *testing.T) {
+	dialKey, listenKey := newTestKey(t), newTestKey(t)
+	_, _, dres, lres := runEncHandshake(t, dialKey, listenKey, discover.PubkeyID(&newTestKey(t).PublicKey))
+	if lres.err != errInvalidRemoteID {
+		t.Errorf("listener: want %v, got %v", errInvalidRemoteID, lres.err)
+	}
+	if dres.err == nil {
+		t.Errorf("dialer completed handshake with the wrong node")
+	}
+}
+
+func TestEncFrameTampered(t *testing.T) {
+	s := secrets{Egress: make([]byte, 32), Ingress: make([]byte, 32)}
+	buf := new(bytes.Buffer)
+	rw, err := newEncFrameRW(buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := message.Send(rw, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	frame := buf.Bytes()
+	frame[len(frame)-1] ^= 0x01
+	if _, err := rw.ReadMsg(); err == nil {
+		t.Errorf("tampered frame is accepted")
+	}
+}
@@ -2,6 +2,7 @@ package transport
 
 import (
 	"bytes"
+	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"io"
@@ -98,12 +99,26 @@ func NewTestPbfmsg(fd net.Conn) Transport {
 	return &pbfmsg{fd: fd, rw: newPBFrameRW(fd)}
 }
 
+// DoEncHandshake does nothing, the plaintext transport can not authenticate
+// the remote node.
+func (p *pbfmsg) DoEncHandshake(prv *ecdsa.PrivateKey, dialDest *discover.Node) (discover.NodeID, error) {
+	return discover.NodeID{}, nil
+}
+
 func (p *pbfmsg) DoProtoHandshake(our *message.ProtoHandshake) (their *message.ProtoHandshake, err error) {
+	p.rw = newPBFrameRW(p.fd)
+	if their, err = doProtoHandshake(p.rw, our); err != nil {
+		return nil, err
+	}
+	p.rw.snappy = useSnappy(our, their)
+	return their, nil
+}
+
+func doProtoHandshake(rw message.MsgReadWriter, our *message.ProtoHandshake) (their *message.ProtoHandshake, err error) {
 	// Writing our handshake happens concurrently, we prefer
 	// returning the handshake read error. If the remote side
 	// disconnects us early with a valid reason, we should return it
 	// as the error so it can be tracked elsewhere.
-	p.rw = newPBFrameRW(p.fd)
 	werr := make(chan error, 1)
 	go func() {
 		var caps []*protobuf.Cap
@@ -112,9 +127,9 @@ func (p *pbfmsg) DoProtoHandshake(our *message.ProtoHandshake) (their *message.P
 		}
 
 		pbmsg := protobuf.ProtoHandshake{Version: &our.Version, Name: &our.Name, ListenPort: &our.ListenPort, Id: our.ID[:], Caps: caps, Rest: our.Rest}
-		werr <- message.Send(p.rw, message.HandshakeMsg, &pbmsg)
+		werr <- message.Send(rw, message.HandshakeMsg, &pbmsg)
 	}()
-	if their, err = readProtocolHandshake(p.rw, our); err != nil {
+	if their, err = readProtocolHandshake(rw, our); err != nil {
 		<-werr // make sure the write terminates too
 		return nil, err
 	}
@@ -185,7 +200,8 @@ var (
 //
 // pbfFrameRW is not safe for concurrent use from multiple goroutines.
 type pbfFrameRW struct {
-	conn io.ReadWriter
+	conn   io.ReadWriter
+	snappy bool
 }
 
 func newPBFrameRW(conn io.ReadWriter) *pbfFrameRW {
@@ -205,6 +221,9 @@ func (rw *pbfFrameRW) WriteMsg(msg message.Msg) error {
 			return err
 		}
 	}
+	if rw.snappy {
+		content = compressPayload(content)
+	}
 
 	protobufMsg := &protobuf.Msg{Code: &msg.Code, Payload: content}
 	// log.Trace("write msg", "protobufMsg.Code", protobufMsg.Code, "protobufMsg.Payload", protobufMsg.Payload)
@@ -254,6 +273,11 @@ func (rw *pbfFrameRW) ReadMsg() (msg message.Msg, err error) {
 	if err := proto.Unmarshal(framebuf, &protubufMsg); err != nil {
 		return msg, err
 	}
+	if rw.snappy {
+		if protubufMsg.Payload, err = decompressPayload(protubufMsg.Payload); err != nil {
+			return msg, err
+		}
+	}
 
 	msg.Code = *protubufMsg.Code
 	msg.Size = uint32(len(protubufMsg.Payload))
@@ -1,12 +1,16 @@
 package transport
 
 import (
+	"crypto/ecdsa"
+
+	"github.com/mihongtech/linkchain/p2p/discover"
 	"github.com/mihongtech/linkchain/p2p/message"
 )
 
 type Transport interface {
-	// The two handshakes.
-	// doEncHandshake(prv *ecdsa.PrivateKey, dialDest *discover.Node) (discover.NodeID, error)
+	// The two handshakes. DoEncHandshake returns the authenticated id of the
+	// remote node, or a zero id if the transport does not authenticate peers.
+	DoEncHandshake(prv *ecdsa.PrivateKey, dialDest *discover.Node) (discover.NodeID, error)
 	DoProtoHandshake(our *message.ProtoHandshake) (*message.ProtoHandshake, error)
 	// The MsgReadWriter can only be used after the encryption
 	// handshake has completed. The code uses conn.id to track this
@@ -217,34 +217,116 @@ func (m *Blocks) GetBlock() []*Block {
 	return nil
 }
 
+type BlockHeaders struct {
+	Headers              []*BlockHeader `protobuf:"bytes,1,rep,name=headers" json:"headers,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *BlockHeaders) Reset()         { *m = BlockHeaders{} }
+func (m *BlockHeaders) String() string { return proto.CompactTextString(m) }
+func (*BlockHeaders) ProtoMessage()    {}
+func (*BlockHeaders) Descriptor() ([]byte, []int) {
+	return fileDescriptor_65a48bcf14e684fd, []int{3}
+}
+
+func (m *BlockHeaders) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BlockHeaders.Unmarshal(m, b)
+}
+func (m *BlockHeaders) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BlockHeaders.Marshal(b, m, deterministic)
+}
+func (m *BlockHeaders) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BlockHeaders.Merge(m, src)
+}
+func (m *BlockHeaders) XXX_Size() int {
+	return xxx_messageInfo_BlockHeaders.Size(m)
+}
+func (m *BlockHeaders) XXX_DiscardUnknown() {
+	xxx_messageInfo_BlockHeaders.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BlockHeaders proto.InternalMessageInfo
+
+func (m *BlockHeaders) GetHeaders() []*BlockHeader {
+	if m != nil {
+		return m.Headers
+	}
+	return nil
+}
+
+type BlockBodies struct {
+	Bodies               []*Transactions `protobuf:"bytes,1,rep,name=bodies" json:"bodies,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *BlockBodies) Reset()         { *m = BlockBodies{} }
+func (m *BlockBodies) String() string { return proto.CompactTextString(m) }
+func (*BlockBodies) ProtoMessage()    {}
+func (*BlockBodies) Descriptor() ([]byte, []int) {
+	return fileDescriptor_65a48bcf14e684fd, []int{4}
+}
+
+func (m *BlockBodies) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BlockBodies.Unmarshal(m, b)
+}
+func (m *BlockBodies) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BlockBodies.Marshal(b, m, deterministic)
+}
+func (m *BlockBodies) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BlockBodies.Merge(m, src)
+}
+func (m *BlockBodies) XXX_Size() int {
+	return xxx_messageInfo_BlockBodies.Size(m)
+}
+func (m *BlockBodies) XXX_DiscardUnknown() {
+	xxx_messageInfo_BlockBodies.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BlockBodies proto.InternalMessageInfo
+
+func (m *BlockBodies) GetBodies() []*Transactions {
+	if m != nil {
+		return m.Bodies
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*BlockHeader)(nil), "protobuf.BlockHeader")
 	proto.RegisterType((*Block)(nil), "protobuf.Block")
 	proto.RegisterType((*Blocks)(nil), "protobuf.Blocks")
+	proto.RegisterType((*BlockHeaders)(nil), "protobuf.BlockHeaders")
+	proto.RegisterType((*BlockBodies)(nil), "protobuf.BlockBodies")
 }
 
 func init() { proto.RegisterFile("protobuf/block.proto", fileDescriptor_65a48bcf14e684fd) }
 
 var fileDescriptor_65a48bcf14e684fd = []byte{
-	// 316 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x91, 0x41, 0x4e, 0xf3, 0x30,
-	0x10, 0x85, 0x95, 0x34, 0x4d, 0xfb, 0x4f, 0x7e, 0x40, 0x32, 0x50, 0x8d, 0xba, 0x40, 0x51, 0x24,
-	0x20, 0x1b, 0x52, 0x29, 0x47, 0x60, 0xd5, 0x05, 0x2b, 0xc3, 0x05, 0xdc, 0xd4, 0x69, 0x2d, 0x8a,
-	0x5d, 0xc5, 0x93, 0x0a, 0xee, 0xc6, 0xe1, 0x90, 0x9d, 0x94, 0x54, 0xa8, 0x3b, 0xbf, 0x79, 0x5f,
-	0x66, 0x32, 0x6f, 0xe0, 0x66, 0xdf, 0x18, 0x32, 0xab, 0xb6, 0x5e, 0xac, 0x76, 0xa6, 0x7a, 0x2f,
-	0xbc, 0x64, 0xd3, 0x63, 0x75, 0x3e, 0xff, 0xf5, 0xa9, 0x11, 0xda, 0x8a, 0x8a, 0x94, 0xd1, 0x1d,
-	0x95, 0x7d, 0x87, 0x90, 0x3c, 0xbb, 0xaf, 0x96, 0x52, 0xac, 0x65, 0xc3, 0x10, 0x26, 0x07, 0xd9,
-	0x58, 0x65, 0x34, 0x06, 0x69, 0x98, 0x5f, 0xf0, 0xa3, 0x64, 0x33, 0x88, 0xb7, 0x52, 0x6d, 0xb6,
-	0x84, 0xa1, 0x37, 0x7a, 0xc5, 0x18, 0x44, 0xa4, 0x3e, 0x24, 0x8e, 0xd2, 0x30, 0x1f, 0x71, 0xff,
-	0x76, 0xac, 0x36, 0xad, 0xae, 0x24, 0x46, 0x1d, 0xdb, 0x29, 0x76, 0x07, 0xb0, 0x56, 0x75, 0xad,
-	0xaa, 0x76, 0x47, 0x5f, 0x38, 0xf6, 0xde, 0x49, 0x85, 0x65, 0x10, 0xed, 0x1b, 0x79, 0xc0, 0x38,
-	0x0d, 0xf3, 0xa4, 0xbc, 0x2c, 0x8e, 0x3f, 0x5e, 0x2c, 0x85, 0xdd, 0x72, 0xef, 0xb1, 0x07, 0x88,
-	0xe9, 0x93, 0x1b, 0x43, 0x38, 0x39, 0x4b, 0xf5, 0xae, 0xe3, 0x2c, 0x09, 0x6a, 0x2d, 0x4e, 0xcf,
-	0x73, 0x9d, 0xcb, 0x1e, 0x21, 0xb2, 0x6a, 0xa3, 0xf1, 0x5f, 0x1a, 0xe4, 0x49, 0x79, 0x3d, 0x50,
-	0xaf, 0x6a, 0xa3, 0x05, 0xb5, 0x8d, 0xe4, 0x1e, 0x70, 0x8b, 0xae, 0x05, 0x09, 0x84, 0x34, 0xc8,
-	0xff, 0x73, 0xff, 0xce, 0x6a, 0x18, 0xfb, 0xf4, 0xd8, 0x93, 0x4b, 0xc7, 0x25, 0xe8, 0x63, 0x4b,
-	0xca, 0xdb, 0xa1, 0xcf, 0x49, 0xbc, 0xbc, 0x87, 0x58, 0xe1, 0x96, 0x78, 0x51, 0xd6, 0x85, 0xe9,
-	0xc6, 0xce, 0x06, 0xfc, 0x6d, 0xb8, 0x91, 0xe5, 0x3d, 0x95, 0x2d, 0x20, 0xf6, 0x6d, 0x2c, 0xbb,
-	0x87, 0xb1, 0xbf, 0x32, 0x06, 0xe9, 0x28, 0x4f, 0xca, 0xab, 0x3f, 0x73, 0x78, 0xe7, 0xfe, 0x04,
-	0x00, 0x00, 0xff, 0xff, 0x6a, 0x99, 0x36, 0xd6, 0x14, 0x02, 0x00, 0x00,
+	// 352 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x92, 0xcf, 0x6a, 0xe3, 0x30,
+	0x10, 0xc6, 0xb1, 0xe3, 0x38, 0xd9, 0x71, 0x76, 0x17, 0xb4, 0xdb, 0x20, 0x72, 0x28, 0xc6, 0xd0,
+	0xd6, 0x97, 0x3a, 0xe0, 0x7b, 0x29, 0xe4, 0x94, 0x43, 0x4f, 0x6a, 0x5f, 0x40, 0xb1, 0xe5, 0x44,
+	0x34, 0x95, 0x82, 0x25, 0x87, 0xf6, 0xdd, 0xfa, 0x70, 0x45, 0x7f, 0x5c, 0x87, 0x92, 0xdc, 0x66,
+	0xf4, 0xfd, 0x46, 0x33, 0xfa, 0x46, 0xf0, 0xff, 0xd0, 0x4a, 0x2d, 0x37, 0x5d, 0xb3, 0xdc, 0xec,
+	0x65, 0xf5, 0x5a, 0xd8, 0x14, 0x4d, 0xfb, 0xd3, 0xc5, 0xe2, 0x5b, 0xd7, 0x2d, 0x15, 0x8a, 0x56,
+	0x9a, 0x4b, 0xe1, 0xa8, 0xec, 0x33, 0x84, 0x64, 0x65, 0xaa, 0xd6, 0x8c, 0xd6, 0xac, 0x45, 0x18,
+	0x26, 0x47, 0xd6, 0x2a, 0x2e, 0x05, 0x0e, 0xd2, 0x30, 0xff, 0x4d, 0xfa, 0x14, 0xcd, 0x21, 0xde,
+	0x31, 0xbe, 0xdd, 0x69, 0x1c, 0x5a, 0xc1, 0x67, 0x08, 0x41, 0xa4, 0xf9, 0x1b, 0xc3, 0xa3, 0x34,
+	0xcc, 0x47, 0xc4, 0xc6, 0x86, 0x15, 0xb2, 0x13, 0x15, 0xc3, 0x91, 0x63, 0x5d, 0x86, 0xae, 0x01,
+	0x6a, 0xde, 0x34, 0xbc, 0xea, 0xf6, 0xfa, 0x03, 0x8f, 0xad, 0x76, 0x72, 0x82, 0x32, 0x88, 0x0e,
+	0x2d, 0x3b, 0xe2, 0x38, 0x0d, 0xf3, 0xa4, 0xfc, 0x53, 0xf4, 0x83, 0x17, 0x6b, 0xaa, 0x76, 0xc4,
+	0x6a, 0xe8, 0x16, 0x62, 0xfd, 0x4e, 0xa4, 0xd4, 0x78, 0x72, 0x96, 0xf2, 0xaa, 0xe1, 0x94, 0xa6,
+	0xba, 0x53, 0x78, 0x7a, 0x9e, 0x73, 0x2a, 0xba, 0x83, 0x48, 0xf1, 0xad, 0xc0, 0xbf, 0xd2, 0x20,
+	0x4f, 0xca, 0x7f, 0x03, 0xf5, 0xcc, 0xb7, 0x82, 0xea, 0xae, 0x65, 0xc4, 0x02, 0xe6, 0xa1, 0x35,
+	0xd5, 0x14, 0x43, 0x1a, 0xe4, 0x33, 0x62, 0xe3, 0xac, 0x81, 0xb1, 0x75, 0x0f, 0xdd, 0x1b, 0x77,
+	0x8c, 0x83, 0xd6, 0xb6, 0xa4, 0xbc, 0x1a, 0xee, 0x39, 0xb1, 0x97, 0x78, 0x08, 0x15, 0xe6, 0x11,
+	0x4f, 0x5c, 0x19, 0x33, 0x4d, 0xdb, 0xf9, 0x80, 0xbf, 0x0c, 0x3b, 0x52, 0xc4, 0x53, 0xd9, 0x12,
+	0x62, 0x7b, 0x8d, 0x42, 0x37, 0x30, 0xb6, 0x5b, 0xc6, 0x41, 0x3a, 0xca, 0x93, 0xf2, 0xef, 0x8f,
+	0x3e, 0xc4, 0xa9, 0xd9, 0x23, 0xcc, 0x4e, 0xfa, 0x2a, 0xb4, 0x84, 0x89, 0x6b, 0xad, 0x7c, 0xe1,
+	0x85, 0x01, 0x7b, 0x2a, 0x7b, 0xf0, 0xff, 0x62, 0x25, 0x6b, 0xce, 0x94, 0x19, 0x78, 0x63, 0x23,
+	0x5f, 0x7e, 0x71, 0x60, 0x47, 0x7d, 0x0d, 0x00, 0x49, 0x53, 0x64, 0xf7, 0x94, 0x02, 0x00, 0x00,
 }
@@ -775,6 +775,123 @@ func (m *Neighbors) GetRest() []byte {
 	return nil
 }
 
+type GetBlockBodiesData struct {
+	Hashes               []*Hash  `protobuf:"bytes,1,rep,name=hashes" json:"hashes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetBlockBodiesData) Reset()         { *m = GetBlockBodiesData{} }
+func (m *GetBlockBodiesData) String() string { return proto.CompactTextString(m) }
+func (*GetBlockBodiesData) ProtoMessage()    {}
+func (*GetBlockBodiesData) Descriptor() ([]byte, []int) {
+	return fileDescriptor_47f67d614acbc48c, []int{13}
+}
+
+func (m *GetBlockBodiesData) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetBlockBodiesData.Unmarshal(m, b)
+}
+func (m *GetBlockBodiesData) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetBlockBodiesData.Marshal(b, m, deterministic)
+}
+func (m *GetBlockBodiesData) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetBlockBodiesData.Merge(m, src)
+}
+func (m *GetBlockBodiesData) XXX_Size() int {
+	return xxx_messageInfo_GetBlockBodiesData.Size(m)
+}
+func (m *GetBlockBodiesData) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetBlockBodiesData.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetBlockBodiesData proto.InternalMessageInfo
+
+func (m *GetBlockBodiesData) GetHashes() []*Hash {
+	if m != nil {
+		return m.Hashes
+	}
+	return nil
+}
+
+type GetNodeDataData struct {
+	Hashes               []*Hash  `protobuf:"bytes,1,rep,name=hashes" json:"hashes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetNodeDataData) Reset()         { *m = GetNodeDataData{} }
+func (m *GetNodeDataData) String() string { return proto.CompactTextString(m) }
+func (*GetNodeDataData) ProtoMessage()    {}
+func (*GetNodeDataData) Descriptor() ([]byte, []int) {
+	return fileDescriptor_47f67d614acbc48c, []int{14}
+}
+
+func (m *GetNodeDataData) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetNodeDataData.Unmarshal(m, b)
+}
+func (m *GetNodeDataData) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetNodeDataData.Marshal(b, m, deterministic)
+}
+func (m *GetNodeDataData) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetNodeDataData.Merge(m, src)
+}
+func (m *GetNodeDataData) XXX_Size() int {
+	return xxx_messageInfo_GetNodeDataData.Size(m)
+}
+func (m *GetNodeDataData) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetNodeDataData.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetNodeDataData proto.InternalMessageInfo
+
+func (m *GetNodeDataData) GetHashes() []*Hash {
+	if m != nil {
+		return m.Hashes
+	}
+	return nil
+}
+
+type NodeData struct {
+	Data                 [][]byte `protobuf:"bytes,1,rep,name=data" json:"data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeData) Reset()         { *m = NodeData{} }
+func (m *NodeData) String() string { return proto.CompactTextString(m) }
+func (*NodeData) ProtoMessage()    {}
+func (*NodeData) Descriptor() ([]byte, []int) {
+	return fileDescriptor_47f67d614acbc48c, []int{15}
+}
+
+func (m *NodeData) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeData.Unmarshal(m, b)
+}
+func (m *NodeData) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeData.Marshal(b, m, deterministic)
+}
+func (m *NodeData) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeData.Merge(m, src)
+}
+func (m *NodeData) XXX_Size() int {
+	return xxx_messageInfo_NodeData.Size(m)
+}
+func (m *NodeData) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeData.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeData proto.InternalMessageInfo
+
+func (m *NodeData) GetData() [][]byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*StatusData)(nil), "protobuf.StatusData")
 	proto.RegisterType((*NewBlockHashData)(nil), "protobuf.NewBlockHashData")
@@ -789,49 +906,55 @@ func init() {
 	proto.RegisterType((*Pong)(nil), "protobuf.Pong")
 	proto.RegisterType((*Findnode)(nil), "protobuf.Findnode")
 	proto.RegisterType((*Neighbors)(nil), "protobuf.Neighbors")
+	proto.RegisterType((*GetBlockBodiesData)(nil), "protobuf.GetBlockBodiesData")
+	proto.RegisterType((*GetNodeDataData)(nil), "protobuf.GetNodeDataData")
+	proto.RegisterType((*NodeData)(nil), "protobuf.NodeData")
 }
 
 func init() { proto.RegisterFile("protobuf/protobufmsg.proto", fileDescriptor_47f67d614acbc48c) }
 
 var fileDescriptor_47f67d614acbc48c = []byte{
-	// 613 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0xcd, 0x6e, 0xd3, 0x4c,
-	0x14, 0x95, 0xed, 0x49, 0xbf, 0xf4, 0xb6, 0xe9, 0x57, 0x4d, 0xa5, 0xca, 0x8a, 0x10, 0x0a, 0x16,
-	0x42, 0x5e, 0x05, 0x29, 0xec, 0x59, 0x50, 0x0a, 0x05, 0x89, 0x28, 0x1a, 0x50, 0xf7, 0x53, 0x7b,
-	0xea, 0x8c, 0x92, 0xcc, 0x8c, 0x66, 0x26, 0x94, 0x22, 0xf1, 0x2e, 0x6c, 0x78, 0x23, 0x1e, 0x08,
-	0xcd, 0xb5, 0x93, 0x38, 0xa1, 0xed, 0xa2, 0xec, 0xee, 0xcf, 0xb9, 0xe7, 0xdc, 0x7b, 0xfc, 0x03,
-	0x7d, 0x63, 0xb5, 0xd7, 0x57, 0xcb, 0xeb, 0x97, 0xab, 0x60, 0xe1, 0xaa, 0x21, 0xc6, 0xb4, 0xbb,
-	0x2a, 0xf5, 0x37, 0x28, 0x6f, 0xb9, 0x72, 0xbc, 0xf0, 0x52, 0xab, 0x1a, 0x95, 0xfd, 0x8e, 0x00,
-	0x3e, 0x7b, 0xee, 0x97, 0xee, 0x2d, 0xf7, 0x9c, 0xe6, 0xf0, 0x3f, 0xd6, 0x0b, 0x3d, 0xbf, 0x14,
-	0xd6, 0x49, 0xad, 0xd2, 0x68, 0x10, 0xe7, 0x3d, 0xb6, 0x5b, 0xa6, 0x4f, 0x60, 0x5f, 0x09, 0x7f,
-	0xa3, 0xed, 0xec, 0x43, 0x99, 0xc6, 0x83, 0x38, 0x27, 0x6c, 0x53, 0xa0, 0xa7, 0xb0, 0x37, 0x15,
-	0xb2, 0x9a, 0xfa, 0x34, 0xc1, 0x56, 0x93, 0xd1, 0x11, 0x1c, 0x16, 0x4b, 0x6b, 0x85, 0xf2, 0x6f,
-	0xe6, 0xba, 0x98, 0xa5, 0x64, 0x10, 0xe7, 0x07, 0xa3, 0xa3, 0xe1, 0x6a, 0xc3, 0xe1, 0x05, 0x77,
-	0x53, 0xb6, 0x85, 0x09, 0x33, 0x95, 0x50, 0xc2, 0x49, 0x57, 0xcf, 0x74, 0xee, 0x9e, 0x69, 0x63,
-	0xb2, 0x31, 0x1c, 0x8f, 0xc5, 0x0d, 0xc6, 0xa1, 0x8b, 0xb7, 0x65, 0x40, 0xa6, 0xdc, 0x4d, 0xf1,
-	0xa0, 0xbf, 0xe7, 0xb1, 0x17, 0xf6, 0x56, 0xcb, 0xc5, 0x95, 0xb0, 0xcd, 0x49, 0x4d, 0x96, 0x9d,
-	0xc3, 0x49, 0x9b, 0x4f, 0xa0, 0x5b, 0x8e, 0x0e, 0x81, 0x94, 0xdc, 0xf3, 0x34, 0x1a, 0x24, 0xf9,
-	0xc1, 0xa8, 0xbf, 0xa1, 0xdc, 0x15, 0x67, 0x88, 0xcb, 0x7e, 0xc0, 0xc9, 0x7b, 0x51, 0x9f, 0x75,
-	0x21, 0x78, 0x29, 0xac, 0xfb, 0xd7, 0xcd, 0x42, 0x9d, 0x2f, 0xf4, 0x52, 0xad, 0x9d, 0xae, 0x33,
-	0x4a, 0x81, 0xb8, 0x99, 0x34, 0xe8, 0x30, 0x61, 0x18, 0x67, 0xaf, 0x20, 0xf9, 0xe4, 0xaa, 0xd0,
-	0x2a, 0x74, 0x29, 0x50, 0x8e, 0x30, 0x8c, 0x69, 0x0a, 0xff, 0x19, 0x7e, 0x3b, 0xd7, 0x3c, 0x3c,
-	0xcc, 0x28, 0x3f, 0x64, 0xab, 0x34, 0x0c, 0x9d, 0x71, 0x13, 0x86, 0x14, 0x5f, 0xd4, 0x43, 0xfb,
-	0x0c, 0xe3, 0x30, 0xf4, 0xb5, 0x79, 0x4b, 0xea, 0xa5, 0x56, 0x69, 0xf6, 0x2b, 0x82, 0xa3, 0x49,
-	0xb8, 0xe2, 0x82, 0xab, 0xd2, 0x4d, 0xf9, 0x6c, 0x0b, 0x1c, 0x6d, 0x81, 0xd7, 0xd4, 0x71, 0x8b,
-	0xfa, 0x19, 0x90, 0x82, 0x1b, 0x97, 0x26, 0xe8, 0x6c, 0x6f, 0x63, 0xc9, 0x19, 0x37, 0x0c, 0x5b,
-	0xf4, 0x29, 0xc0, 0x5c, 0x3a, 0x2f, 0xd4, 0x44, 0x5b, 0x9f, 0x92, 0x41, 0x94, 0x13, 0xd6, 0xaa,
-	0xd0, 0x23, 0x88, 0x65, 0x99, 0x76, 0xf0, 0x9a, 0x58, 0x96, 0x41, 0xc6, 0x0a, 0xe7, 0xd3, 0x3d,
-	0xac, 0x60, 0x9c, 0x7d, 0x04, 0x32, 0x0e, 0xe7, 0x07, 0xac, 0xc1, 0xbd, 0x02, 0xd6, 0xd0, 0x63,
-	0x48, 0x96, 0xa5, 0xc1, 0x8d, 0x7a, 0x2c, 0x84, 0xa1, 0xe2, 0x0b, 0x83, 0x26, 0xf7, 0x58, 0x08,
-	0x1b, 0x7e, 0xd2, 0xcc, 0x94, 0xd9, 0x6b, 0xe8, 0x9e, 0xab, 0xd2, 0x68, 0xa9, 0xfc, 0x63, 0xf8,
-	0xb2, 0x9f, 0x11, 0x90, 0x89, 0x54, 0xd5, 0x03, 0x4e, 0xbd, 0x00, 0x72, 0x6d, 0xf5, 0x02, 0x79,
-	0x0e, 0x46, 0x74, 0xe3, 0xca, 0x4a, 0x98, 0x61, 0x9f, 0x66, 0x10, 0x7b, 0x8d, 0xdc, 0x77, 0xa3,
-	0x62, 0xaf, 0x83, 0x7d, 0xe2, 0x9b, 0x91, 0x96, 0x87, 0xbf, 0x41, 0xf3, 0x9a, 0xb4, 0x2a, 0x6b,
-	0xbb, 0x3a, 0x2d, 0xbb, 0xbe, 0x03, 0x99, 0x68, 0x55, 0x35, 0xfc, 0xd1, 0x83, 0xfc, 0x7d, 0xe8,
-	0x5a, 0x61, 0xe6, 0xb7, 0x5f, 0xf4, 0x0c, 0xf7, 0x3d, 0x64, 0xeb, 0x7c, 0x47, 0x3b, 0xb9, 0x57,
-	0x9b, 0xb4, 0xb4, 0x2f, 0xa1, 0xfb, 0x4e, 0xaa, 0x52, 0x85, 0xc7, 0x75, 0x0a, 0x7b, 0x9e, 0xdb,
-	0x4a, 0xf8, 0xc6, 0xe2, 0x26, 0xdb, 0xe1, 0x8d, 0xef, 0xe5, 0x4d, 0x5a, 0xbc, 0x02, 0xf6, 0xc7,
-	0xe1, 0xe7, 0x74, 0xa5, 0xad, 0xa3, 0xcf, 0xa1, 0x13, 0x04, 0x5c, 0xf3, 0x45, 0xb7, 0x3e, 0xc5,
-	0xf0, 0x9a, 0xb0, 0xba, 0xf9, 0x18, 0x99, 0x3f, 0x01, 0x00, 0x00, 0xff, 0xff, 0x3a, 0x10, 0x6e,
-	0xd8, 0xab, 0x05, 0x00, 0x00,
+	// 659 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0xdd, 0x6a, 0xdb, 0x4a,
+	0x10, 0x46, 0xd2, 0xda, 0xc7, 0x99, 0xd8, 0x49, 0xd8, 0x40, 0x10, 0xe6, 0x10, 0x7c, 0xc4, 0x21,
+	0xe8, 0xca, 0x07, 0x7c, 0xae, 0x0a, 0xa5, 0x17, 0x49, 0xd3, 0xa4, 0x85, 0x1a, 0xb3, 0x2d, 0xb9,
+	0xdf, 0x48, 0x1b, 0x49, 0xd8, 0xde, 0x15, 0xbb, 0xeb, 0xa6, 0x29, 0xf4, 0x5d, 0x7a, 0xd3, 0x37,
+	0xea, 0x03, 0x95, 0x1d, 0x49, 0xb6, 0xe2, 0x26, 0x81, 0xa6, 0x77, 0xf3, 0xf7, 0x7d, 0x33, 0xf3,
+	0x49, 0x3b, 0x30, 0x2c, 0xb5, 0xb2, 0xea, 0x7a, 0x75, 0xf3, 0x5f, 0x63, 0x2c, 0x4d, 0x36, 0x46,
+	0x9b, 0xf6, 0x9a, 0xd0, 0x70, 0x53, 0x65, 0x35, 0x97, 0x86, 0x27, 0xb6, 0x50, 0xb2, 0xaa, 0x8a,
+	0x7e, 0x78, 0x00, 0x1f, 0x2c, 0xb7, 0x2b, 0xf3, 0x9a, 0x5b, 0x4e, 0x63, 0xd8, 0xc7, 0x78, 0xa2,
+	0x16, 0x57, 0x42, 0x9b, 0x42, 0xc9, 0xd0, 0x1b, 0xf9, 0xf1, 0x80, 0x6d, 0x87, 0xe9, 0xdf, 0xb0,
+	0x23, 0x85, 0xbd, 0x55, 0x7a, 0xfe, 0x36, 0x0d, 0xfd, 0x91, 0x1f, 0x13, 0xb6, 0x09, 0xd0, 0x23,
+	0xe8, 0xe6, 0xa2, 0xc8, 0x72, 0x1b, 0x06, 0x98, 0xaa, 0x3d, 0x3a, 0x81, 0x7e, 0xb2, 0xd2, 0x5a,
+	0x48, 0x7b, 0xba, 0x50, 0xc9, 0x3c, 0x24, 0x23, 0x3f, 0xde, 0x9d, 0xec, 0x8d, 0x9b, 0x09, 0xc7,
+	0x97, 0xdc, 0xe4, 0xec, 0x5e, 0x8d, 0xc3, 0x64, 0x42, 0x0a, 0x53, 0x98, 0x0a, 0xd3, 0x79, 0x18,
+	0xd3, 0xae, 0x89, 0xa6, 0x70, 0x30, 0x15, 0xb7, 0x68, 0xbb, 0x2c, 0xee, 0x16, 0x01, 0xc9, 0xb9,
+	0xc9, 0x43, 0xef, 0x41, 0x3c, 0xe6, 0xdc, 0xdc, 0x72, 0xb5, 0xbc, 0x16, 0xba, 0x5e, 0xa9, 0xf6,
+	0xa2, 0x73, 0x38, 0x6c, 0xf3, 0x09, 0x54, 0xcb, 0xd0, 0x31, 0x90, 0x94, 0x5b, 0x1e, 0x7a, 0xa3,
+	0x20, 0xde, 0x9d, 0x0c, 0x37, 0x94, 0xdb, 0xcd, 0x19, 0xd6, 0x45, 0x5f, 0xe1, 0xf0, 0x42, 0x54,
+	0x6b, 0x5d, 0x0a, 0x9e, 0x0a, 0x6d, 0xfe, 0x74, 0x32, 0x17, 0xe7, 0x4b, 0xb5, 0x92, 0x6b, 0xa5,
+	0x2b, 0x8f, 0x52, 0x20, 0x66, 0x5e, 0x94, 0xa8, 0x30, 0x61, 0x68, 0x47, 0xff, 0x43, 0xf0, 0xde,
+	0x64, 0x2e, 0x95, 0xa8, 0x54, 0x60, 0x3b, 0xc2, 0xd0, 0xa6, 0x21, 0xfc, 0x55, 0xf2, 0xbb, 0x85,
+	0xe2, 0xee, 0x63, 0x7a, 0x71, 0x9f, 0x35, 0xae, 0x03, 0x9d, 0xf1, 0xd2, 0x81, 0x24, 0x5f, 0x56,
+	0xa0, 0x1d, 0x86, 0xb6, 0x03, 0x7d, 0xaa, 0xff, 0x92, 0x6a, 0xa8, 0xc6, 0x8d, 0xbe, 0x7b, 0xb0,
+	0x37, 0x73, 0x5b, 0x5c, 0x72, 0x99, 0x9a, 0x9c, 0xcf, 0xef, 0x15, 0x7b, 0xf7, 0x8a, 0xd7, 0xd4,
+	0x7e, 0x8b, 0xfa, 0x1f, 0x20, 0x09, 0x2f, 0x4d, 0x18, 0xa0, 0xb2, 0x83, 0x8d, 0x24, 0x67, 0xbc,
+	0x64, 0x98, 0xa2, 0xc7, 0x00, 0x8b, 0xc2, 0x58, 0x21, 0x67, 0x4a, 0xdb, 0x90, 0x8c, 0xbc, 0x98,
+	0xb0, 0x56, 0x84, 0xee, 0x81, 0x5f, 0xa4, 0x61, 0x07, 0xb7, 0xf1, 0x8b, 0xd4, 0xb5, 0xd1, 0xc2,
+	0xd8, 0xb0, 0x8b, 0x11, 0xb4, 0xa3, 0x77, 0x40, 0xa6, 0x6e, 0x7d, 0x57, 0x5b, 0xe2, 0x5c, 0xae,
+	0xb6, 0xa4, 0x07, 0x10, 0xac, 0xd2, 0x12, 0x27, 0x1a, 0x30, 0x67, 0xba, 0x88, 0x4d, 0x4a, 0x14,
+	0x79, 0xc0, 0x9c, 0x59, 0xf3, 0x93, 0x1a, 0x93, 0x46, 0xaf, 0xa0, 0x77, 0x2e, 0xd3, 0x52, 0x15,
+	0xd2, 0x3e, 0x87, 0x2f, 0xfa, 0xe6, 0x01, 0x99, 0x15, 0x32, 0x7b, 0x42, 0xa9, 0x13, 0x20, 0x37,
+	0x5a, 0x2d, 0x91, 0x67, 0x77, 0x42, 0x37, 0xaa, 0x34, 0x8d, 0x19, 0xe6, 0x69, 0x04, 0xbe, 0x55,
+	0x61, 0xf0, 0x68, 0x95, 0x6f, 0x95, 0x93, 0x4f, 0x7c, 0x2e, 0x0b, 0xcd, 0xdd, 0x35, 0xa8, 0x7f,
+	0x93, 0x56, 0x64, 0x2d, 0x57, 0xa7, 0x25, 0xd7, 0x17, 0x20, 0x33, 0x25, 0xb3, 0x9a, 0xdf, 0x7b,
+	0x92, 0x7f, 0x08, 0x3d, 0x2d, 0xca, 0xc5, 0xdd, 0x47, 0x35, 0xc7, 0x79, 0xfb, 0x6c, 0xed, 0x6f,
+	0xf5, 0x0e, 0x1e, 0xed, 0x4d, 0x5a, 0xbd, 0xaf, 0xa0, 0xf7, 0xa6, 0x90, 0xa9, 0x74, 0x9f, 0xeb,
+	0x08, 0xba, 0x96, 0xeb, 0x4c, 0xd8, 0x5a, 0xe2, 0xda, 0xdb, 0xe2, 0xf5, 0x1f, 0xe5, 0x0d, 0x5a,
+	0xbc, 0x02, 0x76, 0xa6, 0xee, 0x38, 0x5d, 0x2b, 0x6d, 0xe8, 0xbf, 0xd0, 0x71, 0x0d, 0x4c, 0xfd,
+	0xa2, 0x5b, 0x4f, 0xd1, 0xfd, 0x26, 0xac, 0x4a, 0x3e, 0xab, 0xcd, 0x4b, 0xa0, 0xcd, 0xd3, 0x3f,
+	0x55, 0x69, 0x51, 0x5d, 0x10, 0x7a, 0x02, 0xdd, 0x1c, 0xef, 0xc9, 0xaf, 0x0d, 0xf1, 0xed, 0xd7,
+	0xd9, 0xe8, 0x05, 0xec, 0x5f, 0x08, 0xeb, 0x66, 0x70, 0xb0, 0xdf, 0x82, 0x1e, 0x43, 0xaf, 0xc1,
+	0xb9, 0xc1, 0xd6, 0xf7, 0xaa, 0x5f, 0xdd, 0xa4, 0x9f, 0x03, 0x00, 0xe7, 0x6f, 0xdf, 0xf3, 0x44,
+	0x06, 0x00, 0x00,
 }
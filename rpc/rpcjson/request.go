@@ -73,6 +73,17 @@ type Request struct {
 	ID      interface{}     `json:"id"`
 }
 
+// RPCVersion2 is the version of JSON-RPC 2.0 requests and responses.
+const RPCVersion2 = "2.0"
+
+// IsNotification returns whether the request is a JSON-RPC 2.0 notification,
+// which is a request without an id.  No response is sent for notifications.
+// The ID is nil for a null id as well, so the callers which unmarshal the
+// request must check the presence of the id in the raw request too.
+func (r *Request) IsNotification() bool {
+	return r.Jsonrpc == RPCVersion2 && r.ID == nil
+}
+
 // NewRequest returns a new JSON-RPC 1.0 request rpcobject given the provided id,
 // method, and parameters.  The parameters are marshalled into a json.RawMessage
 // for the Params field of the returned request rpcobject.  This function is only
@@ -109,18 +120,20 @@ func NewRequest(id interface{}, method string, param interface{}) (*Request, err
 // interface.  The ID field has to be a pointer for Go to put a null in it when
 // empty.
 type Response struct {
-	Result json.RawMessage `json:"result"`
-	Error  *RPCError       `json:"error"`
-	ID     *interface{}    `json:"id"`
+	Jsonrpc string          `json:"jsonrpc,omitempty"`
+	Result  json.RawMessage `json:"result"`
+	Error   *RPCError       `json:"error"`
+	ID      *interface{}    `json:"id"`
 }
 
-// NewResponse returns a new JSON-RPC response rpcobject given the provided id,
-// marshalled result, and RPC error.  This function is only provided in case the
+// NewResponse returns a new JSON-RPC response rpcobject given the provided rpc
+// version, id, marshalled result, and RPC error.  The rpc version is only set
+// for JSON-RPC 2.0 requests.  This function is only provided in case the
 // caller wants to construct raw responses for some reason.
 //
 // Typically callers will instead want to create the fully marshalled JSON-RPC
 // response to send over the wire with the MarshalResponse function.
-func NewResponse(id interface{}, marshalledResult []byte, rpcErr *RPCError) (*Response, error) {
+func NewResponse(rpcVersion string, id interface{}, marshalledResult []byte, rpcErr *RPCError) (*Response, error) {
 	if !IsValidIDType(id) {
 		str := fmt.Sprintf("the id of type '%T' is invalid", id)
 		return nil, makeError(ErrInvalidType, str)
@@ -128,20 +141,22 @@ func NewResponse(id interface{}, marshalledResult []byte, rpcErr *RPCError) (*Re
 
 	pid := &id
 	return &Response{
-		Result: marshalledResult,
-		Error:  rpcErr,
-		ID:     pid,
+		Jsonrpc: rpcVersion,
+		Result:  marshalledResult,
+		Error:   rpcErr,
+		ID:      pid,
 	}, nil
 }
 
-// MarshalResponse marshals the passed id, result, and RPCError to a JSON-RPC
-// response byte slice that is suitable for transmission to a JSON-RPC client.
-func MarshalResponse(id interface{}, result interface{}, rpcErr *RPCError) ([]byte, error) {
+// MarshalResponse marshals the passed rpc version, id, result, and RPCError to
+// a JSON-RPC response byte slice that is suitable for transmission to a
+// JSON-RPC client.
+func MarshalResponse(rpcVersion string, id interface{}, result interface{}, rpcErr *RPCError) ([]byte, error) {
 	marshalledResult, err := json.Marshal(result)
 	if err != nil {
 		return nil, err
 	}
-	response, err := NewResponse(id, marshalledResult, rpcErr)
+	response, err := NewResponse(rpcVersion, id, marshalledResult, rpcErr)
 	if err != nil {
 		return nil, err
 	}
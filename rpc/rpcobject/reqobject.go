@@ -31,16 +31,38 @@ type SingleCmd struct {
 }
 
 //Transactions
+//the coin selection options are optional, the tickets are pinned in the form of txid:index
+type CoinSelectCmd struct {
+	Strategy    string   `json:"strategy,omitempty"`
+	FeeRate     int64    `json:"feeRate,omitempty"`
+	DustLimit   int64    `json:"dustLimit,omitempty"`
+	Tickets     []string `json:"tickets,omitempty"`
+	Consolidate bool     `json:"consolidate,omitempty"`
+}
+
 type SendToTxCmd struct {
 	FromAccountId string `json:"fromAccountId"`
 	ToAccountId   string `json:"toAccountId"`
 	Amount        int    `json:"amount"`
+	CoinSelectCmd
+}
+
+//the amounts are keyed by recipient, all accounts of wallet fund if fromAccountIds is empty
+type SendManyCmd struct {
+	FromAccountIds  []string         `json:"fromAccountIds,omitempty"`
+	Amounts         map[string]int64 `json:"amounts"`
+	ChangeAccountId string           `json:"changeAccountId,omitempty"`
+	CoinSelectCmd
 }
 
 type GetTransactionByHashCmd struct {
 	Hash string `json:"hash"`
 }
 
+type SendRawTransactionCmd struct {
+	Tx string `json:"tx"`
+}
+
 type PublishContractCmd struct {
 	FromAccountId string `json:"fromAccountId"`
 	Contract      string `json:"contract"`
@@ -74,11 +96,93 @@ type GetTransactionReceiptCmd struct {
 	Hash string `json:"hash"`
 }
 
+//The nil fromBlock and toBlock mean the best block.
+type FilterCmd struct {
+	FromBlock *int64     `json:"fromBlock"`
+	ToBlock   *int64     `json:"toBlock"`
+	Addresses []string   `json:"addresses"`
+	Topics    [][]string `json:"topics"`
+}
+
+type FilterIDCmd struct {
+	ID string `json:"id"`
+}
+
+//Websocket subscription, the type is one of newHeads, pendingTransactions and logs.
+//The filter is only used by logs.
+type SubscribeCmd struct {
+	Type   string     `json:"type"`
+	Filter *FilterCmd `json:"filter"`
+}
+
+type UnsubscribeCmd struct {
+	ID string `json:"id"`
+}
+
+//Poa
+type ProposeCmd struct {
+	AccountId string `json:"accountId"`
+	Authorize bool   `json:"authorize"`
+}
+
+type DiscardCmd struct {
+	AccountId string `json:"accountId"`
+}
+
 //Wallet
+type NewAccountCmd struct {
+	Passphrase string `json:"passphrase"`
+}
+
 type ImportAccountCmd struct {
-	Signer string `json:"accountPrivateKey"`
+	Signer     string `json:"accountPrivateKey"`
+	Passphrase string `json:"passphrase"`
 }
 
 type ExportAccountCmd struct {
-	AccountId string `json:"insuranceID"`
+	AccountId  string `json:"insuranceID"`
+	Passphrase string `json:"passphrase"`
+}
+
+//the duration is in seconds, nil means the default duration and 0 means until locked
+type UnlockAccountCmd struct {
+	AccountId  string  `json:"accountId"`
+	Passphrase string  `json:"passphrase"`
+	Duration   *uint64 `json:"duration"`
+}
+
+type LockAccountCmd struct {
+	AccountId string `json:"accountId"`
+}
+
+type MigrateAccountsCmd struct {
+	Passphrase string `json:"passphrase"`
+}
+
+//HD wallet
+type NewMnemonicCmd struct {
+	Bits int `json:"bits"`
+}
+
+//the passphrase is the BIP-39 passphrase of seed, the password encrypts the seed and derived keys
+type ImportMnemonicCmd struct {
+	Mnemonic   string `json:"mnemonic"`
+	Passphrase string `json:"passphrase"`
+	Path       string `json:"path"`
+	Password   string `json:"password"`
+}
+
+type DiscoverHDAccountsCmd struct {
+	Gap      uint32 `json:"gap"`
+	Password string `json:"password"`
+}
+
+//proof, the block hash of account proof is the best block if it is empty
+type GetAccountProofCmd struct {
+	AccountId string `json:"accountId"`
+	BlockHash string `json:"blockHash"`
+}
+
+type GetTxProofCmd struct {
+	TxID string `json:"txid"`
 }
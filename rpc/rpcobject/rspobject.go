@@ -29,6 +29,20 @@ type WalletInfoRSP struct {
 	Accounts []*WalletAccountRSP `json:"accounts"`
 }
 
+type SendManyRSP struct {
+	Txs []*TransactionWithIDRSP `json:"txs"`
+}
+
+type MigrateAccountsRSP struct {
+	Accounts []string `json:"accounts"`
+	HDWallet bool     `json:"hdWallet"`
+}
+
+type HDWalletInfoRSP struct {
+	Path string `json:"path"`
+	Next uint32 `json:"next"`
+}
+
 //account
 type TxRSP struct {
 	TxID          string `json:"txid"`
@@ -71,3 +85,44 @@ type CallContractRSP struct {
 	GasPrice     int    `json:"gasPrice"`
 	GasLimit     int    `json:"gasLimit"`
 }
+
+//counts of the rejected rpc requests
+type RPCMetricsRSP struct {
+	AuthFailed            uint64 `json:"authFailed"`
+	TooManyClients        uint64 `json:"tooManyClients"`
+	BodyTooLarge          uint64 `json:"bodyTooLarge"`
+	Forbidden             uint64 `json:"forbidden"`
+	RateLimitedIP         uint64 `json:"rateLimitedIP"`
+	RateLimitedCredential uint64 `json:"rateLimitedCredential"`
+	Timeout               uint64 `json:"timeout"`
+}
+
+//proof, the header is the hex of block header whose hash is the block hash.
+//The proof is the hex of trie nodes from the root in header to the account or tx.
+type AccountProofRSP struct {
+	ID          string   `json:"id"`
+	Exist       bool     `json:"exist"`
+	UTXO        []*TxRSP `json:"utxo"`
+	StorageRoot string   `json:"storageRoot"`
+	CodeHash    string   `json:"codeHash"`
+	BlockHash   string   `json:"blockHash"`
+	Height      uint32   `json:"height"`
+	StateRoot   string   `json:"stateRoot"`
+	Header      string   `json:"header"`
+	Proof       []string `json:"proof"`
+}
+
+type TxProofRSP struct {
+	TxID      string   `json:"txid"`
+	BlockHash string   `json:"blockHash"`
+	Height    uint32   `json:"height"`
+	TxRoot    string   `json:"txRoot"`
+	Header    string   `json:"header"`
+	Proof     []string `json:"proof"`
+}
+
+//websocket notification of subscription
+type SubscriptionRSP struct {
+	Subscription string      `json:"subscription"`
+	Result       interface{} `json:"result"`
+}
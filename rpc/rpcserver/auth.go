@@ -0,0 +1,137 @@
+package rpcserver
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mihongtech/linkchain/config"
+	"github.com/mihongtech/linkchain/rpc/rpcjson"
+)
+
+const (
+	// authRealm is the realm of HTTP basic authentication.
+	authRealm = "linkchain RPC"
+
+	// rpcErrForbiddenCode is the error code of calling a method which is not
+	// allowed to the credential.  It is in the server error range of JSON-RPC 2.0.
+	rpcErrForbiddenCode rpcjson.RPCErrorCode = -32001
+)
+
+var errAuthFailed = errors.New("auth failed")
+
+// credential is an authenticated rpc client and the namespaces it is allowed
+// to call.  Only the sha256 of the authorization header is kept, which is
+// compared in constant time.
+type credential struct {
+	authsha    [sha256.Size]byte
+	namespaces map[string]bool
+	bucket     *tokenBucket
+}
+
+// fullAccess is the credential of clients when no credential is configured.
+var fullAccess = &credential{
+	namespaces: map[string]bool{
+		NamespacePublic: true,
+		NamespaceWallet: true,
+		NamespaceMiner:  true,
+		NamespaceAdmin:  true,
+	},
+}
+
+// newCredential returns the credential of the config.  The credential is only
+// allowed to call the public namespace if no namespace is configured.  Its rate
+// is limited by the configured rate and burst, or the default ones if zero.
+func newCredential(c *config.RpcCredential, rate, burst float64) (*credential, error) {
+	var header string
+	switch {
+	case c.Token != "" && c.User != "":
+		return nil, fmt.Errorf("rpc credential of user %q can not have a token", c.User)
+	case c.Token != "":
+		header = "Bearer " + c.Token
+	case c.User != "":
+		login := c.User + ":" + c.Password
+		header = "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
+	default:
+		return nil, errors.New("rpc credential has neither user nor token")
+	}
+
+	cred := &credential{
+		authsha:    sha256.Sum256([]byte(header)),
+		namespaces: make(map[string]bool),
+	}
+	if len(c.Namespaces) == 0 {
+		cred.namespaces[NamespacePublic] = true
+	}
+	for _, namespace := range c.Namespaces {
+		if _, ok := namespacePool[namespace]; !ok {
+			return nil, fmt.Errorf("unknown rpc namespace %q", namespace)
+		}
+		cred.namespaces[namespace] = true
+	}
+
+	if c.Rate > 0 {
+		rate = c.Rate
+	}
+	if c.Burst > 0 {
+		burst = c.Burst
+	}
+	if rate > 0 {
+		cred.bucket = newTokenBucket(rate, burst, time.Now())
+	}
+	return cred, nil
+}
+
+// allowed returns whether the credential is allowed to call the method.  The
+// unknown methods are allowed, so they are replied with method not found.
+func (c *credential) allowed(method string) bool {
+	namespace, ok := methodNamespaces[method]
+	return !ok || c.namespaces[namespace]
+}
+
+// authorize returns an error if the credential is not allowed to call the method.
+func (c *credential) authorize(method string) error {
+	if !c.allowed(method) {
+		return rpcjson.NewRPCError(rpcErrForbiddenCode,
+			fmt.Sprintf("namespace %s is not allowed to call", methodNamespaces[method]))
+	}
+	return nil
+}
+
+// checkAuth checks the HTTP basic or bearer authorization header of request and
+// returns the credential of client.  Every client has full access if there is no
+// credential configured.
+//
+// This check is time-constant.
+func (s *Server) checkAuth(r *http.Request) (*credential, error) {
+	if len(s.credentials) == 0 {
+		return fullAccess, nil
+	}
+
+	authhdr := r.Header["Authorization"]
+	if len(authhdr) == 0 {
+		return nil, errAuthFailed
+	}
+
+	authsha := sha256.Sum256([]byte(authhdr[0]))
+	var matched *credential
+	for _, cred := range s.credentials {
+		if subtle.ConstantTimeCompare(authsha[:], cred.authsha[:]) == 1 {
+			matched = cred
+		}
+	}
+	if matched == nil {
+		return nil, errAuthFailed
+	}
+	return matched, nil
+}
+
+// authFail sends a message back to the client if the http auth is rejected.
+func authFail(w http.ResponseWriter) {
+	w.Header().Add("WWW-Authenticate", `Basic realm="`+authRealm+`"`)
+	http.Error(w, "401 Unauthorized.", http.StatusUnauthorized)
+}
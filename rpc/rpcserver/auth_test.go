@@ -0,0 +1,84 @@
+package rpcserver
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mihongtech/linkchain/config"
+)
+
+func TestCheckAuth(t *testing.T) {
+	s, err := NewRPCServer(&Config{Credentials: []config.RpcCredential{
+		{User: "admin", Password: "secret", Namespaces: []string{NamespacePublic, NamespaceAdmin}},
+		{Token: "apptoken"},
+	}}, nil)
+	if err != nil {
+		t.Fatalf("create server failed: %v", err)
+	}
+
+	newRequest := func(user, pass, token string) *http.Request {
+		r, _ := http.NewRequest("POST", "http://localhost", nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		} else if user != "" {
+			r.SetBasicAuth(user, pass)
+		}
+		return r
+	}
+
+	tests := []struct {
+		user, pass, token string
+		ok                bool
+		allowed           []string
+		forbidden         []string
+	}{
+		{"", "", "", false, nil, nil},
+		{"admin", "wrong", "", false, nil, nil},
+		{"", "", "wrong", false, nil, nil},
+		{"admin", "secret", "", true, []string{"getBestBlock", "shutdown", "unknownMethod"}, []string{"exportAccount", "startMine"}},
+		{"", "", "apptoken", true, []string{"getBestBlock", "subscribe"}, []string{"exportAccount", "startMine", "shutdown"}},
+	}
+	for i, test := range tests {
+		cred, err := s.checkAuth(newRequest(test.user, test.pass, test.token))
+		if (err == nil) != test.ok {
+			t.Fatalf("test %d: auth mismatch: have %v, want %v", i, err, test.ok)
+		}
+		for _, method := range test.allowed {
+			if err := cred.authorize(method); err != nil {
+				t.Errorf("test %d: %s is not allowed: %v", i, method, err)
+			}
+		}
+		for _, method := range test.forbidden {
+			if err := cred.authorize(method); err == nil {
+				t.Errorf("test %d: %s is allowed", i, method)
+			}
+		}
+	}
+}
+
+func TestInvalidCredential(t *testing.T) {
+	credentials := []config.RpcCredential{
+		{},
+		{User: "user", Token: "token"},
+		{Token: "token", Namespaces: []string{"unknown"}},
+	}
+	for i, c := range credentials {
+		if _, err := NewRPCServer(&Config{Credentials: []config.RpcCredential{c}}, nil); err == nil {
+			t.Errorf("test %d: invalid credential is accepted", i)
+		}
+	}
+}
+
+func TestNoCredential(t *testing.T) {
+	s := newTestServer()
+	r, _ := http.NewRequest("POST", "http://localhost", nil)
+	cred, err := s.checkAuth(r)
+	if err != nil {
+		t.Fatalf("auth failed without credentials: %v", err)
+	}
+	for method := range handlerPool {
+		if err := cred.authorize(method); err != nil {
+			t.Errorf("%s is not allowed without credentials", method)
+		}
+	}
+}
@@ -153,7 +153,8 @@ func callContract(s *Server, cmd interface{}, closeChan <-chan struct{}) (interf
 	return nil, err
 }
 
-func doCall(s *Server, args *rpcobject.CallCmd, vmCfg vm.Config, timeout time.Duration) ([]byte, bool, error) {
+//doCall executes the call on the best state, the evm is cancelled once closeChan is closed.
+func doCall(s *Server, args *rpcobject.CallCmd, vmCfg vm.Config, closeChan <-chan struct{}) ([]byte, bool, error) {
 	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
 
 	block := GetNodeAPI(s).GetBestBlock()
@@ -189,12 +190,28 @@ func doCall(s *Server, args *rpcobject.CallCmd, vmCfg vm.Config, timeout time.Du
 		return nil, false, err
 	}
 
+	// Cancel the evm if the request is closed or exceeds the deadline.
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-closeChan:
+			evm.Cancel()
+		case <-finished:
+		}
+	}()
+
 	// Setup the gas pool (also for unmetered requests)
 	// and apply the message.
 	res, _, failed, err := contract.ApplyMessage(evm, msg, new(core.GasPool).AddGas(math.MaxUint64))
 	if err := vmError(); err != nil {
 		return nil, false, err
 	}
+	select {
+	case <-closeChan:
+		return nil, false, errRPCTimeout
+	default:
+	}
 	return res, failed, err
 }
 
@@ -223,7 +240,7 @@ func call(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, e
 	// contractId, _ := meta.NewAccountIdFromStr(c.FromAccountId)
 	// height := c.Height
 
-	result, _, err := doCall(s, c, vm.Config{}, 5*time.Second)
+	result, _, err := doCall(s, c, vm.Config{}, closeChan)
 	if err != nil {
 
 		log.Error("result is", "result", hexutil.Encode(result), "err", err)
@@ -0,0 +1,286 @@
+package rpcserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/mihongtech/linkchain/common/math"
+	"github.com/mihongtech/linkchain/contract"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/helper"
+	"github.com/mihongtech/linkchain/rpc/rpcobject"
+)
+
+const (
+	//filterTimeout is the time after which an installed filter is removed if it is not polled.
+	filterTimeout = 5 * time.Minute
+
+	//maxFilters is the max number of installed filters, the least recently polled one is
+	//removed to install a new filter.
+	maxFilters = 1000
+)
+
+var errFilterNotFound = errors.New("filter not found")
+
+//logFilter is a installed filter which is polled by getFilterChanges or log subscription.
+type logFilter struct {
+	offChain *contract.OffChainState
+	query    contract.FilterQuery
+	toBest   bool         // the filter follows the best block
+	next     uint64       // the next block to be polled
+	lastHash meta.BlockID // the hash of block next-1, used to detect reorg
+	deadline time.Time
+}
+
+func getLogs(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c, ok := cmd.(*rpcobject.FilterCmd)
+	if !ok {
+		fmt.Println("Type error:", reflect.TypeOf(cmd))
+		return nil, nil
+	}
+
+	offChain, err := getLogOffChain(s)
+	if err != nil {
+		return nil, err
+	}
+	best := uint64(GetNodeAPI(s).GetBestBlock().GetHeight())
+	query, err := parseFilterCmd(c, best)
+	if err != nil {
+		return nil, err
+	}
+	if query.FromBlock > best {
+		return nil, errors.New("fromBlock is higher than best block")
+	}
+	if query.ToBlock > best {
+		query.ToBlock = best
+	}
+	return offChain.GetLogs(query)
+}
+
+func newFilter(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c, ok := cmd.(*rpcobject.FilterCmd)
+	if !ok {
+		fmt.Println("Type error:", reflect.TypeOf(cmd))
+		return nil, nil
+	}
+
+	f, err := newLogFilter(s, c)
+	if err != nil {
+		return nil, err
+	}
+	f.deadline = time.Now().Add(filterTimeout)
+
+	id, err := newRandomID()
+	if err != nil {
+		return nil, err
+	}
+
+	s.installFilter(id, f)
+	return id, nil
+}
+
+func getFilterChanges(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c, ok := cmd.(*rpcobject.FilterIDCmd)
+	if !ok {
+		fmt.Println("Type error:", reflect.TypeOf(cmd))
+		return nil, nil
+	}
+
+	//the logs are queried on a copy of filter, the other filters are not blocked by the query
+	s.filtersMtx.Lock()
+	s.expireFilters()
+	f, ok := s.filters[c.ID]
+	if !ok {
+		s.filtersMtx.Unlock()
+		return nil, errFilterNotFound
+	}
+	f.deadline = time.Now().Add(filterTimeout)
+	polled := *f
+	s.filtersMtx.Unlock()
+
+	next, lastHash := polled.next, polled.lastHash
+	logs, err := polled.poll(s)
+	if err != nil {
+		return nil, err
+	}
+
+	//the progress is dropped if the filter is uninstalled or polled by another request meanwhile
+	s.filtersMtx.Lock()
+	defer s.filtersMtx.Unlock()
+	if cur, ok := s.filters[c.ID]; ok && cur.next == next && cur.lastHash.IsEqual(&lastHash) {
+		cur.next, cur.lastHash = polled.next, polled.lastHash
+	}
+	return logs, nil
+}
+
+func uninstallFilter(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c, ok := cmd.(*rpcobject.FilterIDCmd)
+	if !ok {
+		fmt.Println("Type error:", reflect.TypeOf(cmd))
+		return nil, nil
+	}
+
+	s.filtersMtx.Lock()
+	defer s.filtersMtx.Unlock()
+	if _, ok := s.filters[c.ID]; !ok {
+		return nil, errFilterNotFound
+	}
+	delete(s.filters, c.ID)
+	return true, nil
+}
+
+//Create the log filter which reports the logs of blocks after installation.
+func newLogFilter(s *Server, c *rpcobject.FilterCmd) (*logFilter, error) {
+	offChain, err := getLogOffChain(s)
+	if err != nil {
+		return nil, err
+	}
+	best := GetNodeAPI(s).GetBestBlock()
+	query, err := parseFilterCmd(c, uint64(best.GetHeight()))
+	if err != nil {
+		return nil, err
+	}
+
+	f := &logFilter{
+		offChain: offChain,
+		query:    *query,
+		toBest:   c.ToBlock == nil,
+		next:     uint64(best.GetHeight()) + 1,
+		lastHash: *best.GetBlockID(),
+	}
+	if f.next < query.FromBlock {
+		f.next = query.FromBlock
+	}
+	return f, nil
+}
+
+//Get the logs of blocks which are added to main chain since last poll.
+func (f *logFilter) poll(s *Server) ([]*meta.Log, error) {
+	//poll again from the fork point if the polled blocks are reorganized
+	for f.next > 0 {
+		canonical, err := GetNodeAPI(s).GetBlockByHeight(uint32(f.next - 1))
+		if err == nil && canonical != nil && canonical.GetBlockID().IsEqual(&f.lastHash) {
+			break
+		}
+		last, err := GetNodeAPI(s).GetBlockByID(f.lastHash)
+		if err != nil || last == nil {
+			return nil, errors.New("the polled block of filter is not found")
+		}
+		f.next--
+		f.lastHash = *last.GetPrevBlockID()
+	}
+
+	best := GetNodeAPI(s).GetBestBlock()
+	query := f.query
+	query.FromBlock = f.next
+	if f.toBest || query.ToBlock > uint64(best.GetHeight()) {
+		query.ToBlock = uint64(best.GetHeight())
+	}
+	//a filter which is not polled for long catches up in several polls
+	if query.ToBlock >= query.FromBlock+contract.MaxFilterBlocks {
+		query.ToBlock = query.FromBlock + contract.MaxFilterBlocks - 1
+	}
+	if query.FromBlock > query.ToBlock {
+		return []*meta.Log{}, nil
+	}
+
+	logs, err := f.offChain.GetLogs(&query)
+	if err != nil {
+		return nil, err
+	}
+	last, err := GetNodeAPI(s).GetBlockByHeight(uint32(query.ToBlock))
+	if err != nil || last == nil {
+		return nil, errors.New("the polled block of filter is not found")
+	}
+	f.next = query.ToBlock + 1
+	f.lastHash = *last.GetBlockID()
+	return logs, nil
+}
+
+//Install the filter by id, the least recently polled filter is removed if there are too many.
+func (s *Server) installFilter(id string, f *logFilter) {
+	s.filtersMtx.Lock()
+	defer s.filtersMtx.Unlock()
+	s.expireFilters()
+	for len(s.filters) >= maxFilters {
+		var staleID string
+		for fid, filter := range s.filters {
+			if staleID == "" || filter.deadline.Before(s.filters[staleID].deadline) {
+				staleID = fid
+			}
+		}
+		delete(s.filters, staleID)
+	}
+	s.filters[id] = f
+}
+
+//Remove the filters which are not polled in time, the caller must hold filtersMtx.
+func (s *Server) expireFilters() {
+	now := time.Now()
+	for id, f := range s.filters {
+		if now.After(f.deadline) {
+			delete(s.filters, id)
+		}
+	}
+}
+
+//Create the random hex id of filter and subscription.
+func newRandomID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+func getLogOffChain(s *Server) (*contract.OffChainState, error) {
+	offChain, ok := GetNodeAPI(s).GetOffChain().(*contract.OffChainState)
+	if !ok {
+		return nil, errors.New("the log filter is not supported by interpreter")
+	}
+	return offChain, nil
+}
+
+//Parse the filter cmd into query, the nil fromBlock and toBlock are set to best.
+func parseFilterCmd(c *rpcobject.FilterCmd, best uint64) (*contract.FilterQuery, error) {
+	query := &contract.FilterQuery{FromBlock: best, ToBlock: best}
+	if c.FromBlock != nil {
+		if *c.FromBlock < 0 {
+			return nil, errors.New("fromBlock is out of range")
+		}
+		query.FromBlock = uint64(*c.FromBlock)
+	}
+	if c.ToBlock != nil {
+		if *c.ToBlock < 0 {
+			return nil, errors.New("toBlock is out of range")
+		}
+		query.ToBlock = uint64(*c.ToBlock)
+	}
+	if c.ToBlock != nil && query.FromBlock > query.ToBlock {
+		return nil, errors.New("fromBlock is higher than toBlock")
+	}
+
+	for _, address := range c.Addresses {
+		id, err := helper.CreateAccountIdByAddress(address)
+		if err != nil {
+			return nil, err
+		}
+		query.Addresses = append(query.Addresses, *id)
+	}
+	for _, topics := range c.Topics {
+		sub := make([]math.Hash, 0, len(topics))
+		for _, topic := range topics {
+			hash, err := math.NewHashFromStr(topic)
+			if err != nil {
+				return nil, err
+			}
+			sub = append(sub, *hash)
+		}
+		query.Topics = append(query.Topics, sub)
+	}
+	return query, nil
+}
@@ -0,0 +1,36 @@
+package rpcserver
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestInstallFilterLimit(t *testing.T) {
+	s := newTestServer()
+	now := time.Now()
+	for i := 0; i < maxFilters; i++ {
+		s.installFilter(fmt.Sprintf("%d", i), &logFilter{deadline: now.Add(filterTimeout + time.Duration(i)*time.Second)})
+	}
+	//the least recently polled filter is removed for the new one
+	s.installFilter("new", &logFilter{deadline: now.Add(2 * filterTimeout)})
+	if len(s.filters) != maxFilters {
+		t.Fatalf("filters count mismatch: have %d, want %d", len(s.filters), maxFilters)
+	}
+	if _, ok := s.filters["0"]; ok {
+		t.Errorf("least recently polled filter is not removed")
+	}
+	if _, ok := s.filters["new"]; !ok {
+		t.Errorf("new filter is not installed")
+	}
+
+	//the expired filters are removed
+	s.filters["1"].deadline = now.Add(-time.Second)
+	s.installFilter("newer", &logFilter{deadline: now.Add(2 * filterTimeout)})
+	if _, ok := s.filters["1"]; ok {
+		t.Errorf("expired filter is not removed")
+	}
+	if _, ok := s.filters["2"]; !ok {
+		t.Errorf("live filter is removed while an expired one exists")
+	}
+}
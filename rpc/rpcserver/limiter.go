@@ -0,0 +1,210 @@
+package rpcserver
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mihongtech/linkchain/rpc/rpcjson"
+)
+
+const (
+	// maxRateBuckets is the number of buckets of rate limiter which triggers
+	// the removing of the idle buckets.
+	maxRateBuckets = 10000
+
+	// rpcErrRateLimitedCode is the error code of the rejected requests which
+	// exceed the rate limit.  It is in the server error range of JSON-RPC 2.0.
+	rpcErrRateLimitedCode rpcjson.RPCErrorCode = -32005
+
+	// rpcErrTimeoutCode is the error code of the requests which exceed the
+	// execution deadline.
+	rpcErrTimeoutCode rpcjson.RPCErrorCode = -32006
+)
+
+var (
+	errRPCRateLimited = rpcjson.NewRPCError(rpcErrRateLimitedCode, "Rate limit exceeded")
+	errRPCTimeout     = rpcjson.NewRPCError(rpcErrTimeoutCode, "Execution deadline exceeded")
+)
+
+// tokenBucket is a token bucket which is refilled at rate tokens per second
+// up to burst tokens.
+type tokenBucket struct {
+	mtx    sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst float64, now time.Time) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: burst,
+		last:   now,
+	}
+}
+
+// refill adds the tokens since last refill.
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+}
+
+// take takes cost tokens from the bucket, it returns false and takes nothing
+// if there are not enough tokens.  A cost larger than burst is capped, so the
+// expensive request is still allowed with a full bucket.
+func (b *tokenBucket) take(cost float64, now time.Time) bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.refill(now)
+	if cost > b.burst {
+		cost = b.burst
+	}
+	if b.tokens < cost {
+		return false
+	}
+	b.tokens -= cost
+	return true
+}
+
+// full returns whether the bucket is refilled completely, so it is the same
+// as a new bucket.
+func (b *tokenBucket) full(now time.Time) bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.refill(now)
+	return b.tokens >= b.burst
+}
+
+// rateLimiter limits the rate of requests by key with a token bucket of each
+// key.  A nil limiter allows everything.
+type rateLimiter struct {
+	mtx     sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*tokenBucket
+}
+
+// newRateLimiter returns a rate limiter of rate tokens per second, it returns
+// nil if rate is not positive, which means no limit.
+func newRateLimiter(rate, burst float64) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes cost tokens from the bucket of key and returns whether the
+// request is allowed.
+func (l *rateLimiter) allow(key string, cost float64) bool {
+	if l == nil {
+		return true
+	}
+	now := time.Now()
+
+	l.mtx.Lock()
+	bucket, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxRateBuckets {
+			l.removeIdle(now)
+		}
+		bucket = newTokenBucket(l.rate, l.burst, now)
+		l.buckets[key] = bucket
+	}
+	l.mtx.Unlock()
+
+	return bucket.take(cost, now)
+}
+
+// removeIdle removes the full buckets, the caller must hold the lock.
+func (l *rateLimiter) removeIdle(now time.Time) {
+	for key, bucket := range l.buckets {
+		if bucket.full(now) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// methodCost returns the cost weight of method, it is 1 if the method has
+// no configured weight.
+func (s *Server) methodCost(method string) float64 {
+	if cost, ok := s.config.MethodCosts[method]; ok {
+		return cost
+	}
+	if cost, ok := defaultMethodCosts[method]; ok {
+		return cost
+	}
+	return 1
+}
+
+// limitRequest checks the permission and the rate limits of the remote ip and
+// the credential for calling the method, and returns an error if the request
+// is rejected.
+func (s *Server) limitRequest(host string, cred *credential, method string) error {
+	if err := cred.authorize(method); err != nil {
+		atomic.AddUint64(&s.metrics.forbidden, 1)
+		return err
+	}
+
+	cost := s.methodCost(method)
+	if !s.ipLimiter.allow(host, cost) {
+		atomic.AddUint64(&s.metrics.rateLimitedIP, 1)
+		return errRPCRateLimited
+	}
+	if cred.bucket != nil && !cred.bucket.take(cost, time.Now()) {
+		atomic.AddUint64(&s.metrics.rateLimitedCredential, 1)
+		return errRPCRateLimited
+	}
+	return nil
+}
+
+// withDeadline returns a close channel which is closed when the parent is closed
+// or the execution deadline of server is exceeded, and a function to release
+// its resources.
+func (s *Server) withDeadline(parent <-chan struct{}) (<-chan struct{}, func()) {
+	if s.config.Timeout <= 0 {
+		return parent, func() {}
+	}
+
+	closeChan := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		timer := time.NewTimer(s.config.Timeout)
+		defer timer.Stop()
+
+		select {
+		case <-parent:
+		case <-timer.C:
+		case <-done:
+			return
+		}
+		close(closeChan)
+	}()
+	return closeChan, func() { close(done) }
+}
+
+// remoteHost returns the host of the remote address.
+func remoteHost(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
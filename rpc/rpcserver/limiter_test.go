@@ -0,0 +1,128 @@
+package rpcserver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mihongtech/linkchain/config"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Unix(1000, 0)
+	b := newTokenBucket(2, 4, now)
+
+	for i := 0; i < 4; i++ {
+		if !b.take(1, now) {
+			t.Fatalf("take %d failed with a full bucket", i)
+		}
+	}
+	if b.take(1, now) {
+		t.Fatal("take succeeded with an empty bucket")
+	}
+	if !b.take(1, now.Add(500*time.Millisecond)) {
+		t.Fatal("take failed after refill")
+	}
+	if b.full(now.Add(time.Second)) {
+		t.Fatal("bucket is full before refilled")
+	}
+	if !b.full(now.Add(3 * time.Second)) {
+		t.Fatal("bucket is not full after refilled")
+	}
+	//the cost larger than burst is capped
+	if !b.take(10, now.Add(3*time.Second)) {
+		t.Fatal("take of large cost failed with a full bucket")
+	}
+}
+
+func TestLimitRequest(t *testing.T) {
+	s, err := NewRPCServer(&Config{
+		IPRate:         1,
+		IPBurst:        20,
+		CredentialRate: 1,
+		Credentials: []config.RpcCredential{
+			{User: "limited", Password: "pass", Burst: 3},
+			{Token: "app", Burst: 100},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("create server failed: %v", err)
+	}
+	limited, app := s.credentials[0], s.credentials[1]
+
+	//the credential limit of 3
+	for i := 0; i < 3; i++ {
+		if err := s.limitRequest("10.0.0.1", limited, "getBestBlock"); err != nil {
+			t.Fatalf("request %d rejected: %v", i, err)
+		}
+	}
+	if err := s.limitRequest("10.0.0.1", limited, "getBestBlock"); err != errRPCRateLimited {
+		t.Fatalf("error mismatch: have %v, want %v", err, errRPCRateLimited)
+	}
+
+	//the ip limit of 20, and the call costs 10
+	if err := s.limitRequest("10.0.0.2", app, "call"); err != nil {
+		t.Fatalf("call rejected: %v", err)
+	}
+	if err := s.limitRequest("10.0.0.2", app, "call"); err != nil {
+		t.Fatalf("call rejected: %v", err)
+	}
+	if err := s.limitRequest("10.0.0.2", app, "getBestBlock"); err != errRPCRateLimited {
+		t.Fatalf("error mismatch: have %v, want %v", err, errRPCRateLimited)
+	}
+	if err := s.limitRequest("10.0.0.3", app, "getBestBlock"); err != nil {
+		t.Fatalf("request of other ip rejected: %v", err)
+	}
+
+	//forbidden namespace
+	if err := s.limitRequest("10.0.0.4", app, "shutdown"); err == nil {
+		t.Fatal("forbidden request is allowed")
+	}
+
+	m := s.metrics.snapshot()
+	if m.RateLimitedCredential != 1 || m.RateLimitedIP != 1 || m.Forbidden != 1 {
+		t.Fatalf("metrics mismatch: %+v", m)
+	}
+}
+
+func TestExecutionDeadline(t *testing.T) {
+	s, _ := NewRPCServer(&Config{Timeout: 50 * time.Millisecond}, nil)
+
+	handlerPool["testSlow"] = func(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+		<-closeChan
+		return "cancelled", nil
+	}
+	defer delete(handlerPool, "testSlow")
+
+	if _, err := s.standardCmdResult("testSlow", nil, make(chan struct{})); err != errRPCTimeout {
+		t.Fatalf("error mismatch: have %v, want %v", err, errRPCTimeout)
+	}
+	if m := s.metrics.snapshot(); m.Timeout != 1 {
+		t.Fatalf("timeout metric mismatch: have %d, want 1", m.Timeout)
+	}
+}
+
+func TestNoDeadlineMethod(t *testing.T) {
+	s, _ := NewRPCServer(&Config{Timeout: 50 * time.Millisecond}, nil)
+
+	handlerPool["testWrite"] = func(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+		select {
+		case <-closeChan:
+			return nil, errors.New("cancelled")
+		case <-time.After(100 * time.Millisecond):
+			return "written", nil
+		}
+	}
+	noDeadlineMethods["testWrite"] = true
+	defer func() {
+		delete(handlerPool, "testWrite")
+		delete(noDeadlineMethods, "testWrite")
+	}()
+
+	if result, err := s.standardCmdResult("testWrite", nil, make(chan struct{})); err != nil || result != "written" {
+		t.Fatalf("result mismatch: have %v, %v, want %v", result, err, "written")
+	}
+	if m := s.metrics.snapshot(); m.Timeout != 0 {
+		t.Fatalf("timeout metric mismatch: have %d, want 0", m.Timeout)
+	}
+}
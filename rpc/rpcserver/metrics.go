@@ -0,0 +1,35 @@
+package rpcserver
+
+import (
+	"sync/atomic"
+
+	"github.com/mihongtech/linkchain/rpc/rpcobject"
+)
+
+// rpcMetrics counts the rejected requests.
+type rpcMetrics struct {
+	authFailed            uint64
+	tooManyClients        uint64
+	bodyTooLarge          uint64
+	forbidden             uint64
+	rateLimitedIP         uint64
+	rateLimitedCredential uint64
+	timeout               uint64
+}
+
+// snapshot returns the current metrics.
+func (m *rpcMetrics) snapshot() *rpcobject.RPCMetricsRSP {
+	return &rpcobject.RPCMetricsRSP{
+		AuthFailed:            atomic.LoadUint64(&m.authFailed),
+		TooManyClients:        atomic.LoadUint64(&m.tooManyClients),
+		BodyTooLarge:          atomic.LoadUint64(&m.bodyTooLarge),
+		Forbidden:             atomic.LoadUint64(&m.forbidden),
+		RateLimitedIP:         atomic.LoadUint64(&m.rateLimitedIP),
+		RateLimitedCredential: atomic.LoadUint64(&m.rateLimitedCredential),
+		Timeout:               atomic.LoadUint64(&m.timeout),
+	}
+}
+
+func getRpcMetrics(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	return s.metrics.snapshot(), nil
+}
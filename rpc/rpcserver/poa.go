@@ -0,0 +1,83 @@
+package rpcserver
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/mihongtech/linkchain/consensus/poa"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/rpc/rpcobject"
+)
+
+func getPoaEngine(s *Server) (*poa.Poa, error) {
+	engine, ok := GetNodeAPI(s).GetEngine().(*poa.Poa)
+	if !ok {
+		return nil, errors.New("the consensus engine is not poa")
+	}
+	return engine, nil
+}
+
+func getSigners(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	engine, err := getPoaEngine(s)
+	if err != nil {
+		return nil, err
+	}
+	signers, err := engine.GetSigners(GetNodeAPI(s), GetNodeAPI(s).GetBestBlock())
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(signers))
+	for _, signer := range signers {
+		ids = append(ids, signer.String())
+	}
+	return ids, nil
+}
+
+func getProposals(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	engine, err := getPoaEngine(s)
+	if err != nil {
+		return nil, err
+	}
+	proposals := make(map[string]bool)
+	for candidate, authorize := range engine.Proposals() {
+		proposals[candidate.String()] = authorize
+	}
+	return proposals, nil
+}
+
+func propose(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c, ok := cmd.(*rpcobject.ProposeCmd)
+	if !ok {
+		fmt.Println("Type error:", reflect.TypeOf(cmd))
+		return nil, nil
+	}
+	engine, err := getPoaEngine(s)
+	if err != nil {
+		return nil, err
+	}
+	candidate, err := meta.HexToAccountID(c.AccountId)
+	if err != nil {
+		return nil, err
+	}
+	engine.Propose(candidate, c.Authorize)
+	return nil, nil
+}
+
+func discard(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c, ok := cmd.(*rpcobject.DiscardCmd)
+	if !ok {
+		fmt.Println("Type error:", reflect.TypeOf(cmd))
+		return nil, nil
+	}
+	engine, err := getPoaEngine(s)
+	if err != nil {
+		return nil, err
+	}
+	candidate, err := meta.HexToAccountID(c.AccountId)
+	if err != nil {
+		return nil, err
+	}
+	engine.Discard(candidate)
+	return nil, nil
+}
@@ -0,0 +1,94 @@
+package rpcserver
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/mihongtech/linkchain/common/math"
+	"github.com/mihongtech/linkchain/helper"
+	"github.com/mihongtech/linkchain/rpc/rpcobject"
+)
+
+//getAccountProof returns the account in the state of block with the merkle proof against the state root of block.
+func getAccountProof(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c, ok := cmd.(*rpcobject.GetAccountProofCmd)
+	if !ok {
+		fmt.Println("Type error:", reflect.TypeOf(cmd))
+		return nil, nil
+	}
+
+	accountId, err := helper.CreateAccountIdByAddress(c.AccountId)
+	if err != nil {
+		return nil, err
+	}
+	hash := *GetNodeAPI(s).GetBestBlock().GetBlockID()
+	if c.BlockHash != "" {
+		h, err := math.NewHashFromStr(c.BlockHash)
+		if err != nil {
+			return nil, err
+		}
+		hash = *h
+	}
+
+	account, block, proof, err := GetNodeAPI(s).GetAccountProof(*accountId, hash)
+	if err != nil {
+		return nil, err
+	}
+	header, err := helper.EncodeBlockHeader(&block.Header)
+	if err != nil {
+		return nil, err
+	}
+	rsp := &rpcobject.AccountProofRSP{
+		ID:        accountId.String(),
+		UTXO:      make([]*rpcobject.TxRSP, 0),
+		BlockHash: block.GetBlockID().String(),
+		Height:    block.GetHeight(),
+		StateRoot: block.GetStatus().String(),
+		Header:    header,
+		Proof:     proof.Encode(),
+	}
+	if account != nil {
+		rsp.Exist = true
+		for _, u := range account.UTXOs {
+			rsp.UTXO = append(rsp.UTXO, &rpcobject.TxRSP{
+				u.Txid.String(),
+				u.Index,
+				u.Value.GetInt64(),
+				u.LocatedHeight,
+				u.EffectHeight})
+		}
+		rsp.StorageRoot = account.StorageRoot.String()
+		rsp.CodeHash = account.CodeHash.String()
+	}
+	return rsp, nil
+}
+
+//getTxProof returns the merkle proof of tx against the tx root of its block.
+func getTxProof(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c, ok := cmd.(*rpcobject.GetTxProofCmd)
+	if !ok {
+		fmt.Println("Type error:", reflect.TypeOf(cmd))
+		return nil, nil
+	}
+
+	txid, err := math.NewHashFromStr(c.TxID)
+	if err != nil {
+		return nil, err
+	}
+	_, block, proof, err := GetNodeAPI(s).GetTxProof(*txid)
+	if err != nil {
+		return nil, err
+	}
+	header, err := helper.EncodeBlockHeader(&block.Header)
+	if err != nil {
+		return nil, err
+	}
+	return &rpcobject.TxProofRSP{
+		TxID:      txid.String(),
+		BlockHash: block.GetBlockID().String(),
+		Height:    block.GetHeight(),
+		TxRoot:    block.GetMerkleRoot().String(),
+		Header:    header,
+		Proof:     proof.Encode(),
+	}, nil
+}
@@ -13,47 +13,175 @@ import (
 
 type commandHandler func(*Server, interface{}, <-chan struct{}) (interface{}, error)
 
-//handler pool
-var handlerPool = map[string]commandHandler{
-	"getBlockChainInfo": getBlockChainInfo,
+//rpc namespaces, the methods are enabled per credential by namespace
+const (
+	NamespacePublic = "public"
+	NamespaceWallet = "wallet"
+	NamespaceMiner  = "miner"
+	NamespaceAdmin  = "admin"
+)
 
-	"addPeer":    addPeer,
-	"listPeer":   listPeer,
-	"selfPeer":   selfPeer,
-	"removePeer": removePeer,
+//public chain queries
+var publicHandlers = map[string]commandHandler{
+	"getBlockChainInfo": getBlockChainInfo,
 
 	"getBestBlock":     getBestBlock,
 	"getBlockByHeight": getBlockByHeight,
 	"getBlockByHash":   getBlockByHash,
 
-	//miner
-	"getMineInfo": getMineInfo,
-	"startMine":   startMine,
-	"stopMine":    stopMine,
-	"mine":        mine,
+	//poa
+	"getSigners":   getSigners,
+	"getProposals": getProposals,
+
+	"getAccountInfo": getAccountInfo,
 
-	//wallet
+	//transaction
+	"getTxByHash":        getTxByHash,
+	"sendRawTransaction": sendRawTransaction,
+
+	//contract
+	"getCode":            getCode,
+	"call":               call,
+	"transactionReceipt": GetTransactionReceipt,
+
+	//filter
+	"getLogs":          getLogs,
+	"newFilter":        newFilter,
+	"getFilterChanges": getFilterChanges,
+	"uninstallFilter":  uninstallFilter,
+
+	//proof
+	"getAccountProof": getAccountProof,
+	"getTxProof":      getTxProof,
+}
+
+//wallet, the methods use the accounts of wallet
+var walletHandlers = map[string]commandHandler{
 	"exportAccount": exportAccount,
 	"importAccount": importAccount,
 
-	"getWalletInfo":  getWalletInfo,
-	"getAccountInfo": getAccountInfo,
-	"newAcount":      newAcount,
+	"getWalletInfo": getWalletInfo,
+	"newAcount":     newAcount,
+
+	"unlockAccount":   unlockAccount,
+	"lockAccount":     lockAccount,
+	"migrateAccounts": migrateAccounts,
 
 	"sendMoneyTransaction": sendMoneyTransaction,
+	"sendMany":             sendMany,
 
-	//transaction
-	"getTxByHash": getTxByHash,
+	//hd wallet
+	"newMnemonic":        newMnemonic,
+	"importMnemonic":     importMnemonic,
+	"getHDWalletInfo":    getHDWalletInfo,
+	"discoverHDAccounts": discoverHDAccounts,
+
+	//contract
+	"publishContract": publishContract,
+	"callContract":    callContract,
+}
+
+//miner
+var minerHandlers = map[string]commandHandler{
+	"getMineInfo": getMineInfo,
+	"startMine":   startMine,
+	"stopMine":    stopMine,
+	"mine":        mine,
+
+	//poa
+	"propose": propose,
+	"discard": discard,
+}
+
+//admin
+var adminHandlers = map[string]commandHandler{
+	"addPeer":    addPeer,
+	"listPeer":   listPeer,
+	"selfPeer":   selfPeer,
+	"removePeer": removePeer,
 
 	//shutdown
 	"shutdown": shutdown,
 
-	//contract
-	"publishContract":    publishContract,
-	"callContract":       callContract,
-	"getCode":            getCode,
-	"call":               call,
-	"transactionReceipt": GetTransactionReceipt,
+	//metrics
+	"getRpcMetrics": getRpcMetrics,
+}
+
+//cost weights of the expensive methods for rate limiting, the others cost 1
+var defaultMethodCosts = map[string]float64{
+	"call":            10,
+	"callContract":    10,
+	"publishContract": 10,
+	"getLogs":         10,
+	"mine":            10,
+
+	//decrypting keys by scrypt is expensive, and limits guessing passphrases
+	"unlockAccount":      10,
+	"migrateAccounts":    10,
+	"discoverHDAccounts": 10,
+
+	"sendRawTransaction":   2,
+	"sendMoneyTransaction": 2,
+	"sendMany":             10,
+	"getFilterChanges":     2,
+	"getAccountProof":      2,
+	"getTxProof":           2,
+}
+
+//state changing methods which are not bounded by the execution deadline, the client
+//can not know their result if they are replied with timeout while still running
+var noDeadlineMethods = map[string]bool{
+	"sendRawTransaction":   true,
+	"sendMoneyTransaction": true,
+	"sendMany":             true,
+	"publishContract":      true,
+	"callContract":         true,
+
+	"importAccount":      true,
+	"newAcount":          true,
+	"unlockAccount":      true,
+	"lockAccount":        true,
+	"migrateAccounts":    true,
+	"importMnemonic":     true,
+	"discoverHDAccounts": true,
+
+	"startMine": true,
+	"stopMine":  true,
+	"mine":      true,
+	"propose":   true,
+	"discard":   true,
+
+	"addPeer":    true,
+	"removePeer": true,
+	"shutdown":   true,
+}
+
+//namespace pool
+var namespacePool = map[string]map[string]commandHandler{
+	NamespacePublic: publicHandlers,
+	NamespaceWallet: walletHandlers,
+	NamespaceMiner:  minerHandlers,
+	NamespaceAdmin:  adminHandlers,
+}
+
+//handler pool of all namespaces
+var handlerPool = make(map[string]commandHandler)
+
+//namespace of every method
+var methodNamespaces = make(map[string]string)
+
+func init() {
+	for namespace, handlers := range namespacePool {
+		for method, handler := range handlers {
+			handlerPool[method] = handler
+			methodNamespaces[method] = namespace
+		}
+	}
+
+	//the websocket only commands are public
+	for method := range wsHandlers {
+		methodNamespaces[method] = NamespacePublic
+	}
 }
 
 var cmdPool = map[string]reflect.Type{
@@ -67,11 +195,26 @@ var cmdPool = map[string]reflect.Type{
 	"getAccountInfo": reflect.TypeOf((*rpcobject.SingleCmd)(nil)),
 
 	"sendMoneyTransaction": reflect.TypeOf((*rpcobject.SendToTxCmd)(nil)),
+	"sendMany":             reflect.TypeOf((*rpcobject.SendManyCmd)(nil)),
+
+	"getTxByHash":        reflect.TypeOf((*rpcobject.GetTransactionByHashCmd)(nil)),
+	"sendRawTransaction": reflect.TypeOf((*rpcobject.SendRawTransactionCmd)(nil)),
 
-	"getTxByHash": reflect.TypeOf((*rpcobject.GetTransactionByHashCmd)(nil)),
+	//poa
+	"propose": reflect.TypeOf((*rpcobject.ProposeCmd)(nil)),
+	"discard": reflect.TypeOf((*rpcobject.DiscardCmd)(nil)),
 
-	"importAccount": reflect.TypeOf((*rpcobject.ImportAccountCmd)(nil)),
-	"exportAccount": reflect.TypeOf((*rpcobject.ExportAccountCmd)(nil)),
+	"newAcount":       reflect.TypeOf((*rpcobject.NewAccountCmd)(nil)),
+	"importAccount":   reflect.TypeOf((*rpcobject.ImportAccountCmd)(nil)),
+	"exportAccount":   reflect.TypeOf((*rpcobject.ExportAccountCmd)(nil)),
+	"unlockAccount":   reflect.TypeOf((*rpcobject.UnlockAccountCmd)(nil)),
+	"lockAccount":     reflect.TypeOf((*rpcobject.LockAccountCmd)(nil)),
+	"migrateAccounts": reflect.TypeOf((*rpcobject.MigrateAccountsCmd)(nil)),
+
+	//hd wallet
+	"newMnemonic":        reflect.TypeOf((*rpcobject.NewMnemonicCmd)(nil)),
+	"importMnemonic":     reflect.TypeOf((*rpcobject.ImportMnemonicCmd)(nil)),
+	"discoverHDAccounts": reflect.TypeOf((*rpcobject.DiscoverHDAccountsCmd)(nil)),
 
 	//contract
 	"publishContract":    reflect.TypeOf((*rpcobject.PublishContractCmd)(nil)),
@@ -79,6 +222,20 @@ var cmdPool = map[string]reflect.Type{
 	"call":               reflect.TypeOf((*rpcobject.CallCmd)(nil)),
 	"getCode":            reflect.TypeOf((*rpcobject.GetCodeCmd)(nil)),
 	"transactionReceipt": reflect.TypeOf((*rpcobject.GetTransactionReceiptCmd)(nil)),
+
+	//filter
+	"getLogs":          reflect.TypeOf((*rpcobject.FilterCmd)(nil)),
+	"newFilter":        reflect.TypeOf((*rpcobject.FilterCmd)(nil)),
+	"getFilterChanges": reflect.TypeOf((*rpcobject.FilterIDCmd)(nil)),
+	"uninstallFilter":  reflect.TypeOf((*rpcobject.FilterIDCmd)(nil)),
+
+	//proof
+	"getAccountProof": reflect.TypeOf((*rpcobject.GetAccountProofCmd)(nil)),
+	"getTxProof":      reflect.TypeOf((*rpcobject.GetTxProofCmd)(nil)),
+
+	//websocket
+	"subscribe":   reflect.TypeOf((*rpcobject.SubscribeCmd)(nil)),
+	"unsubscribe": reflect.TypeOf((*rpcobject.UnsubscribeCmd)(nil)),
 }
 
 func GetNodeAPI(s *Server) *node.PublicNodeAPI {
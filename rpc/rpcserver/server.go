@@ -1,7 +1,10 @@
 package rpcserver
 
 import (
+	"bytes"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/mihongtech/linkchain/app/context"
 	"io"
@@ -14,6 +17,7 @@ import (
 	"time"
 
 	"github.com/mihongtech/linkchain/common/util/log"
+	"github.com/mihongtech/linkchain/config"
 	"github.com/mihongtech/linkchain/rpc/rpcjson"
 )
 
@@ -26,10 +30,21 @@ const (
 
 	RPCMaxClients = 60
 	RPCQuirks     = true
+
+	// DefaultMaxBodySize is the default max size in bytes of a request body.
+	DefaultMaxBodySize = 5 * 1024 * 1024
+
+	// DefaultBatchLimit is the default max number of requests of a batch
+	// which are processed concurrently.
+	DefaultBatchLimit = 16
+
+	// DefaultTimeout is the default execution deadline of a request.
+	DefaultTimeout = 30 * time.Second
 )
 
 type Server struct {
-	numClients int32
+	numClients    int32
+	numWebsockets int32
 
 	config Config
 
@@ -39,6 +54,19 @@ type Server struct {
 
 	//quit channel
 	requestProcessShutdown chan struct{}
+
+	//installed log filters
+	filtersMtx sync.Mutex
+	filters    map[string]*logFilter
+
+	//configured credentials, every client has full access if it is empty
+	credentials []*credential
+
+	//rate limiter of remote ips
+	ipLimiter *rateLimiter
+
+	//counts of rejected requests
+	metrics rpcMetrics
 }
 
 // rpcserverConfig is a descriptor containing the RPC server configuration.
@@ -47,15 +75,72 @@ type Config struct {
 	// the RPC server started.
 	StartupTime int64
 	Addr        string
+
+	// MaxBodySize is the max size in bytes of a request body, the larger
+	// requests are rejected.  DefaultMaxBodySize is used if it is zero.
+	MaxBodySize int64
+
+	// BatchLimit is the max number of requests of a batch which are
+	// processed concurrently.  DefaultBatchLimit is used if it is zero.
+	BatchLimit int
+
+	// TLSCert and TLSKey are the paths of the certificate and key files,
+	// the server is served with TLS if both are set.
+	TLSCert string
+	TLSKey  string
+
+	// Credentials are the allowed credentials of clients, no authentication
+	// is required if it is empty.
+	Credentials []config.RpcCredential
+
+	// WSOrigins are the origins of browsers which are allowed to connect
+	// the websocket endpoint, "*" allows any origin.  The clients without
+	// origin are not browsers, so they are always allowed.
+	WSOrigins []string
+
+	// IPRate and IPBurst limit the requests cost per second and the burst
+	// of each remote ip, CredentialRate and CredentialBurst limit the ones
+	// of each credential.  The rate is not limited if it is zero.
+	IPRate          float64
+	IPBurst         float64
+	CredentialRate  float64
+	CredentialBurst float64
+
+	// MethodCosts overrides the cost weights of methods for rate limiting.
+	MethodCosts map[string]float64
+
+	// Timeout is the execution deadline of a request, the closeChan of the
+	// handler is closed once it is exceeded.  No deadline if it is zero, and
+	// the state changing methods have no deadline.
+	Timeout time.Duration
 }
 
 // newRPCServer returns a new instance of the rpcServer struct.
 func NewRPCServer(cfg *Config, ctx *context.Context) (*Server, error) {
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = DefaultMaxBodySize
+	}
+	if cfg.BatchLimit <= 0 {
+		cfg.BatchLimit = DefaultBatchLimit
+	}
+	if (cfg.TLSCert == "") != (cfg.TLSKey == "") {
+		return nil, errors.New("both of rpc TLS certificate and key are required")
+	}
+
 	rpc := Server{
 		config:                 *cfg,
 		statusLines:            make(map[int]string),
 		appContext:             ctx,
 		requestProcessShutdown: make(chan struct{}),
+		filters:                make(map[string]*logFilter),
+		ipLimiter:              newRateLimiter(cfg.IPRate, cfg.IPBurst),
+	}
+	for i := range cfg.Credentials {
+		cred, err := newCredential(&cfg.Credentials[i], cfg.CredentialRate, cfg.CredentialBurst)
+		if err != nil {
+			return nil, err
+		}
+		rpc.credentials = append(rpc.credentials, cred)
 	}
 
 	return &rpc, nil
@@ -72,6 +157,10 @@ func (s *Server) Start() {
 		// Timeout connections which don't complete the initial
 		// handshake within the allowed timeframe.
 		ReadTimeout: time.Second * rpcAuthTimeoutSeconds,
+
+		// Disable HTTP/2 of TLS, the hijacking of connection is not
+		// supported by HTTP/2.
+		TLSNextProto: make(map[string]func(*http.Server, *tls.Conn, http.Handler)),
 	}
 
 	rpcServeMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -88,12 +177,39 @@ func (s *Server) Start() {
 		s.incrementClients()
 		defer s.decrementClients()
 
+		// Check authentication for each request.
+		cred, err := s.checkAuth(r)
+		if err != nil {
+			atomic.AddUint64(&s.metrics.authFailed, 1)
+			authFail(w)
+			return
+		}
+
 		// Read and respond to the request.
-		s.jsonRPCRead(w, r)
+		s.jsonRPCRead(w, r, cred)
+	})
+
+	// Websocket endpoint serves the same commands and the subscriptions.
+	rpcServeMux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		cred, err := s.checkAuth(r)
+		if err != nil {
+			atomic.AddUint64(&s.metrics.authFailed, 1)
+			authFail(w)
+			return
+		}
+		s.websocketServer(cred).ServeHTTP(w, r)
 	})
 
 	go func() {
-		httpServer.ListenAndServe()
+		var err error
+		if s.config.TLSCert != "" {
+			err = httpServer.ListenAndServeTLS(s.config.TLSCert, s.config.TLSKey)
+		} else {
+			err = httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Error("RPC server stopped", "err", err)
+		}
 	}()
 }
 
@@ -103,17 +219,24 @@ func (s *Server) Stop() bool {
 }
 
 // jsonRPCRead handles reading and responding to RPC messages.
-func (s *Server) jsonRPCRead(w http.ResponseWriter, r *http.Request) {
-	// Read and close the JSON-RPC request body from the caller.
-	body, err := ioutil.ReadAll(r.Body)
+func (s *Server) jsonRPCRead(w http.ResponseWriter, r *http.Request, cred *credential) {
+	// Read and close the JSON-RPC request body from the caller, the body
+	// is limited to the max allowed size.
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, s.config.MaxBodySize+1))
 	r.Body.Close()
-	log.Info("rpcServer", "raw", string(body))
 	if err != nil {
 		errCode := http.StatusBadRequest
 		http.Error(w, fmt.Sprintf("%d error reading JSON message: %v",
 			errCode, err), errCode)
 		return
 	}
+	if int64(len(body)) > s.config.MaxBodySize {
+		atomic.AddUint64(&s.metrics.bodyTooLarge, 1)
+		errCode := http.StatusRequestEntityTooLarge
+		http.Error(w, fmt.Sprintf("%d request body exceeds the max size of %d bytes",
+			errCode, s.config.MaxBodySize), errCode)
+		return
+	}
 
 	// Unfortunately, the httpclient server doesn't provide the ability to
 	// change the read deadline for the new connection and having one breaks
@@ -139,53 +262,30 @@ func (s *Server) jsonRPCRead(w http.ResponseWriter, r *http.Request) {
 	defer conn.Close()
 	defer buf.Flush()
 
-	// Attempt to parse the raw body into a JSON-RPC request.
-	var responseID interface{}
-	var jsonErr error
-	var result interface{}
-	var request rpcjson.Request
-	if err := json.Unmarshal(body, &request); err != nil {
-		jsonErr = &rpcjson.RPCError{
-			Code:    rpcjson.ErrRPCParse.Code,
-			Message: "Failed to parse request: " + err.Error(),
+	// Setup a close notifier.  Since the connection is hijacked,
+	// the CloseNotifer on the ResponseWriter is not available.
+	closeChan := make(chan struct{}, 1)
+	go func() {
+		_, err := conn.Read(make([]byte, 1))
+		if err != nil {
+			close(closeChan)
 		}
-	}
+	}()
 
-	if jsonErr == nil {
-		if request.ID == nil && !(RPCQuirks && request.Jsonrpc == "") {
-			return
+	// Process the single request or the batch.  Nothing is replied if
+	// all the requests are notifications.
+	host := remoteHost(r.RemoteAddr)
+	msg := s.processBody(body, func(method string, cmd interface{}) (interface{}, error) {
+		if err := s.limitRequest(host, cred, method); err != nil {
+			return nil, err
 		}
-
-		// The parse was at least successful enough to have an ID so
-		// set it for the response.
-		responseID = request.ID
-
-		// Setup a close notifier.  Since the connection is hijacked,
-		// the CloseNotifer on the ResponseWriter is not available.
-		closeChan := make(chan struct{}, 1)
-		go func() {
-			_, err := conn.Read(make([]byte, 1))
-			if err != nil {
-				close(closeChan)
-			}
-		}()
-
-		if jsonErr == nil {
-			// Attempt to parse the JSON-RPC request into a known concrete
-			// command.
-			parsedCmd, err := parseCmd(&request)
-			if err != nil {
-				jsonErr = err
-			} else {
-				result, jsonErr = s.standardCmdResult(request.Method, parsedCmd, closeChan)
-			}
+		return s.standardCmdResult(method, cmd, closeChan)
+	})
+	if msg == nil {
+		err = s.writeHTTPResponseHeaders(r, w.Header(), http.StatusNoContent, buf)
+		if err != nil {
+			log.Error("rpc", "rpcResponse", err)
 		}
-	}
-
-	// Marshal the response.
-	msg, err := createMarshalledReply(responseID, result, jsonErr)
-	if err != nil {
-		log.Error("Failed to marshal reply: %v", err)
 		return
 	}
 
@@ -205,10 +305,143 @@ func (s *Server) jsonRPCRead(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// cmdRunner runs a parsed command and returns its result.
+type cmdRunner func(method string, cmd interface{}) (interface{}, error)
+
+// processBody processes the raw body which is either a single JSON-RPC request
+// or a JSON-RPC 2.0 batch, and returns the marshalled reply.  The requests of
+// a batch are processed concurrently, at most BatchLimit at a time.  The reply
+// is nil if there is nothing to reply, i.e. all the requests are notifications.
+func (s *Server) processBody(body []byte, run cmdRunner) []byte {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		return s.processRequest(body, run)
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(trimmed, &batch); err != nil {
+		return marshalError(rpcjson.RPCVersion2, &rpcjson.RPCError{
+			Code:    rpcjson.ErrRPCParse.Code,
+			Message: "Failed to parse request: " + err.Error(),
+		})
+	}
+	if len(batch) == 0 {
+		return marshalError(rpcjson.RPCVersion2, &rpcjson.RPCError{
+			Code:    rpcjson.ErrRPCInvalidRequest.Code,
+			Message: "Empty batch",
+		})
+	}
+
+	replies := make([][]byte, len(batch))
+	sem := make(chan struct{}, s.config.BatchLimit)
+	var wg sync.WaitGroup
+	for i := range batch {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			replies[i] = s.processRequest(batch[i], run)
+		}(i)
+	}
+	wg.Wait()
+
+	// The notifications are not replied.
+	msgs := make([]json.RawMessage, 0, len(replies))
+	for _, reply := range replies {
+		if reply != nil {
+			msgs = append(msgs, reply)
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	msg, err := json.Marshal(msgs)
+	if err != nil {
+		log.Error("Failed to marshal batch reply", "err", err)
+		return nil
+	}
+	return msg
+}
+
+// processRequest processes a single JSON-RPC request and returns the marshalled
+// reply.  The reply is nil if the request is a notification.
+func (s *Server) processRequest(raw []byte, run cmdRunner) []byte {
+	var request rpcjson.Request
+	if err := json.Unmarshal(raw, &request); err != nil {
+		// The members of an invalid request of a batch are not parsed.
+		code := rpcjson.ErrRPCParse.Code
+		if _, ok := err.(*json.UnmarshalTypeError); ok {
+			code = rpcjson.ErrRPCInvalidRequest.Code
+		}
+		return marshalError(rpcjson.RPCVersion2, &rpcjson.RPCError{
+			Code:    code,
+			Message: "Failed to parse request: " + err.Error(),
+		})
+	}
+
+	// The params are not logged, they may carry passphrases and mnemonics.
+	log.Debug("Received RPC request", "method", request.Method, "id", request.ID)
+
+	// JSON-RPC 1.0 requests are replied without the version.
+	var rpcVersion string
+	if request.Jsonrpc == rpcjson.RPCVersion2 {
+		rpcVersion = rpcjson.RPCVersion2
+	}
+
+	// A JSON-RPC 1.0 request without an id is still replied in quirks mode,
+	// the JSON-RPC 2.0 notifications are processed without reply.  The id is
+	// unmarshalled raw as well, since a JSON-RPC 2.0 request with a null id is
+	// not a notification and is replied with the null id.
+	var rawID struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &rawID); err != nil {
+		log.Error("Failed to unmarshal request id", "err", err)
+		return nil
+	}
+	notification := request.IsNotification() && rawID.ID == nil
+	if request.ID == nil && request.Jsonrpc != rpcjson.RPCVersion2 && !(RPCQuirks && request.Jsonrpc == "") {
+		return nil
+	}
+
+	var result interface{}
+	var jsonErr error
+	if request.Method == "" || (request.Jsonrpc != "" && request.Jsonrpc != "1.0" && request.Jsonrpc != rpcjson.RPCVersion2) {
+		jsonErr = rpcjson.ErrRPCInvalidRequest
+	} else if cmd, err := parseCmd(&request); err != nil {
+		jsonErr = err
+	} else {
+		result, jsonErr = run(request.Method, cmd)
+	}
+	if notification {
+		return nil
+	}
+
+	msg, err := createMarshalledReply(rpcVersion, request.ID, result, jsonErr)
+	if err != nil {
+		log.Error("Failed to marshal reply", "err", err)
+		return nil
+	}
+	return msg
+}
+
+// marshalError returns the marshalled reply of an error without id.
+func marshalError(rpcVersion string, jsonErr *rpcjson.RPCError) []byte {
+	msg, err := rpcjson.MarshalResponse(rpcVersion, nil, nil, jsonErr)
+	if err != nil {
+		log.Error("Failed to marshal reply", "err", err)
+		return nil
+	}
+	return msg
+}
+
 // createMarshalledReply returns a new marshalled JSON-RPC response given the
 // passed parameters.  It will automatically convert errors that are not of
 // the type *btcjson.RPCError to the appropriate type as needed.
-func createMarshalledReply(id, result interface{}, replyErr error) ([]byte, error) {
+func createMarshalledReply(rpcVersion string, id, result interface{}, replyErr error) ([]byte, error) {
 	var jsonErr *rpcjson.RPCError
 	if replyErr != nil {
 		if jErr, ok := replyErr.(*rpcjson.RPCError); ok {
@@ -218,21 +451,52 @@ func createMarshalledReply(id, result interface{}, replyErr error) ([]byte, erro
 		}
 	}
 
-	return rpcjson.MarshalResponse(id, result, jsonErr)
+	return rpcjson.MarshalResponse(rpcVersion, id, result, jsonErr)
 }
 
 // standardCmdResult checks that a parsed command is a standard Bitcoin JSON-RPC
 // command and runs the appropriate handler to reply to the command.  Any
 // commands which are not recognized or not implemented will return an error
-// suitable for use in replies.
+// suitable for use in replies.  The handler is replied with a timeout error if
+// it exceeds the execution deadline, the closeChan passed to the handler is
+// closed at the deadline so it can stop its work.  The state changing methods
+// have no deadline, so their replies always tell the result.
 func (s *Server) standardCmdResult(method string, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	handler, ok := handlerPool[method]
 	if !ok {
 		log.Error("ErrRPCMethodNotFound", method)
 		return nil, rpcjson.ErrRPCMethodNotFound
 	}
+	if s.config.Timeout <= 0 || noDeadlineMethods[method] {
+		return handler(s, cmd, closeChan)
+	}
+
+	deadline, cancel := s.withDeadline(closeChan)
+	defer cancel()
+
+	type reply struct {
+		result interface{}
+		err    error
+	}
+	replyChan := make(chan reply, 1)
+	go func() {
+		result, err := handler(s, cmd, deadline)
+		replyChan <- reply{result, err}
+	}()
 
-	return handler(s, cmd, closeChan)
+	select {
+	case r := <-replyChan:
+		return r.result, r.err
+	case <-deadline:
+		select {
+		case <-closeChan:
+			// The client is gone, it is not a timeout.
+		default:
+			atomic.AddUint64(&s.metrics.timeout, 1)
+			log.Warn("RPC request exceeds the execution deadline", "method", method, "timeout", s.config.Timeout)
+		}
+		return nil, errRPCTimeout
+	}
 }
 
 // parseCmd parses a JSON-RPC request rpcobject into known concrete command.  The
@@ -240,15 +504,26 @@ func (s *Server) standardCmdResult(method string, cmd interface{}, closeChan <-c
 // is suitable for use in replies if the command is invalid in some way such as
 // an unregistered command or invalid parameters.
 func parseCmd(request *rpcjson.Request) (interface{}, error) {
-	if request.Params == nil {
+	if _, ok := handlerPool[request.Method]; !ok {
+		if _, ok := cmdPool[request.Method]; !ok {
+			return nil, rpcjson.ErrRPCMethodNotFound
+		}
+	}
+
+	rtp, ok := cmdPool[request.Method]
+	if !ok {
+		// The command has no params.
 		return nil, nil
 	}
+	if len(request.Params) == 0 || string(request.Params) == "null" {
+		return nil, rpcjson.NewRPCError(rpcjson.ErrRPCInvalidParams.Code, "Missing params")
+	}
 
-	rtp := cmdPool[request.Method]
 	cmd := reflect.New(rtp.Elem()).Interface()
-	err := json.Unmarshal(request.Params, cmd)
-
-	return cmd, err
+	if err := json.Unmarshal(request.Params, cmd); err != nil {
+		return nil, rpcjson.NewRPCError(rpcjson.ErrRPCInvalidParams.Code, "Invalid params: "+err.Error())
+	}
+	return cmd, nil
 }
 
 // writeHTTPResponseHeaders writes the necessary response headers prior to
@@ -295,6 +570,7 @@ func (s *Server) decrementClients() {
 // This function is safe for concurrent access.
 func (s *Server) limitConnections(w http.ResponseWriter, remoteAddr string) bool {
 	if int(atomic.LoadInt32(&s.numClients)+1) > RPCMaxClients {
+		atomic.AddUint64(&s.metrics.tooManyClients, 1)
 		log.Info("Max RPC clients exceeded [%d] - "+
 			"disconnecting client %s", RPCMaxClients,
 			remoteAddr)
@@ -0,0 +1,131 @@
+package rpcserver
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mihongtech/linkchain/rpc/rpcjson"
+)
+
+func newTestServer() *Server {
+	s, _ := NewRPCServer(&Config{}, nil)
+	return s
+}
+
+//countRunner counts the runs and replies the method.
+func countRunner(runs *int32) cmdRunner {
+	return func(method string, cmd interface{}) (interface{}, error) {
+		atomic.AddInt32(runs, 1)
+		return method, nil
+	}
+}
+
+func TestProcessBatch(t *testing.T) {
+	s := newTestServer()
+	body := `[
+		{"jsonrpc":"2.0","method":"getBlockByHeight","params":{"height":1},"id":1},
+		{"jsonrpc":"2.0","method":"getBlockByHeight","params":{"height":2}},
+		{"jsonrpc":"2.0","method":"unknownMethod","id":"x"},
+		{"jsonrpc":"2.0","method":"getBlockByHeight","params":"bad","id":3},
+		1
+	]`
+
+	var runs int32
+	var replies []rpcjson.Response
+	if err := json.Unmarshal(s.processBody([]byte(body), countRunner(&runs)), &replies); err != nil {
+		t.Fatalf("unmarshal batch reply failed: %v", err)
+	}
+	if runs != 2 {
+		t.Fatalf("runs mismatch: have %d, want 2", runs)
+	}
+	if len(replies) != 4 {
+		t.Fatalf("replies mismatch: have %d, want 4", len(replies))
+	}
+
+	if replies[0].Error != nil || string(replies[0].Result) != `"getBlockByHeight"` || replies[0].Jsonrpc != rpcjson.RPCVersion2 {
+		t.Errorf("reply 0 mismatch: %+v", replies[0])
+	}
+	wantCodes := []rpcjson.RPCErrorCode{
+		rpcjson.ErrRPCMethodNotFound.Code,
+		rpcjson.ErrRPCInvalidParams.Code,
+		rpcjson.ErrRPCInvalidRequest.Code,
+	}
+	for i, code := range wantCodes {
+		reply := replies[i+1]
+		if reply.Error == nil || reply.Error.Code != code {
+			t.Errorf("reply %d error mismatch: have %v, want %d", i+1, reply.Error, code)
+		}
+	}
+}
+
+func TestProcessNotifications(t *testing.T) {
+	s := newTestServer()
+
+	var runs int32
+	body := `{"jsonrpc":"2.0","method":"getBlockByHeight","params":{"height":1}}`
+	if reply := s.processBody([]byte(body), countRunner(&runs)); reply != nil {
+		t.Errorf("notification replied: %s", reply)
+	}
+	body = `[` + body + `,` + body + `]`
+	if reply := s.processBody([]byte(body), countRunner(&runs)); reply != nil {
+		t.Errorf("batch of notifications replied: %s", reply)
+	}
+	if runs != 3 {
+		t.Fatalf("runs mismatch: have %d, want 3", runs)
+	}
+}
+
+func TestProcessNullID(t *testing.T) {
+	s := newTestServer()
+
+	var runs int32
+	body := `{"jsonrpc":"2.0","method":"getBlockByHeight","params":{"height":1},"id":null}`
+	var reply map[string]json.RawMessage
+	if err := json.Unmarshal(s.processBody([]byte(body), countRunner(&runs)), &reply); err != nil {
+		t.Fatalf("unmarshal reply failed: %v", err)
+	}
+	if id, ok := reply["id"]; !ok || string(id) != "null" || string(reply["result"]) != `"getBlockByHeight"` {
+		t.Errorf("reply mismatch: %s", reply)
+	}
+
+	var replies []rpcjson.Response
+	body = `[` + body + `,{"jsonrpc":"2.0","method":"getBlockByHeight","params":{"height":2}}]`
+	if err := json.Unmarshal(s.processBody([]byte(body), countRunner(&runs)), &replies); err != nil {
+		t.Fatalf("unmarshal batch reply failed: %v", err)
+	}
+	if len(replies) != 1 {
+		t.Errorf("replies mismatch: have %d, want 1", len(replies))
+	}
+	if runs != 3 {
+		t.Fatalf("runs mismatch: have %d, want 3", runs)
+	}
+}
+
+func TestProcessInvalidBody(t *testing.T) {
+	s := newTestServer()
+
+	tests := []struct {
+		body string
+		code rpcjson.RPCErrorCode
+	}{
+		{`{"jsonrpc":"2.0","method"`, rpcjson.ErrRPCParse.Code},
+		{`[`, rpcjson.ErrRPCParse.Code},
+		{`[]`, rpcjson.ErrRPCInvalidRequest.Code},
+		{`{"jsonrpc":"2.0","id":1}`, rpcjson.ErrRPCInvalidRequest.Code},
+		{`{"jsonrpc":"2.0","method":"getBlockByHeight","id":1}`, rpcjson.ErrRPCInvalidParams.Code},
+	}
+	for i, test := range tests {
+		var runs int32
+		var reply rpcjson.Response
+		if err := json.Unmarshal(s.processBody([]byte(test.body), countRunner(&runs)), &reply); err != nil {
+			t.Fatalf("test %d: unmarshal reply failed: %v", i, err)
+		}
+		if reply.Error == nil || reply.Error.Code != test.code {
+			t.Errorf("test %d: error mismatch: have %v, want %d", i, reply.Error, test.code)
+		}
+		if runs != 0 {
+			t.Errorf("test %d: invalid request is run", i)
+		}
+	}
+}
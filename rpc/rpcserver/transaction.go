@@ -6,6 +6,8 @@ import (
 
 	"github.com/mihongtech/linkchain/common/math"
 	"github.com/mihongtech/linkchain/common/util/log"
+	"github.com/mihongtech/linkchain/helper"
+	"github.com/mihongtech/linkchain/node"
 	"github.com/mihongtech/linkchain/rpc/rpcobject"
 )
 
@@ -29,3 +31,24 @@ func getTxByHash(s *Server, cmd interface{}, closeChan <-chan struct{}) (interfa
 
 	return &rpcobject.TransactionWithIDRSP{transaction.GetTxID().GetString(), transaction}, nil
 }
+
+//Validate the pre-signed raw tx and broadcast it to the network.
+func sendRawTransaction(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c, ok := cmd.(*rpcobject.SendRawTransactionCmd)
+	if !ok {
+		log.Error("sendRawTransaction ", "Type error:", reflect.TypeOf(cmd))
+		return nil, nil
+	}
+
+	transaction, err := helper.DecodeRawTransaction(c.Tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = GetTxpoolAPI(s).ProcessTx(transaction); err != nil {
+		return nil, err
+	}
+	GetNodeAPI(s).GetTxEvent().Send(node.TxEvent{transaction})
+
+	return &rpcobject.TransactionWithIDRSP{transaction.GetTxID().GetString(), transaction}, nil
+}
@@ -2,14 +2,21 @@ package rpcserver
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/mihongtech/linkchain/common/math"
 	"github.com/mihongtech/linkchain/common/util/log"
 	"github.com/mihongtech/linkchain/core/meta"
 	"github.com/mihongtech/linkchain/helper"
 	"github.com/mihongtech/linkchain/node"
 	"github.com/mihongtech/linkchain/rpc/rpcobject"
+	"github.com/mihongtech/linkchain/wallet"
 )
 
 func getWalletInfo(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
@@ -80,10 +87,15 @@ func getAccountInfo(s *Server, cmd interface{}, closeChan <-chan struct{}) (inte
 }
 
 func newAcount(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	a, err := GetWalletAPI(s).NewAccount()
-	if err != nil {
+	c, ok := cmd.(*rpcobject.NewAccountCmd)
+	if !ok {
+		fmt.Println("Type error:", reflect.TypeOf(cmd))
 		return nil, nil
 	}
+	a, err := GetWalletAPI(s).NewAccount(c.Passphrase)
+	if err != nil {
+		return nil, err
+	}
 	return a.String(), nil
 }
 
@@ -96,21 +108,22 @@ func sendMoneyTransaction(s *Server, cmd interface{}, closeChan <-chan struct{})
 	bestHeight := GetNodeAPI(s).GetBestBlock().GetHeight()
 	amount := meta.NewAmount(int64(c.Amount))
 	toID, err := helper.CreateAccountIdByAddress(c.ToAccountId)
+	if err != nil {
+		return nil, err
+	}
 	toCoin := helper.CreateToCoin(*toID, amount)
 
-	from, err := GetWalletAPI(s).GetAccount(c.FromAccountId)
+	fromID, err := helper.CreateAccountIdByAddress(c.FromAccountId)
 	if err != nil {
 		return nil, err
 	}
-	fromCoin, fromAmount, err := from.MakeFromCoin(amount, bestHeight)
+	opts, err := parseCoinSelectOptions(&c.CoinSelectCmd)
 	if err != nil {
 		return nil, err
 	}
-
-	transaction := helper.CreateTransaction(*fromCoin, *toCoin)
-	backChange := helper.CreateToCoin(from.Id, fromAmount.Subtraction(*amount))
-	if backChange.Value.GetInt64() > 0 {
-		transaction.AddToCoin(*backChange)
+	transaction, err := GetWalletAPI(s).CreateTransaction(*fromID, []meta.ToCoin{*toCoin}, opts, bestHeight)
+	if err != nil {
+		return nil, err
 	}
 
 	transaction, err = GetWalletAPI(s).SignTransaction(*transaction)
@@ -125,13 +138,126 @@ func sendMoneyTransaction(s *Server, cmd interface{}, closeChan <-chan struct{})
 	return &rpcobject.TransactionWithIDRSP{transaction.GetTxID().GetString(), transaction}, err
 }
 
+func sendMany(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c, ok := cmd.(*rpcobject.SendManyCmd)
+	if !ok {
+		fmt.Println("Type error:", reflect.TypeOf(cmd))
+		return nil, nil
+	}
+	if len(c.Amounts) == 0 {
+		return nil, errors.New("no recipient")
+	}
+	opts, err := parseCoinSelectOptions(&c.CoinSelectCmd)
+	if err != nil {
+		return nil, err
+	}
+
+	//the recipients are sorted, so the txs are the same for the same cmd
+	recipients := make([]string, 0, len(c.Amounts))
+	for key := range c.Amounts {
+		recipients = append(recipients, key)
+	}
+	sort.Strings(recipients)
+	toCoins := make([]meta.ToCoin, 0, len(recipients))
+	for _, key := range recipients {
+		toID, err := helper.CreateAccountIdByAddress(key)
+		if err != nil {
+			return nil, err
+		}
+		toCoins = append(toCoins, *helper.CreateToCoin(*toID, meta.NewAmount(c.Amounts[key])))
+	}
+
+	froms := make([]meta.AccountID, 0, len(c.FromAccountIds))
+	for _, key := range c.FromAccountIds {
+		fromID, err := helper.CreateAccountIdByAddress(key)
+		if err != nil {
+			return nil, err
+		}
+		froms = append(froms, *fromID)
+	}
+	if len(froms) == 0 {
+		for _, account := range GetWalletAPI(s).GetAllWAccount() {
+			froms = append(froms, account.Id)
+		}
+		sort.Slice(froms, func(i, j int) bool {
+			return froms[i].String() < froms[j].String()
+		})
+	}
+	if c.ChangeAccountId != "" {
+		if opts.Change, err = helper.CreateAccountIdByAddress(c.ChangeAccountId); err != nil {
+			return nil, err
+		}
+	}
+
+	bestHeight := GetNodeAPI(s).GetBestBlock().GetHeight()
+	txs, err := GetWalletAPI(s).CreateTransactions(froms, toCoins, opts, bestHeight)
+	if err != nil {
+		return nil, err
+	}
+	//sign all txs before sending any of them, so a locked account sends nothing
+	for i := range txs {
+		if txs[i], err = GetWalletAPI(s).SignTransaction(*txs[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	rsp := &rpcobject.SendManyRSP{Txs: make([]*rpcobject.TransactionWithIDRSP, 0, len(txs))}
+	for i, tx := range txs {
+		if err := GetTxpoolAPI(s).ProcessTx(tx); err != nil {
+			sent := make([]string, 0, len(rsp.Txs))
+			for _, t := range rsp.Txs {
+				sent = append(sent, t.ID)
+			}
+			return nil, fmt.Errorf("send tx %d of %d failed: %v, the sent txs: %v", i+1, len(txs), err, sent)
+		}
+		GetNodeAPI(s).GetTxEvent().Send(node.TxEvent{tx})
+		rsp.Txs = append(rsp.Txs, &rpcobject.TransactionWithIDRSP{tx.GetTxID().GetString(), tx})
+	}
+	return rsp, nil
+}
+
+func parseCoinSelectOptions(c *rpcobject.CoinSelectCmd) (*wallet.CoinSelectOptions, error) {
+	tickets, err := parseTickets(c.Tickets)
+	if err != nil {
+		return nil, err
+	}
+	return &wallet.CoinSelectOptions{
+		Strategy:    c.Strategy,
+		FeeRate:     c.FeeRate,
+		DustLimit:   c.DustLimit,
+		Tickets:     tickets,
+		Consolidate: c.Consolidate,
+	}, nil
+}
+
+//parse the tickets in the form of txid:index
+func parseTickets(strs []string) ([]meta.Ticket, error) {
+	tickets := make([]meta.Ticket, 0, len(strs))
+	for _, str := range strs {
+		parts := strings.Split(str, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid ticket %q, it must be txid:index", str)
+		}
+		txid, err := math.NewHashFromStr(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid txid of ticket %q: %v", str, err)
+		}
+		index, err := strconv.ParseUint(parts[1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index of ticket %q: %v", str, err)
+		}
+		tickets = append(tickets, *meta.NewTicket(*txid, uint32(index)))
+	}
+	return tickets, nil
+}
+
 func importAccount(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c, ok := cmd.(*rpcobject.ImportAccountCmd)
 	if !ok {
 		fmt.Println("Type error:", reflect.TypeOf(cmd))
 		return nil, nil
 	}
-	accountId, err := GetWalletAPI(s).ImportAccount(c.Signer)
+	accountId, err := GetWalletAPI(s).ImportAccount(c.Signer, c.Passphrase)
 
 	if err != nil {
 		log.Error("importSigner ", "error", err)
@@ -150,7 +276,112 @@ func exportAccount(s *Server, cmd interface{}, closeChan <-chan struct{}) (inter
 	if err != nil {
 		return "", err
 	}
-	privateKey, err := GetWalletAPI(s).ExportAccount(*accountId)
+	privateKey, err := GetWalletAPI(s).ExportAccount(*accountId, c.Passphrase)
 
 	return privateKey, err
 }
+
+func unlockAccount(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c, ok := cmd.(*rpcobject.UnlockAccountCmd)
+	if !ok {
+		fmt.Println("Type error:", reflect.TypeOf(cmd))
+		return nil, nil
+	}
+	accountId, err := meta.NewAccountIdFromStr(c.AccountId)
+	if err != nil {
+		return nil, err
+	}
+	duration := wallet.DefaultUnlockDuration
+	if c.Duration != nil {
+		if *c.Duration > uint64(time.Duration(1<<63-1)/time.Second) {
+			return nil, errors.New("unlock duration is too large")
+		}
+		duration = time.Duration(*c.Duration) * time.Second
+	}
+	if err := GetWalletAPI(s).UnlockAccount(*accountId, c.Passphrase, duration); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func lockAccount(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c, ok := cmd.(*rpcobject.LockAccountCmd)
+	if !ok {
+		fmt.Println("Type error:", reflect.TypeOf(cmd))
+		return nil, nil
+	}
+	accountId, err := meta.NewAccountIdFromStr(c.AccountId)
+	if err != nil {
+		return nil, err
+	}
+	if err := GetWalletAPI(s).LockAccount(*accountId); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func migrateAccounts(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c, ok := cmd.(*rpcobject.MigrateAccountsCmd)
+	if !ok {
+		fmt.Println("Type error:", reflect.TypeOf(cmd))
+		return nil, nil
+	}
+	ids, hdMigrated, err := GetWalletAPI(s).MigrateLegacyPassword(c.Passphrase)
+	if err != nil {
+		log.Error("migrateAccounts ", "error", err)
+		return nil, err
+	}
+	accounts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		accounts = append(accounts, id.String())
+	}
+	return &rpcobject.MigrateAccountsRSP{Accounts: accounts, HDWallet: hdMigrated}, nil
+}
+
+func newMnemonic(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c, ok := cmd.(*rpcobject.NewMnemonicCmd)
+	if !ok {
+		fmt.Println("Type error:", reflect.TypeOf(cmd))
+		return nil, nil
+	}
+	return wallet.NewMnemonic(c.Bits)
+}
+
+func importMnemonic(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c, ok := cmd.(*rpcobject.ImportMnemonicCmd)
+	if !ok {
+		fmt.Println("Type error:", reflect.TypeOf(cmd))
+		return nil, nil
+	}
+	info, err := GetWalletAPI(s).ImportMnemonic(c.Mnemonic, c.Passphrase, c.Path, c.Password)
+	if err != nil {
+		log.Error("importMnemonic ", "error", err)
+		return nil, err
+	}
+	return &rpcobject.HDWalletInfoRSP{Path: info.Path, Next: info.Next}, nil
+}
+
+func getHDWalletInfo(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	info, err := GetWalletAPI(s).GetHDWalletInfo()
+	if err != nil {
+		return nil, err
+	}
+	return &rpcobject.HDWalletInfoRSP{Path: info.Path, Next: info.Next}, nil
+}
+
+func discoverHDAccounts(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c, ok := cmd.(*rpcobject.DiscoverHDAccountsCmd)
+	if !ok {
+		fmt.Println("Type error:", reflect.TypeOf(cmd))
+		return nil, nil
+	}
+	ids, err := GetWalletAPI(s).DiscoverHDAccounts(c.Gap, c.Password)
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		accounts = append(accounts, id.String())
+	}
+	return accounts, nil
+}
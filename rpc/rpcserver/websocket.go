@@ -0,0 +1,359 @@
+package rpcserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mihongtech/linkchain/common/util/event"
+	"github.com/mihongtech/linkchain/common/util/log"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/node"
+	"github.com/mihongtech/linkchain/rpc/rpcjson"
+	"github.com/mihongtech/linkchain/rpc/rpcobject"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// RPCMaxWebsockets is the max number of websocket clients.
+	RPCMaxWebsockets = 25
+
+	// websocketSendBufferSize is the number of replies and notifications
+	// which can be queued for a websocket client.  The client is disconnected
+	// if it can not keep up.
+	websocketSendBufferSize = 256
+
+	// subscriptionNotifyMethod is the method of the notification which is sent
+	// to websocket client for every subscribed event.
+	subscriptionNotifyMethod = "subscription"
+
+	// subscription types
+	subscriptionNewHeads            = "newHeads"
+	subscriptionPendingTransactions = "pendingTransactions"
+	subscriptionLogs                = "logs"
+)
+
+var errSubscriptionNotFound = errors.New("subscription not found")
+
+// wsCommandHandler describes a callback function used to handle the commands
+// which are only available to websocket clients.
+type wsCommandHandler func(*wsClient, interface{}) (interface{}, error)
+
+// wsHandlers maps the websocket only commands to their handlers.
+var wsHandlers = map[string]wsCommandHandler{
+	"subscribe":   handleSubscribe,
+	"unsubscribe": handleUnsubscribe,
+}
+
+// wsClient is a websocket client, it serves the same commands as the http
+// server and pushes the events of its subscriptions.
+type wsClient struct {
+	server *Server
+	conn   *websocket.Conn
+	addr   string
+	cred   *credential
+
+	subsMtx sync.Mutex
+	subs    map[string]event.Subscription
+
+	sendChan chan []byte
+	quit     chan struct{}
+	quitOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// websocketHandler handles a new websocket client until it is disconnected.
+func (s *Server) websocketHandler(conn *websocket.Conn, cred *credential) {
+	addr := conn.Request().RemoteAddr
+
+	// Limit the number of websockets to max allowed.
+	if int(atomic.AddInt32(&s.numWebsockets, 1)) > RPCMaxWebsockets {
+		atomic.AddInt32(&s.numWebsockets, -1)
+		atomic.AddUint64(&s.metrics.tooManyClients, 1)
+		log.Info("Max websocket clients exceeded", "max", RPCMaxWebsockets, "client", addr)
+		conn.Close()
+		return
+	}
+	defer atomic.AddInt32(&s.numWebsockets, -1)
+
+	// Clear the read deadline which is set by the http server for the
+	// handshake, the websocket is long lived.
+	conn.SetReadDeadline(time.Time{})
+
+	client := &wsClient{
+		server:   s,
+		conn:     conn,
+		addr:     addr,
+		cred:     cred,
+		subs:     make(map[string]event.Subscription),
+		sendChan: make(chan []byte, websocketSendBufferSize),
+		quit:     make(chan struct{}),
+	}
+	log.Info("New websocket client", "client", addr)
+
+	client.wg.Add(1)
+	go client.outHandler()
+	client.inHandler()
+
+	client.disconnect()
+	client.unsubscribeAll()
+	client.wg.Wait()
+	log.Info("Disconnected websocket client", "client", addr)
+}
+
+// inHandler reads the requests of client and replies them in order, a message
+// is either a single request or a batch.
+func (c *wsClient) inHandler() {
+	for {
+		var msg []byte
+		if err := websocket.Message.Receive(c.conn, &msg); err != nil {
+			return
+		}
+
+		reply := c.server.processBody(msg, c.runCmd)
+		if reply != nil && !c.send(reply) {
+			return
+		}
+	}
+}
+
+// runCmd runs the websocket only commands by their handlers and the others
+// in the same way as the http server.
+func (c *wsClient) runCmd(method string, cmd interface{}) (interface{}, error) {
+	if err := c.server.limitRequest(remoteHost(c.addr), c.cred, method); err != nil {
+		return nil, err
+	}
+	if handler, ok := wsHandlers[method]; ok {
+		return handler(c, cmd)
+	}
+	return c.server.standardCmdResult(method, cmd, c.quit)
+}
+
+// outHandler writes the queued replies and notifications to client.
+func (c *wsClient) outHandler() {
+	defer c.wg.Done()
+	for {
+		select {
+		case msg := <-c.sendChan:
+			if err := websocket.Message.Send(c.conn, string(msg)); err != nil {
+				c.disconnect()
+				return
+			}
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// send queues the message to client, the client is disconnected if its queue
+// is full.  It returns false if the client is disconnected.
+func (c *wsClient) send(msg []byte) bool {
+	select {
+	case c.sendChan <- msg:
+		return true
+	case <-c.quit:
+		return false
+	default:
+		log.Warn("Websocket client is too slow, disconnecting", "client", c.addr)
+		c.disconnect()
+		return false
+	}
+}
+
+// notify sends the result of subscription to client.
+func (c *wsClient) notify(id string, result interface{}) bool {
+	msg, err := rpcjson.MarshalCmd(nil, subscriptionNotifyMethod, &rpcobject.SubscriptionRSP{id, result})
+	if err != nil {
+		log.Error("Failed to marshal subscription notification", "subscription", id, "err", err)
+		return true
+	}
+	return c.send(msg)
+}
+
+// disconnect closes the client, it is safe to be called by the subscriptions.
+func (c *wsClient) disconnect() {
+	c.quitOnce.Do(func() {
+		close(c.quit)
+		c.conn.Close()
+	})
+}
+
+// unsubscribeAll closes all the subscriptions of the disconnected client.
+func (c *wsClient) unsubscribeAll() {
+	c.subsMtx.Lock()
+	defer c.subsMtx.Unlock()
+	for id, sub := range c.subs {
+		sub.Unsubscribe()
+		delete(c.subs, id)
+	}
+}
+
+func handleSubscribe(c *wsClient, cmd interface{}) (interface{}, error) {
+	sc, ok := cmd.(*rpcobject.SubscribeCmd)
+	if !ok {
+		fmt.Println("Type error:", reflect.TypeOf(cmd))
+		return nil, nil
+	}
+
+	id, err := newRandomID()
+	if err != nil {
+		return nil, err
+	}
+
+	var sub event.Subscription
+	switch sc.Type {
+	case subscriptionNewHeads:
+		sub = c.subscribeNewHeads(id)
+	case subscriptionPendingTransactions:
+		sub = c.subscribePendingTransactions(id)
+	case subscriptionLogs:
+		filter := sc.Filter
+		if filter == nil {
+			filter = &rpcobject.FilterCmd{}
+		}
+		f, err := newLogFilter(c.server, filter)
+		if err != nil {
+			return nil, err
+		}
+		sub = c.subscribeLogs(id, f)
+	default:
+		return nil, fmt.Errorf("unknown subscription type %q", sc.Type)
+	}
+
+	c.subsMtx.Lock()
+	defer c.subsMtx.Unlock()
+	select {
+	case <-c.quit:
+		sub.Unsubscribe()
+		return nil, errors.New("websocket client is disconnected")
+	default:
+	}
+	c.subs[id] = sub
+	return id, nil
+}
+
+func handleUnsubscribe(c *wsClient, cmd interface{}) (interface{}, error) {
+	uc, ok := cmd.(*rpcobject.UnsubscribeCmd)
+	if !ok {
+		fmt.Println("Type error:", reflect.TypeOf(cmd))
+		return nil, nil
+	}
+
+	c.subsMtx.Lock()
+	defer c.subsMtx.Unlock()
+	sub, ok := c.subs[uc.ID]
+	if !ok {
+		return nil, errSubscriptionNotFound
+	}
+	sub.Unsubscribe()
+	delete(c.subs, uc.ID)
+	return true, nil
+}
+
+// subscribeNewHeads pushes every new head of main chain.
+func (c *wsClient) subscribeNewHeads(id string) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		headCh := make(chan meta.ChainHeadEvent, websocketSendBufferSize)
+		headSub := GetNodeAPI(c.server).SubscribeChainHeadEvent(headCh)
+		defer headSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-headCh:
+				if !c.notify(id, getBlockObject(ev.Block)) {
+					return nil
+				}
+			case err := <-headSub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
+
+// subscribePendingTransactions pushes every tx which is accepted by tx pool.
+func (c *wsClient) subscribePendingTransactions(id string) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		txCh := make(chan node.TxEvent, websocketSendBufferSize)
+		txSub := GetNodeAPI(c.server).GetTxEvent().Subscribe(txCh)
+		defer txSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-txCh:
+				if !c.notify(id, &rpcobject.TransactionWithIDRSP{ev.Tx.GetTxID().GetString(), ev.Tx}) {
+					return nil
+				}
+			case err := <-txSub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
+
+// subscribeLogs pushes the matched logs of every new head of main chain.
+func (c *wsClient) subscribeLogs(id string, f *logFilter) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		headCh := make(chan meta.ChainHeadEvent, websocketSendBufferSize)
+		headSub := GetNodeAPI(c.server).SubscribeChainHeadEvent(headCh)
+		defer headSub.Unsubscribe()
+
+		for {
+			select {
+			case <-headCh:
+				logs, err := f.poll(c.server)
+				if err != nil {
+					log.Error("Failed to poll logs of subscription", "subscription", id, "err", err)
+					continue
+				}
+				for _, l := range logs {
+					if !c.notify(id, l) {
+						return nil
+					}
+				}
+			case err := <-headSub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
+
+// websocketServer returns the http handler of the authenticated websocket
+// client.  The origin of browsers must be allowed by WSOrigins, the non browser
+// clients without origin can connect too.
+func (s *Server) websocketServer(cred *credential) http.Handler {
+	return websocket.Server{
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			return s.checkOrigin(config.Origin)
+		},
+		Handler: func(conn *websocket.Conn) {
+			s.websocketHandler(conn, cred)
+		},
+	}
+}
+
+// checkOrigin returns an error if the websocket origin is not allowed.  A nil
+// origin is allowed as the client is not a browser.
+func (s *Server) checkOrigin(origin *url.URL) error {
+	if origin == nil {
+		return nil
+	}
+	for _, allowed := range s.config.WSOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin.Scheme+"://"+origin.Host) {
+			return nil
+		}
+	}
+	return fmt.Errorf("websocket origin %s is not allowed", origin)
+}
@@ -0,0 +1,39 @@
+package rpcserver
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	s, _ := NewRPCServer(&Config{WSOrigins: []string{"https://wallet.example.com/"}}, nil)
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"", true},
+		{"https://wallet.example.com", true},
+		{"HTTPS://Wallet.Example.com", true},
+		{"http://wallet.example.com", false},
+		{"https://evil.example.com", false},
+	}
+	for _, test := range tests {
+		var origin *url.URL
+		if test.origin != "" {
+			origin, _ = url.ParseRequestURI(test.origin)
+		}
+		if err := s.checkOrigin(origin); (err == nil) != test.allowed {
+			t.Errorf("origin %q allowed mismatch: have %v, want %v", test.origin, err == nil, test.allowed)
+		}
+	}
+
+	// No browser is allowed by default, and any is allowed by the wildcard
+	origin, _ := url.ParseRequestURI("http://localhost:8080")
+	if err := newTestServer().checkOrigin(origin); err == nil {
+		t.Errorf("origin is allowed by default")
+	}
+	s, _ = NewRPCServer(&Config{WSOrigins: []string{"*"}}, nil)
+	if err := s.checkOrigin(origin); err != nil {
+		t.Errorf("origin is not allowed by wildcard: %v", err)
+	}
+}
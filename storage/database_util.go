@@ -1,7 +1,7 @@
 package storage
 
 import (
-	_ "bytes"
+	"bytes"
 	"encoding/binary"
 	"encoding/json"
 	"errors"
@@ -33,6 +33,7 @@ var (
 	headBlockKey = []byte("LastBlock")
 	headFastKey  = []byte("LastFast")
 	trieSyncKey  = []byte("TrieSync")
+	pruneKey     = []byte("LastPruned")
 
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`).
 	blockPrefix         = []byte("h")    // blockPrefix + num (uint64 big endian) + hash -> block
@@ -49,6 +50,9 @@ var (
 	// ChainSketch index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
 
+	bloomSectionsSuffix    = []byte("count") // BloomBitsIndexPrefix + bloomSectionsSuffix -> number of indexed sections (uint64 big endian)
+	bloomSectionHeadSuffix = []byte("shead") // BloomBitsIndexPrefix + bloomSectionHeadSuffix + section (uint64 big endian) -> section head hash
+
 	// used by old db, now only used for conversion
 	oldReceiptsPrefix = []byte("receipts-")
 	oldTxMetaSuffix   = []byte{0x01}
@@ -129,22 +133,89 @@ func GetTrieSyncProgress(db DatabaseReader) uint64 {
 	return new(big.Int).SetBytes(data).Uint64()
 }
 
+// GetPruneProgress retrieves the height below which the states of blocks have
+// been pruned, the blocks from it are checked again by the next pruning.
+func GetPruneProgress(db DatabaseReader) uint64 {
+	data, _ := db.Get(pruneKey)
+	if len(data) == 0 {
+		return 0
+	}
+	return new(big.Int).SetBytes(data).Uint64()
+}
+
+// GetBlockHashes retrieves the hashes of all the blocks of the number, including
+// the blocks of side chains.
+func GetBlockHashes(db lcdb.Database, number uint64) ([]math.Hash, error) {
+	prefix := append(append([]byte{}, blockPrefix...), encodeBlockNumber(number)...)
+	hashes := make([]math.Hash, 0, 1)
+	err := lcdb.IterateKeys(db, prefix, func(key []byte) bool {
+		if len(key) == len(prefix)+math.HashSize {
+			hashes = append(hashes, math.BytesToHash(key[len(prefix):]))
+		}
+		return true
+	})
+	return hashes, err
+}
+
+// GetTd retrieves a block's total weight in the canonical chain corresponding
+// to the hash, nil if it's not found.
+func GetTd(db DatabaseReader, hash math.Hash, number uint64) *big.Int {
+	data, _ := db.Get(tdKey(hash, number))
+	if len(data) == 0 {
+		return nil
+	}
+	return new(big.Int).SetBytes(data)
+}
+
 // GetHeaderBytes retrieves a block header in its raw database encoding, or nil
 // if the header's not found.
+// The blocks frozen in the ancient store are read from it.
 func GetBlockBytes(db DatabaseReader, hash math.Hash, number uint64) []byte {
 	data, _ := db.Get(blockKey(hash, number))
+	if len(data) == 0 {
+		data = getAncient(db, freezerBlockTable, hash, number)
+	}
 	return data
 }
 
 func HasBlock(db DatabaseReader, hash math.Hash, number uint64) bool {
-	ok, _ := db.Has(blockKey(hash, number))
-	return ok
+	if ok, _ := db.Has(blockKey(hash, number)); ok {
+		return true
+	}
+	return len(getAncient(db, freezerBlockTable, hash, number)) > 0
+}
+
+// GetHeader retrieves the header of a block, the header of a block whose body is
+// pruned is still read from the ancient store.
+func GetHeader(db DatabaseReader, hash math.Hash, number uint64) *meta.BlockHeader {
+	if data := getAncient(db, freezerHeaderTable, hash, number); len(data) > 0 {
+		var h protobuf.BlockHeader
+		if err := proto.Unmarshal(data, &h); err != nil {
+			log.Error("decode block header failed", "err", err)
+			return nil
+		}
+		header := &meta.BlockHeader{}
+		if err := header.Deserialize(&h); err != nil {
+			log.Error("decode block header failed", "err", err)
+			return nil
+		}
+		return header
+	}
+	block := GetBlock(db, hash, number)
+	if block == nil {
+		return nil
+	}
+	return &block.Header
 }
 
 func blockKey(hash math.Hash, number uint64) []byte {
 	return append(append(blockPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
 
+func tdKey(hash math.Hash, number uint64) []byte {
+	return append(blockKey(hash, number), tdSuffix...)
+}
+
 // GetBlock retrieves an entire block corresponding to the hash, assembling it
 // back from the stored header and body. If either the header or body could not
 // be retrieved nil is returned.
@@ -217,6 +288,24 @@ func GetBloomBits(db DatabaseReader, bit uint, section uint64, head math.Hash) (
 	return db.Get(key)
 }
 
+// GetBloomSections retrieves the number of bloom bits sections which have been indexed.
+func GetBloomSections(db DatabaseReader) uint64 {
+	data, _ := db.Get(append(append([]byte{}, BloomBitsIndexPrefix...), bloomSectionsSuffix...))
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// GetBloomSectionHead retrieves the last block hash of an indexed bloom bits section.
+func GetBloomSectionHead(db DatabaseReader, section uint64) math.Hash {
+	data, _ := db.Get(bloomSectionHeadKey(section))
+	if len(data) == 0 {
+		return math.Hash{}
+	}
+	return math.BytesToHash(data)
+}
+
 // WriteCanonicalHash stores the canonical hash for the given block number.
 func WriteCanonicalHash(db lcdb.Putter, hash math.Hash, number uint64) error {
 	key := append(append(blockPrefix, encodeBlockNumber(number)...), numSuffix...)
@@ -251,6 +340,23 @@ func WriteTrieSyncProgress(db lcdb.Putter, count uint64) error {
 	return nil
 }
 
+// WritePruneProgress stores the height below which the states of blocks have
+// been pruned.
+func WritePruneProgress(db lcdb.Putter, number uint64) error {
+	if err := db.Put(pruneKey, new(big.Int).SetUint64(number).Bytes()); err != nil {
+		log.Crit("Failed to store state prune progress", "err", err)
+	}
+	return nil
+}
+
+// WriteTd serializes the total weight of a block into the database.
+func WriteTd(db lcdb.Putter, hash math.Hash, number uint64, td *big.Int) error {
+	if err := db.Put(tdKey(hash, number), td.Bytes()); err != nil {
+		log.Crit("Failed to store block total weight", "err", err)
+	}
+	return nil
+}
+
 // WriteBlock serializes a block into the database, header and body separately.
 func WriteBlock(db lcdb.Putter, block *meta.Block) error {
 
@@ -312,6 +418,25 @@ func WriteBloomBits(db lcdb.Putter, bit uint, section uint64, head math.Hash, bi
 	}
 }
 
+// WriteBloomSections stores the number of bloom bits sections which have been indexed.
+func WriteBloomSections(db lcdb.Putter, sections uint64) {
+	if err := db.Put(append(append([]byte{}, BloomBitsIndexPrefix...), bloomSectionsSuffix...), encodeBlockNumber(sections)); err != nil {
+		log.Crit("Failed to store bloom sections", "err", err)
+	}
+}
+
+// WriteBloomSectionHead stores the last block hash of an indexed bloom bits section.
+func WriteBloomSectionHead(db lcdb.Putter, section uint64, head math.Hash) {
+	if err := db.Put(bloomSectionHeadKey(section), head.Bytes()); err != nil {
+		log.Crit("Failed to store bloom section head", "err", err)
+	}
+}
+
+// bloomSectionHeadKey = BloomBitsIndexPrefix + bloomSectionHeadSuffix + section (uint64 big endian)
+func bloomSectionHeadKey(section uint64) []byte {
+	return append(append(append([]byte{}, BloomBitsIndexPrefix...), bloomSectionHeadSuffix...), encodeBlockNumber(section)...)
+}
+
 // DeleteCanonicalHash removes the number to hash canonical mapping.
 func DeleteCanonicalHash(db DatabaseDeleter, number uint64) {
 	db.Delete(append(append(blockPrefix, encodeBlockNumber(number)...), numSuffix...))
@@ -323,9 +448,15 @@ func DeleteBlockData(db DatabaseDeleter, hash math.Hash, number uint64) {
 	db.Delete(append(append(blockPrefix, encodeBlockNumber(number)...), hash.Bytes()...))
 }
 
+// DeleteTd removes all block total weight data associated with a hash.
+func DeleteTd(db DatabaseDeleter, hash math.Hash, number uint64) {
+	db.Delete(tdKey(hash, number))
+}
+
 // DeleteBlock removes all block data associated with a hash.
 func DeleteBlock(db DatabaseDeleter, hash math.Hash, number uint64) {
 	DeleteBlockData(db, hash, number)
+	DeleteTd(db, hash, number)
 }
 
 // DeleteTxLookupEntry removes all transaction data associated with a hash.
@@ -381,10 +512,21 @@ func GetCode(db DatabaseReader, hash math.Hash) ([]byte, error) {
 	return db.Get(key)
 }
 
+// SplitCodeKey returns the code hash of the key if it is the database key of code.
+func SplitCodeKey(key []byte) (math.Hash, bool) {
+	if len(key) != len(codePrefix)+math.HashSize || !bytes.HasPrefix(key, codePrefix) {
+		return math.Hash{}, false
+	}
+	return math.BytesToHash(key[len(codePrefix):]), true
+}
+
 // ReadReceipts retrieves all the transaction receipts belonging to a block.
 func ReadReceipts(db DatabaseReader, hash math.Hash, number uint64) core.Receipts {
 	// Retrieve the flattened receipt slice
 	data, _ := db.Get(blockReceiptsKey(number, hash))
+	if len(data) == 0 {
+		data = getAncient(db, freezerReceiptsTable, hash, number)
+	}
 	if len(data) == 0 {
 		return nil
 	}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mihongtech/linkchain/common/util/log"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/protobuf"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// maxExportedBlockSize is the max size of a block of the exported stream, it
+// guards the import against allocating a corrupted length.
+const maxExportedBlockSize = 64 * 1024 * 1024
+
+// ExportBlocks writes the canonical blocks from first to last into w. Every block
+// is encoded by its protobuf serialization prefixed with its length as a 4 bytes
+// big endian integer.
+func ExportBlocks(db DatabaseReader, w io.Writer, first, last uint64) error {
+	logged := time.Now()
+	for number := first; number <= last; number++ {
+		hash := GetCanonicalHash(db, number)
+		if hash.IsEmpty() {
+			return fmt.Errorf("canonical block %d is missing", number)
+		}
+		block := GetBlock(db, hash, number)
+		if block == nil {
+			return fmt.Errorf("body of block %d is missing or pruned", number)
+		}
+		if err := WriteExportedBlock(w, block); err != nil {
+			return err
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Exporting blocks", "number", number, "last", last)
+			logged = time.Now()
+		}
+	}
+	return nil
+}
+
+// WriteExportedBlock writes a block of the exported stream into w.
+func WriteExportedBlock(w io.Writer, block *meta.Block) error {
+	data, err := proto.Marshal(block.Serialize())
+	if err != nil {
+		return err
+	}
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(data)))
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// ReadExportedBlock reads the next block of the exported stream from r, io.EOF is
+// returned at the end of stream.
+func ReadExportedBlock(r io.Reader) (*meta.Block, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(size[:])
+	if length > maxExportedBlockSize {
+		return nil, fmt.Errorf("exported block is too large: %d bytes", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	var b protobuf.Block
+	if err := proto.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	if b.Header == nil || b.TxList == nil {
+		return nil, errors.New("invalid exported block")
+	}
+	block := &meta.Block{}
+	if err := block.Deserialize(&b); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/mihongtech/linkchain/common/lcdb"
+)
+
+// Tests that the exported blocks are read back in order.
+func TestExportBlocks(t *testing.T) {
+	db, _ := lcdb.NewMemDatabase()
+	blocks := makeFreezerChain(t, db, 10)
+
+	buf := new(bytes.Buffer)
+	if err := ExportBlocks(db, buf, 2, 9); err != nil {
+		t.Fatalf("failed to export blocks: %v", err)
+	}
+	data := buf.Bytes()
+
+	r := bytes.NewReader(data)
+	for i := 2; i < 10; i++ {
+		block, err := ReadExportedBlock(r)
+		if err != nil {
+			t.Fatalf("failed to read block %d: %v", i, err)
+		}
+		if !block.GetBlockID().IsEqual(blocks[i].GetBlockID()) || len(block.GetTxs()) != 1 {
+			t.Fatalf("block %d mismatch: have %v, want %v", i, block, blocks[i])
+		}
+	}
+	if _, err := ReadExportedBlock(r); err != io.EOF {
+		t.Fatalf("error mismatch: have %v, want %v", err, io.EOF)
+	}
+
+	// A truncated stream is not a clean end
+	if _, err := ReadExportedBlock(bytes.NewReader(data[:len(data)/len(blocks)])); err != io.ErrUnexpectedEOF {
+		t.Errorf("error mismatch: have %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	// The blocks above the chain can't be exported
+	if err := ExportBlocks(db, new(bytes.Buffer), 5, 10); err == nil {
+		t.Errorf("exported missing block")
+	}
+}
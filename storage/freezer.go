@@ -0,0 +1,345 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mihongtech/linkchain/common/lcdb"
+	"github.com/mihongtech/linkchain/common/math"
+	"github.com/mihongtech/linkchain/common/util/log"
+	"github.com/mihongtech/linkchain/protobuf"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// The kinds of ancient items, the blocks and receipts are dropped by body pruning,
+// the hashes and headers are kept forever.
+const (
+	freezerHashTable     = "hashes"
+	freezerHeaderTable   = "headers"
+	freezerBlockTable    = "blocks"
+	freezerReceiptsTable = "receipts"
+)
+
+var freezerTables = []string{freezerHashTable, freezerHeaderTable, freezerBlockTable, freezerReceiptsTable}
+
+var errNoFreezer = errors.New("database has no ancient store")
+
+// AncientReader wraps the read methods of the ancient store of a database.
+type AncientReader interface {
+	// Ancients returns the number of the blocks frozen in the ancient store.
+	Ancients() uint64
+
+	// Ancient retrieves the item of the kind of the frozen block number.
+	Ancient(kind string, number uint64) ([]byte, error)
+}
+
+// Freezer is an append-only flat file store of the old canonical blocks and
+// receipts, which are moved out of the key-value database as they will never be
+// changed by a reorg. The blocks are frozen in order from the genesis.
+type Freezer struct {
+	tables map[string]*freezerTable
+	frozen uint64 // Number of the blocks frozen
+	lock   sync.RWMutex
+}
+
+// NewFreezer opens the freezer in dir, the blocks frozen partially by a crash
+// are discarded.
+func NewFreezer(dir string) (*Freezer, error) {
+	f := &Freezer{tables: make(map[string]*freezerTable)}
+	for _, name := range freezerTables {
+		table, err := newFreezerTable(dir, name)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		f.tables[name] = table
+	}
+	// The tables are appended one by one, so cut them to the shortest one
+	f.frozen = f.tables[freezerHashTable].Items()
+	for _, table := range f.tables {
+		if items := table.Items(); items < f.frozen {
+			f.frozen = items
+		}
+	}
+	for _, table := range f.tables {
+		if err := table.TruncateHead(f.frozen); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	log.Info("Opened ancient store", "dir", dir, "blocks", f.frozen, "tail", f.AncientTail())
+	return f, nil
+}
+
+// Ancients returns the number of the blocks frozen.
+func (f *Freezer) Ancients() uint64 {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.frozen
+}
+
+// AncientTail returns the number of the blocks whose body and receipts are pruned.
+func (f *Freezer) AncientTail() uint64 {
+	return f.tables[freezerBlockTable].Tail()
+}
+
+// Ancient retrieves the item of the kind of the frozen block number.
+func (f *Freezer) Ancient(kind string, number uint64) ([]byte, error) {
+	table, ok := f.tables[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown ancient kind %s", kind)
+	}
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	if number >= f.frozen {
+		return nil, errOutOfBounds
+	}
+	return table.Retrieve(number)
+}
+
+// AppendAncient freezes the next block, number must be the number of blocks frozen.
+func (f *Freezer) AppendAncient(number uint64, hash, header, block, receipts []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if number != f.frozen {
+		return fmt.Errorf("freezing unexpected block: want %d, have %d", f.frozen, number)
+	}
+	items := map[string][]byte{
+		freezerHashTable:     hash,
+		freezerHeaderTable:   header,
+		freezerBlockTable:    block,
+		freezerReceiptsTable: receipts,
+	}
+	for _, name := range freezerTables {
+		if err := f.tables[name].Append(number, items[name]); err != nil {
+			// Roll back the tables appended
+			for _, table := range f.tables {
+				table.TruncateHead(number)
+			}
+			return err
+		}
+	}
+	f.frozen++
+	return nil
+}
+
+// TruncateAncients discards the frozen blocks from number on.
+func (f *Freezer) TruncateAncients(number uint64) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if number >= f.frozen {
+		return nil
+	}
+	for _, table := range f.tables {
+		if err := table.TruncateHead(number); err != nil {
+			return err
+		}
+	}
+	f.frozen = number
+	return nil
+}
+
+// PruneAncients drops the blocks and receipts below number, the hashes and
+// headers are kept. The items are dropped by whole data files, so the tail may
+// be below number.
+func (f *Freezer) PruneAncients(number uint64) error {
+	for _, name := range []string{freezerBlockTable, freezerReceiptsTable} {
+		if err := f.tables[name].TruncateTail(number); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sync flushes the frozen blocks to disk.
+func (f *Freezer) Sync() error {
+	for _, table := range f.tables {
+		if err := table.Sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the tables of freezer.
+func (f *Freezer) Close() error {
+	var errs []error
+	for _, table := range f.tables {
+		if err := table.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// ChainDB is the chain database whose ancient blocks and receipts are read from
+// the freezer if they are not in the key-value database.
+type ChainDB struct {
+	lcdb.Database
+	freezer *Freezer
+}
+
+// NewChainDB creates the chain database of the key-value database and freezer.
+func NewChainDB(db lcdb.Database, freezer *Freezer) *ChainDB {
+	return &ChainDB{Database: db, freezer: freezer}
+}
+
+// KeyValueStore returns the key-value database of db.
+func (db *ChainDB) KeyValueStore() lcdb.Database {
+	return db.Database
+}
+
+// Freezer returns the ancient store of db.
+func (db *ChainDB) Freezer() *Freezer {
+	return db.freezer
+}
+
+func (db *ChainDB) Ancients() uint64 {
+	return db.freezer.Ancients()
+}
+
+func (db *ChainDB) Ancient(kind string, number uint64) ([]byte, error) {
+	return db.freezer.Ancient(kind, number)
+}
+
+// Close closes the freezer and the key-value database.
+func (db *ChainDB) Close() {
+	if err := db.freezer.Close(); err != nil {
+		log.Error("Failed to close ancient store", "err", err)
+	}
+	db.Database.Close()
+}
+
+// GetAncients returns the number of the blocks frozen in the ancient store of db.
+func GetAncients(db DatabaseReader) uint64 {
+	if ancient, ok := db.(AncientReader); ok {
+		return ancient.Ancients()
+	}
+	return 0
+}
+
+// getAncient retrieves the ancient item of the block if it is frozen.
+func getAncient(db DatabaseReader, kind string, hash math.Hash, number uint64) []byte {
+	ancient, ok := db.(AncientReader)
+	if !ok || number >= ancient.Ancients() {
+		return nil
+	}
+	if h, _ := ancient.Ancient(freezerHashTable, number); !bytes.Equal(h, hash.Bytes()) {
+		return nil
+	}
+	data, _ := ancient.Ancient(kind, number)
+	return data
+}
+
+// FreezeBlocks moves the canonical blocks below limit with their receipts into
+// the ancient store of db, the side chain blocks of the same numbers are deleted.
+// It returns the number of the blocks frozen.
+func FreezeBlocks(db lcdb.Database, limit uint64) (uint64, error) {
+	chain, ok := db.(*ChainDB)
+	if !ok {
+		return 0, errNoFreezer
+	}
+	f := chain.freezer
+	first := f.Ancients()
+	if limit <= first {
+		return first, nil
+	}
+	for number := first; number < limit; number++ {
+		hash := GetCanonicalHash(db, number)
+		data, _ := db.Get(blockKey(hash, number))
+		if len(data) == 0 {
+			return f.Ancients(), fmt.Errorf("block %d is missing", number)
+		}
+		var b protobuf.Block
+		if err := proto.Unmarshal(data, &b); err != nil {
+			return f.Ancients(), err
+		}
+		header, err := proto.Marshal(b.Header)
+		if err != nil {
+			return f.Ancients(), err
+		}
+		receipts, _ := db.Get(blockReceiptsKey(number, hash))
+		if err := f.AppendAncient(number, hash.Bytes(), header, data, receipts); err != nil {
+			return f.Ancients(), err
+		}
+	}
+	if err := f.Sync(); err != nil {
+		return f.Ancients(), err
+	}
+
+	// The blocks are safely frozen, delete them from the key-value database.
+	// The canonical blocks keep their number and td entries for the lookups by hash,
+	// and the genesis block is kept whole as it is loaded to open the chain.
+	for number := first; number < limit; number++ {
+		hash := GetCanonicalHash(db, number)
+		hashes, err := GetBlockHashes(db, number)
+		if err != nil {
+			return f.Ancients(), err
+		}
+		for _, h := range hashes {
+			if h == hash {
+				if number == 0 {
+					continue
+				}
+				if err := db.Delete(blockKey(h, number)); err != nil {
+					return f.Ancients(), err
+				}
+			} else {
+				DeleteBlock(db, h, number)
+			}
+			db.Delete(blockReceiptsKey(number, h))
+		}
+	}
+	log.Info("Frozen ancient blocks", "from", first, "to", limit-1)
+	return f.Ancients(), nil
+}
+
+// PruneBodies drops the frozen blocks and receipts below number with the tx lookup
+// entries of the blocks, the headers are still served. The bodies are dropped by
+// whole data files, and the genesis block kept in the key-value database is never
+// dropped. It returns the number of the blocks whose bodies are pruned.
+func PruneBodies(db lcdb.Database, number uint64) (uint64, error) {
+	chain, ok := db.(*ChainDB)
+	if !ok {
+		return 0, errNoFreezer
+	}
+	f := chain.freezer
+	if frozen := f.Ancients(); number > frozen {
+		number = frozen
+	}
+	number -= number % freezerSegmentItems
+	tail := f.AncientTail()
+	if number <= tail {
+		return tail, nil
+	}
+	for n := tail; n < number; n++ {
+		if n == 0 {
+			continue
+		}
+		hash, _ := f.Ancient(freezerHashTable, n)
+		block := GetBlock(db, math.BytesToHash(hash), n)
+		if block == nil {
+			continue
+		}
+		for _, tx := range block.GetTxs() {
+			if h, _, _ := GetTxLookupEntry(db, *tx.GetTxID()); h == *block.GetBlockID() {
+				DeleteTxLookupEntry(db, *tx.GetTxID())
+			}
+		}
+	}
+	if err := f.PruneAncients(number); err != nil {
+		return f.AncientTail(), err
+	}
+	log.Info("Pruned ancient block bodies", "from", tail, "to", number-1)
+	return f.AncientTail(), nil
+}
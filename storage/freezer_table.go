@@ -0,0 +1,332 @@
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	errOutOfBounds = errors.New("out of bounds")
+	errPrunedItem  = errors.New("item is pruned")
+	errClosed      = errors.New("table is closed")
+)
+
+// freezerSegmentItems is the number of items of a data file of freezer table,
+// the pruning of a table deletes the whole data files below the tail.
+var freezerSegmentItems = uint64(8192)
+
+// freezerTable is an append-only table of items numbered from zero. The items
+// are stored in the data files of freezerSegmentItems items each, and the end
+// offsets of the items in their data files are stored in an index file of 8
+// bytes per item. An item is appended by writing its data first and then its
+// index entry, so a crash leaves the data file no shorter than the index.
+type freezerTable struct {
+	dir   string
+	name  string
+	index *os.File
+	files map[uint64]*os.File // Opened data files by segment
+
+	items uint64 // Number of items stored, including the pruned ones
+	tail  uint64 // Number of items pruned from the start of table
+	lock  sync.RWMutex
+}
+
+// newFreezerTable opens the table of the name in dir, and repairs the items
+// appended partially by a crash.
+func newFreezerTable(dir string, name string) (*freezerTable, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	index, err := os.OpenFile(filepath.Join(dir, name+".idx"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	t := &freezerTable{dir: dir, name: name, index: index, files: make(map[uint64]*os.File)}
+	if err := t.repair(); err != nil {
+		t.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *freezerTable) dataPath(segment uint64) string {
+	return filepath.Join(t.dir, fmt.Sprintf("%s.%04d.dat", t.name, segment))
+}
+
+// repair loads the number of items and the tail, and truncates the items whose
+// data is not fully written.
+func (t *freezerTable) repair() error {
+	stat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	t.items = uint64(stat.Size()) / 8
+
+	// The pruned segments are deleted, the tail starts at the first remaining one
+	t.tail = t.items
+	files, err := ioutil.ReadDir(t.dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		var segment uint64
+		if !strings.HasPrefix(file.Name(), t.name+".") || !strings.HasSuffix(file.Name(), ".dat") {
+			continue
+		}
+		if _, err := fmt.Sscanf(strings.TrimPrefix(file.Name(), t.name+"."), "%d.dat", &segment); err != nil {
+			continue
+		}
+		if tail := segment * freezerSegmentItems; tail < t.tail {
+			t.tail = tail
+		}
+	}
+	// Drop the items whose data is not fully written
+	for t.items > t.tail {
+		start, end, err := t.bounds(t.items - 1)
+		if err != nil {
+			return err
+		}
+		file, err := t.file(t.items-1, false)
+		if os.IsNotExist(err) {
+			// The data is written before the index, so a missing file is pruned
+			t.tail = t.items
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if stat, err = file.Stat(); err != nil {
+			return err
+		}
+		if uint64(stat.Size()) >= end && end >= start {
+			break
+		}
+		t.items--
+	}
+	return t.index.Truncate(int64(t.items * 8))
+}
+
+// bounds returns the start and end offsets of item in its data file.
+func (t *freezerTable) bounds(item uint64) (uint64, uint64, error) {
+	var buf [16]byte
+	if item%freezerSegmentItems == 0 {
+		if _, err := t.index.ReadAt(buf[8:], int64(item*8)); err != nil {
+			return 0, 0, err
+		}
+		return 0, binary.BigEndian.Uint64(buf[8:]), nil
+	}
+	if _, err := t.index.ReadAt(buf[:], int64((item-1)*8)); err != nil {
+		return 0, 0, err
+	}
+	return binary.BigEndian.Uint64(buf[:8]), binary.BigEndian.Uint64(buf[8:]), nil
+}
+
+// file returns the data file of item, it is created if create is set.
+func (t *freezerTable) file(item uint64, create bool) (*os.File, error) {
+	segment := item / freezerSegmentItems
+	if file, ok := t.files[segment]; ok {
+		return file, nil
+	}
+	flag := os.O_RDWR
+	if create {
+		flag |= os.O_CREATE
+	}
+	file, err := os.OpenFile(t.dataPath(segment), flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+	t.files[segment] = file
+	return file, nil
+}
+
+// Items returns the number of items stored in the table.
+func (t *freezerTable) Items() uint64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.items
+}
+
+// Tail returns the number of items pruned from the start of the table.
+func (t *freezerTable) Tail() uint64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.tail
+}
+
+// Append writes the data of the next item, item must be the number of items.
+func (t *freezerTable) Append(item uint64, data []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil {
+		return errClosed
+	}
+	if item != t.items {
+		return fmt.Errorf("appending unexpected item: want %d, have %d", t.items, item)
+	}
+	file, err := t.file(item, true)
+	if err != nil {
+		return err
+	}
+	start := uint64(0)
+	if item%freezerSegmentItems != 0 {
+		if _, start, err = t.bounds(item - 1); err != nil {
+			return err
+		}
+	}
+	// The data after the last item is garbage of a crash, it is overwritten
+	if _, err := file.WriteAt(data, int64(start)); err != nil {
+		return err
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], start+uint64(len(data)))
+	if _, err := t.index.WriteAt(buf[:], int64(item*8)); err != nil {
+		return err
+	}
+	t.items++
+	return nil
+}
+
+// Retrieve reads the data of item.
+func (t *freezerTable) Retrieve(item uint64) ([]byte, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil {
+		return nil, errClosed
+	}
+	if item >= t.items {
+		return nil, errOutOfBounds
+	}
+	if item < t.tail {
+		return nil, errPrunedItem
+	}
+	start, end, err := t.bounds(item)
+	if err != nil {
+		return nil, err
+	}
+	file, err := t.file(item, false)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, end-start)
+	if _, err := file.ReadAt(data, int64(start)); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// TruncateHead discards the items from items on.
+func (t *freezerTable) TruncateHead(items uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if items >= t.items {
+		return nil
+	}
+	for segment := items / freezerSegmentItems; segment <= (t.items-1)/freezerSegmentItems; segment++ {
+		if items > segment*freezerSegmentItems {
+			continue
+		}
+		if err := t.removeFile(segment); err != nil {
+			return err
+		}
+	}
+	if items > t.tail {
+		_, end, err := t.bounds(items - 1)
+		if err != nil {
+			return err
+		}
+		file, err := t.file(items-1, false)
+		if err != nil {
+			return err
+		}
+		if err := file.Truncate(int64(end)); err != nil {
+			return err
+		}
+	} else {
+		t.tail = items
+	}
+	t.items = items
+	return t.index.Truncate(int64(items * 8))
+}
+
+// TruncateTail discards the data files whose items are all below tail.
+func (t *freezerTable) TruncateTail(tail uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if tail > t.items {
+		tail = t.items
+	}
+	tail -= tail % freezerSegmentItems
+	if tail <= t.tail {
+		return nil
+	}
+	for segment := t.tail / freezerSegmentItems; segment < tail/freezerSegmentItems; segment++ {
+		if err := t.removeFile(segment); err != nil {
+			return err
+		}
+	}
+	t.tail = tail
+	return nil
+}
+
+func (t *freezerTable) removeFile(segment uint64) error {
+	if file, ok := t.files[segment]; ok {
+		file.Close()
+		delete(t.files, segment)
+	}
+	if err := os.Remove(t.dataPath(segment)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Sync flushes the index and the data files to disk.
+func (t *freezerTable) Sync() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil {
+		return errClosed
+	}
+	for _, file := range t.files {
+		if err := file.Sync(); err != nil {
+			return err
+		}
+	}
+	return t.index.Sync()
+}
+
+// Close closes the index and the data files.
+func (t *freezerTable) Close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var errs []error
+	for segment, file := range t.files {
+		if err := file.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(t.files, segment)
+	}
+	if t.index != nil {
+		if err := t.index.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		t.index = nil
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
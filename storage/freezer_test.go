@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mihongtech/linkchain/common/lcdb"
+	"github.com/mihongtech/linkchain/core"
+	"github.com/mihongtech/linkchain/core/meta"
+)
+
+func newTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	return dir
+}
+
+func testItem(i uint64) []byte {
+	return bytes.Repeat([]byte{byte(i)}, int(i%7)+1)
+}
+
+// Tests that the items of a freezer table survive a reopen, and the items whose data
+// is not fully written are dropped.
+func TestFreezerTableRepair(t *testing.T) {
+	defer func(items uint64) { freezerSegmentItems = items }(freezerSegmentItems)
+	freezerSegmentItems = 4
+
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+
+	table, err := newFreezerTable(dir, "test")
+	if err != nil {
+		t.Fatalf("failed to open table: %v", err)
+	}
+	for i := uint64(0); i < 10; i++ {
+		if err := table.Append(i, testItem(i)); err != nil {
+			t.Fatalf("failed to append item %d: %v", i, err)
+		}
+	}
+	if err := table.Append(11, testItem(11)); err == nil {
+		t.Fatalf("appended item out of order")
+	}
+	table.Close()
+
+	// Cut the data of the last item as a crash
+	if err := os.Truncate(table.dataPath(2), int64(len(testItem(8)))+1); err != nil {
+		t.Fatalf("failed to truncate data file: %v", err)
+	}
+	if table, err = newFreezerTable(dir, "test"); err != nil {
+		t.Fatalf("failed to reopen table: %v", err)
+	}
+	defer table.Close()
+
+	if table.Items() != 9 {
+		t.Fatalf("items mismatch: have %d, want %d", table.Items(), 9)
+	}
+	for i := uint64(0); i < 9; i++ {
+		if data, err := table.Retrieve(i); err != nil || !bytes.Equal(data, testItem(i)) {
+			t.Errorf("item %d mismatch: have %x, %v, want %x", i, data, err, testItem(i))
+		}
+	}
+	if _, err := table.Retrieve(9); err != errOutOfBounds {
+		t.Errorf("error mismatch: have %v, want %v", err, errOutOfBounds)
+	}
+	// The item dropped is appended again
+	if err := table.Append(9, testItem(9)); err != nil {
+		t.Fatalf("failed to append item again: %v", err)
+	}
+	if data, _ := table.Retrieve(9); !bytes.Equal(data, testItem(9)) {
+		t.Errorf("item mismatch: have %x, want %x", data, testItem(9))
+	}
+}
+
+// Tests that the head truncation drops the recent items, and the tail truncation
+// drops the whole data files below the tail.
+func TestFreezerTableTruncate(t *testing.T) {
+	defer func(items uint64) { freezerSegmentItems = items }(freezerSegmentItems)
+	freezerSegmentItems = 4
+
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+
+	table, err := newFreezerTable(dir, "test")
+	if err != nil {
+		t.Fatalf("failed to open table: %v", err)
+	}
+	for i := uint64(0); i < 14; i++ {
+		if err := table.Append(i, testItem(i)); err != nil {
+			t.Fatalf("failed to append item %d: %v", i, err)
+		}
+	}
+	if err := table.TruncateHead(6); err != nil {
+		t.Fatalf("failed to truncate head: %v", err)
+	}
+	if _, err := os.Stat(table.dataPath(2)); !os.IsNotExist(err) {
+		t.Errorf("data file above head is not deleted: %v", err)
+	}
+	if err := table.TruncateTail(7); err != nil {
+		t.Fatalf("failed to truncate tail: %v", err)
+	}
+	if table.Tail() != 4 {
+		t.Fatalf("tail mismatch: have %d, want %d", table.Tail(), 4)
+	}
+	for i := uint64(6); i < 9; i++ {
+		if err := table.Append(i, testItem(i)); err != nil {
+			t.Fatalf("failed to append item %d: %v", i, err)
+		}
+	}
+	table.Close()
+
+	if table, err = newFreezerTable(dir, "test"); err != nil {
+		t.Fatalf("failed to reopen table: %v", err)
+	}
+	defer table.Close()
+
+	if table.Items() != 9 || table.Tail() != 4 {
+		t.Fatalf("table mismatch: have %d items from %d, want %d from %d", table.Items(), table.Tail(), 9, 4)
+	}
+	if _, err := table.Retrieve(3); err != errPrunedItem {
+		t.Errorf("error mismatch: have %v, want %v", err, errPrunedItem)
+	}
+	for i := uint64(4); i < 9; i++ {
+		if data, err := table.Retrieve(i); err != nil || !bytes.Equal(data, testItem(i)) {
+			t.Errorf("item %d mismatch: have %x, %v, want %x", i, data, err, testItem(i))
+		}
+	}
+}
+
+// makeFreezerChain writes a canonical chain of blocks with a tx and receipt each.
+func makeFreezerChain(t *testing.T, db lcdb.Database, count int) []*meta.Block {
+	var (
+		blocks = make([]*meta.Block, 0, count)
+		prev   meta.BlockID
+	)
+	for i := 0; i < count; i++ {
+		tx := meta.NewEmptyTransaction(1, 0)
+		tx.Data = []byte(fmt.Sprintf("tx %d", i))
+		block := meta.NewBlock(meta.BlockHeader{Height: uint32(i), Time: time.Unix(int64(i), 0), Prev: prev}, []meta.Transaction{*tx})
+
+		receipt := core.NewReceipt(nil, false, uint64(i))
+		receipt.TxHash = *tx.GetTxID()
+		WriteBlock(db, block)
+		WriteReceipts(db, *block.GetBlockID(), uint64(i), core.Receipts{receipt})
+		WriteTxLookupEntries(db, block)
+		WriteCanonicalHash(db, *block.GetBlockID(), uint64(i))
+		prev = *block.GetBlockID()
+		blocks = append(blocks, block)
+	}
+	WriteHeadBlockHash(db, prev)
+	return blocks
+}
+
+// Tests that the frozen blocks and receipts are read from the ancient store, and the
+// headers of the blocks whose bodies are pruned are still read.
+func TestFreezeBlocks(t *testing.T) {
+	defer func(items uint64) { freezerSegmentItems = items }(freezerSegmentItems)
+	freezerSegmentItems = 4
+
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+
+	kvdb, _ := lcdb.NewMemDatabase()
+	freezer, err := NewFreezer(dir)
+	if err != nil {
+		t.Fatalf("failed to open freezer: %v", err)
+	}
+	db := NewChainDB(kvdb, freezer)
+	defer db.Close()
+
+	blocks := makeFreezerChain(t, db, 20)
+	side := meta.NewBlock(meta.BlockHeader{Height: 5, Prev: *blocks[4].GetBlockID(), Data: []byte("side")}, nil)
+	WriteBlock(db, side)
+
+	if frozen, err := FreezeBlocks(db, 10); err != nil || frozen != 10 {
+		t.Fatalf("failed to freeze blocks: %d, %v", frozen, err)
+	}
+	for i, block := range blocks {
+		hash, number := *block.GetBlockID(), uint64(i)
+		if ok, _ := kvdb.Has(blockKey(hash, number)); ok != (i == 0 || i >= 10) {
+			t.Errorf("block %d in database mismatch: have %v", i, ok)
+		}
+		if entry := GetBlock(db, hash, number); entry == nil || !entry.GetBlockID().IsEqual(&hash) {
+			t.Errorf("block %d mismatch: have %v", i, entry)
+		}
+		if !HasBlock(db, hash, number) {
+			t.Errorf("block %d is not found", i)
+		}
+		if receipts := ReadReceipts(db, hash, number); len(receipts) != 1 || receipts[0].CumulativeGasUsed != number {
+			t.Errorf("receipts of block %d mismatch: have %v", i, receipts)
+		}
+	}
+	if HasBlock(db, *side.GetBlockID(), 5) {
+		t.Errorf("side block below ancient blocks is not deleted")
+	}
+	if HasBlock(db, *blocks[6].GetBlockID(), 5) {
+		t.Errorf("block of another number is found")
+	}
+
+	// Prune the bodies of the first two data files
+	if tail, err := PruneBodies(db, 9); err != nil || tail != 8 {
+		t.Fatalf("failed to prune bodies: %d, %v", tail, err)
+	}
+	for i, block := range blocks[:12] {
+		hash, number := *block.GetBlockID(), uint64(i)
+		pruned := i > 0 && i < 8
+		if entry := GetBlock(db, hash, number); (entry == nil) != pruned {
+			t.Errorf("block %d pruned mismatch: have %v, want %v", i, entry == nil, pruned)
+		}
+		if header := GetHeader(db, hash, number); header == nil || !header.GetBlockID().IsEqual(&hash) {
+			t.Errorf("header %d mismatch: have %v", i, header)
+		}
+		if h, _, _ := GetTxLookupEntry(db, *block.GetTxs()[0].GetTxID()); h.IsEmpty() != pruned {
+			t.Errorf("tx lookup of block %d pruned mismatch: have %v, want %v", i, h.IsEmpty(), pruned)
+		}
+	}
+
+	// The freezer is reopened as it is
+	freezer.Close()
+	if freezer, err = NewFreezer(dir); err != nil {
+		t.Fatalf("failed to reopen freezer: %v", err)
+	}
+	db = NewChainDB(kvdb, freezer)
+	if db.Ancients() != 10 || freezer.AncientTail() != 8 {
+		t.Fatalf("freezer mismatch: have %d blocks from %d, want %d from %d", db.Ancients(), freezer.AncientTail(), 10, 8)
+	}
+	if entry := GetBlock(db, *blocks[9].GetBlockID(), 9); entry == nil {
+		t.Errorf("frozen block is not found after reopen")
+	}
+}
@@ -0,0 +1,44 @@
+package pruner
+
+import (
+	"encoding/binary"
+
+	"github.com/mihongtech/linkchain/common/math"
+)
+
+//bloomHashes is the count of bits set for a hash.
+const bloomHashes = 4
+
+//stateBloom marks the trie nodes and codes of the retained states by their hashes.
+//The hashes are uniformly distributed, so the bit indexes are taken from the hash itself.
+//A false positive only keeps a stale node, a retained node is never reported as absent.
+type stateBloom struct {
+	bits []uint64
+	size uint64
+}
+
+//newStateBloom creates a bloom filter of the size in MB.
+func newStateBloom(sizeMB uint64) *stateBloom {
+	size := sizeMB * 1024 * 1024 * 8
+	if size == 0 {
+		size = 64
+	}
+	return &stateBloom{bits: make([]uint64, (size+63)/64), size: size}
+}
+
+func (b *stateBloom) add(hash math.Hash) {
+	for i := 0; i < bloomHashes; i++ {
+		index := binary.BigEndian.Uint64(hash[i*8:]) % b.size
+		b.bits[index/64] |= 1 << (index % 64)
+	}
+}
+
+func (b *stateBloom) contains(hash math.Hash) bool {
+	for i := 0; i < bloomHashes; i++ {
+		index := binary.BigEndian.Uint64(hash[i*8:]) % b.size
+		if b.bits[index/64]&(1<<(index%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,320 @@
+package pruner
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mihongtech/linkchain/common/lcdb"
+	"github.com/mihongtech/linkchain/common/math"
+	"github.com/mihongtech/linkchain/common/trie"
+	"github.com/mihongtech/linkchain/common/util/log"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/protobuf"
+	"github.com/mihongtech/linkchain/storage"
+
+	"github.com/golang/protobuf/proto"
+)
+
+const (
+	//MinRetention is the min count of recent states, the blockchain references the states of
+	//the last 128 blocks in memory, and commits the 127th one to disk when it stops.
+	MinRetention = 128
+
+	DefaultRetention  = 1024
+	DefaultCheckpoint = 10000
+	DefaultInterval   = 1024
+	DefaultBloomSize  = 256
+
+	//logInterval is the interval of progress logs of a long pruning.
+	logInterval = 8 * time.Second
+)
+
+var (
+	ErrFastSyncing      = errors.New("state is being fast synced")
+	ErrMissingHeadState = errors.New("state of head block is missing")
+)
+
+//Config is the configuration of state pruning.
+type Config struct {
+	Retention  uint64 //the count of recent blocks whose states are kept
+	Checkpoint uint64 //the interval of blocks whose states are kept forever, zero means no checkpoint
+	BloomSize  uint64 //the size in MB of the bloom filter marking the retained states
+}
+
+//Pruner deletes the trie nodes and codes which are not reachable from the retained states.
+//The states of the recent blocks, the checkpoint blocks and the genesis block are retained.
+//A reorg whose fork is below the retention re-executes the blocks from the nearest retained
+//ancestor, the checkpoint before the fork or the genesis, so a reorg of any depth is still
+//processed, and the re-executed blocks are bounded by the checkpoint interval.
+type Pruner struct {
+	config Config
+	db     lcdb.Database
+	triedb *trie.Database
+}
+
+//NewPruner creates the pruner of db, the state is read through triedb which may hold the
+//nodes not yet committed to db, a new trie database of db is used if it is nil.
+func NewPruner(db lcdb.Database, triedb *trie.Database, config Config) *Pruner {
+	if config.Retention < MinRetention {
+		log.Warn("State retention is too small, use the min retention", "retention", config.Retention, "min", MinRetention)
+		config.Retention = MinRetention
+	}
+	if triedb == nil {
+		triedb = trie.NewDatabase(db)
+	}
+	return &Pruner{config: config, db: db, triedb: triedb}
+}
+
+//Prune deletes the states which are not retained by the head block.
+//It must not run with the block imports, the nodes committed during pruning are not marked.
+func (p *Pruner) Prune(head *meta.Block) error {
+	number := uint64(head.GetHeight())
+	if fast := storage.GetHeadFastBlockHash(p.db); fast != (math.Hash{}) {
+		if n := storage.GetBlockNumber(p.db, fast); n != storage.MissingNumber && n > number {
+			return ErrFastSyncing
+		}
+	}
+	if number < p.config.Retention {
+		log.Debug("No state to prune", "number", number, "retention", p.config.Retention)
+		return nil
+	}
+	horizon := number - p.config.Retention + 1
+
+	start := time.Now()
+	roots, err := p.retainedRoots(head, horizon)
+	if err != nil {
+		return err
+	}
+	bloom := newStateBloom(p.config.BloomSize)
+	if err := p.mark(bloom, roots); err != nil {
+		return err
+	}
+	nodes, codes, err := p.sweep(bloom)
+	if err != nil {
+		return err
+	}
+	dangling, err := p.deleteStaleRoots(bloom, roots, number)
+	if err != nil {
+		return err
+	}
+	storage.WritePruneProgress(p.db, horizon)
+
+	log.Info("Pruned stale state", "number", number, "horizon", horizon, "retained", len(roots),
+		"nodes", nodes+dangling, "codes", codes, "elapsed", time.Since(start))
+	return nil
+}
+
+//retainedRoots returns the state roots of the canonical genesis and checkpoints below the
+//horizon, and all the blocks from the horizon to the head in order of height, so the side
+//chains within the retention keep their states for a reorg.
+func (p *Pruner) retainedRoots(head *meta.Block, horizon uint64) ([]math.Hash, error) {
+	number := uint64(head.GetHeight())
+	if _, err := trie.New(*head.GetStatus(), p.triedb); err != nil {
+		return nil, ErrMissingHeadState
+	}
+
+	roots := make([]math.Hash, 0, number-horizon+2)
+	seen := make(map[math.Hash]struct{})
+	retain := func(hash math.Hash, n uint64) {
+		header := storage.GetHeader(p.db, hash, n)
+		if header == nil {
+			log.Debug("Retained block is missing", "number", n, "hash", hash)
+			return
+		}
+		root := header.Status
+		if _, ok := seen[root]; ok {
+			return
+		}
+		//the state of an old block may be missing, it is re-executed if it is required
+		if _, err := trie.New(root, p.triedb); err != nil {
+			log.Debug("Retained state is missing", "number", n, "root", root)
+			return
+		}
+		seen[root] = struct{}{}
+		roots = append(roots, root)
+	}
+
+	retain(storage.GetCanonicalHash(p.db, 0), 0)
+	if p.config.Checkpoint > 0 {
+		for n := p.config.Checkpoint; n < horizon; n += p.config.Checkpoint {
+			retain(storage.GetCanonicalHash(p.db, n), n)
+		}
+	}
+	for n := horizon; n <= number; n++ {
+		hashes, err := storage.GetBlockHashes(p.db, n)
+		if err != nil {
+			return nil, err
+		}
+		//the canonical blocks frozen in the ancient store have no block entries
+		if hash := storage.GetCanonicalHash(p.db, n); !containsHash(hashes, hash) {
+			hashes = append(hashes, hash)
+		}
+		for _, hash := range hashes {
+			retain(hash, n)
+		}
+	}
+	if _, ok := seen[*head.GetStatus()]; !ok {
+		roots = append(roots, *head.GetStatus())
+	}
+	return roots, nil
+}
+
+//mark adds the trie nodes and codes of the states to the bloom.
+//The states of adjacent blocks share most of the nodes, so only the nodes which are not
+//in the previous state are walked, the subtries of the same hash are skipped exactly.
+func (p *Pruner) mark(bloom *stateBloom, roots []math.Hash) error {
+	var (
+		prev     *trie.Trie
+		storages = make(map[math.Hash]struct{})
+		logged   = time.Now()
+		count    int
+	)
+	for i, root := range roots {
+		tr, err := trie.New(root, p.triedb)
+		if err != nil {
+			return err
+		}
+		it := tr.NodeIterator(nil)
+		if prev != nil {
+			it, _ = trie.NewDifferenceIterator(prev.NodeIterator(nil), it)
+		}
+		for it.Next(true) {
+			if hash := it.Hash(); hash != (math.Hash{}) {
+				bloom.add(hash)
+				count++
+			}
+			if !it.Leaf() {
+				continue
+			}
+			account, err := decodeAccount(it.LeafBlob())
+			if err != nil {
+				return err
+			}
+			if !account.StorageRoot.IsEmpty() {
+				if _, ok := storages[account.StorageRoot]; !ok {
+					storages[account.StorageRoot] = struct{}{}
+					if err := p.markTrie(bloom, account.StorageRoot); err != nil {
+						return err
+					}
+				}
+			}
+			if !account.CodeHash.IsEmpty() {
+				bloom.add(account.CodeHash)
+			}
+		}
+		if err := it.Error(); err != nil {
+			return err
+		}
+		prev = tr
+
+		if time.Since(logged) > logInterval {
+			log.Info("Marking retained state", "roots", i+1, "total", len(roots), "nodes", count)
+			logged = time.Now()
+		}
+	}
+	return nil
+}
+
+//markTrie adds all the nodes of the storage trie to the bloom.
+func (p *Pruner) markTrie(bloom *stateBloom, root math.Hash) error {
+	tr, err := trie.New(root, p.triedb)
+	if err != nil {
+		return err
+	}
+	it := tr.NodeIterator(nil)
+	for it.Next(true) {
+		if hash := it.Hash(); hash != (math.Hash{}) {
+			bloom.add(hash)
+		}
+	}
+	return it.Error()
+}
+
+//sweep deletes the trie nodes and codes which are not in the bloom.
+//The trie nodes are the only entries keyed by a raw hash.
+func (p *Pruner) sweep(bloom *stateBloom) (int, int, error) {
+	var (
+		nodes, codes int
+		deleteErr    error
+		logged       = time.Now()
+	)
+	err := lcdb.IterateKeys(p.db, nil, func(key []byte) bool {
+		if len(key) == math.HashSize {
+			if !bloom.contains(math.BytesToHash(key)) {
+				deleteErr = p.db.Delete(key)
+				nodes++
+			}
+		} else if hash, ok := storage.SplitCodeKey(key); ok && !bloom.contains(hash) {
+			deleteErr = p.db.Delete(key)
+			codes++
+		}
+		if time.Since(logged) > logInterval {
+			log.Info("Sweeping stale state", "nodes", nodes, "codes", codes)
+			logged = time.Now()
+		}
+		return deleteErr == nil
+	})
+	if err != nil {
+		return nodes, codes, err
+	}
+	return nodes, codes, deleteErr
+}
+
+//deleteStaleRoots deletes the root nodes of the pruned states kept by the false positives of bloom.
+//The state is available to the blockchain if its root node exists, so a root node whose children
+//are swept would make the blocks on it be executed on the partial state.
+func (p *Pruner) deleteStaleRoots(bloom *stateBloom, roots []math.Hash, number uint64) (int, error) {
+	retained := make(map[math.Hash]struct{}, len(roots))
+	for _, root := range roots {
+		retained[root] = struct{}{}
+	}
+	count := 0
+	for n := storage.GetPruneProgress(p.db); n <= number; n++ {
+		hashes, err := storage.GetBlockHashes(p.db, n)
+		if err != nil {
+			return count, err
+		}
+		//the canonical blocks frozen in the ancient store have no block entries
+		if hash := storage.GetCanonicalHash(p.db, n); !containsHash(hashes, hash) {
+			hashes = append(hashes, hash)
+		}
+		for _, hash := range hashes {
+			header := storage.GetHeader(p.db, hash, n)
+			if header == nil {
+				continue
+			}
+			root := header.Status
+			if _, ok := retained[root]; ok || !bloom.contains(root) {
+				continue
+			}
+			if ok, _ := p.db.Has(root[:]); ok {
+				if err := p.db.Delete(root[:]); err != nil {
+					return count, err
+				}
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+func containsHash(hashes []math.Hash, hash math.Hash) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeAccount(data []byte) (*meta.Account, error) {
+	pa := &protobuf.Account{}
+	if err := proto.Unmarshal(data, pa); err != nil {
+		return nil, err
+	}
+	account := &meta.Account{}
+	if err := account.Deserialize(pa); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
@@ -0,0 +1,194 @@
+package pruner
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mihongtech/linkchain/common/lcdb"
+	"github.com/mihongtech/linkchain/common/math"
+	"github.com/mihongtech/linkchain/common/trie"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/storage"
+
+	"github.com/golang/protobuf/proto"
+)
+
+//makeTestChain writes a canonical chain whose blocks change an account of the state each,
+//the account of every 10th block also has a new contract storage and code.
+func makeTestChain(t *testing.T, db lcdb.Database, count int) []*meta.Block {
+	var (
+		triedb         = trie.NewDatabase(db)
+		stateTrie, _   = trie.New(math.Hash{}, triedb)
+		storageTrie, _ = trie.New(math.Hash{}, triedb)
+		blocks         = make([]*meta.Block, 0, count)
+		prev           meta.BlockID
+	)
+	for i := 0; i < count; i++ {
+		id := meta.BytesToAccountID([]byte{byte(i % 50)})
+		account := meta.NewAccount(id, uint32(i), nil, meta.NewClearTime(0, 0), id)
+		if i%10 == 0 {
+			storageTrie.Update(math.HashB([]byte{byte(i)}), []byte(fmt.Sprintf("storage value %d", i)))
+			account.StorageRoot, _ = storageTrie.Commit(nil)
+			if err := triedb.Commit(account.StorageRoot, false); err != nil {
+				t.Fatalf("failed to commit storage: %v", err)
+			}
+			account.CodeHash = writeTestCode(db, i)
+		}
+		data, err := proto.Marshal(account.Serialize())
+		if err != nil {
+			t.Fatalf("failed to encode account: %v", err)
+		}
+		stateTrie.Update(math.HashB(id.CloneBytes()), data)
+		root, _ := stateTrie.Commit(nil)
+		if err := triedb.Commit(root, false); err != nil {
+			t.Fatalf("failed to commit state: %v", err)
+		}
+
+		block := meta.NewBlock(meta.BlockHeader{Height: uint32(i), Time: time.Unix(int64(i), 0), Prev: prev, Status: root}, nil)
+		storage.WriteBlock(db, block)
+		storage.WriteCanonicalHash(db, *block.GetBlockID(), uint64(i))
+		prev = *block.GetBlockID()
+		blocks = append(blocks, block)
+	}
+	storage.WriteHeadBlockHash(db, prev)
+	return blocks
+}
+
+func writeTestCode(db lcdb.Database, i int) math.Hash {
+	code := []byte(fmt.Sprintf("contract code %d", i))
+	hash := math.HashH(code)
+	storage.WriteCode(db, hash, code)
+	return hash
+}
+
+//checkState walks the whole state of root with its storage tries and codes.
+func checkState(db lcdb.Database, root math.Hash) error {
+	triedb := trie.NewDatabase(db)
+	tr, err := trie.New(root, triedb)
+	if err != nil {
+		return err
+	}
+	it := trie.NewIterator(tr.NodeIterator(nil))
+	for it.Next() {
+		account, err := decodeAccount(it.Value)
+		if err != nil {
+			return err
+		}
+		if !account.StorageRoot.IsEmpty() {
+			st, err := trie.New(account.StorageRoot, triedb)
+			if err != nil {
+				return err
+			}
+			sit := st.NodeIterator(nil)
+			for sit.Next(true) {
+			}
+			if err := sit.Error(); err != nil {
+				return err
+			}
+		}
+		if !account.CodeHash.IsEmpty() {
+			if code, _ := storage.GetCode(db, account.CodeHash); len(code) == 0 {
+				return fmt.Errorf("code %x is missing", account.CodeHash)
+			}
+		}
+	}
+	return it.Err
+}
+
+func TestPrune(t *testing.T) {
+	db, _ := lcdb.NewMemDatabase()
+	blocks := makeTestChain(t, db, 300)
+
+	//a side chain block of a pruned height
+	side := meta.NewBlock(meta.BlockHeader{Height: 150, Prev: *blocks[149].GetBlockID(), Status: *blocks[10].GetStatus(), Data: []byte("side")}, nil)
+	storage.WriteBlock(db, side)
+	//a side chain block within the retention keeps its state
+	recent := meta.NewBlock(meta.BlockHeader{Height: 250, Prev: *blocks[249].GetBlockID(), Status: *blocks[60].GetStatus(), Data: []byte("side")}, nil)
+	storage.WriteBlock(db, recent)
+
+	size := db.Len()
+	p := NewPruner(db, nil, Config{Retention: 128, Checkpoint: 100, BloomSize: 1})
+	if err := p.Prune(blocks[299]); err != nil {
+		t.Fatalf("failed to prune state: %v", err)
+	}
+	if db.Len() >= size {
+		t.Fatalf("nothing is pruned: have %d entries, had %d", db.Len(), size)
+	}
+
+	//the genesis, checkpoints, recent states and the state of recent side block are retained
+	retained := []int{0, 60, 100, 200}
+	for n := 172; n < 300; n++ {
+		retained = append(retained, n)
+	}
+	for _, n := range retained {
+		if err := checkState(db, *blocks[n].GetStatus()); err != nil {
+			t.Errorf("state of block %d is broken: %v", n, err)
+		}
+	}
+	//the other states are deleted
+	for _, n := range []int{10, 50, 150, 171} {
+		if ok, _ := db.Has(blocks[n].GetStatus().Bytes()); ok {
+			t.Errorf("state of block %d is not pruned", n)
+		}
+	}
+	//the code of block 110 is only referenced by the pruned states
+	for n, exist := range map[int]bool{100: true, 110: false, 160: true, 290: true} {
+		code, _ := storage.GetCode(db, math.HashH([]byte(fmt.Sprintf("contract code %d", n))))
+		if (len(code) > 0) != exist {
+			t.Errorf("code of block %d existence mismatch: have %v, want %v", n, len(code) > 0, exist)
+		}
+	}
+	if storage.GetPruneProgress(db) != 172 {
+		t.Errorf("prune progress mismatch: have %d, want %d", storage.GetPruneProgress(db), 172)
+	}
+
+	//pruning again is harmless
+	size = db.Len()
+	if err := p.Prune(blocks[299]); err != nil {
+		t.Fatalf("failed to prune state again: %v", err)
+	}
+	if db.Len() != size {
+		t.Errorf("entries mismatch: have %d, want %d", db.Len(), size)
+	}
+	for _, n := range retained {
+		if err := checkState(db, *blocks[n].GetStatus()); err != nil {
+			t.Errorf("state of block %d is broken: %v", n, err)
+		}
+	}
+}
+
+//the bloom of 64 bits contains every hash, so all the nodes are kept by false positives
+func TestPruneStaleRoots(t *testing.T) {
+	db, _ := lcdb.NewMemDatabase()
+	blocks := makeTestChain(t, db, 200)
+
+	p := NewPruner(db, nil, Config{Retention: 128, BloomSize: 0})
+	if err := p.Prune(blocks[199]); err != nil {
+		t.Fatalf("failed to prune state: %v", err)
+	}
+	for n := 1; n < 72; n++ {
+		if ok, _ := db.Has(blocks[n].GetStatus().Bytes()); ok {
+			t.Errorf("stale root of block %d is not deleted", n)
+		}
+	}
+	for _, n := range []int{0, 72, 199} {
+		if err := checkState(db, *blocks[n].GetStatus()); err != nil {
+			t.Errorf("state of block %d is broken: %v", n, err)
+		}
+	}
+}
+
+func TestPruneFastSyncing(t *testing.T) {
+	db, _ := lcdb.NewMemDatabase()
+	blocks := makeTestChain(t, db, 200)
+
+	//the fast synced head is above the head block
+	storage.WriteHeadBlockHash(db, *blocks[150].GetBlockID())
+	storage.WriteHeadFastBlockHash(db, *blocks[199].GetBlockID())
+
+	p := NewPruner(db, nil, Config{Retention: 128, BloomSize: 1})
+	if err := p.Prune(blocks[150]); err != ErrFastSyncing {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrFastSyncing)
+	}
+}
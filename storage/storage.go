@@ -30,6 +30,17 @@ func NewStrorage(dataDir string) *Storage {
 		return nil
 	}
 
+	//the old blocks are frozen into the ancient store of disk database
+	if s.dataDir != "" {
+		freezer, err := NewFreezer(s.resolvePath("ancient"))
+		if err != nil {
+			log.Error("init ancient store failed", "err", err)
+			s.db.Close()
+			return nil
+		}
+		s.db = NewChainDB(s.db, freezer)
+	}
+
 	return s
 }
 
@@ -8,6 +8,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/mihongtech/linkchain/common/lcdb"
 	"github.com/mihongtech/linkchain/common/util/event"
 	"github.com/mihongtech/linkchain/common/util/log"
 	"github.com/mihongtech/linkchain/core/meta"
@@ -16,7 +17,10 @@ import (
 
 var (
 	MaxBlockFetch   = 192 // Amount of blocks to be fetched per retrieval request
+	MaxHeaderFetch  = 192 // Amount of block headers to be fetched per retrieval request
 	MaxSkeletonSize = 128 // Number of header fetches to need for a skeleton assembly
+	MaxBodyFetch    = 128 // Amount of block bodies to be fetched per retrieval request
+	MaxStateFetch   = 384 // Amount of node state values to allow fetching per request
 
 	rttMinEstimate   = 2 * time.Second  // Minimum round-trip time to target for download requests
 	rttMaxEstimate   = 20 * time.Second // Maximum rount-trip time to target for download requests
@@ -28,10 +32,11 @@ var (
 	qosConfidenceCap = 10   // Number of peers above which not to modify RTT confidence
 	qosTuningImpact  = 0.25 // Impact that a new tuning target has on the previous value
 
-	maxBlocksProcess  = 2048 // Number of header download results to import at once into the chain
+	maxHeadersProcess = 2048 // Number of header download results to import at once into the chain
 	maxResultsProcess = 2048 // Number of content download results to import at once into the chain
 
 	fsBlockContCheck = 3 * time.Second
+	fsMinFullBlocks  = 64 // Number of blocks to retrieve fully even in fast sync
 )
 
 var (
@@ -45,11 +50,15 @@ var (
 	errPeersUnavailable        = errors.New("no peers available or all tried for download")
 	errInvalidAncestor         = errors.New("retrieved ancestor is invalid")
 	errInvalidChain            = errors.New("retrieved hash chain is invalid")
-	errCancelBlockFetch        = errors.New("block download canceled (requested)")
-	errCancelBlockProcessing   = errors.New("block processing canceled (requested)")
+	errInvalidBody             = errors.New("retrieved block body is invalid")
+	errCancelHeaderFetch       = errors.New("block header download canceled (requested)")
+	errCancelBodyFetch         = errors.New("block body download canceled (requested)")
+	errCancelStateFetch        = errors.New("state data download canceled (requested)")
+	errCancelHeaderProcessing  = errors.New("header processing canceled (requested)")
 	errCancelContentProcessing = errors.New("content processing canceled (requested)")
 	errNoSyncActive            = errors.New("no sync active")
 	errTooOld                  = errors.New("peer doesn't speak recent enough protocol version")
+	errPivotUncommitted        = errors.New("fast sync pivot block is not committed")
 )
 
 type Downloader struct {
@@ -63,6 +72,7 @@ type Downloader struct {
 	rttConfidence uint64 // Confidence in the estimated RTT (unit: millionths to allow atomic ops)
 
 	nodeAPI *node.PublicNodeAPI
+	stateDB lcdb.Database // Database to state sync into (and deduplicate via)
 
 	// Callbacks
 	dropPeer peerDropFn // Drops a peer for misbehaving
@@ -73,8 +83,11 @@ type Downloader struct {
 	committed     int32
 
 	// Channels
-	blockCh     chan dataPack      // [full/62] Channel receiving inbound block headers
-	blockProcCh chan []*meta.Block // [full/62] Channel to feed the header processor new tasks
+	headerCh     chan dataPack            // [full/02] Channel receiving inbound block headers
+	bodyCh       chan dataPack            // [full/02] Channel receiving inbound block bodies
+	bodyWakeCh   chan bool                // [full/02] Channel to signal the block body fetcher of new tasks
+	headerProcCh chan []*meta.BlockHeader // [full/02] Channel to feed the header processor new tasks
+	stateCh      chan dataPack            // [full/03] Channel receiving inbound node state data
 
 	// Cancellation and termination
 	cancelPeer string        // Identifier of the peer currently being used as the master (cancel on drop)
@@ -86,7 +99,7 @@ type Downloader struct {
 }
 
 // New creates a new downloader to fetch hashes and blocks from remote peers.
-func New(mux *event.TypeMux, nodeSvc *node.PublicNodeAPI, dropPeer peerDropFn) *Downloader {
+func New(mux *event.TypeMux, stateDb lcdb.Database, nodeSvc *node.PublicNodeAPI, dropPeer peerDropFn) *Downloader {
 
 	dl := &Downloader{
 		mode:          FullSync,
@@ -96,9 +109,13 @@ func New(mux *event.TypeMux, nodeSvc *node.PublicNodeAPI, dropPeer peerDropFn) *
 		rttEstimate:   uint64(rttMaxEstimate),
 		rttConfidence: uint64(1000000),
 		nodeAPI:       nodeSvc,
+		stateDB:       stateDb,
 		dropPeer:      dropPeer,
-		blockCh:       make(chan dataPack, 1),
-		blockProcCh:   make(chan []*meta.Block, 1),
+		headerCh:      make(chan dataPack, 1),
+		bodyCh:        make(chan dataPack, 1),
+		bodyWakeCh:    make(chan bool, 1),
+		headerProcCh:  make(chan []*meta.BlockHeader, 1),
+		stateCh:       make(chan dataPack, 1),
 		quitCh:        make(chan struct{}),
 	}
 	go dl.qosTuner()
@@ -155,14 +172,18 @@ func (d *Downloader) UnregisterPeer(id string) error {
 
 // Synchronise tries to sync up our local block chain with a remote peer, both
 // adding various sanity checks as well as wrapping it with various log entries.
-func (d *Downloader) Synchronise(id string, head meta.BlockID) error {
-	err := d.synchronise(id, head)
+func (d *Downloader) Synchronise(id string, head meta.BlockID, mode SyncMode) error {
+	err := d.synchronise(id, head, mode)
 	switch err {
 	case nil:
 	case errBusy:
 
+	case errTooOld:
+		// The old peer is not synced from, but kept for the block and tx propagation
+		log.Debug("Synchronisation skipped, peer too old", "peer", id)
+
 	case errTimeout, errBadPeer, errStallingPeer,
-		errEmptyBlockSet, errPeersUnavailable, errTooOld,
+		errEmptyBlockSet, errPeersUnavailable,
 		errInvalidAncestor, errInvalidChain:
 		log.Warn("Synchronisation failed, dropping peer", "peer", id, "err", err)
 		if d.dropPeer == nil {
@@ -181,7 +202,7 @@ func (d *Downloader) Synchronise(id string, head meta.BlockID) error {
 // synchronise will select the peer and use it for synchronising. If an empty string is given
 // it will use the best peer possible and synchronize if its TD is higher than our own. If any of the
 // checks fail an error will be returned. This method is synchronous
-func (d *Downloader) synchronise(id string, hash meta.BlockID) error {
+func (d *Downloader) synchronise(id string, hash meta.BlockID, mode SyncMode) error {
 	// Make sure only one goroutine is ever allowed past this point at once
 	if !atomic.CompareAndSwapInt32(&d.synchronising, 0, 1) {
 		return errBusy
@@ -196,7 +217,16 @@ func (d *Downloader) synchronise(id string, hash meta.BlockID) error {
 	d.queue.Reset()
 	d.peers.Reset()
 
-	for _, ch := range []chan dataPack{d.blockCh} {
+	for _, ch := range []chan dataPack{d.headerCh, d.bodyCh, d.stateCh} {
+		for empty := false; !empty; {
+			select {
+			case <-ch:
+			default:
+				empty = true
+			}
+		}
+	}
+	for _, ch := range []chan bool{d.bodyWakeCh} {
 		for empty := false; !empty; {
 			select {
 			case <-ch:
@@ -207,7 +237,7 @@ func (d *Downloader) synchronise(id string, hash meta.BlockID) error {
 	}
 	for empty := false; !empty; {
 		select {
-		case <-d.blockProcCh:
+		case <-d.headerProcCh:
 		default:
 			empty = true
 		}
@@ -220,6 +250,9 @@ func (d *Downloader) synchronise(id string, hash meta.BlockID) error {
 
 	defer d.Cancel() // No matter what, we can't leave the cancel channel open
 
+	// Set the requested sync mode, unless it's forbidden
+	d.mode = mode
+
 	// Retrieve the origin peer and initiate the downloading process
 	p := d.peers.Peer(id)
 	if p == nil {
@@ -241,7 +274,7 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash meta.BlockID) (err err
 			d.mux.Post(DoneEvent{})
 		}
 	}()
-	if p.version < 1 {
+	if p.version < 2 {
 		return errTooOld
 	}
 
@@ -255,22 +288,41 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash meta.BlockID) (err err
 	if err != nil {
 		return err
 	}
-	height := uint64(latest.GetHeight())
+	height := uint64(latest.Height)
 
 	origin, err := d.findAncestor(p, height)
 	if err != nil {
 		return err
 	}
 
+	// Ensure our origin point is below the fast sync pivot, the state of the pivot
+	// is downloaded and the blocks above it are fully imported
+	pivot := uint64(0)
+	if d.mode == FastSync {
+		if height <= uint64(fsMinFullBlocks) {
+			d.mode = FullSync
+		} else {
+			pivot = height - uint64(fsMinFullBlocks)
+			if pivot <= origin {
+				origin = pivot - 1
+			}
+			log.Debug("Fast sync pivot selected", "pivot", pivot, "origin", origin)
+		}
+	}
 	d.committed = 1
+	if d.mode == FastSync && pivot != 0 {
+		d.committed = 0
+	}
 	d.queue.Prepare(origin+1, d.mode)
-	pivot := uint64(0)
 	fetchers := []func() error{
-		func() error { return d.fetchBlocks(p, origin+1, pivot) },
-		func() error { return d.processBlocks(origin+1, pivot) },
+		func() error { return d.fetchHeaders(p, origin+1, pivot) }, // Headers are always retrieved
+		func() error { return d.fetchBodies(origin + 1) },          // Bodies are retrieved during normal sync
+		func() error { return d.processHeaders(origin + 1) },
 	}
 	if d.mode == FullSync {
 		fetchers = append(fetchers, d.processFullSyncContent)
+	} else if d.mode == FastSync {
+		fetchers = append(fetchers, func() error { return d.processFastSyncContent(pivot) })
 	}
 	return d.spawnSync(fetchers)
 }
@@ -336,38 +388,38 @@ func (d *Downloader) Terminate() {
 
 // fetchHeight retrieves the head header of the remote peer to aid in estimating
 // the total time a pending synchronisation would take.
-func (d *Downloader) fetchHeight(p *peerConnection) (*meta.Block, error) {
+func (d *Downloader) fetchHeight(p *peerConnection) (*meta.BlockHeader, error) {
 	p.log.Trace("Retrieving remote chain height")
 
 	// Request the advertised remote head block and wait for the response
 	head, _ := p.peer.Head()
-	go p.peer.RequestBlocksByHash(head, 1, 0)
+	go p.peer.RequestHeadersByHash(head, 1, 0)
 
 	ttl := d.requestTTL()
 	timeout := time.After(ttl)
 	for {
 		select {
 		case <-d.cancelCh:
-			return nil, errCancelBlockFetch
+			return nil, errCancelHeaderFetch
 
-		case packet := <-d.blockCh:
+		case packet := <-d.headerCh:
 			// Discard anything not from the origin peer
 			if packet.PeerId() != p.id {
-				log.Trace("Received blocks from incorrect peer", "peer", packet.PeerId())
+				log.Trace("Received headers from incorrect peer", "peer", packet.PeerId())
 				break
 			}
 			// Make sure the peer actually gave something valid
-			blocks := packet.(*blockPack).blocks
-			if len(blocks) != 1 {
-				p.log.Trace("Multiple blocks for single request", "blocks", len(blocks))
+			headers := packet.(*headerPack).headers
+			if len(headers) != 1 {
+				p.log.Trace("Multiple headers for single request", "headers", len(headers))
 				return nil, errBadPeer
 			}
-			block := blocks[0]
-			p.log.Trace("Remote blocks identified", "number", block.GetHeight(), "hash", block.GetBlockID())
-			return block, nil
+			head := headers[0]
+			p.log.Trace("Remote head header identified", "number", head.Height, "hash", head.GetBlockID())
+			return head, nil
 
 		case <-timeout:
-			p.log.Trace("Waiting for head height header timed out", "elapsed", ttl)
+			p.log.Trace("Waiting for head header timed out", "elapsed", ttl)
 			return nil, errTimeout
 		}
 	}
@@ -383,6 +435,8 @@ func (d *Downloader) findAncestor(p *peerConnection, height uint64) (uint64, err
 	floor := int64(-1)
 	if d.mode == FullSync {
 		ceil = uint64(d.nodeAPI.GetBestBlock().GetHeight())
+	} else if d.mode == FastSync {
+		ceil = uint64(d.nodeAPI.GetBestFastBlock().GetHeight())
 	}
 
 	p.log.Debug("Looking for common ancestor", "local", ceil, "remote", height)
@@ -392,18 +446,18 @@ func (d *Downloader) findAncestor(p *peerConnection, height uint64) (uint64, err
 	if head > height {
 		head = height
 	}
-	from := int64(head) - int64(MaxBlockFetch)
+	from := int64(head) - int64(MaxHeaderFetch)
 	if from < 0 {
 		from = 0
 	}
 	// Span out with 15 block gaps into the future to catch bad head reports
-	limit := 2 * MaxBlockFetch / 16
+	limit := 2 * MaxHeaderFetch / 16
 	count := 1 + int((int64(ceil)-from)/16)
 	if count > limit {
 		count = limit
 	}
-	log.Debug("findAncestor RequestBlocksByNumber", "from", from, "count", count)
-	go p.peer.RequestBlocksByNumber(uint64(from), count, 15)
+	log.Debug("findAncestor RequestHeadersByNumber", "from", from, "count", count)
+	go p.peer.RequestHeadersByNumber(uint64(from), count, 15)
 
 	// Wait for the remote response to the head fetch
 	number := uint64(0)
@@ -415,37 +469,37 @@ func (d *Downloader) findAncestor(p *peerConnection, height uint64) (uint64, err
 	for finished := false; !finished; {
 		select {
 		case <-d.cancelCh:
-			return 0, errCancelBlockFetch
+			return 0, errCancelHeaderFetch
 
-		case packet := <-d.blockCh:
+		case packet := <-d.headerCh:
 			// Discard anything not from the origin peer
 			if packet.PeerId() != p.id {
 				log.Debug("Received headers from incorrect peer", "peer", packet.PeerId())
 				break
 			}
 			// Make sure the peer actually gave something valid
-			blocks := packet.(*blockPack).blocks
-			if len(blocks) == 0 {
-				p.log.Warn("Empty head block set")
+			headers := packet.(*headerPack).headers
+			if len(headers) == 0 {
+				p.log.Warn("Empty head header set")
 				return 0, errEmptyBlockSet
 			}
 			// Make sure the peer's reply conforms to the request
-			for i := 0; i < len(blocks); i++ {
-				if number := int64(blocks[i].GetHeight()); number != from+int64(i)*16 {
-					p.log.Warn("Head blocks broke chain ordering", "index", i, "requested", from+int64(i)*16, "received", number)
+			for i := 0; i < len(headers); i++ {
+				if number := int64(headers[i].Height); number != from+int64(i)*16 {
+					p.log.Warn("Head headers broke chain ordering", "index", i, "requested", from+int64(i)*16, "received", number)
 					return 0, errInvalidChain
 				}
 			}
 			// Check if a common ancestor was found
 			finished = true
-			for i := len(blocks) - 1; i >= 0; i-- {
+			for i := len(headers) - 1; i >= 0; i-- {
 				// Skip any headers that underflow/overflow our requested set
-				if int64(blocks[i].GetHeight()) < from || uint64(blocks[i].GetHeight()) > ceil {
+				if int64(headers[i].Height) < from || uint64(headers[i].Height) > ceil {
 					continue
 				}
 				// Otherwise check if we already know the header or not
-				if d.mode == FullSync && d.nodeAPI.HasBlock(*blocks[i].GetBlockID()) {
-					number, hash = uint64(blocks[i].GetHeight()), *blocks[i].GetBlockID()
+				if (d.mode == FullSync || d.mode == FastSync) && d.nodeAPI.HasBlock(*headers[i].GetBlockID()) {
+					number, hash = uint64(headers[i].Height), *headers[i].GetBlockID()
 
 					// If every header is known, even future ones, the peer straight out lied about its head
 					if number > height && i == limit-1 {
@@ -462,7 +516,7 @@ func (d *Downloader) findAncestor(p *peerConnection, height uint64) (uint64, err
 		}
 	}
 	// If the head fetch already found an ancestor, return
-	if hash.IsEmpty() {
+	if !hash.IsEmpty() {
 		if int64(number) <= floor {
 			p.log.Warn("Ancestor below allowance", "number", number, "hash", hash, "allowance", floor)
 			return 0, errInvalidAncestor
@@ -472,7 +526,9 @@ func (d *Downloader) findAncestor(p *peerConnection, height uint64) (uint64, err
 	}
 	// Ancestor not found, we need to binary search over our chain
 	start, end := uint64(0), head
-
+	if from > 0 {
+		end = uint64(from)
+	}
 	for start+1 < end {
 		// Split our chain interval in two, and request the hash to cross check
 		check := (start + end) / 2
@@ -480,34 +536,38 @@ func (d *Downloader) findAncestor(p *peerConnection, height uint64) (uint64, err
 		ttl := d.requestTTL()
 		timeout := time.After(ttl)
 
-		go p.peer.RequestBlocksByNumber(check, 1, 0)
+		go p.peer.RequestHeadersByNumber(check, 1, 0)
 
 		// Wait until a reply arrives to this request
 		for arrived := false; !arrived; {
 			select {
 			case <-d.cancelCh:
-				return 0, errCancelBlockFetch
+				return 0, errCancelHeaderFetch
 
-			case packer := <-d.blockCh:
+			case packer := <-d.headerCh:
 				// Discard anything not from the origin peer
 				if packer.PeerId() != p.id {
 					log.Trace("Received headers from incorrect peer", "peer", packer.PeerId())
 					break
 				}
 				// Make sure the peer actually gave something valid
-				blocks := packer.(*blockPack).blocks
-				if len(blocks) != 1 {
-					p.log.Trace("Multiple blocks for single request", "blocks", len(blocks))
+				headers := packer.(*headerPack).headers
+				if len(headers) != 1 {
+					p.log.Trace("Multiple headers for single request", "headers", len(headers))
 					return 0, errBadPeer
 				}
 				arrived = true
 
 				// Modify the search interval based on the response
-				if d.mode == FullSync && !d.nodeAPI.HasBlock(*blocks[0].GetBlockID()) {
+				if (d.mode == FullSync || d.mode == FastSync) && !d.nodeAPI.HasBlock(*headers[0].GetBlockID()) {
 					end = check
 					break
 				}
-				start = check
+				if uint64(headers[0].Height) != check {
+					p.log.Trace("Received non requested header", "number", headers[0].Height, "hash", headers[0].GetBlockID(), "request", check)
+					return 0, errBadPeer
+				}
+				start, hash = check, *headers[0].GetBlockID()
 
 			case <-timeout:
 				p.log.Trace("Waiting for search header timed out", "elapsed", ttl)
@@ -520,53 +580,57 @@ func (d *Downloader) findAncestor(p *peerConnection, height uint64) (uint64, err
 	return start, nil
 }
 
-func (d *Downloader) fetchBlocks(p *peerConnection, from uint64, pivot uint64) error {
-	p.log.Debug("Directing block downloads", "origin", from)
-	defer p.log.Debug("Block download terminated")
+// fetchHeaders keeps retrieving headers concurrently from the number requested,
+// until no more are returned. To facilitate concurrency but still protect
+// against malicious nodes sending bad headers, a header chain skeleton is built
+// from the origin peer we are syncing with and the gaps are filled in by any
+// other peer. Headers from other peers are only accepted if they map cleanly to
+// the skeleton. If no one can fill in the skeleton - not even the origin peer -
+// it's assumed invalid and the origin is dropped.
+func (d *Downloader) fetchHeaders(p *peerConnection, from uint64, pivot uint64) error {
+	p.log.Debug("Directing header downloads", "origin", from)
+	defer p.log.Debug("Header download terminated")
 
 	// Create a timeout timer, and the associated header fetcher
-	skeleton := false           // Skeleton assembly phase or finishing up
+	skeleton := true            // Skeleton assembly phase or finishing up
 	request := time.Now()       // time of the last skeleton fetch request
 	timeout := time.NewTimer(0) // timer to dump a non-responsive active peer
 	<-timeout.C                 // timeout channel should be initially empty
 	defer timeout.Stop()
 
-	var ttl time.Duration
-	getBlocks := func(from uint64) {
+	getHeaders := func(from uint64) {
 		request = time.Now()
-
-		ttl = d.requestTTL()
-		timeout.Reset(ttl)
+		timeout.Reset(d.requestTTL())
 
 		if skeleton {
-			p.log.Trace("Fetching skeleton blocks", "count", MaxBlockFetch, "from", from)
-			go p.peer.RequestBlocksByNumber(from+uint64(MaxBlockFetch)-1, MaxSkeletonSize, MaxBlockFetch-1)
+			p.log.Trace("Fetching skeleton headers", "count", MaxHeaderFetch, "from", from)
+			go p.peer.RequestHeadersByNumber(from+uint64(MaxHeaderFetch)-1, MaxSkeletonSize, MaxHeaderFetch-1)
 		} else {
-			p.log.Trace("Fetching full headers", "count", MaxBlockFetch, "from", from)
-			go p.peer.RequestBlocksByNumber(from, MaxBlockFetch, 0)
+			p.log.Trace("Fetching full headers", "count", MaxHeaderFetch, "from", from)
+			go p.peer.RequestHeadersByNumber(from, MaxHeaderFetch, 0)
 		}
 	}
 	// Start pulling the header chain skeleton until all is done
-	getBlocks(from)
+	getHeaders(from)
 
 	for {
 		select {
 		case <-d.cancelCh:
-			return errCancelBlockFetch
+			return errCancelHeaderFetch
 
-		case packet := <-d.blockCh:
+		case packet := <-d.headerCh:
 			// Make sure the active peer is giving us the skeleton headers
 			if packet.PeerId() != p.id {
 				log.Debug("Received skeleton from incorrect peer", "peer", packet.PeerId())
 				break
 			}
-
 			timeout.Stop()
-			log.Trace("Received skeleton result", "packet.Items()", packet.Items())
+			p.log.Trace("Received header batch", "count", packet.Items(), "skeleton", skeleton, "elapsed", time.Since(request))
+
 			// If the skeleton's finished, pull any remaining head headers directly from the origin
 			if packet.Items() == 0 && skeleton {
 				skeleton = false
-				getBlocks(from)
+				getHeaders(from)
 				continue
 			}
 			// If no more headers are inbound, notify the content fetchers and return
@@ -576,44 +640,44 @@ func (d *Downloader) fetchBlocks(p *peerConnection, from uint64, pivot uint64) e
 					p.log.Trace("No headers, waiting for pivot commit")
 					select {
 					case <-time.After(fsBlockContCheck):
-						getBlocks(from)
+						getHeaders(from)
 						continue
 					case <-d.cancelCh:
-						return errCancelBlockFetch
+						return errCancelHeaderFetch
 					}
 				}
 				// Pivot done (or not in fast sync) and no more headers, terminate the process
 				p.log.Trace("No more headers available")
 				select {
-				case d.blockProcCh <- nil:
+				case d.headerProcCh <- nil:
 					return nil
 				case <-d.cancelCh:
-					return errCancelBlockFetch
+					return errCancelHeaderFetch
 				}
 			}
-			blocks := packet.(*blockPack).blocks
+			headers := packet.(*headerPack).headers
 
 			// If we received a skeleton batch, resolve internals concurrently
 			if skeleton {
-				filled, proced, err := d.fillBlockSkeleton(from, blocks)
+				filled, proced, err := d.fillHeaderSkeleton(from, headers)
 				if err != nil {
-					p.log.Trace("Skeleton chain invalid", "err", err)
+					p.log.Debug("Skeleton chain invalid", "err", err)
 					return errInvalidChain
 				}
-				blocks = filled[proced:]
+				headers = filled[proced:]
 				from += uint64(proced)
 			}
 			// Insert all the new headers and fetch the next batch
-			if len(blocks) > 0 {
-				p.log.Trace("Scheduling new blocks", "count", len(blocks), "from", from)
+			if len(headers) > 0 {
+				p.log.Trace("Scheduling new headers", "count", len(headers), "from", from)
 				select {
-				case d.blockProcCh <- blocks:
+				case d.headerProcCh <- headers:
 				case <-d.cancelCh:
-					return errCancelBlockFetch
+					return errCancelHeaderFetch
 				}
-				from += uint64(len(blocks))
+				from += uint64(len(headers))
 			}
-			getBlocks(from)
+			getHeaders(from)
 
 		case <-timeout.C:
 			if d.dropPeer == nil {
@@ -622,13 +686,14 @@ func (d *Downloader) fetchBlocks(p *peerConnection, from uint64, pivot uint64) e
 				p.log.Warn("Downloader wants to drop peer, but peerdrop-function is not set", "peer", p.id)
 				break
 			}
-			// header retrieval timed out, consider the peer bad and drop
-			p.log.Trace("header request timed out", "elapsed", ttl)
+			// Header retrieval timed out, consider the peer bad and drop
+			p.log.Debug("Header request timed out", "elapsed", time.Since(request))
 
 			d.dropPeer(p.id)
 
+			// Finish the sync gracefully instead of dumping the gathered data though
 			select {
-			case d.blockProcCh <- nil:
+			case d.headerProcCh <- nil:
 			case <-d.cancelCh:
 			}
 			return errBadPeer
@@ -636,37 +701,70 @@ func (d *Downloader) fetchBlocks(p *peerConnection, from uint64, pivot uint64) e
 	}
 }
 
-func (d *Downloader) fillBlockSkeleton(from uint64, skeleton []*meta.Block) ([]*meta.Block, int, error) {
-	log.Trace("Filling up skeleton", "from", from)
+// fillHeaderSkeleton concurrently retrieves headers from all our available peers
+// and maps them to the provided skeleton header chain.
+//
+// Any partial results from the beginning of the skeleton is (if possible) forwarded
+// immediately to the header processor to keep the rest of the pipeline full even
+// in the case of header stalls.
+//
+// The method returns the entire filled skeleton and also the number of headers
+// already forwarded for processing.
+func (d *Downloader) fillHeaderSkeleton(from uint64, skeleton []*meta.BlockHeader) ([]*meta.BlockHeader, int, error) {
+	log.Debug("Filling up skeleton", "from", from)
 	d.queue.ScheduleSkeleton(from, skeleton)
 
 	var (
 		deliver = func(packet dataPack) (int, error) {
-			pack := packet.(*blockPack)
-			return d.queue.DeliverBlocks(pack.peerId, pack.blocks, d.blockProcCh)
+			pack := packet.(*headerPack)
+			return d.queue.DeliverHeaders(pack.peerId, pack.headers, d.headerProcCh)
 		}
-		expire   = func() map[string]int { return d.queue.ExpireBlocks(d.requestTTL()) }
+		expire   = func() map[string]int { return d.queue.ExpireHeaders(d.requestTTL()) }
 		throttle = func() bool { return false }
 		reserve  = func(p *peerConnection, count int) (*fetchRequest, bool, error) {
-			return d.queue.ReserveBlocks(p, count), false, nil
+			return d.queue.ReserveHeaders(p, count), false, nil
 		}
-		fetch    = func(p *peerConnection, req *fetchRequest) error { return p.FetchBlocks(req.From, MaxBlockFetch) }
-		capacity = func(p *peerConnection) int { return p.BlockCapacity(d.requestRTT()) }
-		setIdle  = func(p *peerConnection, accepted int) { p.SetBlocksIdle(accepted) }
+		fetch    = func(p *peerConnection, req *fetchRequest) error { return p.FetchHeaders(req.From, MaxHeaderFetch) }
+		capacity = func(p *peerConnection) int { return p.HeaderCapacity(d.requestRTT()) }
+		setIdle  = func(p *peerConnection, accepted int) { p.SetHeadersIdle(accepted) }
 	)
-	err := d.fetchParts(errCancelBlockFetch, d.blockCh, deliver, d.queue.blockContCh, expire,
-		d.queue.PendingBlocks, d.queue.InFlightBlocks, throttle, reserve,
-		nil, fetch, d.queue.CancelBlocks, capacity, d.peers.BlockIdlePeers, setIdle, "blocks")
+	err := d.fetchParts(errCancelHeaderFetch, d.headerCh, deliver, d.queue.headerContCh, expire,
+		d.queue.PendingHeaders, d.queue.InFlightHeaders, throttle, reserve,
+		nil, fetch, d.queue.CancelHeaders, capacity, d.peers.HeaderIdlePeers, setIdle, "headers")
 
-	log.Trace("Skeleton fill terminated", "err", err)
+	log.Debug("Skeleton fill terminated", "err", err)
 
-	filled, proced := d.queue.RetrieveBlocks()
+	filled, proced := d.queue.RetrieveHeaders()
 	return filled, proced, err
 }
 
+// fetchBodies iteratively downloads the scheduled block bodies, taking any
+// available peers, reserving a chunk of blocks for each, waiting for delivery
+// and also periodically checking for timeouts.
+func (d *Downloader) fetchBodies(from uint64) error {
+	log.Debug("Downloading block bodies", "origin", from)
+
+	var (
+		deliver = func(packet dataPack) (int, error) {
+			pack := packet.(*bodyPack)
+			return d.queue.DeliverBodies(pack.peerId, pack.transactions)
+		}
+		expire   = func() map[string]int { return d.queue.ExpireBodies(d.requestTTL()) }
+		fetch    = func(p *peerConnection, req *fetchRequest) error { return p.FetchBodies(req) }
+		capacity = func(p *peerConnection) int { return p.BlockCapacity(d.requestRTT()) }
+		setIdle  = func(p *peerConnection, accepted int) { p.SetBodiesIdle(accepted) }
+	)
+	err := d.fetchParts(errCancelBodyFetch, d.bodyCh, deliver, d.bodyWakeCh, expire,
+		d.queue.PendingBlocks, d.queue.InFlightBlocks, d.queue.ShouldThrottleBlocks, d.queue.ReserveBodies,
+		nil, fetch, d.queue.CancelBodies, capacity, d.peers.BodyIdlePeers, setIdle, "bodies")
+
+	log.Debug("Block body download terminated", "err", err)
+	return err
+}
+
 func (d *Downloader) fetchParts(errCancel error, deliveryCh chan dataPack, deliver func(dataPack) (int, error), wakeCh chan bool,
 	expire func() map[string]int, pending func() int, inFlight func() bool, throttle func() bool, reserve func(*peerConnection, int) (*fetchRequest, bool, error),
-	fetchHook func([]*meta.Block), fetch func(*peerConnection, *fetchRequest) error, cancel func(*fetchRequest), capacity func(*peerConnection) int,
+	fetchHook func([]*meta.BlockHeader), fetch func(*peerConnection, *fetchRequest) error, cancel func(*fetchRequest), capacity func(*peerConnection) int,
 	idle func() ([]*peerConnection, int), setIdle func(*peerConnection, int), kind string) error {
 
 	// Create a ticker to detect expired retrieval tasks
@@ -799,11 +897,11 @@ func (d *Downloader) fetchParts(errCancel error, deliveryCh chan dataPack, deliv
 				if request.From > 0 {
 					peer.log.Trace("Requesting new batch of data", "type", kind, "from", request.From)
 				} else {
-					peer.log.Trace("Requesting new batch of data", "type", kind, "count", len(request.Blocks), "from", request.Blocks[0].GetHeight())
+					peer.log.Trace("Requesting new batch of data", "type", kind, "count", len(request.Headers), "from", request.Headers[0].Height)
 				}
 				// Fetch the chunk and make sure any errors return the hashes to the queue
 				if fetchHook != nil {
-					fetchHook(request.Blocks)
+					fetchHook(request.Headers)
 				}
 				if err := fetch(peer, request); err != nil {
 					// Although we could try and make an attempt to fix this, this error really
@@ -824,77 +922,136 @@ func (d *Downloader) fetchParts(errCancel error, deliveryCh chan dataPack, deliv
 	}
 }
 
-func (d *Downloader) processBlocks(origin uint64, pivot uint64) error {
-	// Keep a count of uncertain headers to roll back
-	rollback := []*meta.Block{}
-	defer func() {
-		if len(rollback) > 0 {
-			// Flatten the headers and roll them back
-			hashes := make([]meta.BlockID, len(rollback))
-			for i, block := range rollback {
-				hashes[i] = *block.GetBlockID()
-			}
-
-			lastBlock := d.nodeAPI.GetBestBlock().GetHeight()
-
-			// TODO: add rollback code
-			// d.lightchain.Rollback(hashes)
-
-			curBlock := d.nodeAPI.GetBestBlock().GetHeight()
-			log.Warn("Rolled back blocks", "count", len(hashes),
-				"block", fmt.Sprintf("%d->%d", lastBlock, curBlock))
-		}
-	}()
-
-	// Wait for batches of blocks to process
-	// gotBlocks := false
-
+// processHeaders takes batches of retrieved headers from an input channel, checks
+// their seals and keeps scheduling them for body retrieval until the stream ends
+// or a failure occurs.
+func (d *Downloader) processHeaders(origin uint64) error {
+	var prev *meta.BlockHeader // Last header checked, the parent of the next one
 	for {
 		select {
 		case <-d.cancelCh:
-			return errCancelBlockProcessing
+			return errCancelHeaderProcessing
 
-		case blocks := <-d.blockProcCh:
+		case headers := <-d.headerProcCh:
 			// Terminate header processing if we synced up
-			if len(blocks) == 0 {
-				// Disable any rollback and return
-				rollback = nil
+			if len(headers) == 0 {
+				// Notify everyone that headers are fully processed
+				select {
+				case d.bodyWakeCh <- false:
+				case <-d.cancelCh:
+				}
 				return nil
 			}
 			// Otherwise split the chunk of headers into batches and process them
-			// gotBlocks = true
-
-			for len(blocks) > 0 {
+			for len(headers) > 0 {
 				// Terminate if something failed in between processing chunks
 				select {
 				case <-d.cancelCh:
-					return errCancelBlockProcessing
+					return errCancelHeaderProcessing
 				default:
 				}
 				// Select the next chunk of headers to import
-				limit := maxBlocksProcess
-				if limit > len(blocks) {
-					limit = len(blocks)
+				limit := maxHeadersProcess
+				if limit > len(headers) {
+					limit = len(headers)
 				}
-				chunk := blocks[:limit]
+				chunk := headers[:limit]
 
+				if err := d.verifyHeaderSeals(chunk, prev); err != nil {
+					return err
+				}
+				prev = chunk[len(chunk)-1]
 				// Unless we're doing light chains, schedule the headers for associated content retrieval
-				if d.mode == FullSync {
+				if d.mode == FullSync || d.mode == FastSync {
 					// Otherwise insert the headers for content retrieval
 					inserts := d.queue.Schedule(chunk, origin)
 					if len(inserts) != len(chunk) {
-						log.Trace("Stale headers")
+						log.Debug("Stale headers")
 						return errBadPeer
 					}
 				}
-				blocks = blocks[limit:]
+				headers = headers[limit:]
 				origin += uint64(limit)
 			}
+			// Signal the content downloaders of the availablility of new tasks
+			select {
+			case d.bodyWakeCh <- true:
+			default:
+			}
+		}
+	}
+}
+
+// verifyHeaderSeals checks the consensus seals of the headers whose parents are in
+// the local chain. The other headers have to extend the header checked before, prev,
+// their seals are checked by verifyBlockSeals once the bodies are delivered, as the
+// signer votes of poa are carried in the bodies. A header whose parent is neither in
+// the batch nor in the local chain is rejected.
+func (d *Downloader) verifyHeaderSeals(headers []*meta.BlockHeader, prev *meta.BlockHeader) error {
+	engine := d.nodeAPI.GetEngine()
+	for _, header := range headers {
+		if prev != nil && header.Prev.IsEqual(prev.GetBlockID()) {
+			prev = header
+			continue
+		}
+		if !d.nodeAPI.HasBlock(header.Prev) {
+			log.Debug("Unknown header ancestor", "number", header.Height, "hash", header.GetBlockID(), "parent", header.Prev)
+			return errInvalidChain
+		}
+		if err := engine.VerifySeal(d.nodeAPI, &meta.Block{Header: *header}); err != nil {
+			log.Debug("Invalid header seal", "number", header.Height, "hash", header.GetBlockID(), "err", err)
+			return errInvalidChain
+		}
+		prev = header
+	}
+	return nil
+}
+
+// verifyBlockSeals checks the consensus seals of the downloaded blocks before they
+// are written, the parents and signer snapshots are resolved from the blocks checked
+// before in the batch or from the local chain. A block whose parent is in neither is
+// rejected.
+func (d *Downloader) verifyBlockSeals(results []*fetchResult) error {
+	engine := d.nodeAPI.GetEngine()
+	chain := &sealChain{chain: d.nodeAPI, blocks: make(map[meta.BlockID]*meta.Block, len(results))}
+	for _, result := range results {
+		block := result.Block
+		if d.nodeAPI.HasBlock(*block.GetBlockID()) {
+			continue
 		}
+		if _, ok := chain.blocks[*block.GetPrevBlockID()]; !ok && !d.nodeAPI.HasBlock(*block.GetPrevBlockID()) {
+			log.Debug("Unknown block ancestor", "number", block.GetHeight(), "hash", block.GetBlockID(), "parent", block.GetPrevBlockID())
+			return errInvalidChain
+		}
+		if err := engine.VerifySeal(chain, block); err != nil {
+			log.Debug("Invalid block seal", "number", block.GetHeight(), "hash", block.GetBlockID(), "err", err)
+			return errInvalidChain
+		}
+		chain.blocks[*block.GetBlockID()] = block
 	}
 	return nil
 }
 
+// sealChain is the chain the seals of a downloaded batch are checked against, the
+// blocks checked in the batch are found before they are written to the local chain.
+type sealChain struct {
+	chain  meta.ChainReader
+	blocks map[meta.BlockID]*meta.Block
+}
+
+func (c *sealChain) GetBlockByID(hash meta.BlockID) (*meta.Block, error) {
+	if block, ok := c.blocks[hash]; ok {
+		return block, nil
+	}
+	return c.chain.GetBlockByID(hash)
+}
+
+// GetBlockByHeight returns the canonical block of the local chain, the batch is
+// not canonical until it is imported.
+func (c *sealChain) GetBlockByHeight(height uint32) (*meta.Block, error) {
+	return c.chain.GetBlockByHeight(height)
+}
+
 // processFullSyncContent takes fetch results from the queue and imports them into the chain.
 func (d *Downloader) processFullSyncContent() error {
 	for {
@@ -902,6 +1059,9 @@ func (d *Downloader) processFullSyncContent() error {
 		if len(results) == 0 {
 			return nil
 		}
+		if err := d.verifyBlockSeals(results); err != nil {
+			return err
+		}
 		if err := d.importBlockResults(results); err != nil {
 			log.Error("importBlockResults failed", "err", err)
 			return err
@@ -909,6 +1069,79 @@ func (d *Downloader) processFullSyncContent() error {
 	}
 }
 
+// processFastSyncContent takes fetch results from the queue and writes them to the
+// chain without execution up to the pivot. The state of the pivot is downloaded
+// and committed as the head, the blocks above it are fully imported.
+func (d *Downloader) processFastSyncContent(pivot uint64) error {
+	for {
+		results := d.queue.Results(true)
+		if len(results) == 0 {
+			if atomic.LoadInt32(&d.committed) == 0 {
+				return errPivotUncommitted
+			}
+			return nil
+		}
+		if err := d.verifyBlockSeals(results); err != nil {
+			return err
+		}
+		if atomic.LoadInt32(&d.committed) == 1 {
+			if err := d.importBlockResults(results); err != nil {
+				return err
+			}
+			continue
+		}
+		P, beforeP, afterP := splitAroundPivot(pivot, results)
+		if err := d.commitFastSyncData(beforeP); err != nil {
+			return err
+		}
+		if P != nil {
+			if err := d.commitPivotBlock(P); err != nil {
+				return err
+			}
+			atomic.StoreInt32(&d.committed, 1)
+		}
+		if err := d.importBlockResults(afterP); err != nil {
+			return err
+		}
+	}
+}
+
+// commitFastSyncData writes the blocks below the pivot without execution.
+func (d *Downloader) commitFastSyncData(results []*fetchResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	select {
+	case <-d.quitCh:
+		return errCancelContentProcessing
+	default:
+	}
+	blocks := make([]*meta.Block, len(results))
+	for i, result := range results {
+		blocks[i] = result.Block
+	}
+	if err := d.nodeAPI.InsertFastChain(blocks); err != nil {
+		log.Debug("Downloaded item processing failed", "number", blocks[0].GetHeight(), "err", err)
+		return errInvalidChain
+	}
+	return nil
+}
+
+// commitPivotBlock writes the pivot block, downloads its state and sets it as
+// the head block.
+func (d *Downloader) commitPivotBlock(result *fetchResult) error {
+	block := result.Block
+	log.Debug("Committing fast sync pivot as new head", "number", block.GetHeight(), "hash", block.GetBlockID())
+	if err := d.nodeAPI.InsertFastChain([]*meta.Block{block}); err != nil {
+		log.Debug("Downloaded pivot processing failed", "number", block.GetHeight(), "err", err)
+		return errInvalidChain
+	}
+	if err := d.syncState(*block.GetStatus()); err != nil {
+		return err
+	}
+	return d.nodeAPI.FastSyncCommitHead(*block.GetBlockID())
+}
+
 func (d *Downloader) ImportBlocks(id string, blocks []*meta.Block) error {
 	var results []*fetchResult
 	for _, block := range blocks {
@@ -970,8 +1203,18 @@ func splitAroundPivot(pivot uint64, results []*fetchResult) (p *fetchResult, bef
 
 // DeliverHeaders injects a new batch of block headers received from a remote
 // node into the download schedule.
-func (d *Downloader) DeliverBlocks(id string, blocks []*meta.Block) (err error) {
-	return d.deliver(id, d.blockCh, &blockPack{id, blocks})
+func (d *Downloader) DeliverHeaders(id string, headers []*meta.BlockHeader) (err error) {
+	return d.deliver(id, d.headerCh, &headerPack{id, headers})
+}
+
+// DeliverBodies injects a new batch of block bodies received from a remote node.
+func (d *Downloader) DeliverBodies(id string, transactions [][]meta.Transaction) (err error) {
+	return d.deliver(id, d.bodyCh, &bodyPack{id, transactions})
+}
+
+// DeliverNodeData injects a new batch of node state data received from a remote node.
+func (d *Downloader) DeliverNodeData(id string, data [][]byte) (err error) {
+	return d.deliver(id, d.stateCh, &nodeDataPack{id, data})
 }
 
 // deliver injects a new batch of data received from a remote node.
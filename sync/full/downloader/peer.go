@@ -10,6 +10,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	common_math "github.com/mihongtech/linkchain/common/math"
 	"github.com/mihongtech/linkchain/common/util/event"
 	"github.com/mihongtech/linkchain/common/util/log"
 	"github.com/mihongtech/linkchain/core/meta"
@@ -30,13 +31,19 @@ var (
 type peerConnection struct {
 	id string // Unique identifier of the peer
 
-	blockIdle int32 // Current header activity state of the peer (idle = 0, active = 1)
+	headerIdle int32 // Current header activity state of the peer (idle = 0, active = 1)
+	blockIdle  int32 // Current block activity state of the peer (idle = 0, active = 1)
+	stateIdle  int32 // Current node data activity state of the peer (idle = 0, active = 1)
 
-	blockThroughput float64 // Number of headers measured to be retrievable per second
+	headerThroughput float64 // Number of headers measured to be retrievable per second
+	blockThroughput  float64 // Number of blocks (bodies) measured to be retrievable per second
+	stateThroughput  float64 // Number of node data pieces measured to be retrievable per second
 
 	rtt time.Duration // Request round trip time to track responsiveness (QoS)
 
-	blockStarted time.Time // Time instance when the last header fetch was started
+	headerStarted time.Time // Time instance when the last header fetch was started
+	blockStarted  time.Time // Time instance when the last block (body) fetch was started
+	stateStarted  time.Time // Time instance when the last node data fetch was started
 
 	lacking map[meta.BlockID]struct{} // Set of hashes not to request (didn't have previously)
 
@@ -50,13 +57,15 @@ type peerConnection struct {
 // LightPeer encapsulates the methods required to synchronise with a remote light peer.
 type LightPeer interface {
 	Head() (meta.BlockID, uint64)
-	RequestBlocksByHash(meta.BlockID, int, int) error
-	RequestBlocksByNumber(uint64, int, int) error
+	RequestHeadersByHash(meta.BlockID, int, int) error
+	RequestHeadersByNumber(uint64, int, int) error
 }
 
 // Peer encapsulates the methods required to synchronise with a remote full peer.
 type Peer interface {
 	LightPeer
+	RequestBodies([]meta.BlockID) error
+	RequestNodeData([]common_math.Hash) error
 }
 
 // lightPeerWrapper wraps a LightPeer struct, stubbing out the Peer-only methods.
@@ -65,11 +74,17 @@ type lightPeerWrapper struct {
 }
 
 func (w *lightPeerWrapper) Head() (meta.BlockID, uint64) { return w.peer.Head() }
-func (w *lightPeerWrapper) RequestBlocksByHash(h meta.BlockID, amount int, skip int) error {
-	return w.peer.RequestBlocksByHash(h, amount, skip)
+func (w *lightPeerWrapper) RequestHeadersByHash(h meta.BlockID, amount int, skip int) error {
+	return w.peer.RequestHeadersByHash(h, amount, skip)
 }
-func (w *lightPeerWrapper) RequestBlocksByNumber(i uint64, amount int, skip int) error {
-	return w.peer.RequestBlocksByNumber(i, amount, skip)
+func (w *lightPeerWrapper) RequestHeadersByNumber(i uint64, amount int, skip int) error {
+	return w.peer.RequestHeadersByNumber(i, amount, skip)
+}
+func (w *lightPeerWrapper) RequestBodies([]meta.BlockID) error {
+	panic("RequestBodies not supported in light client mode sync")
+}
+func (w *lightPeerWrapper) RequestNodeData([]common_math.Hash) error {
+	panic("RequestNodeData not supported in light client mode sync")
 }
 
 // newPeerConnection creates a new downloader peer.
@@ -90,18 +105,40 @@ func (p *peerConnection) Reset() {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
+	atomic.StoreInt32(&p.headerIdle, 0)
 	atomic.StoreInt32(&p.blockIdle, 0)
+	atomic.StoreInt32(&p.stateIdle, 0)
 
+	p.headerThroughput = 0
 	p.blockThroughput = 0
+	p.stateThroughput = 0
 
 	p.lacking = make(map[meta.BlockID]struct{})
 }
 
 // FetchHeaders sends a header retrieval request to the remote peer.
-func (p *peerConnection) FetchBlocks(from uint64, count int) error {
+func (p *peerConnection) FetchHeaders(from uint64, count int) error {
 	// Sanity check the protocol version
-	if p.version < 1 {
-		panic(fmt.Sprintf("header fetch [eth/62+] requested on eth/%d", p.version))
+	if p.version < 2 {
+		panic(fmt.Sprintf("header fetch [full/02+] requested on full/%d", p.version))
+	}
+	// Short circuit if the peer is already fetching
+	if !atomic.CompareAndSwapInt32(&p.headerIdle, 0, 1) {
+		return errAlreadyFetching
+	}
+	p.headerStarted = time.Now()
+
+	// Issue the header retrieval request (absolut upwards without gaps)
+	go p.peer.RequestHeadersByNumber(from, count, 0)
+
+	return nil
+}
+
+// FetchBodies sends a block body retrieval request to the remote peer.
+func (p *peerConnection) FetchBodies(request *fetchRequest) error {
+	// Sanity check the protocol version
+	if p.version < 2 {
+		panic(fmt.Sprintf("body fetch [full/02+] requested on full/%d", p.version))
 	}
 	// Short circuit if the peer is already fetching
 	if !atomic.CompareAndSwapInt32(&p.blockIdle, 0, 1) {
@@ -109,19 +146,54 @@ func (p *peerConnection) FetchBlocks(from uint64, count int) error {
 	}
 	p.blockStarted = time.Now()
 
-	// Issue the header retrieval request (absolut upwards without gaps)
-	go p.peer.RequestBlocksByNumber(from, count, 0)
+	// Convert the header set to a retrievable slice
+	hashes := make([]meta.BlockID, 0, len(request.Headers))
+	for _, header := range request.Headers {
+		hashes = append(hashes, *header.GetBlockID())
+	}
+	go p.peer.RequestBodies(hashes)
 
 	return nil
 }
 
-// SetBlocksIdle sets the peer to idle, allowing it to execute new block retrieval
-// requests. Its estimated block retrieval throughput is updated with that measured
+// FetchNodeData sends a node state data retrieval request to the remote peer.
+func (p *peerConnection) FetchNodeData(hashes []common_math.Hash) error {
+	// Sanity check the protocol version
+	if p.version < 3 {
+		panic(fmt.Sprintf("node data fetch [full/03+] requested on full/%d", p.version))
+	}
+	// Short circuit if the peer is already fetching
+	if !atomic.CompareAndSwapInt32(&p.stateIdle, 0, 1) {
+		return errAlreadyFetching
+	}
+	p.stateStarted = time.Now()
+
+	go p.peer.RequestNodeData(hashes)
+
+	return nil
+}
+
+// SetHeadersIdle sets the peer to idle, allowing it to execute new header retrieval
+// requests. Its estimated header retrieval throughput is updated with that measured
 // just now.
-func (p *peerConnection) SetBlocksIdle(delivered int) {
+func (p *peerConnection) SetHeadersIdle(delivered int) {
+	p.setIdle(p.headerStarted, delivered, &p.headerThroughput, &p.headerIdle)
+}
+
+// SetBodiesIdle sets the peer to idle, allowing it to execute new block body
+// retrieval requests. Its estimated body retrieval throughput is updated with
+// that measured just now.
+func (p *peerConnection) SetBodiesIdle(delivered int) {
 	p.setIdle(p.blockStarted, delivered, &p.blockThroughput, &p.blockIdle)
 }
 
+// SetNodeDataIdle sets the peer to idle, allowing it to execute new state trie
+// data retrieval requests. Its estimated state retrieval throughput is updated
+// with that measured just now.
+func (p *peerConnection) SetNodeDataIdle(delivered int) {
+	p.setIdle(p.stateStarted, delivered, &p.stateThroughput, &p.stateIdle)
+}
+
 // setIdle sets the peer to idle, allowing it to execute new retrieval requests.
 // Its estimated retrieval throughput is updated with that measured just now.
 func (p *peerConnection) setIdle(started time.Time, delivered int, throughput *float64, idle *int32) {
@@ -144,17 +216,35 @@ func (p *peerConnection) setIdle(started time.Time, delivered int, throughput *f
 	p.rtt = time.Duration((1-measurementImpact)*float64(p.rtt) + measurementImpact*float64(elapsed))
 
 	p.log.Trace("Peer throughput measurements updated",
-		"hps", p.blockThroughput,
+		"hps", p.headerThroughput, "bps", p.blockThroughput, "sps", p.stateThroughput,
 		"miss", len(p.lacking), "rtt", p.rtt)
 }
 
 // HeaderCapacity retrieves the peers header download allowance based on its
 // previously discovered throughput.
+func (p *peerConnection) HeaderCapacity(targetRTT time.Duration) int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return int(math.Min(1+math.Max(1, p.headerThroughput*float64(targetRTT)/float64(time.Second)), float64(MaxHeaderFetch)))
+}
+
+// BlockCapacity retrieves the peers block body download allowance based on its
+// previously discovered throughput.
 func (p *peerConnection) BlockCapacity(targetRTT time.Duration) int {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
 
-	return int(math.Min(1+math.Max(1, p.blockThroughput*float64(targetRTT)/float64(time.Second)), float64(MaxBlockFetch)))
+	return int(math.Min(1+math.Max(1, p.blockThroughput*float64(targetRTT)/float64(time.Second)), float64(MaxBodyFetch)))
+}
+
+// NodeDataCapacity retrieves the peers state download allowance based on its
+// previously discovered throughput.
+func (p *peerConnection) NodeDataCapacity(targetRTT time.Duration) int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return int(math.Min(1+math.Max(1, p.stateThroughput*float64(targetRTT)/float64(time.Second)), float64(MaxStateFetch)))
 }
 
 // MarkLacking appends a new entity to the set of items (blocks, receipts, states)
@@ -237,14 +327,18 @@ func (ps *peerSet) Register(p *peerConnection) error {
 		return errAlreadyRegistered
 	}
 	if len(ps.peers) > 0 {
-		p.blockThroughput = 0
+		p.headerThroughput, p.blockThroughput, p.stateThroughput = 0, 0, 0
 
 		for _, peer := range ps.peers {
 			peer.lock.RLock()
+			p.headerThroughput += peer.headerThroughput
 			p.blockThroughput += peer.blockThroughput
+			p.stateThroughput += peer.stateThroughput
 			peer.lock.RUnlock()
 		}
+		p.headerThroughput /= float64(len(ps.peers))
 		p.blockThroughput /= float64(len(ps.peers))
+		p.stateThroughput /= float64(len(ps.peers))
 	}
 	ps.peers[p.id] = p
 	ps.lock.Unlock()
@@ -299,7 +393,21 @@ func (ps *peerSet) AllPeers() []*peerConnection {
 
 // HeaderIdlePeers retrieves a flat list of all the currently header-idle peers
 // within the active peer set, ordered by their reputation.
-func (ps *peerSet) BlockIdlePeers() ([]*peerConnection, int) {
+func (ps *peerSet) HeaderIdlePeers() ([]*peerConnection, int) {
+	idle := func(p *peerConnection) bool {
+		return atomic.LoadInt32(&p.headerIdle) == 0
+	}
+	throughput := func(p *peerConnection) float64 {
+		p.lock.RLock()
+		defer p.lock.RUnlock()
+		return p.headerThroughput
+	}
+	return ps.idlePeers(2, 3, idle, throughput)
+}
+
+// BodyIdlePeers retrieves a flat list of all the currently body-idle peers within
+// the active peer set, ordered by their reputation.
+func (ps *peerSet) BodyIdlePeers() ([]*peerConnection, int) {
 	idle := func(p *peerConnection) bool {
 		return atomic.LoadInt32(&p.blockIdle) == 0
 	}
@@ -308,7 +416,21 @@ func (ps *peerSet) BlockIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.blockThroughput
 	}
-	return ps.idlePeers(62, 64, idle, throughput)
+	return ps.idlePeers(2, 3, idle, throughput)
+}
+
+// NodeDataIdlePeers retrieves a flat list of all the currently node-data-idle
+// peers within the active peer set, ordered by their reputation.
+func (ps *peerSet) NodeDataIdlePeers() ([]*peerConnection, int) {
+	idle := func(p *peerConnection) bool {
+		return atomic.LoadInt32(&p.stateIdle) == 0
+	}
+	throughput := func(p *peerConnection) float64 {
+		p.lock.RLock()
+		defer p.lock.RUnlock()
+		return p.stateThroughput
+	}
+	return ps.idlePeers(3, 3, idle, throughput)
 }
 
 // idlePeers retrieves a flat list of all currently idle peers satisfying the
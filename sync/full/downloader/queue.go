@@ -25,10 +25,10 @@ var (
 
 // fetchRequest is a currently running data retrieval operation.
 type fetchRequest struct {
-	Peer   *peerConnection // Peer to which the request was sent
-	From   uint64
-	Blocks []*meta.Block
-	Time   time.Time // Time when the request was made
+	Peer    *peerConnection     // Peer to which the request was sent
+	From    uint64              // [full/02] Requested chain element index (used for skeleton fills only)
+	Headers []*meta.BlockHeader // [full/02] Requested headers, sorted by request order
+	Time    time.Time           // Time when the request was made
 }
 
 // fetchResult is a struct collecting partial results from data fetchers until
@@ -36,7 +36,8 @@ type fetchRequest struct {
 type fetchResult struct {
 	Pending int          // Number of data fetches still pending
 	Hash    meta.BlockID // Hash of the block to prevent recalculating
-	Block   *meta.Block
+	Header  *meta.BlockHeader
+	Block   *meta.Block // Block assembled from the header and its delivered body
 }
 type StorageSize float64
 
@@ -44,16 +45,22 @@ type StorageSize float64
 type queue struct {
 	mode SyncMode // Synchronisation mode to decide on the block parts to schedule for fetching
 
-	// Blocks are "special", they download in batches, supported by a skeleton chain
-	blockHead      meta.BlockID                   // [full/62] Hash of the last queued block to verify order
-	blockTaskPool  map[uint64]*meta.Block         // [full/62] Pending block retrieval tasks, mapping starting indexes to skeleton headers
-	blockTaskQueue *prque.Prque                   // [full/62] Priority queue of the skeleton indexes to fetch the filling headers for
-	blockPeerMiss  map[string]map[uint64]struct{} // [full/62] Set of per-peer block batches known to be unavailable
-	blockPendPool  map[string]*fetchRequest       // [full/62] Currently pending block retrieval operations
-	blockResults   []*meta.Block                  // [full/62] Result cache accumulating the completed headers
-	blockProced    int                            // [full/62] Number of headers already processed from the results
-	blockOffset    uint64                         // [full/62] Number of the first block in the result cache
-	blockContCh    chan bool                      // [full/62] Channel to notify when block download finishes
+	// Headers are "special", they download in batches, supported by a skeleton chain
+	headerHead      meta.BlockID                   // [full/02] Hash of the last queued header to verify order
+	headerTaskPool  map[uint64]*meta.BlockHeader   // [full/02] Pending header retrieval tasks, mapping starting indexes to skeleton headers
+	headerTaskQueue *prque.Prque                   // [full/02] Priority queue of the skeleton indexes to fetch the filling headers for
+	headerPeerMiss  map[string]map[uint64]struct{} // [full/02] Set of per-peer header batches known to be unavailable
+	headerPendPool  map[string]*fetchRequest       // [full/02] Currently pending header retrieval operations
+	headerResults   []*meta.BlockHeader            // [full/02] Result cache accumulating the completed headers
+	headerProced    int                            // [full/02] Number of headers already processed from the results
+	headerOffset    uint64                         // [full/02] Number of the first header in the result cache
+	headerContCh    chan bool                      // [full/02] Channel to notify when header download finishes
+
+	// All data retrievals below are based on an already assembles header chain
+	blockTaskPool  map[meta.BlockID]*meta.BlockHeader // [full/02] Pending block (body) retrieval tasks, mapping hashes to headers
+	blockTaskQueue *prque.Prque                       // [full/02] Priority queue of the headers to fetch the blocks (bodies) for
+	blockPendPool  map[string]*fetchRequest           // [full/02] Currently pending block (body) retrieval operations
+	blockDonePool  map[meta.BlockID]struct{}          // [full/02] Set of the completed block (body) fetches
 
 	resultCache  []*fetchResult // Downloaded but not yet delivered fetch results
 	resultOffset uint64         // Offset of the first cached fetch result in the block chain
@@ -68,11 +75,15 @@ type queue struct {
 func newQueue() *queue {
 	lock := new(sync.Mutex)
 	return &queue{
-		blockPendPool: make(map[string]*fetchRequest),
-		blockContCh:   make(chan bool),
-		resultCache:   make([]*fetchResult, blockCacheItems),
-		active:        sync.NewCond(lock),
-		lock:          lock,
+		headerPendPool: make(map[string]*fetchRequest),
+		headerContCh:   make(chan bool),
+		blockTaskPool:  make(map[meta.BlockID]*meta.BlockHeader),
+		blockTaskQueue: prque.New(),
+		blockPendPool:  make(map[string]*fetchRequest),
+		blockDonePool:  make(map[meta.BlockID]struct{}),
+		resultCache:    make([]*fetchResult, blockCacheItems),
+		active:         sync.NewCond(lock),
+		lock:           lock,
 	}
 }
 
@@ -84,7 +95,13 @@ func (q *queue) Reset() {
 	q.closed = false
 	q.mode = FullSync
 
-	q.blockHead = meta.BlockID{}
+	q.headerHead = meta.BlockID{}
+	q.headerPendPool = make(map[string]*fetchRequest)
+
+	q.blockTaskPool = make(map[meta.BlockID]*meta.BlockHeader)
+	q.blockTaskQueue.Reset()
+	q.blockPendPool = make(map[string]*fetchRequest)
+	q.blockDonePool = make(map[meta.BlockID]struct{})
 
 	q.resultCache = make([]*fetchResult, blockCacheItems)
 	q.resultOffset = 0
@@ -99,7 +116,15 @@ func (q *queue) Close() {
 	q.active.Broadcast()
 }
 
-// PendingBlocks retrieves the number of block requests pending for retrieval.
+// PendingHeaders retrieves the number of header requests pending for retrieval.
+func (q *queue) PendingHeaders() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.headerTaskQueue.Size()
+}
+
+// PendingBlocks retrieves the number of block body requests pending for retrieval.
 func (q *queue) PendingBlocks() int {
 	q.lock.Lock()
 	defer q.lock.Unlock()
@@ -107,7 +132,16 @@ func (q *queue) PendingBlocks() int {
 	return q.blockTaskQueue.Size()
 }
 
-// InFlightBlocks retrieves whether there are block fetch requests currently
+// InFlightHeaders retrieves whether there are header fetch requests currently
+// in flight.
+func (q *queue) InFlightHeaders() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return len(q.headerPendPool) > 0
+}
+
+// InFlightBlocks retrieves whether there are block body fetch requests currently
 // in flight.
 func (q *queue) InFlightBlocks() bool {
 	q.lock.Lock()
@@ -122,8 +156,19 @@ func (q *queue) Idle() bool {
 	defer q.lock.Unlock()
 
 	queued := q.blockTaskQueue.Size()
+	pending := len(q.blockPendPool)
+	cached := len(q.blockDonePool)
 
-	return queued == 0
+	return (queued + pending + cached) == 0
+}
+
+// ShouldThrottleBlocks checks if the download should be throttled (active block
+// (body) fetches exceed block cache).
+func (q *queue) ShouldThrottleBlocks() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.resultSlots(q.blockPendPool, q.blockDonePool) <= 0
 }
 
 // resultSlots calculates the number of results slots available for requests
@@ -148,8 +193,8 @@ func (q *queue) resultSlots(pendPool map[string]*fetchRequest, donePool map[meta
 	// Calculate the number of slots currently downloading
 	pending := 0
 	for _, request := range pendPool {
-		for _, block := range request.Blocks {
-			if uint64(block.GetHeight()) < q.resultOffset+uint64(limit) {
+		for _, header := range request.Headers {
+			if uint64(header.Height) < q.resultOffset+uint64(limit) {
 				pending++
 			}
 		}
@@ -158,67 +203,75 @@ func (q *queue) resultSlots(pendPool map[string]*fetchRequest, donePool map[meta
 	return limit - finished - pending
 }
 
-// ScheduleSkeleton adds a batch of block retrieval tasks to the queue to fill
-// up an already retrieved block skeleton.
-func (q *queue) ScheduleSkeleton(from uint64, skeleton []*meta.Block) {
+// ScheduleSkeleton adds a batch of header retrieval tasks to the queue to fill
+// up an already retrieved header skeleton.
+func (q *queue) ScheduleSkeleton(from uint64, skeleton []*meta.BlockHeader) {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
 	// No skeleton retrieval can be in progress, fail hard if so (huge implementation bug)
-	if q.blockResults != nil {
+	if q.headerResults != nil {
 		panic("skeleton assembly already in progress")
 	}
-	// Shedule all the block retrieval tasks for the skeleton assembly
-	q.blockTaskPool = make(map[uint64]*meta.Block)
-	q.blockTaskQueue = prque.New()
-	q.blockPeerMiss = make(map[string]map[uint64]struct{}) // Reset availability to correct invalid chains
-	q.blockResults = make([]*meta.Block, len(skeleton)*MaxBlockFetch)
-	q.blockProced = 0
-	q.blockOffset = from
-	q.blockContCh = make(chan bool, 1)
+	// Shedule all the header retrieval tasks for the skeleton assembly
+	q.headerTaskPool = make(map[uint64]*meta.BlockHeader)
+	q.headerTaskQueue = prque.New()
+	q.headerPeerMiss = make(map[string]map[uint64]struct{}) // Reset availability to correct invalid chains
+	q.headerResults = make([]*meta.BlockHeader, len(skeleton)*MaxHeaderFetch)
+	q.headerProced = 0
+	q.headerOffset = from
+	q.headerContCh = make(chan bool, 1)
 
-	for i, block := range skeleton {
-		index := from + uint64(i*MaxBlockFetch)
+	for i, header := range skeleton {
+		index := from + uint64(i*MaxHeaderFetch)
 
-		q.blockTaskPool[index] = block
-		q.blockTaskQueue.Push(index, -float32(index))
+		q.headerTaskPool[index] = header
+		q.headerTaskQueue.Push(index, -float32(index))
 	}
 }
 
-// RetrieveBlocks retrieves the block chain assemble based on the scheduled
+// RetrieveHeaders retrieves the header chain assemble based on the scheduled
 // skeleton.
-func (q *queue) RetrieveBlocks() ([]*meta.Block, int) {
+func (q *queue) RetrieveHeaders() ([]*meta.BlockHeader, int) {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
-	blocks, proced := q.blockResults, q.blockProced
-	q.blockResults, q.blockProced = nil, 0
+	headers, proced := q.headerResults, q.headerProced
+	q.headerResults, q.headerProced = nil, 0
 
-	return blocks, proced
+	return headers, proced
 }
 
 // Schedule adds a set of headers for the download queue for scheduling, returning
 // the new headers encountered.
-func (q *queue) Schedule(blocks []*meta.Block, from uint64) []*meta.Block {
+func (q *queue) Schedule(headers []*meta.BlockHeader, from uint64) []*meta.BlockHeader {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
 	// Insert all the headers prioritised by the contained block number
-	inserts := make([]*meta.Block, 0, len(blocks))
-	for _, block := range blocks {
+	inserts := make([]*meta.BlockHeader, 0, len(headers))
+	for _, header := range headers {
 		// Make sure chain order is honoured and preserved throughout
-		hash := *block.GetBlockID()
-		if uint64(block.GetHeight()) != from {
-			log.Warn("header broke chain ordering", "number", block.GetHeight(), "hash", hash, "expected", from)
+		hash := *header.GetBlockID()
+		if uint64(header.Height) != from {
+			log.Warn("header broke chain ordering", "number", header.Height, "hash", hash, "expected", from)
 			break
 		}
-		if !q.blockHead.IsEmpty() && !q.blockHead.IsEqual(block.GetPrevBlockID()) {
-			log.Warn("header broke chain ancestry", "number", block.GetHeight(), "hash", hash, "q.blockHead", q.blockHead, "block.GetPrevBlockID()", block.GetPrevBlockID())
+		if !q.headerHead.IsEmpty() && !q.headerHead.IsEqual(&header.Prev) {
+			log.Warn("header broke chain ancestry", "number", header.Height, "hash", hash, "q.headerHead", q.headerHead, "prev", header.Prev)
 			break
 		}
+		// Make sure no duplicate requests are executed
+		if _, ok := q.blockTaskPool[hash]; ok {
+			log.Warn("header already scheduled for block fetch", "number", header.Height, "hash", hash)
+			continue
+		}
+		// Queue the header for body retrieval
+		q.blockTaskPool[hash] = header
+		q.blockTaskQueue.Push(header, -float32(header.Height))
 
-		inserts = append(inserts, block)
-		q.blockHead = hash
+		inserts = append(inserts, header)
+		q.headerHead = hash
 		from++
 	}
 	return inserts
@@ -246,6 +299,10 @@ func (q *queue) Results(block bool) []*fetchResult {
 	results := make([]*fetchResult, nproc)
 	copy(results, q.resultCache[:nproc])
 	if len(results) > 0 {
+		// Mark results as done before dropping them from the cache.
+		for _, result := range results {
+			delete(q.blockDonePool, result.Hash)
+		}
 		// Delete the results from the cache and clear the tail.
 		copy(q.resultCache, q.resultCache[nproc:])
 		for i := len(q.resultCache) - nproc; i < len(q.resultCache); i++ {
@@ -273,23 +330,23 @@ func (q *queue) countProcessableItems() int {
 	return len(q.resultCache)
 }
 
-// ReserveBlocks reserves a set of headers for the given peer, skipping any
+// ReserveHeaders reserves a set of headers for the given peer, skipping any
 // previously failed batches.
-func (q *queue) ReserveBlocks(p *peerConnection, count int) *fetchRequest {
+func (q *queue) ReserveHeaders(p *peerConnection, count int) *fetchRequest {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
 	// Short circuit if the peer's already downloading something (sanity check to
 	// not corrupt state)
-	if _, ok := q.blockPendPool[p.id]; ok {
+	if _, ok := q.headerPendPool[p.id]; ok {
 		return nil
 	}
 	// Retrieve a batch of hashes, skipping previously failed ones
 	send, skip := uint64(0), []uint64{}
-	for send == 0 && !q.blockTaskQueue.Empty() {
-		from, _ := q.blockTaskQueue.Pop()
-		if q.blockPeerMiss[p.id] != nil {
-			if _, ok := q.blockPeerMiss[p.id][from.(uint64)]; ok {
+	for send == 0 && !q.headerTaskQueue.Empty() {
+		from, _ := q.headerTaskQueue.Pop()
+		if q.headerPeerMiss[p.id] != nil {
+			if _, ok := q.headerPeerMiss[p.id][from.(uint64)]; ok {
 				skip = append(skip, from.(uint64))
 				continue
 			}
@@ -298,9 +355,9 @@ func (q *queue) ReserveBlocks(p *peerConnection, count int) *fetchRequest {
 	}
 	// Merge all the skipped batches back
 	for _, from := range skip {
-		q.blockTaskQueue.Push(from, -float32(from))
+		q.headerTaskQueue.Push(from, -float32(from))
 	}
-	// Assemble and return the block download request
+	// Assemble and return the header download request
 	if send == 0 {
 		return nil
 	}
@@ -309,20 +366,29 @@ func (q *queue) ReserveBlocks(p *peerConnection, count int) *fetchRequest {
 		From: send,
 		Time: time.Now(),
 	}
-	log.Info("start to ReserveBlocks", "id", p.id, "request", request)
-	q.blockPendPool[p.id] = request
+	log.Debug("start to ReserveHeaders", "id", p.id, "from", request.From)
+	q.headerPendPool[p.id] = request
 	return request
 }
 
-// reserveBlocks reserves a set of data download operations for a given peer,
+// ReserveBodies reserves a set of body fetches for the given peer, skipping any
+// previously failed downloads.
+func (q *queue) ReserveBodies(p *peerConnection, count int) (*fetchRequest, bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.reserveHeaders(p, count, q.blockTaskPool, q.blockTaskQueue, q.blockPendPool, q.blockDonePool)
+}
+
+// reserveHeaders reserves a set of data download operations for a given peer,
 // skipping any previously failed ones. This method is a generic version used
 // by the individual special reservation functions.
 //
 // Note, this method expects the queue lock to be already held for writing. The
 // reason the lock is not obtained in here is because the parameters already need
 // to access the queue, so they already need a lock anyway.
-func (q *queue) reserveBlocks(p *peerConnection, count int, taskPool map[meta.BlockID]*meta.Block, taskQueue *prque.Prque,
-	pendPool map[string]*fetchRequest, donePool map[meta.BlockID]struct{}, isNoop func(*meta.Block) bool) (*fetchRequest, bool, error) {
+func (q *queue) reserveHeaders(p *peerConnection, count int, taskPool map[meta.BlockID]*meta.BlockHeader, taskQueue *prque.Prque,
+	pendPool map[string]*fetchRequest, donePool map[meta.BlockID]struct{}) (*fetchRequest, bool, error) {
 	// Short circuit if the pool has been depleted, or if the peer's already
 	// downloading something (sanity check not to corrupt state)
 	if taskQueue.Empty() {
@@ -335,72 +401,59 @@ func (q *queue) reserveBlocks(p *peerConnection, count int, taskPool map[meta.Bl
 	space := q.resultSlots(pendPool, donePool)
 
 	// Retrieve a batch of tasks, skipping previously failed ones
-	send := make([]*meta.Block, 0, count)
-	skip := make([]*meta.Block, 0)
+	send := make([]*meta.BlockHeader, 0, count)
+	skip := make([]*meta.BlockHeader, 0)
 
-	progress := false
 	for proc := 0; proc < space && len(send) < count && !taskQueue.Empty(); proc++ {
-		block := taskQueue.PopItem().(*meta.Block)
-		hash := *block.GetBlockID()
+		header := taskQueue.PopItem().(*meta.BlockHeader)
+		hash := *header.GetBlockID()
 
 		// If we're the first to request this task, initialise the result container
-		index := int(int64(block.GetHeight()) - int64(q.resultOffset))
+		index := int(int64(header.Height) - int64(q.resultOffset))
 		if index >= len(q.resultCache) || index < 0 {
 			log.Error("index allocation went beyond available resultCache space")
 			return nil, false, errInvalidChain
 		}
 		if q.resultCache[index] == nil {
-			components := 1
-			if q.mode == FastSync {
-				components = 2
-			}
 			q.resultCache[index] = &fetchResult{
-				Pending: components,
+				Pending: 1,
 				Hash:    hash,
-				Block:   block,
+				Header:  header,
 			}
 		}
-		// If this fetch task is a noop, skip this fetch operation
-		if isNoop(block) {
-			donePool[hash] = struct{}{}
-			delete(taskPool, hash)
-
-			space, proc = space-1, proc-1
-			q.resultCache[index].Pending--
-			progress = true
-			continue
-		}
 		// Otherwise unless the peer is known not to have the data, add to the retrieve list
 		if p.Lacks(hash) {
-			skip = append(skip, block)
+			skip = append(skip, header)
 		} else {
-			send = append(send, block)
+			send = append(send, header)
 		}
 	}
 	// Merge all the skipped headers back
-	for _, block := range skip {
-		taskQueue.Push(block, -float32(block.GetHeight()))
-	}
-	if progress {
-		// Wake WaitResults, resultCache was modified
-		q.active.Signal()
+	for _, header := range skip {
+		taskQueue.Push(header, -float32(header.Height))
 	}
 	// Assemble and return the block download request
 	if len(send) == 0 {
-		return nil, progress, nil
+		return nil, false, nil
 	}
 	request := &fetchRequest{
-		Peer:   p,
-		Blocks: send,
-		Time:   time.Now(),
+		Peer:    p,
+		Headers: send,
+		Time:    time.Now(),
 	}
 	pendPool[p.id] = request
 
-	return request, progress, nil
+	return request, false, nil
+}
+
+// CancelHeaders aborts a fetch request, returning all pending skeleton indexes to the queue.
+func (q *queue) CancelHeaders(request *fetchRequest) {
+	q.cancel(request, q.headerTaskQueue, q.headerPendPool)
 }
 
-// CancelBlocks aborts a fetch request, returning all pending skeleton indexes to the queue.
-func (q *queue) CancelBlocks(request *fetchRequest) {
+// CancelBodies aborts a body fetch request, returning all pending headers to the
+// task queue.
+func (q *queue) CancelBodies(request *fetchRequest) {
 	q.cancel(request, q.blockTaskQueue, q.blockPendPool)
 }
 
@@ -412,8 +465,8 @@ func (q *queue) cancel(request *fetchRequest, taskQueue *prque.Prque, pendPool m
 	if request.From > 0 {
 		taskQueue.Push(request.From, -float32(request.From))
 	}
-	for _, block := range request.Blocks {
-		taskQueue.Push(block, -float32(block.GetHeight()))
+	for _, header := range request.Headers {
+		taskQueue.Push(header, -float32(header.Height))
 	}
 	delete(pendPool, request.Peer.id)
 }
@@ -425,17 +478,30 @@ func (q *queue) Revoke(peerId string) {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
+	if request, ok := q.headerPendPool[peerId]; ok {
+		q.headerTaskQueue.Push(request.From, -float32(request.From))
+		delete(q.headerPendPool, peerId)
+	}
 	if request, ok := q.blockPendPool[peerId]; ok {
-		for _, block := range request.Blocks {
-			q.blockTaskQueue.Push(block, -float32(block.GetHeight()))
+		for _, header := range request.Headers {
+			q.blockTaskQueue.Push(header, -float32(header.Height))
 		}
 		delete(q.blockPendPool, peerId)
 	}
 }
 
-// ExpireBlocks checks for in flight requests that exceeded a timeout allowance,
+// ExpireHeaders checks for in flight requests that exceeded a timeout allowance,
 // canceling them and returning the responsible peers for penalisation.
-func (q *queue) ExpireBlocks(timeout time.Duration) map[string]int {
+func (q *queue) ExpireHeaders(timeout time.Duration) map[string]int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.expire(timeout, q.headerPendPool, q.headerTaskQueue)
+}
+
+// ExpireBodies checks for in flight block body requests that exceeded a timeout
+// allowance, canceling them and returning the responsible peers for penalisation.
+func (q *queue) ExpireBodies(timeout time.Duration) map[string]int {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
@@ -458,11 +524,11 @@ func (q *queue) expire(timeout time.Duration, pendPool map[string]*fetchRequest,
 			if request.From > 0 {
 				taskQueue.Push(request.From, -float32(request.From))
 			}
-			for _, block := range request.Blocks {
-				taskQueue.Push(block, -float32(block.GetHeight()))
+			for _, header := range request.Headers {
+				taskQueue.Push(header, -float32(header.Height))
 			}
 			// Add the peer to the expiry report along the the number of failed requests
-			expiries[id] = len(request.Blocks)
+			expiries[id] = len(request.Headers)
 		}
 	}
 	// Remove the expired requests from the pending pool
@@ -472,49 +538,48 @@ func (q *queue) expire(timeout time.Duration, pendPool map[string]*fetchRequest,
 	return expiries
 }
 
-// DeliverBlocks injects a block retrieval response into the block results
+// DeliverHeaders injects a header retrieval response into the header results
 // cache. This method either accepts all headers it received, or none of them
 // if they do not map correctly to the skeleton.
 //
 // If the headers are accepted, the method makes an attempt to deliver the set
 // of ready headers to the processor to keep the pipeline full. However it will
 // not block to prevent stalling other pending deliveries.
-func (q *queue) DeliverBlocks(id string, blocks []*meta.Block, blockProcCh chan []*meta.Block) (int, error) {
+func (q *queue) DeliverHeaders(id string, headers []*meta.BlockHeader, headerProcCh chan []*meta.BlockHeader) (int, error) {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
 	// Short circuit if the data was never requested
-	request := q.blockPendPool[id]
-	log.Info("start to DeliverBlocks", "id", id, "len(blocks)", len(blocks))
+	request := q.headerPendPool[id]
+	log.Debug("start to DeliverHeaders", "id", id, "len(headers)", len(headers))
 	if request == nil {
 		return 0, errNoFetchesPending
 	}
-	// blockReqTimer.UpdateSince(request.Time)
-	delete(q.blockPendPool, id)
+	delete(q.headerPendPool, id)
 
 	// Ensure headers can be mapped onto the skeleton chain
-	target := q.blockTaskPool[request.From].GetBlockID()
+	target := q.headerTaskPool[request.From].GetBlockID()
 
-	accepted := len(blocks) == MaxBlockFetch
+	accepted := len(headers) == MaxHeaderFetch
 	if accepted {
-		if uint64(blocks[0].GetHeight()) != request.From {
-			log.Trace("First block broke chain ordering", "peer", id, "number", blocks[0].GetHeight(), "hash", blocks[0].GetBlockID(), request.From)
+		if uint64(headers[0].Height) != request.From {
+			log.Trace("First header broke chain ordering", "peer", id, "number", headers[0].Height, "hash", headers[0].GetBlockID(), "expected", request.From)
 			accepted = false
-		} else if !blocks[len(blocks)-1].GetBlockID().IsEqual(target) {
-			log.Trace("Last block broke skeleton structure ", "peer", id, "number", blocks[len(blocks)-1].GetHeight(), "hash", blocks[len(blocks)-1].GetBlockID(), "expected", target)
+		} else if !headers[len(headers)-1].GetBlockID().IsEqual(target) {
+			log.Trace("Last header broke skeleton structure ", "peer", id, "number", headers[len(headers)-1].Height, "hash", headers[len(headers)-1].GetBlockID(), "expected", target)
 			accepted = false
 		}
 	}
 	if accepted {
-		for i, block := range blocks[1:] {
-			hash := block.GetBlockID()
-			if want := request.From + 1 + uint64(i); uint64(block.GetHeight()) != want {
-				log.Warn("header broke chain ordering", "peer", id, "number", block.GetHeight(), "hash", hash, "expected", want)
+		for i, header := range headers[1:] {
+			hash := header.GetBlockID()
+			if want := request.From + 1 + uint64(i); uint64(header.Height) != want {
+				log.Warn("header broke chain ordering", "peer", id, "number", header.Height, "hash", hash, "expected", want)
 				accepted = false
 				break
 			}
-			if !blocks[i].GetBlockID().IsEqual(block.GetPrevBlockID()) {
-				log.Warn("header broke chain ancestry", "peer", id, "number", block.GetHeight(), "hash", hash)
+			if !headers[i].GetBlockID().IsEqual(&header.Prev) {
+				log.Warn("header broke chain ancestry", "peer", id, "number", header.Height, "hash", hash)
 				accepted = false
 				break
 			}
@@ -524,41 +589,59 @@ func (q *queue) DeliverBlocks(id string, blocks []*meta.Block, blockProcCh chan
 	if !accepted {
 		log.Trace("Skeleton filling not accepted", "peer", id, "from", request.From)
 
-		miss := q.blockPeerMiss[id]
+		miss := q.headerPeerMiss[id]
 		if miss == nil {
-			q.blockPeerMiss[id] = make(map[uint64]struct{})
-			miss = q.blockPeerMiss[id]
+			q.headerPeerMiss[id] = make(map[uint64]struct{})
+			miss = q.headerPeerMiss[id]
 		}
 		miss[request.From] = struct{}{}
 
-		q.blockTaskQueue.Push(request.From, -float32(request.From))
+		q.headerTaskQueue.Push(request.From, -float32(request.From))
 		return 0, errors.New("delivery not accepted")
 	}
 	// Clean up a successful fetch and try to deliver any sub-results
-	copy(q.blockResults[request.From-q.blockOffset:], blocks)
-	delete(q.blockTaskPool, request.From)
+	copy(q.headerResults[request.From-q.headerOffset:], headers)
+	delete(q.headerTaskPool, request.From)
 
 	ready := 0
-	for q.blockProced+ready < len(q.blockResults) && q.blockResults[q.blockProced+ready] != nil {
-		ready += MaxBlockFetch
+	for q.headerProced+ready < len(q.headerResults) && q.headerResults[q.headerProced+ready] != nil {
+		ready += MaxHeaderFetch
 	}
 	if ready > 0 {
-		// Blocks are ready for delivery, gather them and push forward (non blocking)
-		process := make([]*meta.Block, ready)
-		copy(process, q.blockResults[q.blockProced:q.blockProced+ready])
+		// Headers are ready for delivery, gather them and push forward (non blocking)
+		process := make([]*meta.BlockHeader, ready)
+		copy(process, q.headerResults[q.headerProced:q.headerProced+ready])
 
 		select {
-		case blockProcCh <- process:
-			log.Trace("Pre-scheduled new headers", "peer", id, "count", len(process), "from", process[0].GetHeight())
-			q.blockProced += len(process)
+		case headerProcCh <- process:
+			log.Trace("Pre-scheduled new headers", "peer", id, "count", len(process), "from", process[0].Height)
+			q.headerProced += len(process)
 		default:
 		}
 	}
 	// Check for termination and return
-	if len(q.blockTaskPool) == 0 {
-		q.blockContCh <- false
+	if len(q.headerTaskPool) == 0 {
+		q.headerContCh <- false
+	}
+	return len(headers), nil
+}
+
+// DeliverBodies injects a block body retrieval response into the results queue.
+// The method returns the number of blocks bodies accepted from the delivery and
+// also wakes any threads waiting for data delivery.
+func (q *queue) DeliverBodies(id string, txLists [][]meta.Transaction) (int, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	reconstruct := func(header *meta.BlockHeader, index int, result *fetchResult) error {
+		block := meta.NewBlock(*header, txLists[index])
+		if root := block.CalculateTxTreeRoot(); !root.IsEqual(header.GetMerkleRoot()) {
+			return errInvalidBody
+		}
+		result.Block = block
+		return nil
 	}
-	return len(blocks), nil
+	return q.deliver(id, q.blockTaskPool, q.blockTaskQueue, q.blockPendPool, q.blockDonePool, len(txLists), reconstruct)
 }
 
 // deliver injects a data retrieval response into the results queue.
@@ -566,12 +649,12 @@ func (q *queue) DeliverBlocks(id string, blocks []*meta.Block, blockProcCh chan
 // Note, this method expects the queue lock to be already held for writing. The
 // reason the lock is not obtained in here is because the parameters already need
 // to access the queue, so they already need a lock anyway.
-func (q *queue) deliver(id string, taskPool map[meta.BlockID]*meta.Block, taskQueue *prque.Prque,
+func (q *queue) deliver(id string, taskPool map[meta.BlockID]*meta.BlockHeader, taskQueue *prque.Prque,
 	pendPool map[string]*fetchRequest, donePool map[meta.BlockID]struct{},
-	results int, reconstruct func(block *meta.Block, index int, result *fetchResult) error) (int, error) {
+	results int, reconstruct func(header *meta.BlockHeader, index int, result *fetchResult) error) (int, error) {
 
 	// Short circuit if the data was never requested
-	log.Info("start to deliver", "id", id)
+	log.Debug("start to deliver", "id", id)
 	request := pendPool[id]
 	if request == nil {
 		return 0, errNoFetchesPending
@@ -581,8 +664,8 @@ func (q *queue) deliver(id string, taskPool map[meta.BlockID]*meta.Block, taskQu
 
 	// If no data items were retrieved, mark them as unavailable for the origin peer
 	if results == 0 {
-		for _, block := range request.Blocks {
-			request.Peer.MarkLacking(*block.GetBlockID())
+		for _, header := range request.Headers {
+			request.Peer.MarkLacking(*header.GetBlockID())
 		}
 	}
 	// Assemble each of the results with their headers and retrieved data parts
@@ -591,22 +674,22 @@ func (q *queue) deliver(id string, taskPool map[meta.BlockID]*meta.Block, taskQu
 		failure  error
 		useful   bool
 	)
-	for i, block := range request.Blocks {
+	for i, header := range request.Headers {
 		// Short circuit assembly if no more fetch results are found
 		if i >= results {
 			break
 		}
 		// Reconstruct the next result if contents match up
-		index := int(int64(block.GetHeight()) - int64(q.resultOffset))
+		index := int(int64(header.Height) - int64(q.resultOffset))
 		if index >= len(q.resultCache) || index < 0 || q.resultCache[index] == nil {
 			failure = errInvalidChain
 			break
 		}
-		if err := reconstruct(block, i, q.resultCache[index]); err != nil {
+		if err := reconstruct(header, i, q.resultCache[index]); err != nil {
 			failure = err
 			break
 		}
-		hash := *block.GetBlockID()
+		hash := *header.GetBlockID()
 
 		donePool[hash] = struct{}{}
 		q.resultCache[index].Pending--
@@ -614,13 +697,13 @@ func (q *queue) deliver(id string, taskPool map[meta.BlockID]*meta.Block, taskQu
 		accepted++
 
 		// Clean up a successful fetch
-		request.Blocks[i] = nil
+		request.Headers[i] = nil
 		delete(taskPool, hash)
 	}
 	// Return all failed or missing fetches to the queue
-	for _, block := range request.Blocks {
-		if block != nil {
-			taskQueue.Push(block, -float32(block.GetHeight()))
+	for _, header := range request.Headers {
+		if header != nil {
+			taskQueue.Push(header, -float32(header.Height))
 		}
 	}
 	// Wake up WaitResults
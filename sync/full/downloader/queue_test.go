@@ -0,0 +1,87 @@
+package downloader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mihongtech/linkchain/common/math"
+	"github.com/mihongtech/linkchain/common/util/log"
+	"github.com/mihongtech/linkchain/config"
+	"github.com/mihongtech/linkchain/core/meta"
+)
+
+//Create a chain of headers with empty bodies on top of parent.
+func makeTestHeaders(parent meta.BlockID, from uint32, n int) []*meta.BlockHeader {
+	headers := make([]*meta.BlockHeader, 0, n)
+	for i := 0; i < n; i++ {
+		header := meta.NewBlockHeader(config.DefaultBlockVersion, from+uint32(i), time.Unix(1487780010+int64(i), 0), config.DefaultNounce, config.DefaultDifficulty, parent, math.Hash{}, math.Hash{}, meta.Signature{Code: make([]byte, 0)}, nil)
+		block := meta.NewBlock(*header, nil)
+		header.SetMerkleRoot(block.CalculateTxTreeRoot())
+
+		headers = append(headers, header)
+		parent = *header.GetBlockID()
+	}
+	return headers
+}
+
+func TestQueueDeliverBodies(t *testing.T) {
+	headers := makeTestHeaders(math.Hash{}, 1, 3)
+
+	q := newQueue()
+	q.Prepare(1, FullSync)
+	if inserts := q.Schedule(headers, 1); len(inserts) != len(headers) {
+		t.Fatalf("scheduled headers mismatch: have %d, want %d", len(inserts), len(headers))
+	}
+	p := newPeerConnection("peer", 2, nil, log.New())
+	request, _, err := q.ReserveBodies(p, MaxBodyFetch)
+	if err != nil || request == nil || len(request.Headers) != len(headers) {
+		t.Fatalf("reserve bodies failed: request %v, err %v", request, err)
+	}
+	accepted, err := q.DeliverBodies(p.id, make([][]meta.Transaction, len(headers)))
+	if err != nil || accepted != len(headers) {
+		t.Fatalf("deliver bodies failed: accepted %d, err %v", accepted, err)
+	}
+	results := q.Results(false)
+	if len(results) != len(headers) {
+		t.Fatalf("results mismatch: have %d, want %d", len(results), len(headers))
+	}
+	for i, result := range results {
+		if !result.Block.GetBlockID().IsEqual(headers[i].GetBlockID()) {
+			t.Errorf("block %d hash mismatch: have %v, want %v", i, result.Block.GetBlockID(), headers[i].GetBlockID())
+		}
+	}
+}
+
+func TestQueueRejectInvalidBody(t *testing.T) {
+	headers := makeTestHeaders(math.Hash{}, 1, 1)
+	headers[0].SetMerkleRoot(math.Hash{1})
+
+	q := newQueue()
+	q.Prepare(1, FullSync)
+	q.Schedule(headers, 1)
+
+	p := newPeerConnection("peer", 2, nil, log.New())
+	if request, _, _ := q.ReserveBodies(p, MaxBodyFetch); request == nil {
+		t.Fatal("no bodies reserved")
+	}
+	if _, err := q.DeliverBodies(p.id, make([][]meta.Transaction, 1)); err != errStaleDelivery {
+		t.Fatalf("deliver error mismatch: have %v, want %v", err, errStaleDelivery)
+	}
+	if pending := q.PendingBlocks(); pending != 1 {
+		t.Fatalf("rejected body not rescheduled: pending %d", pending)
+	}
+	if results := q.Results(false); len(results) != 0 {
+		t.Fatalf("invalid body produced %d results", len(results))
+	}
+}
+
+func TestQueueScheduleBrokenChain(t *testing.T) {
+	headers := makeTestHeaders(math.Hash{}, 1, 2)
+	headers = append(headers, makeTestHeaders(math.Hash{}, 3, 1)...)
+
+	q := newQueue()
+	q.Prepare(1, FullSync)
+	if inserts := q.Schedule(headers, 1); len(inserts) != 2 {
+		t.Fatalf("scheduled headers mismatch: have %d, want %d", len(inserts), 2)
+	}
+}
@@ -0,0 +1,252 @@
+package downloader
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mihongtech/linkchain/common/lcdb"
+	"github.com/mihongtech/linkchain/common/math"
+	"github.com/mihongtech/linkchain/common/trie"
+	"github.com/mihongtech/linkchain/common/util/log"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/protobuf"
+	"github.com/mihongtech/linkchain/storage"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// stateReq represents a batch of state fetch requests grouped together into
+// a single data retrieval network packet.
+type stateReq struct {
+	hashes  []math.Hash     // Hashes of the state items requested
+	peer    *peerConnection // Peer the request was sent to
+	timeout time.Time       // Deadline of the request, expired afterwards
+}
+
+// stateSync schedules requests for downloading the state trie defined by a
+// given state root, including the storage tries and the codes of contracts.
+type stateSync struct {
+	d *Downloader
+
+	sched  *trie.TrieSync         // State trie sync scheduler defining the tasks
+	codes  map[math.Hash]struct{} // Contract codes scheduled as raw entries
+	tasks  map[math.Hash]struct{} // Tasks popped from the scheduler, not yet assigned
+	active map[string]*stateReq   // Requests in flight, keyed by peer id
+
+	uncommitted int    // Size of the processed data not yet written to the database
+	processed   uint64 // Number of state entries processed, persisted as progress
+}
+
+// codePutter writes the raw entries of contract codes with the code prefix,
+// the trie nodes are written as is.
+type codePutter struct {
+	lcdb.Putter
+	codes map[math.Hash]struct{}
+}
+
+func (p *codePutter) Put(key []byte, value []byte) error {
+	hash := math.BytesToHash(key)
+	if _, ok := p.codes[hash]; ok {
+		delete(p.codes, hash)
+		return storage.WriteCode(p.Putter, hash, value)
+	}
+	return p.Putter.Put(key, value)
+}
+
+// syncState downloads the state trie of root from the full/03 peers, and
+// returns when the whole state is written to the database.
+func (d *Downloader) syncState(root math.Hash) error {
+	s := &stateSync{
+		d:         d,
+		codes:     make(map[math.Hash]struct{}),
+		tasks:     make(map[math.Hash]struct{}),
+		active:    make(map[string]*stateReq),
+		processed: storage.GetTrieSyncProgress(d.stateDB),
+	}
+	s.sched = trie.NewTrieSync(root, d.stateDB, s.onLeaf)
+
+	log.Info("Syncing state", "root", root, "pending", s.sched.Pending())
+	return s.run()
+}
+
+// onLeaf schedules the storage trie and the code of a synced account.
+func (s *stateSync) onLeaf(leaf []byte, parent math.Hash) error {
+	pa := &protobuf.Account{}
+	if err := proto.Unmarshal(leaf, pa); err != nil {
+		return err
+	}
+	var account meta.Account
+	if err := account.Deserialize(pa); err != nil {
+		return err
+	}
+	if !account.StorageRoot.IsEmpty() {
+		s.sched.AddSubTrie(account.StorageRoot, 64, parent, nil)
+	}
+	// The codes are not stored under the raw hash, so the scheduler can not tell a known one
+	if !account.CodeHash.IsEmpty() {
+		if code, _ := storage.GetCode(s.d.stateDB, account.CodeHash); len(code) == 0 {
+			s.codes[account.CodeHash] = struct{}{}
+			s.sched.AddRawEntry(account.CodeHash, 64, parent)
+		}
+	}
+	return nil
+}
+
+// run assigns the state tasks to the idle peers and processes the deliveries
+// until all the state entries are downloaded.
+func (s *stateSync) run() error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for s.sched.Pending() > 0 {
+		if err := s.commit(false); err != nil {
+			return err
+		}
+		if err := s.assignTasks(); err != nil {
+			return err
+		}
+		select {
+		case <-s.d.cancelCh:
+			return errCancelStateFetch
+
+		case packet := <-s.d.stateCh:
+			// Discard any data not requested (or previously timed out)
+			req := s.active[packet.PeerId()]
+			if req == nil {
+				log.Debug("Unrequested node data", "peer", packet.PeerId(), "len", packet.Items())
+				break
+			}
+			delete(s.active, packet.PeerId())
+
+			delivered, err := s.process(req, packet.(*nodeDataPack).data)
+			req.peer.SetNodeDataIdle(delivered)
+			if err != nil {
+				req.peer.log.Debug("Invalid node data", "err", err)
+				return errBadPeer
+			}
+
+		case <-ticker.C:
+			s.expire()
+		}
+	}
+	return s.commit(true)
+}
+
+// assignTasks sends the pending state tasks to all the idle peers, limited by
+// their estimated capacity.
+func (s *stateSync) assignTasks() error {
+	peers, total := s.d.peers.NodeDataIdlePeers()
+	for _, p := range peers {
+		n := p.NodeDataCapacity(s.d.requestRTT())
+		if len(s.tasks) < n {
+			for _, hash := range s.sched.Missing(n - len(s.tasks)) {
+				s.tasks[hash] = struct{}{}
+			}
+		}
+		req := &stateReq{peer: p, timeout: time.Now().Add(s.d.requestTTL())}
+		for hash := range s.tasks {
+			if len(req.hashes) >= n {
+				break
+			}
+			// Skip the entries the peer did not deliver previously
+			if p.Lacks(hash) {
+				continue
+			}
+			req.hashes = append(req.hashes, hash)
+			delete(s.tasks, hash)
+		}
+		if len(req.hashes) == 0 {
+			continue
+		}
+		if err := p.FetchNodeData(req.hashes); err != nil {
+			for _, hash := range req.hashes {
+				s.tasks[hash] = struct{}{}
+			}
+			continue
+		}
+		req.peer.log.Trace("Requesting new batch of data", "type", "state", "count", len(req.hashes))
+		s.active[p.id] = req
+	}
+	// Nothing is in flight, so all the peers are idle and none of them can serve the tasks
+	if len(s.active) == 0 && s.sched.Pending() > 0 {
+		if s.d.peers.Len() == 0 {
+			return errNoPeers
+		}
+		if len(peers) == total {
+			return errPeersUnavailable
+		}
+	}
+	return nil
+}
+
+// process injects the delivered node data of req into the scheduler, the
+// entries not delivered are rescheduled for the other peers.
+func (s *stateSync) process(req *stateReq, data [][]byte) (int, error) {
+	pending := make(map[math.Hash]struct{}, len(req.hashes))
+	for _, hash := range req.hashes {
+		pending[hash] = struct{}{}
+	}
+	delivered := 0
+	for _, blob := range data {
+		hash := math.HashH(blob)
+		if _, ok := pending[hash]; !ok {
+			continue
+		}
+		delete(pending, hash)
+
+		_, _, err := s.sched.Process([]trie.SyncResult{{Hash: hash, Data: blob}})
+		switch err {
+		case nil:
+			delivered++
+			s.processed++
+			s.uncommitted += len(blob)
+		case trie.ErrNotRequested, trie.ErrAlreadyProcessed:
+		default:
+			return delivered, fmt.Errorf("invalid state node %s: %v", hash.String(), err)
+		}
+	}
+	for hash := range pending {
+		req.peer.MarkLacking(hash)
+		s.tasks[hash] = struct{}{}
+	}
+	return delivered, nil
+}
+
+// expire reschedules the tasks of the timed out requests.
+func (s *stateSync) expire() {
+	now := time.Now()
+	for id, req := range s.active {
+		if now.Before(req.timeout) {
+			continue
+		}
+		req.peer.log.Trace("Data delivery timed out", "type", "state", "count", len(req.hashes))
+		delete(s.active, id)
+		for _, hash := range req.hashes {
+			s.tasks[hash] = struct{}{}
+		}
+		req.peer.SetNodeDataIdle(0)
+	}
+}
+
+// commit writes the processed state entries to the database if they are large
+// enough or force is set, with the sync progress.
+func (s *stateSync) commit(force bool) error {
+	if !force && s.uncommitted < lcdb.IdealBatchSize {
+		return nil
+	}
+	start := time.Now()
+	batch := s.d.stateDB.NewBatch()
+	written, err := s.sched.Commit(&codePutter{Putter: batch, codes: s.codes})
+	if err != nil {
+		return err
+	}
+	if err := storage.WriteTrieSyncProgress(batch, s.processed); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return fmt.Errorf("state commit failed: %v", err)
+	}
+	s.uncommitted = 0
+	log.Info("Imported new state entries", "count", written, "elapsed", time.Since(start), "processed", s.processed, "pending", s.sched.Pending())
+	return nil
+}
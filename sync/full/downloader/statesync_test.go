@@ -0,0 +1,126 @@
+package downloader
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mihongtech/linkchain/common/lcdb"
+	"github.com/mihongtech/linkchain/common/math"
+	"github.com/mihongtech/linkchain/common/trie"
+	"github.com/mihongtech/linkchain/common/util/event"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/storage"
+
+	"github.com/golang/protobuf/proto"
+)
+
+//stateTestPeer serves the node data of a source database.
+type stateTestPeer struct {
+	id     string
+	d      *Downloader
+	triedb *trie.Database
+	diskdb lcdb.Database
+}
+
+func (p *stateTestPeer) Head() (meta.BlockID, uint64)                      { return meta.BlockID{}, 0 }
+func (p *stateTestPeer) RequestHeadersByHash(meta.BlockID, int, int) error { return nil }
+func (p *stateTestPeer) RequestHeadersByNumber(uint64, int, int) error     { return nil }
+func (p *stateTestPeer) RequestBodies([]meta.BlockID) error                { return nil }
+
+func (p *stateTestPeer) RequestNodeData(hashes []math.Hash) error {
+	data := make([][]byte, 0, len(hashes))
+	for _, hash := range hashes {
+		if node, err := p.triedb.Node(hash); err == nil && len(node) > 0 {
+			data = append(data, node)
+		} else if code, _ := storage.GetCode(p.diskdb, hash); len(code) > 0 {
+			data = append(data, code)
+		}
+	}
+	go p.d.DeliverNodeData(p.id, data)
+	return nil
+}
+
+//makeTestState creates the account trie whose accounts have a storage trie and a contract code.
+func makeTestState(t *testing.T) (*trie.Database, lcdb.Database, math.Hash, []byte) {
+	diskdb, _ := lcdb.NewMemDatabase()
+	triedb := trie.NewDatabase(diskdb)
+
+	storageTrie, _ := trie.New(math.Hash{}, triedb)
+	for i := byte(0); i < 100; i++ {
+		storageTrie.Update(bytes.Repeat([]byte{i}, 32), bytes.Repeat([]byte{i + 1}, 40))
+	}
+	storageRoot, _ := storageTrie.Commit(nil)
+
+	code := []byte("test contract code")
+	codeHash := math.HashH(code)
+	storage.WriteCode(diskdb, codeHash, code)
+
+	accountTrie, _ := trie.New(math.Hash{}, triedb)
+	for i := byte(0); i < 100; i++ {
+		id := meta.BytesToAccountID([]byte{i})
+		account := meta.NewAccount(id, 0, nil, meta.NewClearTime(0, 0), id)
+		if i%10 == 0 {
+			account.StorageRoot = storageRoot
+			account.CodeHash = codeHash
+		}
+		data, err := proto.Marshal(account.Serialize())
+		if err != nil {
+			t.Fatalf("failed to encode account: %v", err)
+		}
+		accountTrie.Update(math.HashB(id.CloneBytes()), data)
+	}
+	root, _ := accountTrie.Commit(nil)
+	if err := triedb.Commit(root, false); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	return triedb, diskdb, root, code
+}
+
+func TestSyncState(t *testing.T) {
+	srcTrieDb, srcDiskDb, root, code := makeTestState(t)
+
+	db, _ := lcdb.NewMemDatabase()
+	d := New(new(event.TypeMux), db, nil, nil)
+	defer d.Terminate()
+	d.cancelCh = make(chan struct{})
+
+	peer := &stateTestPeer{id: "peer", d: d, triedb: srcTrieDb, diskdb: srcDiskDb}
+	if err := d.RegisterPeer(peer.id, 3, peer); err != nil {
+		t.Fatalf("failed to register peer: %v", err)
+	}
+	if err := d.syncState(root); err != nil {
+		t.Fatalf("failed to sync state: %v", err)
+	}
+
+	//every node of the source state is synced
+	for _, hash := range srcTrieDb.Nodes() {
+		if ok, _ := db.Has(hash.Bytes()); !ok {
+			t.Errorf("trie node %x is not synced", hash)
+		}
+	}
+	if have, _ := storage.GetCode(db, math.HashH(code)); !bytes.Equal(have, code) {
+		t.Errorf("code mismatch: have %x, want %x", have, code)
+	}
+	if storage.GetTrieSyncProgress(db) == 0 {
+		t.Errorf("sync progress is not written")
+	}
+}
+
+func TestSyncStateUnavailable(t *testing.T) {
+	_, _, root, _ := makeTestState(t)
+
+	db, _ := lcdb.NewMemDatabase()
+	d := New(new(event.TypeMux), db, nil, nil)
+	defer d.Terminate()
+	d.cancelCh = make(chan struct{})
+
+	//the peer serves nothing
+	emptyDb, _ := lcdb.NewMemDatabase()
+	peer := &stateTestPeer{id: "peer", d: d, triedb: trie.NewDatabase(emptyDb), diskdb: emptyDb}
+	if err := d.RegisterPeer(peer.id, 3, peer); err != nil {
+		t.Fatalf("failed to register peer: %v", err)
+	}
+	if err := d.syncState(root); err != errPeersUnavailable {
+		t.Fatalf("error mismatch: have %v, want %v", err, errPeersUnavailable)
+	}
+}
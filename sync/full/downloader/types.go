@@ -17,11 +17,31 @@ type dataPack interface {
 }
 
 // headerPack is a batch of block headers returned by a peer.
-type blockPack struct {
+type headerPack struct {
+	peerId  string
+	headers []*meta.BlockHeader
+}
+
+func (p *headerPack) PeerId() string { return p.peerId }
+func (p *headerPack) Items() int     { return len(p.headers) }
+func (p *headerPack) Stats() string  { return fmt.Sprintf("%d", len(p.headers)) }
+
+// bodyPack is a batch of block bodies returned by a peer.
+type bodyPack struct {
+	peerId       string
+	transactions [][]meta.Transaction
+}
+
+func (p *bodyPack) PeerId() string { return p.peerId }
+func (p *bodyPack) Items() int     { return len(p.transactions) }
+func (p *bodyPack) Stats() string  { return fmt.Sprintf("%d", len(p.transactions)) }
+
+// nodeDataPack is a batch of state trie nodes and contract codes returned by a peer.
+type nodeDataPack struct {
 	peerId string
-	blocks []*meta.Block
+	data   [][]byte
 }
 
-func (p *blockPack) PeerId() string { return p.peerId }
-func (p *blockPack) Items() int     { return len(p.blocks) }
-func (p *blockPack) Stats() string  { return fmt.Sprintf("%d", len(p.blocks)) }
+func (p *nodeDataPack) PeerId() string { return p.peerId }
+func (p *nodeDataPack) Items() int     { return len(p.data) }
+func (p *nodeDataPack) Stats() string  { return fmt.Sprintf("%d", len(p.data)) }
@@ -20,6 +20,8 @@ import (
 	"github.com/mihongtech/linkchain/sync/full/downloader"
 	"github.com/mihongtech/linkchain/sync/full/fetcher"
 	"github.com/mihongtech/linkchain/txpool"
+
+	"github.com/golang/protobuf/proto"
 )
 
 // errIncompatibleConfig is returned if the requested protocols and configs are
@@ -28,12 +30,17 @@ var errIncompatibleConfig = errors.New("incompatible configuration")
 
 const (
 	txChanSize = 4096
+
+	softResponseLimit = 2 * 1024 * 1024 // Target maximum size of returned blocks or headers
 )
 
 type ProtocolManager struct {
 	networkId uint64
-	maxPeers  int
-	peers     *peerSet
+
+	fastSync uint32 // Flag whether fast sync is enabled (gets disabled if we already have blocks)
+
+	maxPeers int
+	peers    *peerSet
 
 	downloader *downloader.Downloader
 	fetcher    *fetcher.Fetcher
@@ -62,7 +69,11 @@ type ProtocolManager struct {
 
 // NewProtocolManager returns a new linkchain sub protocol manager. The Linkchain sub protocol manages peers capable
 // with the linkchain network.
-func NewProtocolManager(config interface{}, nodeSvc *node.PublicNodeAPI, txPoolSvc *txpool.TxPool, networkId uint64, mux *event.TypeMux, tx *event.Feed) (*ProtocolManager, error) {
+func NewProtocolManager(config interface{}, mode downloader.SyncMode, nodeSvc *node.PublicNodeAPI, txPoolSvc *txpool.TxPool, networkId uint64, mux *event.TypeMux, tx *event.Feed) (*ProtocolManager, error) {
+	// Light sync of headers only is not supported by full peers
+	if mode == downloader.LightSync {
+		return nil, errIncompatibleConfig
+	}
 	// Create the protocol manager with the base fields
 	manager := &ProtocolManager{
 		networkId:   networkId,
@@ -78,6 +89,15 @@ func NewProtocolManager(config interface{}, nodeSvc *node.PublicNodeAPI, txPoolS
 		quitSync:    make(chan struct{}),
 	}
 
+	// Figure out whether to allow fast sync or not
+	if mode == downloader.FastSync && nodeSvc.GetBestBlock().GetHeight() > 0 {
+		log.Warn("Blockchain not empty, fast sync disabled")
+		mode = downloader.FullSync
+	}
+	if mode == downloader.FastSync {
+		manager.fastSync = uint32(1)
+	}
+
 	// Initiate a sub-protocol for every implemented version we can handle
 	manager.SubProtocols = make([]p2p_peer.Protocol, 0, len(ProtocolVersions))
 	for i, version := range ProtocolVersions {
@@ -113,7 +133,7 @@ func NewProtocolManager(config interface{}, nodeSvc *node.PublicNodeAPI, txPoolS
 		return nil, errIncompatibleConfig
 	}
 
-	manager.downloader = downloader.New(manager.eventMux, manager.nodeAPI, manager.removePeer)
+	manager.downloader = downloader.New(manager.eventMux, nodeSvc.GetDB(), manager.nodeAPI, manager.removePeer)
 
 	heighter := func() uint64 {
 		return uint64(manager.nodeAPI.GetBestBlock().GetHeight())
@@ -212,8 +232,6 @@ func (pm *ProtocolManager) handle(p *peer) error {
 			return err
 		}
 	}
-
-	return nil
 }
 
 // handleMsg is invoked whenever an inbound message is received from a remote
@@ -236,10 +254,36 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		// Status messages should never arrive after the handshake
 		return errResp(ErrExtraStatusMsg, "uncontrolled status message")
 
-	// Block header query, collect the requested headers and reply
+	// Block query, collect the requested blocks and reply
 	case msg.Code == GetBlockMsg:
-		// Decode the complex header query
+		// Decode the complex block query
+		var query protobuf.GetBlockHeadersData
+		if err := msg.Decode(&query); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		data := &getBlockHeadersData{}
+		data.Deserialize(&query)
+
+		blocks := make([]*meta.Block, 0)
+		for _, header := range pm.queryHeaders(p, data, downloader.MaxBlockFetch) {
+			// The bodies of the ancient blocks may be pruned
+			block, err := pm.nodeAPI.GetBlockByID(*header.GetBlockID())
+			if err != nil || block == nil {
+				break
+			}
+			blocks = append(blocks, block)
+		}
+		for i, b := range blocks {
+			log.Debug("Receive GetBlockMsg", "query is", data, "index", i, "block", b)
+		}
+
+		p.SendBlock(blocks)
+
+		return nil
 
+	// Block header query, collect the requested headers and reply
+	case p.version >= full02 && msg.Code == GetBlockHeadersMsg:
+		// Decode the complex header query
 		var query protobuf.GetBlockHeadersData
 		if err := msg.Decode(&query); err != nil {
 			return errResp(ErrDecode, "%v: %v", msg, err)
@@ -247,61 +291,116 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		data := &getBlockHeadersData{}
 		data.Deserialize(&query)
 
+		headers := pm.queryHeaders(p, data, downloader.MaxHeaderFetch)
+		log.Debug("Receive GetBlockHeadersMsg", "query is", data, "headers", len(headers))
+		return p.SendBlockHeaders(headers)
+
+	case p.version >= full02 && msg.Code == BlockHeadersMsg:
+		// A batch of headers arrived to one of our previous requests
+		var h protobuf.BlockHeaders
+		if err := msg.Decode(&h); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		headers := make([]*meta.BlockHeader, 0, len(h.Headers))
+		for _, prob := range h.Headers {
+			header := &meta.BlockHeader{}
+			if err := header.Deserialize(prob); err != nil {
+				return errResp(ErrDecode, "msg %v: %v", msg, err)
+			}
+			headers = append(headers, header)
+		}
+		log.Debug("Receive BlockHeadersMsg", "len(headers) is", len(headers))
+		if err := pm.downloader.DeliverHeaders(p.id, headers); err != nil {
+			log.Debug("Failed to deliver headers", "err", err)
+		}
+
+	case p.version >= full02 && msg.Code == GetBlockBodiesMsg:
+		// Decode the retrieval message
+		var query protobuf.GetBlockBodiesData
+		if err := msg.Decode(&query); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		var hashes getBlockBodiesData
+		hashes.Deserialize(&query)
+
+		// Gather blocks until the fetch or network limits is reached
 		var (
-			blocks  []*meta.Block
-			unknown bool
+			bytes  int
+			bodies []*protobuf.Transactions
 		)
-		for !unknown && len(blocks) < int(data.Amount) && len(blocks) < downloader.MaxBlockFetch {
-			// Retrieve the next header satisfying the query
-			var block *meta.Block
-			var err error
-			if data.Hash.IsEmpty() {
-				block, err = pm.nodeAPI.GetBlockByHeight(uint32(data.Number))
-				log.Debug("get block by height", "number", data.Number, "block", block)
-			} else {
-				block, err = pm.nodeAPI.GetBlockByID(data.Hash)
-				log.Debug("get block by id", "Hash", data.Hash, "block", block)
+		for _, hash := range hashes {
+			if bytes >= softResponseLimit || len(bodies) >= downloader.MaxBodyFetch {
+				break
 			}
+			block, err := pm.nodeAPI.GetBlockByID(hash)
 			if err != nil || block == nil {
-				log.Debug("get block msg error", "query data", data, "err", err)
-				break
+				continue
 			}
-			// number := uint64(block.GetHeight())
-			blocks = append(blocks, block)
-
-			// Advance to the next header of the query
-			switch {
-			case !data.Hash.IsEmpty():
-				// Hash based traversal towards the leaf block
-				var (
-					current = uint64(block.GetHeight())
-					next    = current + data.Skip + 1
-				)
-				if next <= current {
-					infos, _ := json.MarshalIndent(p.Peer.Info(), "", "  ")
-					p.Log().Warn("GetBlockHeaders skip overflow attack", "current", current, "skip", query.Skip, "next", next, "attacker", infos)
-					unknown = true
-				} else {
-					if b, e := pm.nodeAPI.GetBlockByHeight(uint32(next)); (b != nil) && (e == nil) {
-						log.Debug("get block by height", "number", current, "skip", data.Skip, "next", next)
-						data.Hash.SetBytes(b.GetBlockID().CloneBytes())
-					} else {
-						unknown = true
-					}
+			body := block.Serialize().(*protobuf.Block).TxList
+			bodies = append(bodies, body)
+			bytes += proto.Size(body)
+		}
+		log.Debug("Receive GetBlockBodiesMsg", "requested", len(hashes), "bodies", len(bodies))
+		return p.SendBlockBodies(bodies)
 
+	case p.version >= full02 && msg.Code == BlockBodiesMsg:
+		// A batch of block bodies arrived to one of our previous requests
+		var b protobuf.BlockBodies
+		if err := msg.Decode(&b); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		transactions := make([][]meta.Transaction, len(b.Bodies))
+		for i, body := range b.Bodies {
+			for _, t := range body.Txs {
+				tx := meta.Transaction{}
+				if err := tx.Deserialize(t); err != nil {
+					return errResp(ErrDecode, "msg %v: %v", msg, err)
 				}
-			case data.Hash.IsEmpty():
-				// Number based traversal towards the leaf block
-				data.Number += data.Skip + 1
+				transactions[i] = append(transactions[i], tx)
 			}
 		}
-		for i, b := range blocks {
-			log.Debug("Receive GetBlockMsg", "query is", data, "index", i, "block", b)
+		log.Debug("Receive BlockBodiesMsg", "len(bodies) is", len(transactions))
+		if err := pm.downloader.DeliverBodies(p.id, transactions); err != nil {
+			log.Debug("Failed to deliver bodies", "err", err)
 		}
 
-		p.SendBlock(blocks)
+	case p.version >= full03 && msg.Code == GetNodeDataMsg:
+		// Decode the retrieval message
+		var query protobuf.GetNodeDataData
+		if err := msg.Decode(&query); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		var hashes getNodeDataData
+		hashes.Deserialize(&query)
 
-		return nil
+		// Gather state data until the fetch or network limits is reached
+		var (
+			bytes int
+			data  [][]byte
+		)
+		for _, hash := range hashes {
+			if bytes >= softResponseLimit || len(data) >= downloader.MaxStateFetch {
+				break
+			}
+			// Retrieve the requested state entry, the unknown ones are skipped
+			if entry, err := pm.nodeAPI.TrieNode(hash); err == nil && len(entry) > 0 {
+				data = append(data, entry)
+				bytes += len(entry)
+			}
+		}
+		log.Debug("Receive GetNodeDataMsg", "requested", len(hashes), "entries", len(data))
+		return p.SendNodeData(data)
+
+	case p.version >= full03 && msg.Code == NodeDataMsg:
+		// A batch of node state data arrived to one of our previous requests
+		var d protobuf.NodeData
+		if err := msg.Decode(&d); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		log.Debug("Receive NodeDataMsg", "len(data) is", len(d.Data))
+		if err := pm.downloader.DeliverNodeData(p.id, d.Data); err != nil {
+			log.Debug("Failed to deliver node state data", "err", err)
+		}
 
 	case msg.Code == BlockMsg:
 
@@ -320,16 +419,9 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		for i, b := range blocks {
 			log.Debug("Receive BlockMsg", "index", i, "block", b)
 		}
-		filter := len(blocks) == 1
-		if filter {
+		if len(blocks) == 1 {
 			blocks = pm.fetcher.FilterBlocks(p.id, blocks, time.Now())
 		}
-		if len(blocks) > 0 || !filter {
-			err := pm.downloader.DeliverBlocks(p.id, blocks)
-			if err != nil {
-				log.Debug("Failed to deliver blocks", "err", err)
-			}
-		}
 		pm.downloader.ImportBlocks(p.id, blocks)
 
 	case msg.Code == NewBlockMsg:
@@ -361,13 +453,22 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			return errResp(ErrDecode, "msg %v: %v", msg, err)
 		}
 		transaction := &meta.Transaction{}
-		transaction.Deserialize(&t)
+		if err := transaction.Deserialize(&t); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
 		p.MarkTransaction(*transaction.GetTxID())
 		log.Debug("Receive TxMsg", "transaction is", transaction)
 		if err = pm.txPoolAPI.ProcessTx(transaction); err != nil {
-			return errResp(ErrDecode, "msg %v: %v", msg, err)
+			//only an invalid tx drops the peer, an honest peer may relay a tx which is known,
+			//double spent in pool or too cheap for our pool
+			if txpool.IsInvalidTx(err) {
+				return errResp(ErrDecode, "msg %v: %v", msg, err)
+			}
+			log.Debug("Discard tx of peer", "txid", transaction.GetTxID(), "peer", p.id, "err", err)
+			return nil
 		}
-		pm.BroadcastTx(*transaction.GetTxID(), transaction)
+		//the tx is broadcast by txBroadcastLoop, so the subscribers of tx event are noticed too
+		pm.eventTx.Send(node.TxEvent{transaction})
 		//		for _, t := range pm.txmanager.getAllTransaction() {
 		//			log.Debug("all txs is", "tx", t)
 		//		}
@@ -378,6 +479,58 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	return nil
 }
 
+// queryHeaders collects the headers satisfying a block or header query, stopping
+// at the first unknown block or once limit headers have been gathered. The headers
+// of the blocks whose bodies are pruned are still collected.
+func (pm *ProtocolManager) queryHeaders(p *peer, data *getBlockHeadersData, limit int) []*meta.BlockHeader {
+	var (
+		headers []*meta.BlockHeader
+		unknown bool
+	)
+	for !unknown && len(headers) < int(data.Amount) && len(headers) < limit {
+		// Retrieve the next header satisfying the query
+		var header *meta.BlockHeader
+		if data.Hash.IsEmpty() {
+			header = pm.nodeAPI.GetHeaderByHeight(data.Number)
+			log.Debug("get header by height", "number", data.Number, "header", header)
+		} else {
+			header = pm.nodeAPI.GetHeaderByID(data.Hash)
+			log.Debug("get header by id", "Hash", data.Hash, "header", header)
+		}
+		if header == nil {
+			log.Debug("get block msg error", "query data", data)
+			break
+		}
+		headers = append(headers, header)
+
+		// Advance to the next block of the query
+		switch {
+		case !data.Hash.IsEmpty():
+			// Hash based traversal towards the leaf block
+			var (
+				current = uint64(header.Height)
+				next    = current + data.Skip + 1
+			)
+			if next <= current {
+				infos, _ := json.MarshalIndent(p.Peer.Info(), "", "  ")
+				p.Log().Warn("GetBlockHeaders skip overflow attack", "current", current, "skip", data.Skip, "next", next, "attacker", infos)
+				unknown = true
+			} else {
+				if h := pm.nodeAPI.GetHeaderByHeight(next); h != nil {
+					log.Debug("get header by height", "number", current, "skip", data.Skip, "next", next)
+					data.Hash.SetBytes(h.GetBlockID().CloneBytes())
+				} else {
+					unknown = true
+				}
+			}
+		case data.Hash.IsEmpty():
+			// Number based traversal towards the leaf block
+			data.Number += data.Skip + 1
+		}
+	}
+	return headers
+}
+
 func (pm *ProtocolManager) newPeer(pv int, p *p2p_peer.Peer, rw message.MsgReadWriter) *peer {
 	return newPeer(pv, p, rw)
 }
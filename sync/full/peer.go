@@ -153,8 +153,60 @@ func (p *peer) SendBlock(blocks []*meta.Block) error {
 
 }
 
+// SendBlockHeaders sends a batch of block headers to the remote peer.
+func (p *peer) SendBlockHeaders(headers []*meta.BlockHeader) error {
+	headerArray := make([]*protobuf.BlockHeader, 0, len(headers))
+	for _, header := range headers {
+		headerArray = append(headerArray, header.Serialize().(*protobuf.BlockHeader))
+	}
+	log.Debug("Send BlockHeadersMsg", "count", len(headers))
+	return message.Send(p.rw, BlockHeadersMsg, &protobuf.BlockHeaders{Headers: headerArray})
+}
+
+// SendBlockBodies sends a batch of block contents to the remote peer.
+func (p *peer) SendBlockBodies(bodies []*protobuf.Transactions) error {
+	log.Debug("Send BlockBodiesMsg", "count", len(bodies))
+	return message.Send(p.rw, BlockBodiesMsg, &protobuf.BlockBodies{Bodies: bodies})
+}
+
+// RequestHeadersByHash fetches a batch of blocks' headers corresponding to the
+// specified header query, based on the hash of an origin block.
+func (p *peer) RequestHeadersByHash(origin meta.BlockID, amount int, skip int) error {
+	p.Log().Trace("Fetching batch of headers", "count", amount, "fromhash", origin, "skip", skip)
+	data := &getBlockHeadersData{Hash: origin, Amount: uint64(amount), Skip: uint64(skip)}
+	return message.Send(p.rw, GetBlockHeadersMsg, data.Serialize().(*protobuf.GetBlockHeadersData))
+}
+
+// RequestHeadersByNumber fetches a batch of blocks' headers corresponding to the
+// specified header query, based on the number of an origin block.
+func (p *peer) RequestHeadersByNumber(origin uint64, amount int, skip int) error {
+	p.Log().Trace("Fetching batch of headers", "count", amount, "fromnum", origin, "skip", skip)
+	data := &getBlockHeadersData{Number: origin, Amount: uint64(amount), Skip: uint64(skip)}
+	return message.Send(p.rw, GetBlockHeadersMsg, data.Serialize().(*protobuf.GetBlockHeadersData))
+}
+
 // RequestBodies fetches a batch of blocks' bodies corresponding to the hashes
 // specified.
+func (p *peer) RequestBodies(hashes []meta.BlockID) error {
+	p.Log().Trace("Fetching batch of block bodies", "count", len(hashes))
+	return message.Send(p.rw, GetBlockBodiesMsg, getBlockBodiesData(hashes).Serialize())
+}
+
+// SendNodeData sends a batch of state trie nodes and contract codes to the
+// remote peer, corresponding to the hashes requested.
+func (p *peer) SendNodeData(data [][]byte) error {
+	log.Debug("Send NodeDataMsg", "count", len(data))
+	return message.Send(p.rw, NodeDataMsg, &protobuf.NodeData{Data: data})
+}
+
+// RequestNodeData fetches a batch of state trie nodes and contract codes
+// corresponding to the hashes specified.
+func (p *peer) RequestNodeData(hashes []math.Hash) error {
+	p.Log().Trace("Fetching batch of state data", "count", len(hashes))
+	return message.Send(p.rw, GetNodeDataMsg, getNodeDataData(hashes).Serialize())
+}
+
+// RequestBlock fetches a batch of blocks corresponding to the hashes specified.
 func (p *peer) RequestBlock(hashes []meta.BlockID) error {
 	p.Log().Trace("Fetching batch of block bodies", "count", len(hashes))
 	for _, hash := range hashes {
@@ -252,19 +304,6 @@ func (p *peer) String() string {
 	)
 }
 
-func (p *peer) RequestBlocksByHash(h meta.BlockID, amount int, skip int) error {
-	p.Log().Trace("Fetching block by hash", "hash", h)
-	data := &getBlockHeadersData{Hash: h, Amount: uint64(amount), Skip: uint64(skip)}
-	log.Debug("Send GetBlockMsg", "query data is", data)
-	return message.Send(p.rw, GetBlockMsg, data.Serialize().(*protobuf.GetBlockHeadersData))
-}
-func (p *peer) RequestBlocksByNumber(i uint64, amount int, skip int) error {
-	p.Log().Trace("Fetching block by number", "number", i)
-	data := &getBlockHeadersData{Number: i, Amount: uint64(amount), Skip: uint64(skip)}
-	log.Debug("Send GetBlockMsg", "query data is", data)
-	return message.Send(p.rw, GetBlockMsg, data.Serialize().(*protobuf.GetBlockHeadersData))
-}
-
 //func (p *peer) SendNewBlockHashes(hashes []meta.DataID, numbers []uint64) error {
 //	for _, hash := range hashes {
 //		p.knownBlocks.Add(hash)
@@ -371,7 +410,8 @@ func (ps *peerSet) PeersWithoutTx(hash meta.BlockID) []*peer {
 	return list
 }
 
-// BestPeer retrieves the known peer with the currently highest total difficulty.
+// BestPeer retrieves the known peer with the currently highest total difficulty,
+// the full/01 peers are skipped as they can not serve the header sync.
 func (ps *peerSet) BestPeer() *peer {
 	ps.lock.RLock()
 	defer ps.lock.RUnlock()
@@ -382,6 +422,9 @@ func (ps *peerSet) BestPeer() *peer {
 	)
 
 	for _, p := range ps.peers {
+		if p.version < full02 {
+			continue
+		}
 		if _, height := p.Head(); bestPeer == nil || height > bestHeight {
 			bestPeer, bestHeight = p, height
 		}
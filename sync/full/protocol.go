@@ -5,26 +5,30 @@ import (
 	_ "io"
 	_ "math/big"
 
+	"github.com/mihongtech/linkchain/common/math"
 	"github.com/mihongtech/linkchain/common/serialize"
 	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/p2p/message"
 	"github.com/mihongtech/linkchain/protobuf"
 )
 
 // Constants to match up protocol versions and messages
 const (
 	full01 = 1
+	full02 = 2
+	full03 = 3
 )
 
 // Official short name of the protocol used during capability negotiation.
 var ProtocolName = "full"
 
 // Supported versions of the linkchain protocol (first is primary).
-var ProtocolVersions = []uint64{full01}
+var ProtocolVersions = []uint64{full03, full02, full01}
 
 // Number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = []uint64{8}
+var ProtocolLengths = []uint64{12, 10, 8}
 
-const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
+const ProtocolMaxMsgSize = message.MaxMsgSize // Maximum cap on the size of a protocol message
 
 // linkchain protocol message codes
 const (
@@ -35,6 +39,16 @@ const (
 	GetBlockMsg       = 0x03
 	BlockMsg          = 0x04
 	NewBlockMsg       = 0x05
+
+	// Protocol messages belonging to full/02
+	GetBlockHeadersMsg = 0x06
+	BlockHeadersMsg    = 0x07
+	GetBlockBodiesMsg  = 0x08
+	BlockBodiesMsg     = 0x09
+
+	// Protocol messages belonging to full/03
+	GetNodeDataMsg = 0x0a
+	NodeDataMsg    = 0x0b
 )
 
 type errCode int
@@ -158,3 +172,45 @@ func (n *getBlockHeadersData) Deserialize(data serialize.SerializeStream) {
 	n.Amount = *(d.Amount)
 	n.Skip = *(d.Skip)
 }
+
+// getBlockBodiesData is the network packet for block body queries.
+type getBlockBodiesData []meta.BlockID
+
+func (n getBlockBodiesData) Serialize() serialize.SerializeStream {
+	hashes := make([]*protobuf.Hash, 0, len(n))
+	for i := range n {
+		hashes = append(hashes, n[i].Serialize().(*protobuf.Hash))
+	}
+	return &protobuf.GetBlockBodiesData{Hashes: hashes}
+}
+
+func (n *getBlockBodiesData) Deserialize(data serialize.SerializeStream) {
+	d := data.(*protobuf.GetBlockBodiesData)
+	*n = make(getBlockBodiesData, 0, len(d.Hashes))
+	for _, h := range d.Hashes {
+		hash := meta.BlockID{}
+		hash.Deserialize(h)
+		*n = append(*n, hash)
+	}
+}
+
+// getNodeDataData is the network packet for state trie node and contract code queries.
+type getNodeDataData []math.Hash
+
+func (n getNodeDataData) Serialize() serialize.SerializeStream {
+	hashes := make([]*protobuf.Hash, 0, len(n))
+	for i := range n {
+		hashes = append(hashes, n[i].Serialize().(*protobuf.Hash))
+	}
+	return &protobuf.GetNodeDataData{Hashes: hashes}
+}
+
+func (n *getNodeDataData) Deserialize(data serialize.SerializeStream) {
+	d := data.(*protobuf.GetNodeDataData)
+	*n = make(getNodeDataData, 0, len(d.Hashes))
+	for _, h := range d.Hashes {
+		hash := math.Hash{}
+		hash.Deserialize(h)
+		*n = append(*n, hash)
+	}
+}
@@ -3,12 +3,13 @@ package full
 import (
 	_ "math/big"
 	"math/rand"
-	_ "sync/atomic"
+	"sync/atomic"
 	"time"
 
-	_ "github.com/mihongtech/linkchain/common/util/log"
+	"github.com/mihongtech/linkchain/common/util/log"
 	"github.com/mihongtech/linkchain/core/meta"
 	"github.com/mihongtech/linkchain/p2p/discover"
+	"github.com/mihongtech/linkchain/sync/full/downloader"
 )
 
 const (
@@ -143,10 +144,21 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 		return
 	}
 
-	//	// Run the sync cycle, and disable fast sync if we've went past the pivot block
-	if err := pm.downloader.Synchronise(peer.id, pHead); err != nil {
+	mode := downloader.FullSync
+	if atomic.LoadUint32(&pm.fastSync) == 1 {
+		mode = downloader.FastSync
+	}
+	// Run the sync cycle, and disable fast sync if we've went past the pivot block
+	if err := pm.downloader.Synchronise(peer.id, pHead, mode); err != nil {
 		return
 	}
+	if atomic.LoadUint32(&pm.fastSync) == 1 {
+		// Disable fast sync if we indeed have something in our chain
+		if pm.nodeAPI.GetBestBlock().GetHeight() > 0 {
+			log.Info("Fast sync complete, auto disabling")
+			atomic.StoreUint32(&pm.fastSync, 0)
+		}
+	}
 
 	if block := pm.nodeAPI.GetBestBlock(); block.GetHeight() > 0 {
 		// We've completed a sync cycle, notify all peers of new state. This path is
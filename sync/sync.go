@@ -2,9 +2,11 @@ package sync
 
 import (
 	"github.com/mihongtech/linkchain/app/context"
+	"github.com/mihongtech/linkchain/common/util/log"
 	"github.com/mihongtech/linkchain/node"
 	p2p_peer "github.com/mihongtech/linkchain/p2p/peer"
 	"github.com/mihongtech/linkchain/sync/full"
+	"github.com/mihongtech/linkchain/sync/full/downloader"
 	"github.com/mihongtech/linkchain/txpool"
 )
 
@@ -18,9 +20,17 @@ func (s *Service) Setup(i interface{}) bool {
 	//log.Info("sync service init...");
 	nodeAPI := i.(*context.Context).NodeAPI.(*node.PublicNodeAPI)
 	txPoolAPI := i.(*context.Context).TxpoolAPI.(*txpool.TxPool)
-	engine, err := full.NewProtocolManager(i,
+	mode := downloader.FullSync
+	if cfg := i.(*context.Context).Config; cfg != nil && cfg.SyncMode != "" {
+		if err := mode.UnmarshalText([]byte(cfg.SyncMode)); err != nil {
+			log.Error("sync service init failed", "err", err)
+			return false
+		}
+	}
+	engine, err := full.NewProtocolManager(i, mode,
 		nodeAPI, txPoolAPI, 0, nodeAPI.GetBlockEvent(), nodeAPI.GetTxEvent())
 	if err != nil {
+		log.Error("sync service init failed", "mode", mode, "err", err)
 		return false
 	}
 	s.Engine = engine
@@ -0,0 +1,47 @@
+package txpool
+
+import "errors"
+
+var (
+	// ErrKnownTx is returned when a transaction is already contained in the pool.
+	ErrKnownTx = errors.New("tx already known")
+
+	// ErrDoubleSpend is returned when a ticket of the transaction is already
+	// spent by another transaction in the pool.
+	ErrDoubleSpend = errors.New("tx ticket already spent in pool")
+
+	// ErrCoinBaseTx is returned when a coinbase transaction is sent to the pool.
+	ErrCoinBaseTx = errors.New("coinbase tx can not be added to pool")
+
+	// ErrVoteTx is returned when a vote transaction is sent to the pool.
+	ErrVoteTx = errors.New("vote tx can not be added to pool")
+
+	// ErrNegativeFee is returned when the to value of a transaction is more than
+	// the value of its tickets.
+	ErrNegativeFee = errors.New("tx fee is negative")
+
+	// ErrUnderpriced is returned when the pool is full and the fee per byte of
+	// the transaction is not higher than the cheapest one in the pool.
+	ErrUnderpriced = errors.New("tx pool is full and tx fee is too low")
+)
+
+// InvalidTxError is returned when a transaction fails the check or the verification
+// against the best state, an honest sender never relays such a transaction.
+type InvalidTxError struct {
+	Err error
+}
+
+func (e *InvalidTxError) Error() string {
+	return e.Err.Error()
+}
+
+// IsInvalidTx returns whether err is caused by an invalid transaction rather
+// than by the pool policy. The coinbase and vote transactions are only packed
+// by miners, so they are invalid to be relayed too.
+func IsInvalidTx(err error) bool {
+	if err == ErrCoinBaseTx || err == ErrVoteTx {
+		return true
+	}
+	_, ok := err.(*InvalidTxError)
+	return ok
+}
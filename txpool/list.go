@@ -0,0 +1,53 @@
+package txpool
+
+import (
+	"math/big"
+
+	"github.com/mihongtech/linkchain/core/meta"
+)
+
+type txEntry struct {
+	tx    *meta.Transaction
+	fee   int64 //the value of from tickets minus the value of to
+	size  int
+	index int //the index in txPriceHeap
+}
+
+//cheaper compare the fee per byte of two entry.
+//Return true if a pays less fee per byte than b.
+func (a *txEntry) cheaper(b *txEntry) bool {
+	// a.fee/a.size < b.fee/b.size => a.fee*b.size < b.fee*a.size
+	x := new(big.Int).Mul(big.NewInt(a.fee), big.NewInt(int64(b.size)))
+	y := new(big.Int).Mul(big.NewInt(b.fee), big.NewInt(int64(a.size)))
+	return x.Cmp(y) < 0
+}
+
+//txPriceHeap is a min heap of txEntry which is ordered by fee per byte.
+//The cheapest tx is always at the top of heap.
+type txPriceHeap []*txEntry
+
+func (h txPriceHeap) Len() int { return len(h) }
+
+func (h txPriceHeap) Less(i, j int) bool { return h[i].cheaper(h[j]) }
+
+func (h txPriceHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *txPriceHeap) Push(x interface{}) {
+	entry := x.(*txEntry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *txPriceHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	entry.index = -1
+	*h = old[0 : n-1]
+	return entry
+}
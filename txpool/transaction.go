@@ -4,7 +4,9 @@ import (
 	"errors"
 
 	"github.com/mihongtech/linkchain/common/util/log"
+	"github.com/mihongtech/linkchain/config"
 	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/storage/state"
 )
 
 func (tp *TxPool) ProcessTx(tx *meta.Transaction) error {
@@ -22,22 +24,78 @@ func (tp *TxPool) RemoveTransaction(txID meta.TxID) error {
 func (tp *TxPool) checkTx(tx *meta.Transaction) error {
 	err := tp.validatorAPI.CheckTx(tx)
 	if err != nil {
-		return errors.New("CheckTx" + "\ttx:" + tx.GetTxID().String() + "\nerror:" + err.Error())
+		return &InvalidTxError{errors.New("CheckTx" + "\ttx:" + tx.GetTxID().String() + "\nerror:" + err.Error())}
 	}
 	return err
 }
 
+func (tp *TxPool) verifyTx(tx *meta.Transaction, stateDb *state.StateDB) error {
+	err := tp.nodeAPI.VerifyTx(tx, stateDb)
+	if err != nil {
+		return &InvalidTxError{errors.New("VerifyTx" + "\ttx:" + tx.GetTxID().String() + "\nerror:" + err.Error())}
+	}
+	return err
+}
+
+//calculate the fee of tx by the value of tickets in state.
+func calcTxFee(tx *meta.Transaction, stateDb *state.StateDB) (int64, error) {
+	fromValue := meta.NewAmount(0)
+	for _, fc := range tx.From.Coins {
+		obj := stateDb.GetObject(meta.GetAccountHash(fc.GetId()))
+		if obj == nil {
+			return 0, errors.New("can not find tx from in state")
+		}
+		value, err := obj.GetAccount().GetFromCoinValue(&fc)
+		if err != nil {
+			return 0, err
+		}
+		fromValue.Addition(*value)
+	}
+	fee := fromValue.Subtraction(*tx.GetToValue()).GetInt64()
+	if fee < 0 {
+		return 0, ErrNegativeFee
+	}
+	return fee, nil
+}
+
 func (tp *TxPool) processTx(tx *meta.Transaction) error {
 	log.Info("ProcessTx ...")
+	if tx.GetType() == config.CoinBaseTx {
+		return ErrCoinBaseTx
+	}
+	if tx.GetType() == config.VoteTx {
+		return ErrVoteTx
+	}
 	//1.checkTx
 	if err := tp.checkTx(tx); err != nil {
 		return err
 	}
-	//2.push Tx into storage
-	err := tp.addTransaction(tx)
+	//2.verifyTx with best state
+	best := tp.nodeAPI.GetBestBlock()
+	stateDb, err := tp.nodeAPI.StateAt(best.Header.Status)
 	if err != nil {
 		return err
 	}
-	log.Info("Add Tranasaction Pool  ...", "txid", tx.GetTxID(), "tx", tx)
+	return tp.processTxWithState(tx, stateDb)
+}
+
+//verify the checked tx with stateDb, then push it into pool.
+func (tp *TxPool) processTxWithState(tx *meta.Transaction, stateDb *state.StateDB) error {
+	if err := tp.verifyTx(tx, stateDb); err != nil {
+		return err
+	}
+	fee, err := calcTxFee(tx, stateDb)
+	if err != nil {
+		return err
+	}
+	size, err := tx.Size()
+	if err != nil {
+		return err
+	}
+	//3.push Tx into pool
+	if err := tp.addTransaction(tx, fee, size); err != nil {
+		return err
+	}
+	log.Info("Add Tranasaction Pool  ...", "txid", tx.GetTxID(), "fee", fee, "size", size)
 	return nil
 }
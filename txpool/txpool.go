@@ -1,18 +1,37 @@
 package txpool
 
 import (
+	"container/heap"
+	"sort"
 	"sync"
 
 	"github.com/mihongtech/linkchain/app/context"
+	"github.com/mihongtech/linkchain/common/math"
 	"github.com/mihongtech/linkchain/common/util/event"
+	"github.com/mihongtech/linkchain/common/util/log"
+	"github.com/mihongtech/linkchain/config"
 	"github.com/mihongtech/linkchain/core/meta"
 	"github.com/mihongtech/linkchain/interpreter"
 	"github.com/mihongtech/linkchain/node"
+	"github.com/mihongtech/linkchain/storage/state"
 )
 
+//poolNode is the part of node api which the txs of pool are verified with.
+type poolNode interface {
+	GetTxPoolEvent() *event.TypeMux
+	GetBestBlock() *meta.Block
+	StateAt(root math.Hash) (*state.StateDB, error)
+	VerifyTx(tx *meta.Transaction, stateDb *state.StateDB) error
+	ApplyTx(tx *meta.Transaction, stateDb *state.StateDB) error
+}
+
 type TxPool struct {
-	txPool       []meta.Transaction
-	nodeAPI      *node.PublicNodeAPI
+	all      map[meta.TxID]*txEntry    //all txs in pool indexed by txid
+	spent    map[meta.Ticket]meta.TxID //the tickets spent by txs in pool
+	priced   txPriceHeap               //txs in pool ordered by fee per byte
+	capacity int                       //the max count of txs in pool
+
+	nodeAPI      poolNode
 	validatorAPI interpreter.Validator
 
 	txPollMtx sync.RWMutex
@@ -22,19 +41,25 @@ type TxPool struct {
 
 func NewTxPool() *TxPool {
 	return &TxPool{
-		txPool: make([]meta.Transaction, 0),
+		all:      make(map[meta.TxID]*txEntry),
+		spent:    make(map[meta.Ticket]meta.TxID),
+		priced:   make(txPriceHeap, 0),
+		capacity: config.DefaultTxPoolSize,
 	}
 }
 
 func (tp *TxPool) Setup(i interface{}) bool {
 	tp.nodeAPI = i.(*context.Context).NodeAPI.(*node.PublicNodeAPI)
 	tp.validatorAPI = i.(*context.Context).InterpreterAPI.(interpreter.Validator)
+	if cfg := i.(*context.Context).Config; cfg != nil && cfg.TxPoolSize > 0 {
+		tp.capacity = cfg.TxPoolSize
+	}
 	return true
 }
 
 func (tp *TxPool) Start() bool {
 	txPoolEvent := tp.nodeAPI.GetTxPoolEvent()
-	tp.insertBlockSub = txPoolEvent.Subscribe(node.InsertBlockEvent{})
+	tp.insertBlockSub = txPoolEvent.Subscribe(node.InsertBlockEvent{}, node.RemovedTxsEvent{})
 	go tp.updateTxLoop()
 	return true
 }
@@ -50,31 +75,115 @@ func (tp *TxPool) updateTxLoop() {
 			switch ev := ev.Data.(type) {
 			case node.InsertBlockEvent:
 				tp.updateTransaction(ev.Block)
+			case node.RemovedTxsEvent:
+				tp.reinjectTransaction(ev.Txs)
 			}
 		}
 	}
 }
+
+//Remove the txs which are packed into block and the txs which spend the same tickets as block.
 func (tp *TxPool) updateTransaction(block *meta.Block) {
+	tp.txPollMtx.Lock()
+	defer tp.txPollMtx.Unlock()
+
 	txs := block.GetTxs()
 	for i := range txs {
-		tp.removeTransaction(*txs[i].GetTxID())
+		tp.removeEntry(*txs[i].GetTxID())
+		for _, fc := range txs[i].From.Coins {
+			for _, t := range fc.Ticket {
+				if txid, ok := tp.spent[t]; ok {
+					tp.removeEntry(txid)
+				}
+			}
+		}
 	}
 }
 
-func (tp *TxPool) addTransaction(tx *meta.Transaction) error {
+//Put the txs which are dropped from main chain by a reorg back to pool.
+//The txs come oldest first, and the reinjected txs are applied to the best state
+//so that the txs spending their outputs are reinjected too. The txs were checked
+//when their blocks were inserted, so they are only verified with the state.
+//The txs which are invalid on the new best block are discarded.
+func (tp *TxPool) reinjectTransaction(txs []meta.Transaction) {
+	best := tp.nodeAPI.GetBestBlock()
+	stateDb, err := tp.nodeAPI.StateAt(best.Header.Status)
+	if err != nil {
+		log.Error("Reinject txs of dropped blocks failed", "best", best.GetBlockID(), "err", err)
+		return
+	}
+
+	for i := range txs {
+		if txs[i].GetType() == config.CoinBaseTx || txs[i].GetType() == config.VoteTx {
+			continue
+		}
+		if err := tp.processTxWithState(&txs[i], stateDb); err != nil {
+			log.Debug("Discard tx of dropped block", "txid", txs[i].GetTxID(), "err", err)
+			continue
+		}
+		if err := tp.nodeAPI.ApplyTx(&txs[i], stateDb); err != nil {
+			//the state is broken by the tx partially applied
+			log.Error("Apply tx of dropped block failed", "txid", txs[i].GetTxID(), "err", err)
+			return
+		}
+	}
+}
+
+func (tp *TxPool) addTransaction(tx *meta.Transaction, fee int64, size int) error {
 	tp.txPollMtx.Lock()
 	defer tp.txPollMtx.Unlock()
+
+	txid := *tx.GetTxID()
+	if _, ok := tp.all[txid]; ok {
+		return ErrKnownTx
+	}
+	for _, fc := range tx.From.Coins {
+		for _, t := range fc.Ticket {
+			if _, ok := tp.spent[t]; ok {
+				return ErrDoubleSpend
+			}
+		}
+	}
+
 	newTx := *tx
-	tp.txPool = append(tp.txPool, newTx)
+	entry := &txEntry{tx: &newTx, fee: fee, size: size}
+
+	//evict the cheapest txs if pool is full
+	if len(tp.all) >= tp.capacity {
+		if !tp.priced[0].cheaper(entry) {
+			return ErrUnderpriced
+		}
+		for len(tp.all) >= tp.capacity {
+			tp.removeEntry(*tp.priced[0].tx.GetTxID())
+		}
+	}
+
+	tp.all[txid] = entry
+	for _, fc := range tx.From.Coins {
+		for _, t := range fc.Ticket {
+			tp.spent[t] = txid
+		}
+	}
+	heap.Push(&tp.priced, entry)
 	return nil
 }
 
+//Return all txs in pool, the tx which has higher fee per byte is in front.
 func (tp *TxPool) getAllTransaction() []meta.Transaction {
 	tp.txPollMtx.RLock()
 	defer tp.txPollMtx.RUnlock()
-	txs := make([]meta.Transaction, 0)
-	for _, tx := range tp.txPool {
-		txs = append(txs, tx)
+
+	entries := make([]*txEntry, 0, len(tp.all))
+	for _, entry := range tp.all {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[j].cheaper(entries[i])
+	})
+
+	txs := make([]meta.Transaction, 0, len(entries))
+	for _, entry := range entries {
+		txs = append(txs, *entry.tx)
 	}
 	return txs
 }
@@ -83,15 +192,24 @@ func (tp *TxPool) removeTransaction(txID meta.TxID) error {
 	tp.txPollMtx.Lock()
 	defer tp.txPollMtx.Unlock()
 
-	txLen := len(tp.txPool)
-	for i := 0; i < txLen; i++ {
-		txHash := tp.txPool[i].GetTxID()
-		if txHash.IsEqual(&txID) {
-			tp.txPool = append(tp.txPool[:i], tp.txPool[i+1:]...)
-			i--
-			txLen--
+	tp.removeEntry(txID)
+	return nil
+}
+
+//removeEntry remove tx from all indexes.
+//The caller must hold txPollMtx.
+func (tp *TxPool) removeEntry(txID meta.TxID) {
+	entry, ok := tp.all[txID]
+	if !ok {
+		return
+	}
+	delete(tp.all, txID)
+	for _, fc := range entry.tx.From.Coins {
+		for _, t := range fc.Ticket {
+			if spender, ok := tp.spent[t]; ok && spender.IsEqual(&txID) {
+				delete(tp.spent, t)
+			}
 		}
 	}
-
-	return nil
+	heap.Remove(&tp.priced, entry.index)
 }
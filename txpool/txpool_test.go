@@ -0,0 +1,208 @@
+package txpool
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mihongtech/linkchain/common/lcdb"
+	"github.com/mihongtech/linkchain/common/math"
+	"github.com/mihongtech/linkchain/common/util/event"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/helper"
+	"github.com/mihongtech/linkchain/interpreter"
+	"github.com/mihongtech/linkchain/normal"
+	"github.com/mihongtech/linkchain/storage/state"
+)
+
+func newTestTx(ticket meta.Ticket, value int64) *meta.Transaction {
+	id := meta.AccountID{}
+	return helper.CreateTransaction(*helper.CreateFromCoin(id, ticket), *helper.CreateToCoin(id, meta.NewAmount(value)))
+}
+
+func newTestPool(capacity int) *TxPool {
+	tp := NewTxPool()
+	tp.capacity = capacity
+	return tp
+}
+
+func TestAddTransactionDoubleSpend(t *testing.T) {
+	tp := newTestPool(10)
+	ticket := *meta.NewTicket(*meta.MakeTxID([]byte("prev")), 0)
+
+	tx := newTestTx(ticket, 10)
+	if err := tp.addTransaction(tx, 10, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := tp.addTransaction(tx, 10, 100); err != ErrKnownTx {
+		t.Errorf("add known tx: want %v, got %v", ErrKnownTx, err)
+	}
+	if err := tp.addTransaction(newTestTx(ticket, 20), 10, 100); err != ErrDoubleSpend {
+		t.Errorf("add double spend tx: want %v, got %v", ErrDoubleSpend, err)
+	}
+
+	tp.removeTransaction(*tx.GetTxID())
+	if len(tp.all) != 0 || len(tp.spent) != 0 || len(tp.priced) != 0 {
+		t.Errorf("remove tx: indexes are not empty")
+	}
+	if err := tp.addTransaction(newTestTx(ticket, 20), 10, 100); err != nil {
+		t.Errorf("add tx after remove: %v", err)
+	}
+}
+
+func TestAddTransactionEvict(t *testing.T) {
+	tp := newTestPool(2)
+	prev := *meta.MakeTxID([]byte("prev"))
+
+	cheap := newTestTx(*meta.NewTicket(prev, 0), 1)
+	middle := newTestTx(*meta.NewTicket(prev, 1), 2)
+	rich := newTestTx(*meta.NewTicket(prev, 2), 3)
+	if err := tp.addTransaction(cheap, 10, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := tp.addTransaction(middle, 100, 200); err != nil {
+		t.Fatal(err)
+	}
+	if err := tp.addTransaction(newTestTx(*meta.NewTicket(prev, 3), 4), 5, 100); err != ErrUnderpriced {
+		t.Errorf("add underpriced tx: want %v, got %v", ErrUnderpriced, err)
+	}
+	if err := tp.addTransaction(rich, 1000, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	txs := tp.getAllTransaction()
+	if len(txs) != 2 {
+		t.Fatalf("pool size: want 2, got %d", len(txs))
+	}
+	if !txs[0].GetTxID().IsEqual(rich.GetTxID()) || !txs[1].GetTxID().IsEqual(middle.GetTxID()) {
+		t.Errorf("pool order is not by fee per byte")
+	}
+	if _, ok := tp.spent[*meta.NewTicket(prev, 0)]; ok {
+		t.Errorf("ticket of evicted tx is still spent")
+	}
+}
+
+//stubValidator fails the stateless check of every tx with err.
+type stubValidator struct {
+	interpreter.Validator
+	err error
+}
+
+func (v *stubValidator) CheckTx(tx *meta.Transaction) error {
+	return v.err
+}
+
+func TestInvalidTx(t *testing.T) {
+	tp := newTestPool(10)
+	tp.validatorAPI = &stubValidator{err: errors.New("bad sign")}
+
+	tx := newTestTx(*meta.NewTicket(*meta.MakeTxID([]byte("prev")), 0), 10)
+	if err := tp.processTx(tx); !IsInvalidTx(err) {
+		t.Errorf("failed check is not an invalid tx: %v", err)
+	}
+	coinbase := helper.CreateCoinBaseTx(meta.AccountID{}, meta.NewAmount(10), 1)
+	if err := tp.processTx(coinbase); !IsInvalidTx(err) {
+		t.Errorf("coinbase tx is not an invalid tx: %v", err)
+	}
+
+	//the tx spending an unknown ticket fails the verification with state
+	tp.validatorAPI = &stubValidator{}
+	tp.nodeAPI = &testNode{best: meta.NewBlock(meta.BlockHeader{Height: 1}, nil)}
+	if err := tp.processTx(tx); !IsInvalidTx(err) {
+		t.Errorf("failed verification is not an invalid tx: %v", err)
+	}
+	for _, err := range []error{ErrKnownTx, ErrDoubleSpend, ErrUnderpriced, ErrNegativeFee} {
+		if IsInvalidTx(err) {
+			t.Errorf("pool policy error is an invalid tx: %v", err)
+		}
+	}
+}
+
+//testNode verifies and applies txs with the normal interpreter, its best state is
+//the funding txs applied to an empty state.
+type testNode struct {
+	interpreter normal.Interpreter
+	best        *meta.Block
+	funds       []meta.Transaction
+}
+
+func (n *testNode) GetTxPoolEvent() *event.TypeMux {
+	return nil
+}
+
+func (n *testNode) GetBestBlock() *meta.Block {
+	return n.best
+}
+
+func (n *testNode) StateAt(root math.Hash) (*state.StateDB, error) {
+	db, _ := lcdb.NewMemDatabase()
+	stateDb, err := state.New(math.Hash{}, db)
+	if err != nil {
+		return nil, err
+	}
+	for i := range n.funds {
+		if err := n.ApplyTx(&n.funds[i], stateDb); err != nil {
+			return nil, err
+		}
+	}
+	return stateDb, nil
+}
+
+func (n *testNode) inputData(stateDb *state.StateDB) interpreter.Params {
+	header := meta.BlockHeader{Height: n.best.GetHeight() + 1, Prev: *n.best.GetBlockID()}
+	return n.interpreter.CreateInputData(&header, stateDb, nil)
+}
+
+func (n *testNode) VerifyTx(tx *meta.Transaction, stateDb *state.StateDB) error {
+	return n.interpreter.VerifyTx(tx, n.inputData(stateDb))
+}
+
+func (n *testNode) ApplyTx(tx *meta.Transaction, stateDb *state.StateDB) error {
+	err, _ := n.interpreter.ProcessTxState(tx, n.inputData(stateDb))
+	return err
+}
+
+func newChainedTx(from meta.AccountID, prev *meta.Transaction, to meta.AccountID, value int64) *meta.Transaction {
+	ticket := *meta.NewTicket(*prev.GetTxID(), 0)
+	return helper.CreateTransaction(*helper.CreateFromCoin(from, ticket), *helper.CreateToCoin(to, meta.NewAmount(value)))
+}
+
+//Tests that the chained txs dropped by a reorg are reinjected when they come oldest first.
+func TestReinjectTransaction(t *testing.T) {
+	a, b, c := meta.CreateAccountId([]byte("a")), meta.CreateAccountId([]byte("b")), meta.CreateAccountId([]byte("c"))
+
+	fund := helper.CreateCoinBaseTx(a, meta.NewAmount(100), 1)
+	tx1 := newChainedTx(a, fund, b, 90)
+	tx2 := newChainedTx(b, tx1, c, 80)
+	tx3 := newChainedTx(c, tx2, a, 75)
+	side1 := meta.NewBlock(meta.BlockHeader{Height: 2}, []meta.Transaction{*helper.CreateCoinBaseTx(c, meta.NewAmount(50), 2), *tx1, *tx2})
+	side2 := meta.NewBlock(meta.BlockHeader{Height: 3, Prev: *side1.GetBlockID()}, []meta.Transaction{*tx3})
+
+	node := &testNode{best: meta.NewBlock(meta.BlockHeader{Height: 3, Data: []byte("new")}, nil), funds: []meta.Transaction{*fund}}
+	tp := newTestPool(10)
+	tp.nodeAPI = node
+	tp.validatorAPI = &stubValidator{}
+	tp.reinjectTransaction(append(side1.GetTxs(), side2.GetTxs()...))
+
+	if len(tp.all) != 3 {
+		t.Fatalf("pool size: want 3, got %d", len(tp.all))
+	}
+	for _, want := range []struct {
+		tx  *meta.Transaction
+		fee int64
+	}{{tx1, 10}, {tx2, 10}, {tx3, 5}} {
+		if entry, ok := tp.all[*want.tx.GetTxID()]; !ok {
+			t.Errorf("tx %v is not reinjected", want.tx.GetTxID())
+		} else if entry.fee != want.fee {
+			t.Errorf("fee of tx %v: want %d, got %d", want.tx.GetTxID(), want.fee, entry.fee)
+		}
+	}
+
+	//the txs spending the outputs of unknown txs are discarded
+	tp = newTestPool(10)
+	tp.nodeAPI = node
+	tp.validatorAPI = &stubValidator{}
+	tp.reinjectTransaction(side2.GetTxs())
+	if len(tp.all) != 0 {
+		t.Errorf("pool size: want 0, got %d", len(tp.all))
+	}
+}
@@ -0,0 +1,242 @@
+package wallet
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/mihongtech/linkchain/core/meta"
+)
+
+//the strategies of coin selection
+const (
+	CoinSelectBnB          = "bnb"
+	CoinSelectLargestFirst = "largest"
+	CoinSelectOldestFirst  = "oldest"
+	CoinSelectRandom       = "random"
+
+	//DefaultCoinSelect is the strategy if it is not specified
+	DefaultCoinSelect = CoinSelectBnB
+
+	//the max tries of branch and bound search
+	bnbMaxTries = 100000
+)
+
+var (
+	ErrInsufficientFunds  = errors.New("insufficient funds to cover the value and fee")
+	ErrUnknownCoinSelect  = errors.New("unknown coin selection strategy")
+	ErrNoExactMatch       = errors.New("no exact match of coins")
+	ErrTicketNotSpendable = errors.New("the pinned ticket is not a spendable utxo of account")
+)
+
+//CoinSelectParams is the target of a coin selector.
+//The effective value of an utxo is its value minus InputFee, the fee to spend it.
+//The selected utxos must have effective values of at least Target in sum,
+//the excess less than CostOfChange is not worth a change and is paid as fee.
+type CoinSelectParams struct {
+	Target       int64
+	InputFee     int64
+	CostOfChange int64
+}
+
+//effectiveValue returns the value of utxo minus the fee to spend it.
+func (p *CoinSelectParams) effectiveValue(u *meta.UTXO) int64 {
+	return u.Value.GetInt64() - p.InputFee
+}
+
+//CoinSelector selects utxos to cover the target of params.
+type CoinSelector interface {
+	Select(utxos []meta.UTXO, params *CoinSelectParams) ([]meta.UTXO, error)
+}
+
+var (
+	coinSelectors = map[string]CoinSelector{
+		CoinSelectBnB:          &bnbSelector{fallback: &largestFirstSelector{}},
+		CoinSelectLargestFirst: &largestFirstSelector{},
+		CoinSelectOldestFirst:  &oldestFirstSelector{},
+		CoinSelectRandom:       &randomSelector{},
+	}
+	coinSelectorsMtx sync.RWMutex
+)
+
+//RegisterCoinSelector registers the coin selector of strategy, it replaces the existed one.
+func RegisterCoinSelector(strategy string, selector CoinSelector) {
+	coinSelectorsMtx.Lock()
+	defer coinSelectorsMtx.Unlock()
+	coinSelectors[strategy] = selector
+}
+
+//GetCoinSelector returns the coin selector of strategy, DefaultCoinSelect if it is empty.
+func GetCoinSelector(strategy string) (CoinSelector, error) {
+	if strategy == "" {
+		strategy = DefaultCoinSelect
+	}
+	coinSelectorsMtx.RLock()
+	defer coinSelectorsMtx.RUnlock()
+	selector, ok := coinSelectors[strategy]
+	if !ok {
+		return nil, ErrUnknownCoinSelect
+	}
+	return selector, nil
+}
+
+//selectInOrder takes the utxos in order until the target is covered,
+//the utxos which cost more fee than their values are skipped.
+func selectInOrder(utxos []meta.UTXO, params *CoinSelectParams) ([]meta.UTXO, error) {
+	selected := make([]meta.UTXO, 0)
+	sum := int64(0)
+	for i := range utxos {
+		if sum >= params.Target {
+			break
+		}
+		value := params.effectiveValue(&utxos[i])
+		if value <= 0 {
+			continue
+		}
+		selected = append(selected, utxos[i])
+		sum += value
+	}
+	if sum < params.Target {
+		return nil, ErrInsufficientFunds
+	}
+	return selected, nil
+}
+
+//compareTicket orders utxos by txid and index, which makes the selection deterministic.
+func compareTicket(a, b *meta.UTXO) bool {
+	if c := a.Txid.Big().Cmp(b.Txid.Big()); c != 0 {
+		return c < 0
+	}
+	return a.Index < b.Index
+}
+
+//largestFirstSelector takes the largest utxos first, it spends the fewest utxos.
+type largestFirstSelector struct{}
+
+func (s *largestFirstSelector) Select(utxos []meta.UTXO, params *CoinSelectParams) ([]meta.UTXO, error) {
+	sorted := append([]meta.UTXO(nil), utxos...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if c := sorted[i].Value.GetBigInt().Cmp(sorted[j].Value.GetBigInt()); c != 0 {
+			return c > 0
+		}
+		return compareTicket(&sorted[i], &sorted[j])
+	})
+	return selectInOrder(sorted, params)
+}
+
+//oldestFirstSelector takes the utxos which are located in the lowest height first.
+type oldestFirstSelector struct{}
+
+func (s *oldestFirstSelector) Select(utxos []meta.UTXO, params *CoinSelectParams) ([]meta.UTXO, error) {
+	sorted := append([]meta.UTXO(nil), utxos...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].LocatedHeight != sorted[j].LocatedHeight {
+			return sorted[i].LocatedHeight < sorted[j].LocatedHeight
+		}
+		return compareTicket(&sorted[i], &sorted[j])
+	})
+	return selectInOrder(sorted, params)
+}
+
+//randomSelector takes the utxos in random order, so the spent utxos do not reveal the wallet's order.
+type randomSelector struct{}
+
+func (s *randomSelector) Select(utxos []meta.UTXO, params *CoinSelectParams) ([]meta.UTXO, error) {
+	var seed [8]byte
+	if _, err := crand.Read(seed[:]); err != nil {
+		return nil, err
+	}
+	r := rand.New(rand.NewSource(int64(binary.LittleEndian.Uint64(seed[:]))))
+
+	shuffled := append([]meta.UTXO(nil), utxos...)
+	r.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return selectInOrder(shuffled, params)
+}
+
+//bnbSelector searches the utxos by branch and bound for the match whose excess is less than
+//the cost of change, so the tx has no change.  The match with the least excess is chosen.
+//The fallback selects the utxos if there is no match, ErrNoExactMatch is returned if fallback is nil.
+type bnbSelector struct {
+	fallback CoinSelector
+}
+
+func (s *bnbSelector) Select(utxos []meta.UTXO, params *CoinSelectParams) ([]meta.UTXO, error) {
+	pool := make([]meta.UTXO, 0, len(utxos))
+	available := int64(0)
+	for i := range utxos {
+		if value := params.effectiveValue(&utxos[i]); value > 0 {
+			pool = append(pool, utxos[i])
+			available += value
+		}
+	}
+	if available < params.Target {
+		return nil, ErrInsufficientFunds
+	}
+
+	//the larger utxos first, the search overshoots and cuts branches earlier
+	sort.Slice(pool, func(i, j int) bool {
+		if c := pool[i].Value.GetBigInt().Cmp(pool[j].Value.GetBigInt()); c != 0 {
+			return c > 0
+		}
+		return compareTicket(&pool[i], &pool[j])
+	})
+	values := make([]int64, len(pool))
+	for i := range pool {
+		values[i] = params.effectiveValue(&pool[i])
+	}
+
+	upper := params.Target + params.CostOfChange
+	current := make([]bool, len(pool))
+	var best []bool
+	bestExcess := int64(-1)
+	tries := 0
+
+	var search func(i int, sum int64, remain int64)
+	search = func(i int, sum int64, remain int64) {
+		if tries >= bnbMaxTries || bestExcess == 0 {
+			return
+		}
+		tries++
+		if sum > upper {
+			return
+		}
+		if sum >= params.Target {
+			if excess := sum - params.Target; bestExcess < 0 || excess < bestExcess {
+				bestExcess = excess
+				best = append(best[:0], current...)
+			}
+			return
+		}
+		if i == len(values) || sum+remain < params.Target {
+			return
+		}
+
+		//including an utxo of the same value as the excluded previous one is a searched branch
+		if i == 0 || current[i-1] || values[i] != values[i-1] {
+			current[i] = true
+			search(i+1, sum+values[i], remain-values[i])
+			current[i] = false
+		}
+		search(i+1, sum, remain-values[i])
+	}
+	search(0, 0, available)
+
+	if best == nil {
+		if s.fallback == nil {
+			return nil, ErrNoExactMatch
+		}
+		return s.fallback.Select(utxos, params)
+	}
+	selected := make([]meta.UTXO, 0)
+	for i, ok := range best {
+		if ok {
+			selected = append(selected, pool[i])
+		}
+	}
+	return selected, nil
+}
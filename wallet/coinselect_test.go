@@ -0,0 +1,168 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/mihongtech/linkchain/common/math"
+	"github.com/mihongtech/linkchain/config"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/helper"
+)
+
+//newTestUTXOs creates utxos of values, the i-th utxo is located at height i+1.
+func newTestUTXOs(values ...int64) []meta.UTXO {
+	utxos := make([]meta.UTXO, 0, len(values))
+	for i, value := range values {
+		txid := math.BytesToHash([]byte{byte(i + 1)})
+		utxos = append(utxos, *meta.NewUTXO(meta.NewTicket(txid, 0), uint32(i+1), uint32(i+1), *meta.NewAmount(value)))
+	}
+	return utxos
+}
+
+func TestBnBExactMatch(t *testing.T) {
+	utxos := newTestUTXOs(100, 70, 50, 30, 20)
+	selector := &bnbSelector{}
+
+	selected, err := selector.Select(utxos, &CoinSelectParams{Target: 90})
+	if err != nil {
+		t.Fatalf("select failed: %v", err)
+	}
+	if sum := sumUTXOs(selected); sum != 90 {
+		t.Fatalf("sum mismatch: have %d, want 90", sum)
+	}
+
+	//the fee of inputs is counted, 2 inputs of 50 and 30 cost 10
+	selected, err = selector.Select(utxos, &CoinSelectParams{Target: 70, InputFee: 5})
+	if err != nil {
+		t.Fatalf("select with fee failed: %v", err)
+	}
+	if sum := sumUTXOs(selected) - int64(len(selected))*5; sum != 70 {
+		t.Fatalf("effective sum mismatch: have %d, want 70", sum)
+	}
+
+	if _, err := selector.Select(utxos, &CoinSelectParams{Target: 15}); err != ErrNoExactMatch {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrNoExactMatch)
+	}
+	if _, err := selector.Select(utxos, &CoinSelectParams{Target: 15, CostOfChange: 5}); err != nil {
+		t.Fatalf("select within cost of change failed: %v", err)
+	}
+	if _, err := selector.Select(utxos, &CoinSelectParams{Target: 300}); err != ErrInsufficientFunds {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrInsufficientFunds)
+	}
+}
+
+func TestBnBFallback(t *testing.T) {
+	utxos := newTestUTXOs(100, 70, 50)
+	selector, err := GetCoinSelector("")
+	if err != nil {
+		t.Fatalf("get default selector failed: %v", err)
+	}
+	selected, err := selector.Select(utxos, &CoinSelectParams{Target: 110})
+	if err != nil {
+		t.Fatalf("select failed: %v", err)
+	}
+	if len(selected) != 2 || selected[0].Value.GetInt64() != 100 || selected[1].Value.GetInt64() != 70 {
+		t.Fatalf("fallback to largest first mismatch: %v", selected)
+	}
+}
+
+func TestOrderedSelectors(t *testing.T) {
+	utxos := newTestUTXOs(10, 40, 5, 60, 1)
+	tests := []struct {
+		strategy string
+		target   int64
+		inputFee int64
+		want     []int64
+	}{
+		{CoinSelectLargestFirst, 90, 0, []int64{60, 40}},
+		{CoinSelectOldestFirst, 50, 0, []int64{10, 40}},
+		//the utxo of 1 costs more fee than its value
+		{CoinSelectOldestFirst, 104, 2, []int64{10, 40, 5, 60}},
+	}
+	for i, test := range tests {
+		selector, err := GetCoinSelector(test.strategy)
+		if err != nil {
+			t.Fatalf("test %d: get selector failed: %v", i, err)
+		}
+		selected, err := selector.Select(utxos, &CoinSelectParams{Target: test.target, InputFee: test.inputFee})
+		if err != nil {
+			t.Fatalf("test %d: select failed: %v", i, err)
+		}
+		if len(selected) != len(test.want) {
+			t.Fatalf("test %d: count mismatch: have %d, want %d", i, len(selected), len(test.want))
+		}
+		for j := range selected {
+			if selected[j].Value.GetInt64() != test.want[j] {
+				t.Errorf("test %d: utxo %d mismatch: have %d, want %d", i, j, selected[j].Value.GetInt64(), test.want[j])
+			}
+		}
+	}
+
+	if _, err := GetCoinSelector("unknown"); err != ErrUnknownCoinSelect {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrUnknownCoinSelect)
+	}
+}
+
+func TestRandomSelector(t *testing.T) {
+	utxos := newTestUTXOs(10, 20, 30, 40, 50)
+	selector, _ := GetCoinSelector(CoinSelectRandom)
+	for i := 0; i < 20; i++ {
+		selected, err := selector.Select(utxos, &CoinSelectParams{Target: 75})
+		if err != nil {
+			t.Fatalf("select failed: %v", err)
+		}
+		if sum := sumUTXOs(selected); sum < 75 {
+			t.Fatalf("target is not covered: %d", sum)
+		}
+	}
+	if _, err := selector.Select(utxos, &CoinSelectParams{Target: 151}); err != ErrInsufficientFunds {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrInsufficientFunds)
+	}
+}
+
+func TestPinUTXOs(t *testing.T) {
+	utxos := newTestUTXOs(10, 20, 30)
+
+	pinned, rest, err := pinUTXOs(utxos, []meta.Ticket{utxos[1].Ticket, utxos[1].Ticket}, 3)
+	if err != nil {
+		t.Fatalf("pin failed: %v", err)
+	}
+	if len(pinned) != 1 || pinned[0].Value.GetInt64() != 20 || len(rest) != 2 {
+		t.Fatalf("pin mismatch: pinned %v, rest %v", pinned, rest)
+	}
+
+	//the utxo of height 3 is not effective at height 2
+	if _, _, err := pinUTXOs(utxos, []meta.Ticket{utxos[2].Ticket}, 2); err == nil {
+		t.Fatalf("the ineffective ticket is pinned")
+	}
+}
+
+func TestEstimateTxSize(t *testing.T) {
+	from := meta.BytesToAccountID([]byte{1})
+	to := meta.BytesToAccountID([]byte{2})
+	tx := helper.CreateTempleteTx(config.DefaultTransactionVersion, config.NormalTx)
+	tx.AddToCoin(*helper.CreateToCoin(to, meta.NewAmount(100)))
+
+	baseSize, err := estimateTxSize(tx, []meta.FromCoin{*helper.CreateFromCoin(from)})
+	if err != nil {
+		t.Fatalf("estimate failed: %v", err)
+	}
+	utxos := newTestUTXOs(10, 20, 30)
+	fc := helper.CreateFromCoin(from)
+	for i := range utxos {
+		fc.AddTicket(&utxos[i].Ticket)
+	}
+	size, err := estimateTxSize(tx, []meta.FromCoin{*fc})
+	if err != nil {
+		t.Fatalf("estimate failed: %v", err)
+	}
+	if size > baseSize+len(utxos)*ticketSize {
+		t.Fatalf("ticket size is underestimated: have %d, max %d", size, baseSize+len(utxos)*ticketSize)
+	}
+
+	tx.AddToCoin(*helper.CreateToCoin(from, meta.NewAmount(1<<62)))
+	withChange, _ := estimateTxSize(tx, []meta.FromCoin{*fc})
+	if withChange > size+toCoinSize(from) {
+		t.Fatalf("change size is underestimated: have %d, max %d", withChange-size, toCoinSize(from))
+	}
+}
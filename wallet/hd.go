@@ -0,0 +1,353 @@
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mihongtech/linkchain/accounts"
+	"github.com/mihongtech/linkchain/accounts/hdwallet"
+	"github.com/mihongtech/linkchain/accounts/keystore"
+	"github.com/mihongtech/linkchain/common/util/log"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/helper"
+)
+
+const (
+	//the file of hd wallet in the wallet dir
+	hdWalletFile = "hdwallet.json"
+
+	//DefaultDiscoveryGap is the number of consecutive unused accounts to stop the discovery, BIP-44 gap limit
+	DefaultDiscoveryGap = 20
+
+	//CoinType is the BIP-44 coin type of linkchain accounts, "LC" in ascii
+	CoinType = 0x4c43
+)
+
+var (
+	//DefaultRootDerivationPath is the root path which the relative derivation paths are appended to, m/44'/19523'/0'/0
+	DefaultRootDerivationPath = accounts.DerivationPath{0x80000000 + 44, 0x80000000 + CoinType, 0x80000000 + 0, 0}
+
+	//DefaultBaseDerivationPath is the base path of hd accounts, the first account is at m/44'/19523'/0'/0/0,
+	//the second at m/44'/19523'/0'/0/1, etc.
+	DefaultBaseDerivationPath = accounts.DerivationPath{0x80000000 + 44, 0x80000000 + CoinType, 0x80000000 + 0, 0, 0}
+)
+
+var (
+	ErrHDWalletExists     = errors.New("hd wallet is already initialized")
+	ErrHDWalletNotExists  = errors.New("hd wallet is not initialized")
+	ErrInvalidDerivePath  = errors.New("the last component of derivation path must not be hardened")
+	ErrHDAccountExhausted = errors.New("no valid hd account left in the derivation path")
+)
+
+//hdWalletJSON is the persisted hd wallet, the seed is encrypted by the scrypt scheme of keystore
+//with the password of hd wallet, which also encrypts the keys of derived accounts.
+//The accounts are derived by incrementing the last component of base path.
+type hdWalletJSON struct {
+	Seed keystore.CryptoJSON `json:"seed"`
+	Path string              `json:"path"`
+	Next uint32              `json:"next"`
+}
+
+//HDWalletInfo is the info of hd wallet.
+type HDWalletInfo struct {
+	Path string `json:"path"`
+	Next uint32 `json:"next"`
+}
+
+//hdWallet is the loaded hd wallet.
+type hdWallet struct {
+	seed keystore.CryptoJSON
+	path accounts.DerivationPath
+	next uint32
+}
+
+//parseDerivationPath parses the absolute path or the path relative to DefaultRootDerivationPath.
+func parseDerivationPath(path string) (accounts.DerivationPath, error) {
+	if components := strings.Split(path, "/"); strings.TrimSpace(components[0]) != "m" && strings.TrimSpace(components[0]) != "" {
+		path = DefaultRootDerivationPath.String() + "/" + path
+	}
+	return accounts.ParseDerivationPath(path)
+}
+
+//accountPath returns the derivation path of the account at index.
+func (hd *hdWallet) accountPath(index uint32) accounts.DerivationPath {
+	path := make(accounts.DerivationPath, len(hd.path))
+	copy(path, hd.path)
+	path[len(path)-1] += index
+	return path
+}
+
+//maxIndex returns the end of account indexes, the last component of path can not be hardened.
+func (hd *hdWallet) maxIndex() uint32 {
+	return hdwallet.HardenedKeyStart - hd.path[len(hd.path)-1]
+}
+
+//NewMnemonic generates a new mnemonic of the entropy bits, it is not stored in wallet.
+func NewMnemonic(bits int) (string, error) {
+	if bits == 0 {
+		bits = hdwallet.DefaultEntropyBits
+	}
+	entropy, err := hdwallet.NewEntropy(bits)
+	if err != nil {
+		return "", err
+	}
+	return hdwallet.NewMnemonic(entropy)
+}
+
+//ImportMnemonic initializes the hd wallet with the seed of mnemonic and passphrase.
+//The accounts are derived along the path, DefaultBaseDerivationPath if it is empty.
+//Only the seed encrypted by password is persisted, the mnemonic is not.
+func (w *Wallet) ImportMnemonic(mnemonic string, passphrase string, path string, password string) (*HDWalletInfo, error) {
+	if password == "" {
+		return nil, ErrEmptyPassphrase
+	}
+	w.hdMtx.Lock()
+	defer w.hdMtx.Unlock()
+
+	if w.hd != nil {
+		return nil, ErrHDWalletExists
+	}
+
+	basePath := DefaultBaseDerivationPath
+	if path != "" {
+		var err error
+		if basePath, err = parseDerivationPath(path); err != nil {
+			return nil, err
+		}
+	}
+	if basePath[len(basePath)-1] >= hdwallet.HardenedKeyStart {
+		return nil, ErrInvalidDerivePath
+	}
+	seed, err := hdwallet.NewSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := hdwallet.NewMaster(seed); err != nil {
+		return nil, err
+	}
+	seedJSON, err := keystore.EncryptDataV3(seed, []byte(password), w.scryptN, w.scryptP)
+	if err != nil {
+		return nil, err
+	}
+
+	hd := &hdWallet{seed: seedJSON, path: basePath}
+	if err := w.storeHDWallet(hd); err != nil {
+		return nil, err
+	}
+	w.hd = hd
+	log.Info("Imported hd wallet", "path", basePath)
+	return &HDWalletInfo{Path: basePath.String(), Next: hd.next}, nil
+}
+
+//GetHDWalletInfo returns the path and next index of hd wallet.
+func (w *Wallet) GetHDWalletInfo() (*HDWalletInfo, error) {
+	w.hdMtx.Lock()
+	defer w.hdMtx.Unlock()
+
+	if w.hd == nil {
+		return nil, ErrHDWalletNotExists
+	}
+	return &HDWalletInfo{Path: w.hd.path.String(), Next: w.hd.next}, nil
+}
+
+//NewHDAccount derives the next account of hd wallet and imports it into keystore.
+func (w *Wallet) NewHDAccount(password string) (*meta.AccountID, error) {
+	w.hdMtx.Lock()
+	defer w.hdMtx.Unlock()
+
+	if w.hd == nil {
+		return nil, ErrHDWalletNotExists
+	}
+	master, err := w.hdMaster(password)
+	if err != nil {
+		return nil, err
+	}
+
+	for index := w.hd.next; index < w.hd.maxIndex(); index++ {
+		key, err := master.Derive(w.hd.accountPath(index))
+		if err == hdwallet.ErrInvalidChild {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		id, err := w.importHDKey(key, password)
+		if err != nil {
+			return nil, err
+		}
+		if err := w.advanceHDWallet(index + 1); err != nil {
+			return nil, err
+		}
+		return id, nil
+	}
+	return nil, ErrHDAccountExhausted
+}
+
+//DiscoverHDAccounts scans the derived accounts from the first one and imports the ones
+//which are found on chain.  The scan stops after gap consecutive accounts are not found.
+func (w *Wallet) DiscoverHDAccounts(gap uint32, password string) ([]meta.AccountID, error) {
+	w.hdMtx.Lock()
+	defer w.hdMtx.Unlock()
+
+	if w.hd == nil {
+		return nil, ErrHDWalletNotExists
+	}
+	if gap == 0 {
+		gap = DefaultDiscoveryGap
+	}
+	master, err := w.hdMaster(password)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]meta.AccountID, 0)
+	next := w.hd.next
+	for index, unused := uint32(0), uint32(0); unused < gap && index < w.hd.maxIndex(); index++ {
+		key, err := master.Derive(w.hd.accountPath(index))
+		if err == hdwallet.ErrInvalidChild {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		id := meta.NewAccountId(key.PrivateKey().PubKey())
+		if _, err := w.nodeAPI.GetAccount(*id); err != nil {
+			unused++
+			continue
+		}
+		unused = 0
+
+		if _, err := w.importHDKey(key, password); err != nil {
+			return nil, err
+		}
+		found = append(found, *id)
+		if index+1 > next {
+			next = index + 1
+		}
+	}
+
+	if err := w.advanceHDWallet(next); err != nil {
+		return nil, err
+	}
+	w.reScanAllAccount()
+	log.Info("Discovered hd accounts", "count", len(found), "next", next)
+	return found, nil
+}
+
+//hdMaster decrypts the seed and returns the master key, the caller must hold hdMtx.
+func (w *Wallet) hdMaster(password string) (*hdwallet.ExtendedKey, error) {
+	seed, err := keystore.DecryptDataV3(w.hd.seed, password)
+	if err != nil {
+		return nil, err
+	}
+	return hdwallet.NewMaster(seed)
+}
+
+//importHDKey imports the derived key into keystore and wallet, the key which is already
+//in keystore is not imported again.
+func (w *Wallet) importHDKey(key *hdwallet.ExtendedKey, password string) (*meta.AccountID, error) {
+	priv := key.PrivateKey()
+	id := meta.NewAccountId(priv.PubKey())
+	if !w.keystore.HasAddress(*id) {
+		if _, err := w.keystore.ImportECDSA(priv, password); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := w.accounts[id.String()]; !ok {
+		w.AddAccount(*helper.CreateTemplateAccount(*id))
+	}
+	return id, nil
+}
+
+//migrateHDWallet re-encrypts the seed of hd wallet if it is encrypted by the old password.
+func (w *Wallet) migrateHDWallet(oldPassword string, newPassword string) (bool, error) {
+	w.hdMtx.Lock()
+	defer w.hdMtx.Unlock()
+
+	if w.hd == nil {
+		return false, nil
+	}
+	seed, err := keystore.DecryptDataV3(w.hd.seed, oldPassword)
+	if err == keystore.ErrDecrypt {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	seedJSON, err := keystore.EncryptDataV3(seed, []byte(newPassword), w.scryptN, w.scryptP)
+	if err != nil {
+		return false, err
+	}
+
+	hd := *w.hd
+	hd.seed = seedJSON
+	if err := w.storeHDWallet(&hd); err != nil {
+		return false, err
+	}
+	w.hd.seed = seedJSON
+	log.Info("Migrated hd wallet")
+	return true, nil
+}
+
+//advanceHDWallet persists the next index of hd wallet, the caller must hold hdMtx.
+func (w *Wallet) advanceHDWallet(next uint32) error {
+	if next == w.hd.next {
+		return nil
+	}
+	hd := *w.hd
+	hd.next = next
+	if err := w.storeHDWallet(&hd); err != nil {
+		return err
+	}
+	w.hd.next = next
+	return nil
+}
+
+//loadHDWallet loads the hd wallet from wallet dir, it is nil if not initialized.
+func (w *Wallet) loadHDWallet() (*hdWallet, error) {
+	data, err := ioutil.ReadFile(w.hdWalletPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var hdJSON hdWalletJSON
+	if err := json.Unmarshal(data, &hdJSON); err != nil {
+		return nil, err
+	}
+	path, err := accounts.ParseDerivationPath(hdJSON.Path)
+	if err != nil {
+		return nil, err
+	}
+	if path[len(path)-1] >= hdwallet.HardenedKeyStart {
+		return nil, ErrInvalidDerivePath
+	}
+	return &hdWallet{seed: hdJSON.Seed, path: path, next: hdJSON.Next}, nil
+}
+
+//storeHDWallet writes the hd wallet into a temp file and renames it, so the file is never broken.
+func (w *Wallet) storeHDWallet(hd *hdWallet) error {
+	data, err := json.Marshal(&hdWalletJSON{Seed: hd.seed, Path: hd.path.String(), Next: hd.next})
+	if err != nil {
+		return err
+	}
+
+	path := w.hdWalletPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (w *Wallet) hdWalletPath() string {
+	return filepath.Join(w.instanceDir(w.DataDir), hdWalletFile)
+}
@@ -0,0 +1,202 @@
+package wallet
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/mihongtech/linkchain/accounts/keystore"
+	"github.com/mihongtech/linkchain/common/util/event"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/helper"
+)
+
+const testHDPassword = "hd password"
+
+//testNode serves the accounts which are found on chain.
+type testNode struct {
+	onChain map[meta.AccountID]bool
+}
+
+func (n *testNode) GetAccountEvent() *event.TypeMux {
+	return nil
+}
+
+func (n *testNode) GetAccount(id meta.AccountID) (meta.Account, error) {
+	if !n.onChain[id] {
+		return meta.Account{}, errors.New("account not found")
+	}
+	return *helper.CreateTemplateAccount(id), nil
+}
+
+//newTestWallet opens the wallet in dir as Setup does, the hd wallet is loaded from dir.
+func newTestWallet(t *testing.T, dir string, node *testNode) *Wallet {
+	w := NewWallet()
+	w.DataDir = dir
+	w.nodeAPI = node
+	w.scryptN, w.scryptP = keystore.LightScryptN, keystore.LightScryptP
+	w.keystore = keystore.NewKeyStore(w.instanceDir(dir), w.scryptN, w.scryptP)
+
+	hd, err := w.loadHDWallet()
+	if err != nil {
+		t.Fatalf("failed to load hd wallet: %v", err)
+	}
+	w.hd = hd
+	return w
+}
+
+func newTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "wallet")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	return dir
+}
+
+//newTestHDAccounts derives the first count accounts of mnemonic in a throwaway wallet.
+func newTestHDAccounts(t *testing.T, mnemonic string, count int) []meta.AccountID {
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+
+	w := newTestWallet(t, dir, &testNode{})
+	if _, err := w.ImportMnemonic(mnemonic, "", "", testHDPassword); err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	ids := make([]meta.AccountID, 0, count)
+	for i := 0; i < count; i++ {
+		id, err := w.NewHDAccount(testHDPassword)
+		if err != nil {
+			t.Fatalf("failed to derive account %d: %v", i, err)
+		}
+		ids = append(ids, *id)
+	}
+	return ids
+}
+
+//Tests that the hd wallet stored is reloaded with its next index, and the accounts
+//derived after reload continue the derivation path.
+func TestHDWalletReload(t *testing.T) {
+	mnemonic, err := NewMnemonic(0)
+	if err != nil {
+		t.Fatalf("failed to create mnemonic: %v", err)
+	}
+	want := newTestHDAccounts(t, mnemonic, 3)
+
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+
+	w := newTestWallet(t, dir, &testNode{})
+	if _, err := w.GetHDWalletInfo(); err != ErrHDWalletNotExists {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrHDWalletNotExists)
+	}
+	if _, err := w.ImportMnemonic(mnemonic, "", "", testHDPassword); err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	if _, err := w.ImportMnemonic(mnemonic, "", "", testHDPassword); err != ErrHDWalletExists {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrHDWalletExists)
+	}
+	for i := 0; i < 2; i++ {
+		if id, err := w.NewHDAccount(testHDPassword); err != nil || *id != want[i] {
+			t.Fatalf("account %d mismatch: have %v, %v, want %v", i, id, err, want[i])
+		}
+	}
+	if _, err := w.NewHDAccount("wrong password"); err == nil {
+		t.Fatalf("account derived with wrong password")
+	}
+
+	w = newTestWallet(t, dir, &testNode{})
+	info, err := w.GetHDWalletInfo()
+	if err != nil {
+		t.Fatalf("failed to get reloaded hd wallet: %v", err)
+	}
+	if info.Path != DefaultBaseDerivationPath.String() || info.Next != 2 {
+		t.Fatalf("reloaded hd wallet mismatch: have %+v, want path %s next %d", info, DefaultBaseDerivationPath, 2)
+	}
+	if id, err := w.NewHDAccount(testHDPassword); err != nil || *id != want[2] {
+		t.Fatalf("account after reload mismatch: have %v, %v, want %v", id, err, want[2])
+	}
+	for _, id := range want {
+		if !w.keystore.HasAddress(id) {
+			t.Errorf("account %v is not in keystore", id)
+		}
+	}
+}
+
+//Tests that the discovery imports the accounts found on chain, and stops after gap
+//consecutive accounts are not found.
+func TestDiscoverHDAccounts(t *testing.T) {
+	mnemonic, err := NewMnemonic(0)
+	if err != nil {
+		t.Fatalf("failed to create mnemonic: %v", err)
+	}
+	ids := newTestHDAccounts(t, mnemonic, 8)
+
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+
+	node := &testNode{onChain: map[meta.AccountID]bool{ids[1]: true, ids[4]: true}}
+	w := newTestWallet(t, dir, node)
+	if _, err := w.DiscoverHDAccounts(0, testHDPassword); err != ErrHDWalletNotExists {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrHDWalletNotExists)
+	}
+	if _, err := w.ImportMnemonic(mnemonic, "", "", testHDPassword); err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+
+	//the gap of accounts 2 and 3 stops the discovery before account 4
+	found, err := w.DiscoverHDAccounts(2, testHDPassword)
+	if err != nil {
+		t.Fatalf("failed to discover accounts: %v", err)
+	}
+	if len(found) != 1 || found[0] != ids[1] {
+		t.Fatalf("found accounts mismatch: have %v, want %v", found, ids[1:2])
+	}
+	if info, _ := w.GetHDWalletInfo(); info.Next != 2 {
+		t.Fatalf("next index mismatch: have %d, want %d", info.Next, 2)
+	}
+
+	found, err = w.DiscoverHDAccounts(3, testHDPassword)
+	if err != nil {
+		t.Fatalf("failed to discover accounts: %v", err)
+	}
+	if len(found) != 2 || found[0] != ids[1] || found[1] != ids[4] {
+		t.Fatalf("found accounts mismatch: have %v, want %v", found, []meta.AccountID{ids[1], ids[4]})
+	}
+	for i, id := range ids {
+		if w.keystore.HasAddress(id) != node.onChain[id] {
+			t.Errorf("account %d in keystore mismatch: have %v, want %v", i, w.keystore.HasAddress(id), node.onChain[id])
+		}
+		if _, ok := w.accounts[id.String()]; ok != node.onChain[id] {
+			t.Errorf("account %d in wallet mismatch: have %v, want %v", i, ok, node.onChain[id])
+		}
+	}
+
+	//the next index is stored, the next account follows the discovered ones
+	w = newTestWallet(t, dir, node)
+	if id, err := w.NewHDAccount(testHDPassword); err != nil || *id != ids[5] {
+		t.Fatalf("account after discovery mismatch: have %v, %v, want %v", id, err, ids[5])
+	}
+}
+
+func TestParseDerivationPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "m/44'/60'/0'/0/0", want: "m/44'/60'/0'/0/0"},
+		{path: "0", want: "m/44'/19523'/0'/0/0"},
+		{path: "1/2", want: "m/44'/19523'/0'/0/1/2"},
+	}
+	for _, test := range tests {
+		path, err := parseDerivationPath(test.path)
+		if err != nil {
+			t.Errorf("parse %s failed: %v", test.path, err)
+		} else if path.String() != test.want {
+			t.Errorf("parse %s mismatch: have %s, want %s", test.path, path, test.want)
+		}
+	}
+	if _, err := parseDerivationPath("/0"); err == nil {
+		t.Errorf("parse ambiguous path succeeded")
+	}
+}
@@ -0,0 +1,333 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/mihongtech/linkchain/config"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/helper"
+
+	"github.com/golang/protobuf/proto"
+)
+
+const (
+	//DefaultDustLimit is the min value of change, the less change is paid as fee
+	DefaultDustLimit = 1000
+
+	//ticketSize is the max serialized size of a ticket in from coin, 32 bytes txid and varint index with the tags
+	ticketSize = 44
+
+	//signatureSize is the size of compact signature
+	signatureSize = 65
+)
+
+var (
+	ErrTxTooLarge = errors.New("too many tickets, the tx exceeds the size limit")
+
+	//maxTxSize is the size limit of the created tx
+	maxTxSize = config.TransactionSizeLimit
+)
+
+//CoinSelectOptions are the options to select the tickets of tx.
+type CoinSelectOptions struct {
+	Strategy    string          //the coin selection strategy, DefaultCoinSelect if it is empty
+	FeeRate     int64           //the fee per byte of tx
+	DustLimit   int64           //the change less than it is paid as fee, DefaultDustLimit if it is 0
+	Tickets     []meta.Ticket   //the pinned tickets which must be spent
+	Consolidate bool            //spend the dust utxos of accounts, their values go to the change
+	Change      *meta.AccountID //the account of change, the first funding account if it is nil
+}
+
+//fundingPool is the utxos of the funding accounts, the owner of every utxo is kept by its ticket.
+type fundingPool struct {
+	accounts []meta.AccountID
+	owners   map[meta.Ticket]meta.AccountID
+	utxos    []meta.UTXO
+}
+
+//newFundingPool loads the utxos of the funding accounts of wallet.
+func (w *Wallet) newFundingPool(froms []meta.AccountID) (*fundingPool, error) {
+	if len(froms) == 0 {
+		return nil, errors.New("no funding account")
+	}
+	pool := &fundingPool{owners: make(map[meta.Ticket]meta.AccountID)}
+	for _, from := range froms {
+		if _, ok := w.accounts[from.String()]; !ok {
+			return nil, fmt.Errorf("can not find account id %s in wallet", from.String())
+		}
+		duplicated := false
+		for _, id := range pool.accounts {
+			duplicated = duplicated || id.IsEqual(from)
+		}
+		if duplicated {
+			continue
+		}
+		account, err := w.nodeAPI.GetAccount(from)
+		if err != nil {
+			return nil, err
+		}
+		pool.accounts = append(pool.accounts, from)
+		for _, u := range account.UTXOs {
+			pool.owners[u.Ticket] = from
+			pool.utxos = append(pool.utxos, u)
+		}
+	}
+	return pool, nil
+}
+
+//without returns the pool without the utxos of tickets.
+func (pool *fundingPool) without(spent map[meta.Ticket]bool) *fundingPool {
+	rest := &fundingPool{accounts: pool.accounts, owners: pool.owners}
+	for _, u := range pool.utxos {
+		if !spent[u.Ticket] {
+			rest.utxos = append(rest.utxos, u)
+		}
+	}
+	return rest
+}
+
+//CreateTransaction creates the unsigned tx which pays to the coins from the account of wallet.
+//The tickets are selected by the options, the fee is paid by the account and the rest returns to it as change.
+func (w *Wallet) CreateTransaction(from meta.AccountID, to []meta.ToCoin, opts *CoinSelectOptions, height uint32) (*meta.Transaction, error) {
+	pool, err := w.newFundingPool([]meta.AccountID{from})
+	if err != nil {
+		return nil, err
+	}
+	return createTransaction(pool, to, opts, height)
+}
+
+//CreateTransactions creates the unsigned txs which pay to the coins from the funding accounts of wallet.
+//The coins are paid by as few txs as possible, a new tx is created when the tx exceeds the size limit.
+//The txs never spend the same ticket, every tx must be signed by the owners of its from coins.
+func (w *Wallet) CreateTransactions(froms []meta.AccountID, to []meta.ToCoin, opts *CoinSelectOptions, height uint32) ([]*meta.Transaction, error) {
+	if len(to) == 0 {
+		return nil, errors.New("no to coin")
+	}
+	pool, err := w.newFundingPool(froms)
+	if err != nil {
+		return nil, err
+	}
+	return createTransactions(pool, to, opts, height)
+}
+
+//createTransactions creates the txs which pay to the coins from the pool, every tx pays the most coins
+//in order which fit in the size limit.
+func createTransactions(pool *fundingPool, to []meta.ToCoin, opts *CoinSelectOptions, height uint32) ([]*meta.Transaction, error) {
+	if opts == nil {
+		opts = &CoinSelectOptions{}
+	}
+
+	txs := make([]*meta.Transaction, 0)
+	spent := make(map[meta.Ticket]bool)
+	for start := 0; start < len(to); {
+		//the pinned tickets which are spent by the previous txs are not pinned again
+		rest := pool.without(spent)
+		txOpts := *opts
+		txOpts.Tickets = make([]meta.Ticket, 0, len(opts.Tickets))
+		for _, t := range opts.Tickets {
+			if !spent[t] {
+				txOpts.Tickets = append(txOpts.Tickets, t)
+			}
+		}
+
+		tx, err := createTransaction(rest, to[start:start+1], &txOpts, height)
+		if err != nil {
+			return nil, err
+		}
+		//search the most coins in the size limit, the tx of to[start:end] fits and to[start:exceed] exceeds
+		end, exceed := start+1, len(to)+1
+		for end+1 < exceed {
+			mid := (end + exceed) / 2
+			if exceed > len(to) {
+				//try all the rest coins first
+				mid = len(to)
+			}
+			next, err := createTransaction(rest, to[start:mid], &txOpts, height)
+			if err == ErrTxTooLarge {
+				exceed = mid
+			} else if err != nil {
+				return nil, err
+			} else {
+				tx, end = next, mid
+			}
+		}
+
+		for _, fc := range tx.GetFromCoins() {
+			for _, t := range fc.GetTickets() {
+				spent[t] = true
+			}
+		}
+		txs = append(txs, tx)
+		start = end
+	}
+	return txs, nil
+}
+
+//createTransaction creates the tx which pays to the coins from the pool.
+func createTransaction(pool *fundingPool, to []meta.ToCoin, opts *CoinSelectOptions, height uint32) (*meta.Transaction, error) {
+	if opts == nil {
+		opts = &CoinSelectOptions{}
+	}
+	if opts.FeeRate < 0 || opts.DustLimit < 0 {
+		return nil, errors.New("fee rate and dust limit can not be negative")
+	}
+	selector, err := GetCoinSelector(opts.Strategy)
+	if err != nil {
+		return nil, err
+	}
+	change := pool.accounts[0]
+	if opts.Change != nil {
+		change = *opts.Change
+	}
+
+	target := int64(0)
+	for i := range to {
+		if to[i].Value.GetInt64() <= 0 {
+			return nil, errors.New("the value of to coin must be positive")
+		}
+		target += to[i].Value.GetInt64()
+	}
+
+	//every funding account is assumed to sign, the fee of unused ones is overpaid
+	tx := helper.CreateTempleteTx(config.DefaultTransactionVersion, config.NormalTx)
+	tx.AddToCoin(to...)
+	fromCoins := make([]meta.FromCoin, 0, len(pool.accounts))
+	for _, id := range pool.accounts {
+		fromCoins = append(fromCoins, *helper.CreateFromCoin(id))
+	}
+	baseSize, err := estimateTxSize(tx, fromCoins)
+	if err != nil {
+		return nil, err
+	}
+	changeSize := toCoinSize(change)
+	dustLimit := opts.DustLimit
+	if dustLimit == 0 {
+		dustLimit = DefaultDustLimit
+	}
+	params := &CoinSelectParams{
+		InputFee:     ticketSize * opts.FeeRate,
+		CostOfChange: int64(changeSize)*opts.FeeRate + dustLimit,
+	}
+	baseFee := int64(baseSize) * opts.FeeRate
+	maxTickets := (maxTxSize - baseSize - changeSize) / ticketSize
+
+	pinned, rest, err := pinUTXOs(pool.utxos, opts.Tickets, height)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Consolidate {
+		dust := make([]meta.UTXO, 0)
+		others := make([]meta.UTXO, 0)
+		for _, u := range rest {
+			if u.Value.GetInt64() < dustLimit && len(pinned)+len(dust) < maxTickets {
+				dust = append(dust, u)
+			} else {
+				others = append(others, u)
+			}
+		}
+		pinned, rest = append(pinned, dust...), others
+	}
+
+	//the selector covers the value and fee which are not covered by the pinned utxos
+	tickets := pinned
+	need := target + baseFee + int64(len(pinned))*params.InputFee - sumUTXOs(pinned)
+	if need > 0 {
+		params.Target = need
+		selected, err := selector.Select(rest, params)
+		if err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, selected...)
+	}
+	if baseSize+changeSize+len(tickets)*ticketSize > maxTxSize {
+		return nil, ErrTxTooLarge
+	}
+
+	//the from coins are in order of funding accounts
+	for _, id := range pool.accounts {
+		fc := helper.CreateFromCoin(id)
+		for i := range tickets {
+			if owner := pool.owners[tickets[i].Ticket]; owner.IsEqual(id) {
+				fc.AddTicket(&tickets[i].Ticket)
+			}
+		}
+		if len(fc.Ticket) > 0 {
+			tx.AddFromCoin(*fc)
+		}
+	}
+
+	fee := baseFee + int64(len(tickets))*params.InputFee
+	if leftover := sumUTXOs(tickets) - target - fee; leftover >= params.CostOfChange {
+		value := leftover - int64(changeSize)*opts.FeeRate
+		tx.AddToCoin(*helper.CreateToCoin(change, meta.NewAmount(value)))
+	}
+	return tx, nil
+}
+
+//pinUTXOs splits the spendable utxos at height into the pinned ones of tickets and the rest.
+func pinUTXOs(utxos []meta.UTXO, tickets []meta.Ticket, height uint32) ([]meta.UTXO, []meta.UTXO, error) {
+	spendable := make([]meta.UTXO, 0, len(utxos))
+	for _, u := range utxos {
+		if height >= u.EffectHeight {
+			spendable = append(spendable, u)
+		}
+	}
+
+	isPinned := make([]bool, len(spendable))
+	pinned := make([]meta.UTXO, 0, len(tickets))
+	for _, t := range tickets {
+		found := false
+		for i := range spendable {
+			if spendable[i].Txid.IsEqual(&t.Txid) && spendable[i].Index == t.Index {
+				if !isPinned[i] {
+					isPinned[i] = true
+					pinned = append(pinned, spendable[i])
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("%v: %s:%d", ErrTicketNotSpendable, t.Txid.String(), t.Index)
+		}
+	}
+
+	rest := make([]meta.UTXO, 0, len(spendable)-len(pinned))
+	for i := range spendable {
+		if !isPinned[i] {
+			rest = append(rest, spendable[i])
+		}
+	}
+	return pinned, rest, nil
+}
+
+func sumUTXOs(utxos []meta.UTXO) int64 {
+	sum := int64(0)
+	for _, u := range utxos {
+		sum += u.Value.GetInt64()
+	}
+	return sum
+}
+
+//estimateTxSize returns the size of tx with the from coins, which is signed by every from coin.
+func estimateTxSize(tx *meta.Transaction, fromCoins []meta.FromCoin) (int, error) {
+	signs := make([]meta.Signature, len(fromCoins))
+	for i := range signs {
+		signs[i] = *meta.NewSignature(make([]byte, signatureSize))
+	}
+	estimated := meta.NewTransaction(tx.Version, tx.Type, *meta.NewTransactionFrom(fromCoins), tx.To, signs, tx.Data)
+	buffer, err := proto.Marshal(estimated.Serialize())
+	if err != nil {
+		return 0, err
+	}
+	return len(buffer), nil
+}
+
+//toCoinSize returns the max serialized size of a to coin of the account, with the tags.
+func toCoinSize(id meta.AccountID) int {
+	coin := helper.CreateToCoin(id, meta.NewAmount(math.MaxInt64))
+	size := proto.Size(coin.Serialize())
+	return size + 1 + proto.SizeVarint(uint64(size))
+}
@@ -0,0 +1,150 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/mihongtech/linkchain/common/math"
+	"github.com/mihongtech/linkchain/core/meta"
+	"github.com/mihongtech/linkchain/helper"
+
+	"github.com/golang/protobuf/proto"
+)
+
+//newTestPool creates the pool of accounts, every account has the utxos of values.
+func newTestPool(values [][]int64) *fundingPool {
+	pool := &fundingPool{owners: make(map[meta.Ticket]meta.AccountID)}
+	n := 0
+	for i := range values {
+		id := meta.BytesToAccountID([]byte{byte(i + 1)})
+		pool.accounts = append(pool.accounts, id)
+		for _, value := range values[i] {
+			n++
+			txid := math.BytesToHash([]byte{byte(n >> 8), byte(n)})
+			u := meta.NewUTXO(meta.NewTicket(txid, 0), 1, 1, *meta.NewAmount(value))
+			pool.owners[u.Ticket] = id
+			pool.utxos = append(pool.utxos, *u)
+		}
+	}
+	return pool
+}
+
+func newTestToCoins(count int, value int64) []meta.ToCoin {
+	coins := make([]meta.ToCoin, 0, count)
+	for i := 0; i < count; i++ {
+		id := meta.BytesToAccountID([]byte{0xff, byte(i >> 8), byte(i)})
+		coins = append(coins, *helper.CreateToCoin(id, meta.NewAmount(value)))
+	}
+	return coins
+}
+
+//txFee returns the fee of tx whose tickets are in pool.
+func txFee(pool *fundingPool, tx *meta.Transaction) int64 {
+	values := make(map[meta.Ticket]int64)
+	for _, u := range pool.utxos {
+		values[u.Ticket] = u.Value.GetInt64()
+	}
+	fee := -tx.GetToValue().GetInt64()
+	for _, fc := range tx.GetFromCoins() {
+		for _, t := range fc.GetTickets() {
+			fee += values[t]
+		}
+	}
+	return fee
+}
+
+func TestCreateMultiSourceTransaction(t *testing.T) {
+	pool := newTestPool([][]int64{{3000}, {5000}})
+	to := newTestToCoins(2, 3500)
+
+	tx, err := createTransaction(pool, to, &CoinSelectOptions{Strategy: CoinSelectLargestFirst, FeeRate: 1}, 1)
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	froms := tx.GetFromCoins()
+	if len(froms) != 2 || !froms[0].GetId().IsEqual(pool.accounts[0]) || !froms[1].GetId().IsEqual(pool.accounts[1]) {
+		t.Fatalf("from coins mismatch: %v", froms)
+	}
+
+	//the tx is signed by both accounts
+	for range froms {
+		tx.AddSignature(meta.NewSignature(make([]byte, signatureSize)))
+	}
+	size := proto.Size(tx.Serialize())
+	if fee := txFee(pool, tx); fee < int64(size) {
+		t.Fatalf("fee rate is not paid: fee %d, size %d", fee, size)
+	}
+
+	//the change of 1000 minus fee is dust
+	if len(tx.GetToCoins()) != 2 {
+		t.Fatalf("dust change is created: %v", tx.GetToCoins())
+	}
+
+	if _, err := createTransaction(pool, newTestToCoins(1, 8001), nil, 1); err != ErrInsufficientFunds {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrInsufficientFunds)
+	}
+}
+
+func TestCreateTransactionChange(t *testing.T) {
+	pool := newTestPool([][]int64{{10000}, {20000}})
+	change := meta.BytesToAccountID([]byte{9})
+
+	tx, err := createTransaction(pool, newTestToCoins(1, 12000), &CoinSelectOptions{Change: &change}, 1)
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	coins := tx.GetToCoins()
+	if len(coins) != 2 || !coins[1].GetId().IsEqual(change) || coins[1].GetValue().GetInt64() != 8000 {
+		t.Fatalf("change mismatch: %v", coins)
+	}
+	if fee := txFee(pool, tx); fee != 0 {
+		t.Fatalf("fee mismatch: have %d, want 0", fee)
+	}
+}
+
+func TestCreateTransactionsSplit(t *testing.T) {
+	defer func(size int) { maxTxSize = size }(maxTxSize)
+	maxTxSize = 1024
+
+	pool := newTestPool([][]int64{{1000000}, {1000000}, {1000000}})
+	to := newTestToCoins(40, 100)
+	txs, err := createTransactions(pool, to, nil, 1)
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if len(txs) < 2 {
+		t.Fatalf("txs are not split: %d", len(txs))
+	}
+
+	paid := 0
+	spent := make(map[meta.Ticket]bool)
+	for i, tx := range txs {
+		for range tx.GetFromCoins() {
+			tx.AddSignature(meta.NewSignature(make([]byte, signatureSize)))
+		}
+		if size := proto.Size(tx.Serialize()); size > maxTxSize {
+			t.Fatalf("tx %d exceeds the size limit: %d", i, size)
+		}
+		for _, fc := range tx.GetFromCoins() {
+			for _, ticket := range fc.GetTickets() {
+				if spent[ticket] {
+					t.Fatalf("tx %d spends the spent ticket %v", i, ticket)
+				}
+				spent[ticket] = true
+			}
+		}
+		for _, coin := range tx.GetToCoins() {
+			if coin.GetValue().GetInt64() == 100 {
+				paid++
+			}
+		}
+	}
+	if paid != len(to) {
+		t.Fatalf("paid coins mismatch: have %d, want %d", paid, len(to))
+	}
+
+	//a single coin which can not fit
+	maxTxSize = 100
+	if _, err := createTransactions(pool, to, nil, 1); err != ErrTxTooLarge {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrTxTooLarge)
+	}
+}
@@ -3,7 +3,10 @@ package wallet
 import (
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/mihongtech/linkchain/accounts"
 	"github.com/mihongtech/linkchain/accounts/keystore"
@@ -17,20 +20,44 @@ import (
 	"github.com/mihongtech/linkchain/node"
 )
 
+const (
+	//LegacyPassword is the password which encrypted all keys of the old wallet, the keys should be migrated
+	LegacyPassword = "password"
+
+	//DefaultUnlockDuration is the duration of unlocking account if it is not specified
+	DefaultUnlockDuration = 300 * time.Second
+)
+
+var (
+	ErrEmptyPassphrase = errors.New("passphrase can not be empty")
+)
+
+//walletNode is the part of node api which the accounts of wallet are read from.
+type walletNode interface {
+	GetAccountEvent() *event.TypeMux
+	GetAccount(id meta.AccountID) (meta.Account, error)
+}
+
 type Wallet struct {
 	keystore         *keystore.KeyStore
-	password         string
 	Name             string
 	DataDir          string
 	accounts         map[string]meta.Account
-	nodeAPI          *node.PublicNodeAPI
+	nodeAPI          walletNode
 	updateAccountSub *event.TypeMuxSubscription
+
+	//scrypt params of keystore and hd seed
+	scryptN int
+	scryptP int
+
+	//hd wallet, nil if not initialized
+	hd    *hdWallet
+	hdMtx sync.Mutex
 }
 
 func NewWallet() *Wallet {
 	name := "wallet"
-	password := "password"
-	return &Wallet{accounts: make(map[string]meta.Account), Name: name, password: password}
+	return &Wallet{accounts: make(map[string]meta.Account), Name: name}
 }
 
 func (w *Wallet) Setup(i interface{}) bool {
@@ -38,8 +65,38 @@ func (w *Wallet) Setup(i interface{}) bool {
 	w.nodeAPI = i.(*context.Context).NodeAPI.(*node.PublicNodeAPI)
 	w.DataDir = globalConfig.DataDir
 	path := w.instanceDir(w.DataDir)
-	w.keystore = keystore.NewKeyStore(path, keystore.StandardScryptN, keystore.StandardScryptP)
+	w.scryptN, w.scryptP = keystore.StandardScryptN, keystore.StandardScryptP
+	w.keystore = keystore.NewKeyStore(path, w.scryptN, w.scryptP)
 	w.nodeAPI = i.(*context.Context).NodeAPI.(*node.PublicNodeAPI)
+
+	hd, err := w.loadHDWallet()
+	if err != nil {
+		log.Error("load hd wallet failed", "err", err)
+		return false
+	}
+	w.hd = hd
+
+	//unlock the accounts of config until exit, e.g. the signer of miner
+	for i, key := range globalConfig.WalletUnlock {
+		id, err := meta.HexToAccountID(key)
+		if err != nil {
+			log.Error("invalid unlock account", "account", key, "err", err)
+			return false
+		}
+		if len(globalConfig.WalletPasswords) == 0 {
+			log.Error("no password to unlock account", "account", key)
+			return false
+		}
+		passphrase := globalConfig.WalletPasswords[len(globalConfig.WalletPasswords)-1]
+		if i < len(globalConfig.WalletPasswords) {
+			passphrase = globalConfig.WalletPasswords[i]
+		}
+		if err := w.keystore.Unlock(accounts.Account{Address: id}, passphrase); err != nil {
+			log.Error("unlock account failed", "account", key, "err", err)
+			return false
+		}
+		log.Info("Unlocked account", "account", key)
+	}
 	return true
 }
 
@@ -100,8 +157,20 @@ func (w *Wallet) updateWalletAccount(account meta.Account) error {
 	return nil
 }
 
-func (w *Wallet) NewAccount() (*meta.AccountID, error) {
-	ksAccount, err := w.keystore.NewAccount(w.password)
+//NewAccount derives the next hd account if hd wallet is initialized, otherwise creates a random key.
+//The key is encrypted by passphrase, which must be the password of hd wallet if it is initialized.
+func (w *Wallet) NewAccount(passphrase string) (*meta.AccountID, error) {
+	if passphrase == "" {
+		return nil, ErrEmptyPassphrase
+	}
+	w.hdMtx.Lock()
+	hd := w.hd
+	w.hdMtx.Unlock()
+	if hd != nil {
+		return w.NewHDAccount(passphrase)
+	}
+
+	ksAccount, err := w.keystore.NewAccount(passphrase)
 	if err != nil {
 		log.Error("wallet", "newAccount", err)
 		return nil, err
@@ -149,6 +218,7 @@ func (w *Wallet) SignTransaction(tx meta.Transaction) (*meta.Transaction, error)
 	return &tx, nil
 }
 
+//SignMessage signs the hash by the account, which must be unlocked.
 func (w *Wallet) SignMessage(accountId meta.AccountID, hash []byte) (math.ISignature, error) {
 	_, ok := w.accounts[accountId.String()]
 	if !ok {
@@ -159,17 +229,50 @@ func (w *Wallet) SignMessage(accountId meta.AccountID, hash []byte) (math.ISigna
 	if err != nil {
 		return nil, err
 	}
-	sign, err := w.keystore.SignHashWithPassphrase(ksAccount, w.password, hash)
+	sign, err := w.keystore.SignHash(ksAccount, hash)
+	if err == keystore.ErrLocked {
+		return nil, fmt.Errorf("account %s is locked, please unlock it", accountId.String())
+	} else if err != nil {
+		return nil, err
+	}
 	return meta.NewSignature(sign), nil
 }
 
-func (w *Wallet) importKey(privkeyStr string) (*meta.AccountID, error) {
+//UnlockAccount decrypts the key of account by passphrase and keeps it in memory for duration,
+//the account is unlocked until locked or exit if duration is 0.
+func (w *Wallet) UnlockAccount(id meta.AccountID, passphrase string, duration time.Duration) error {
+	if _, ok := w.accounts[id.String()]; !ok {
+		return errors.New("unlock can not find account id")
+	}
+	return w.keystore.TimedUnlock(accounts.Account{Address: id}, passphrase, duration)
+}
+
+//IsUnlocked reports whether the account can sign without passphrase.
+func (w *Wallet) IsUnlocked(id meta.AccountID) bool {
+	if _, ok := w.accounts[id.String()]; !ok {
+		return false
+	}
+	return w.keystore.IsUnlocked(id)
+}
+
+//LockAccount removes the decrypted key of account from memory.
+func (w *Wallet) LockAccount(id meta.AccountID) error {
+	if _, ok := w.accounts[id.String()]; !ok {
+		return errors.New("lock can not find account id")
+	}
+	return w.keystore.Lock(id)
+}
+
+func (w *Wallet) importKey(privkeyStr string, passphrase string) (*meta.AccountID, error) {
+	if passphrase == "" {
+		return nil, ErrEmptyPassphrase
+	}
 	privkeyBuff, err := hex.DecodeString(privkeyStr)
 	if err != nil {
 		return nil, err
 	}
 	privkey, _ := btcec.PrivKeyFromBytes(btcec.S256(), privkeyBuff)
-	ksAccount, err := w.keystore.ImportECDSA(privkey, w.password)
+	ksAccount, err := w.keystore.ImportECDSA(privkey, passphrase)
 	if err != nil {
 		return nil, err
 	}
@@ -178,15 +281,15 @@ func (w *Wallet) importKey(privkeyStr string) (*meta.AccountID, error) {
 	return &ksAccount.Address, err
 }
 
-func (w *Wallet) ImportAccount(privateKeyStr string) (*meta.AccountID, error) {
-	a, err := w.importKey(privateKeyStr)
+func (w *Wallet) ImportAccount(privateKeyStr string, passphrase string) (*meta.AccountID, error) {
+	a, err := w.importKey(privateKeyStr, passphrase)
 	if err != nil {
 		return nil, err
 	}
 	return a, nil
 }
 
-func (w *Wallet) ExportAccount(id meta.AccountID) (string, error) {
+func (w *Wallet) ExportAccount(id meta.AccountID, passphrase string) (string, error) {
 	_, ok := w.accounts[id.String()]
 	if !ok {
 		return "", errors.New("export can not find account id")
@@ -196,7 +299,33 @@ func (w *Wallet) ExportAccount(id meta.AccountID) (string, error) {
 		return "", err
 	}
 
-	return w.keystore.ExportECDSA(ksAccount, w.password)
+	return w.keystore.ExportECDSA(ksAccount, passphrase)
+}
+
+//MigrateLegacyPassword re-encrypts the keys and hd seed which are encrypted by LegacyPassword with passphrase.
+//The keys which are not encrypted by LegacyPassword are skipped, the migrated accounts are returned.
+func (w *Wallet) MigrateLegacyPassword(passphrase string) ([]meta.AccountID, bool, error) {
+	if passphrase == "" || passphrase == LegacyPassword {
+		return nil, false, errors.New("passphrase can not be empty or the legacy password")
+	}
+
+	migrated := make([]meta.AccountID, 0)
+	for _, a := range w.keystore.Accounts() {
+		err := w.keystore.Update(a, LegacyPassword, passphrase)
+		if err == keystore.ErrDecrypt {
+			continue
+		} else if err != nil {
+			return migrated, false, err
+		}
+		migrated = append(migrated, a.Address)
+		log.Info("Migrated account", "account", a.Address.String())
+	}
+
+	hdMigrated, err := w.migrateHDWallet(LegacyPassword, passphrase)
+	if err != nil {
+		return migrated, false, err
+	}
+	return migrated, hdMigrated, nil
 }
 
 func (w *Wallet) instanceDir(path string) string {